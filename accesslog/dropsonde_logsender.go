@@ -1,24 +1,79 @@
 package accesslog
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/mdimiceli/gorouter/accesslog/schema"
 	"github.com/mdimiceli/gorouter/config"
 	goRouterLogger "github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/metrics"
 	"github.com/cloudfoundry/dropsonde"
-	"github.com/cloudfoundry/dropsonde/emitter"
 	"github.com/cloudfoundry/sonde-go/events"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
 )
 
+// DropsondeLogSender emits access log messages as dropsonde envelopes. When
+// configured with emitter workers, envelopes are queued and emitted by a
+// fixed pool of goroutines instead of on the caller's goroutine. Envelopes
+// that do not fit into the queue, or that are sent once the sender has been
+// stopped, are dropped, counted and reported.
 type DropsondeLogSender struct {
 	eventEmitter   dropsonde.EventEmitter
 	sourceInstance string
 	logger         goRouterLogger.Logger
+	reporter       metrics.ProxyReporter
+
+	queue   chan *pooledEnvelope
+	stopCh  chan struct{}
+	stopMu  sync.RWMutex
+	stopped bool
+	wg      sync.WaitGroup
+	dropped uint64
+}
+
+var errMissingOrigin = errors.New("event not emitted due to missing origin information")
+
+// envelopePool reuses the envelopes built for access logs, which would
+// otherwise be allocated for every request. Emitters marshal envelopes
+// synchronously, so an envelope can be reused once it has been emitted.
+var envelopePool = sync.Pool{
+	New: func() interface{} { return new(pooledEnvelope) },
+}
+
+// pooledEnvelope holds an envelope together with its log message and the
+// values its pointer fields refer to, so that none of them is allocated
+// separately.
+type pooledEnvelope struct {
+	envelope    events.Envelope
+	logMessage  events.LogMessage
+	message     []byte
+	appID       string
+	origin      string
+	sourceType  string
+	messageType events.LogMessage_MessageType
+	eventType   events.Envelope_EventType
+	timestamp   int64
+}
+
+// getEnvelope returns an envelope from the pool with every field of its
+// previous use cleared.
+func getEnvelope() *pooledEnvelope {
+	e := envelopePool.Get().(*pooledEnvelope)
+	proto.Reset(&e.envelope)
+	proto.Reset(&e.logMessage)
+	e.message = e.message[:0]
+	e.appID = ""
+	e.origin = ""
+	e.sourceType = ""
+	e.messageType = 0
+	e.eventType = 0
+	e.timestamp = 0
+	return e
 }
 
 func (l *DropsondeLogSender) SendAppLog(appID, message string, tags map[string]string) {
@@ -32,42 +87,143 @@ func (l *DropsondeLogSender) SendAppLog(appID, message string, tags map[string]s
 		return
 	}
 
-	sourceType := "RTR"
-	messageType := events.LogMessage_OUT
-	logMessage := &events.LogMessage{
-		Message:        []byte(message),
-		AppId:          proto.String(appID),
-		MessageType:    &messageType,
-		SourceType:     &sourceType,
-		SourceInstance: &l.sourceInstance,
-		Timestamp:      proto.Int64(time.Now().UnixNano()),
+	origin := l.eventEmitter.Origin()
+	if origin == "" {
+		l.logger.Error("error-wrapping-access-log-for-emitting", zap.Error(errMissingOrigin))
+		return
+	}
+
+	envelope := getEnvelope()
+	envelope.message = append(envelope.message, message...)
+	envelope.appID = appID
+	envelope.origin = origin
+	envelope.sourceType = "RTR"
+	envelope.messageType = events.LogMessage_OUT
+	envelope.eventType = events.Envelope_LogMessage
+	envelope.timestamp = time.Now().UnixNano()
+
+	envelope.logMessage.Message = envelope.message
+	envelope.logMessage.AppId = &envelope.appID
+	envelope.logMessage.MessageType = &envelope.messageType
+	envelope.logMessage.SourceType = &envelope.sourceType
+	envelope.logMessage.SourceInstance = &l.sourceInstance
+	envelope.logMessage.Timestamp = &envelope.timestamp
+
+	envelope.envelope.Origin = &envelope.origin
+	envelope.envelope.EventType = &envelope.eventType
+	envelope.envelope.Timestamp = &envelope.timestamp
+	envelope.envelope.LogMessage = &envelope.logMessage
+	envelope.envelope.Tags = tags
+
+	if l.queue == nil {
+		l.emit(envelope)
+		return
 	}
 
-	envelope, err := emitter.Wrap(logMessage, l.eventEmitter.Origin())
-	if err != nil {
-		l.logger.Error("error-wrapping-access-log-for-emitting", zap.Error(err))
+	// the workers drain the queue once stopped, so nothing may be queued
+	// after that
+	l.stopMu.RLock()
+	defer l.stopMu.RUnlock()
+
+	if l.stopped {
+		l.drop(envelope, appID, "emitter is stopped")
 		return
 	}
 
-	envelope.Tags = tags
+	select {
+	case l.queue <- envelope:
+	default:
+		l.drop(envelope, appID, "emitter queue is full")
+	}
+}
 
-	if err = l.eventEmitter.EmitEnvelope(envelope); err != nil {
+func (l *DropsondeLogSender) drop(envelope *pooledEnvelope, appID, reason string) {
+	envelopePool.Put(envelope)
+	dropped := atomic.AddUint64(&l.dropped, 1)
+	l.reporter.CaptureAccessLogDropped()
+	l.logger.Debug("dropping-loggregator-access-log",
+		zap.Error(errors.New(reason)),
+		zap.String("appID", appID),
+		zap.Uint64("dropped", dropped),
+	)
+}
+
+// DroppedCount returns the number of envelopes dropped because the emitter
+// queue was full or the sender was stopped.
+func (l *DropsondeLogSender) DroppedCount() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// Stop flushes all queued envelopes and waits for the emitter workers to exit.
+// Envelopes sent afterwards are dropped.
+func (l *DropsondeLogSender) Stop() {
+	if l.queue == nil {
+		return
+	}
+
+	l.stopMu.Lock()
+	if !l.stopped {
+		l.stopped = true
+		close(l.stopCh)
+	}
+	l.stopMu.Unlock()
+	l.wg.Wait()
+}
+
+func (l *DropsondeLogSender) emit(envelope *pooledEnvelope) {
+	if err := l.eventEmitter.EmitEnvelope(&envelope.envelope); err != nil {
 		l.logger.Error("error-emitting-access-log-to-writers", zap.Error(err))
 	}
+	envelopePool.Put(envelope)
+}
+
+func (l *DropsondeLogSender) runEmitter() {
+	defer l.wg.Done()
+
+	for {
+		select {
+		case envelope := <-l.queue:
+			l.emit(envelope)
+		case <-l.stopCh:
+			for {
+				select {
+				case envelope := <-l.queue:
+					l.emit(envelope)
+				default:
+					return
+				}
+			}
+		}
+	}
 }
 
 func NewLogSender(
 	c *config.Config,
 	e dropsonde.EventEmitter,
+	reporter metrics.ProxyReporter,
 	logger goRouterLogger.Logger,
-) schema.LogSender {
+) *DropsondeLogSender {
 	var dropsondeSourceInstance string
 
 	if c.Logging.LoggregatorEnabled {
 		dropsondeSourceInstance = strconv.FormatUint(uint64(c.Index), 10)
 	}
 
-	return &DropsondeLogSender{
-		e, dropsondeSourceInstance, logger,
+	sender := &DropsondeLogSender{
+		eventEmitter:   e,
+		sourceInstance: dropsondeSourceInstance,
+		logger:         logger,
+		reporter:       reporter,
+	}
+
+	if c.Logging.LoggregatorEmitterWorkers > 0 {
+		sender.queue = make(chan *pooledEnvelope, c.Logging.LoggregatorEmitterQueueSize)
+		sender.stopCh = make(chan struct{})
+		for i := 0; i < c.Logging.LoggregatorEmitterWorkers; i++ {
+			sender.wg.Add(1)
+			go sender.runEmitter()
+		}
 	}
+
+	return sender
 }
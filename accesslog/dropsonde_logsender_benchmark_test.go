@@ -0,0 +1,89 @@
+package accesslog_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/cloudfoundry/sonde-go/events"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/mdimiceli/gorouter/accesslog"
+	"github.com/mdimiceli/gorouter/config"
+	loggerFakes "github.com/mdimiceli/gorouter/logger/fakes"
+	metricsFakes "github.com/mdimiceli/gorouter/metrics/fakes"
+)
+
+// contendedEmitter mimics an emitter that serializes writes behind a lock,
+// which is what makes emitting on the request path expensive.
+type contendedEmitter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (e *contendedEmitter) Emit(events.Event) error { return nil }
+func (e *contendedEmitter) Origin() string          { return "gorouter" }
+func (e *contendedEmitter) EmitEnvelope(*events.Envelope) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := 0; i < 1000; i++ {
+		e.count++
+	}
+	return nil
+}
+
+func benchmarkSendAppLog(b *testing.B, workers int) {
+	cfg, err := config.DefaultConfig()
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfg.Logging.LoggregatorEnabled = true
+	cfg.Logging.LoggregatorEmitterWorkers = workers
+	cfg.Logging.LoggregatorEmitterQueueSize = 65536
+
+	sender := accesslog.NewLogSender(cfg, &contendedEmitter{}, &metricsFakes.FakeProxyReporter{}, &loggerFakes.FakeLogger{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sender.SendAppLog("app-id", "message", nil)
+		}
+	})
+	sender.Stop()
+}
+
+func BenchmarkSendAppLogSynchronous(b *testing.B) {
+	benchmarkSendAppLog(b, 0)
+}
+
+func BenchmarkSendAppLogWithEmitterWorkers(b *testing.B) {
+	benchmarkSendAppLog(b, 2)
+}
+
+// noopEmitter marshals envelopes like the dropsonde emitter does, without the
+// cost of writing them anywhere, so allocations of the sender itself show.
+type noopEmitter struct{}
+
+func (noopEmitter) Emit(events.Event) error { return nil }
+func (noopEmitter) Origin() string          { return "gorouter" }
+func (noopEmitter) EmitEnvelope(envelope *events.Envelope) error {
+	_, err := proto.Marshal(envelope)
+	return err
+}
+
+func BenchmarkSendAppLogAllocations(b *testing.B) {
+	cfg, err := config.DefaultConfig()
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfg.Logging.LoggregatorEnabled = true
+
+	sender := accesslog.NewLogSender(cfg, noopEmitter{}, &metricsFakes.FakeProxyReporter{}, &loggerFakes.FakeLogger{})
+	tags := map[string]string{"source_id": "app-id"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sender.SendAppLog("app-id", "message", tags)
+	}
+}
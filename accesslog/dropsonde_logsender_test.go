@@ -1,14 +1,17 @@
 package accesslog_test
 
 import (
+	"github.com/cloudfoundry/sonde-go/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
 	"github.com/mdimiceli/gorouter/accesslog"
 	"github.com/mdimiceli/gorouter/accesslog/fakes"
 	"github.com/mdimiceli/gorouter/accesslog/schema"
 	"github.com/mdimiceli/gorouter/config"
 	loggerFakes "github.com/mdimiceli/gorouter/logger/fakes"
-	. "github.com/onsi/ginkgo/v2"
-	. "github.com/onsi/gomega"
-	"google.golang.org/protobuf/proto"
+	metricsFakes "github.com/mdimiceli/gorouter/metrics/fakes"
 )
 
 //go:generate counterfeiter -o fakes/eventemitter.go github.com/cloudfoundry/dropsonde.EventEmitter
@@ -19,6 +22,7 @@ var _ = Describe("DropsondeLogSender", func() {
 			logSender    schema.LogSender
 			conf         *config.Config
 			eventEmitter *fakes.FakeEventEmitter
+			reporter     *metricsFakes.FakeProxyReporter
 			logger       *loggerFakes.FakeLogger
 		)
 
@@ -29,9 +33,11 @@ var _ = Describe("DropsondeLogSender", func() {
 			conf.Logging.LoggregatorEnabled = true
 
 			eventEmitter = &fakes.FakeEventEmitter{}
+			reporter = &metricsFakes.FakeProxyReporter{}
 			logger = &loggerFakes.FakeLogger{}
 
-			logSender = accesslog.NewLogSender(conf, eventEmitter, logger)
+			conf.Logging.LoggregatorEmitterWorkers = 0
+			logSender = accesslog.NewLogSender(conf, eventEmitter, reporter, logger)
 
 			eventEmitter.OriginReturns("someOrigin")
 		})
@@ -62,6 +68,44 @@ var _ = Describe("DropsondeLogSender", func() {
 			}))
 		})
 
+		It("does not carry data of a previous access log into reused envelopes", func() {
+			var emitted []*events.Envelope
+			eventEmitter.EmitEnvelopeStub = func(envelope *events.Envelope) error {
+				emitted = append(emitted, proto.Clone(envelope).(*events.Envelope))
+				return nil
+			}
+
+			logSender.SendAppLog("firstID", "a longer first message", map[string]string{"foo": "bar"})
+			logSender.SendAppLog("secondID", "second", nil)
+
+			Expect(emitted).To(HaveLen(2))
+			envelope := emitted[1]
+			Expect(envelope.Tags).To(BeEmpty())
+			Expect(envelope.GetOrigin()).To(Equal("someOrigin"))
+			Expect(envelope.GetEventType()).To(Equal(events.Envelope_LogMessage))
+			Expect(envelope.GetTimestamp()).NotTo(BeZero())
+
+			logMessage := envelope.GetLogMessage()
+			Expect(logMessage.GetAppId()).To(Equal("secondID"))
+			Expect(logMessage.GetMessage()).To(Equal([]byte("second")))
+			Expect(logMessage.GetMessageType()).To(Equal(events.LogMessage_OUT))
+			Expect(logMessage.GetSourceType()).To(Equal("RTR"))
+			Expect(logMessage.GetSourceInstance()).To(Equal("0"))
+		})
+
+		Context("when the emitter has no origin", func() {
+			BeforeEach(func() {
+				eventEmitter.OriginReturns("")
+			})
+
+			It("logs an error and does not emit an envelope", func() {
+				logSender.SendAppLog("someID", "someMessage", nil)
+
+				Expect(logger.ErrorCallCount()).To(Equal(1))
+				Expect(eventEmitter.EmitEnvelopeCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when app id is empty", func() {
 			It("does not emit an envelope", func() {
 				logSender.SendAppLog("", "someMessage", nil)
@@ -70,4 +114,99 @@ var _ = Describe("DropsondeLogSender", func() {
 			})
 		})
 	})
+
+	Describe("SendAppLog with emitter workers", func() {
+		var (
+			logSender    *accesslog.DropsondeLogSender
+			conf         *config.Config
+			eventEmitter *fakes.FakeEventEmitter
+			reporter     *metricsFakes.FakeProxyReporter
+			logger       *loggerFakes.FakeLogger
+		)
+
+		BeforeEach(func() {
+			var err error
+			conf, err = config.DefaultConfig()
+			Expect(err).ToNot(HaveOccurred())
+			conf.Logging.LoggregatorEnabled = true
+			conf.Logging.LoggregatorEmitterWorkers = 1
+			conf.Logging.LoggregatorEmitterQueueSize = 1
+
+			eventEmitter = &fakes.FakeEventEmitter{}
+			eventEmitter.OriginReturns("someOrigin")
+			reporter = &metricsFakes.FakeProxyReporter{}
+			logger = &loggerFakes.FakeLogger{}
+		})
+
+		JustBeforeEach(func() {
+			logSender = accesslog.NewLogSender(conf, eventEmitter, reporter, logger)
+		})
+
+		It("emits envelopes asynchronously", func() {
+			logSender.SendAppLog("someID", "someMessage", nil)
+
+			Eventually(eventEmitter.EmitEnvelopeCallCount).Should(Equal(1))
+			logMessage := eventEmitter.EmitEnvelopeArgsForCall(0).LogMessage
+			Expect(logMessage.AppId).To(Equal(proto.String("someID")))
+			Expect(logMessage.Message).To(Equal([]byte("someMessage")))
+
+			logSender.Stop()
+		})
+
+		Context("when the queue is full", func() {
+			var unblock chan struct{}
+
+			BeforeEach(func() {
+				unblock = make(chan struct{})
+				eventEmitter.EmitEnvelopeStub = func(*events.Envelope) error {
+					<-unblock
+					return nil
+				}
+			})
+
+			It("drops, counts and reports the envelopes that do not fit", func() {
+				logSender.SendAppLog("someID", "first", nil)
+				Eventually(eventEmitter.EmitEnvelopeCallCount).Should(Equal(1))
+
+				logSender.SendAppLog("someID", "second", nil)
+				logSender.SendAppLog("someID", "third", nil)
+
+				Expect(logSender.DroppedCount()).To(Equal(uint64(1)))
+				Expect(reporter.CaptureAccessLogDroppedCallCount()).To(Equal(1))
+				Expect(logger.DebugCallCount()).To(Equal(1))
+
+				close(unblock)
+				logSender.Stop()
+
+				Expect(eventEmitter.EmitEnvelopeCallCount()).To(Equal(2))
+				Expect(eventEmitter.EmitEnvelopeArgsForCall(1).LogMessage.Message).To(Equal([]byte("second")))
+			})
+		})
+
+		Context("when stopped", func() {
+			BeforeEach(func() {
+				conf.Logging.LoggregatorEmitterQueueSize = 100
+			})
+
+			It("flushes all queued envelopes", func() {
+				for i := 0; i < 100; i++ {
+					logSender.SendAppLog("someID", "someMessage", nil)
+				}
+				logSender.Stop()
+
+				Expect(eventEmitter.EmitEnvelopeCallCount()).To(Equal(100))
+				Expect(logSender.DroppedCount()).To(BeZero())
+				Expect(reporter.CaptureAccessLogDroppedCallCount()).To(BeZero())
+			})
+
+			It("drops, counts and reports the envelopes sent afterwards", func() {
+				logSender.Stop()
+				logSender.SendAppLog("someID", "someMessage", nil)
+
+				Expect(eventEmitter.EmitEnvelopeCallCount()).To(BeZero())
+				Expect(logSender.DroppedCount()).To(Equal(uint64(1)))
+				Expect(reporter.CaptureAccessLogDroppedCallCount()).To(Equal(1))
+			})
+		})
+	})
 })
@@ -31,11 +31,17 @@ func (x *NullAccessLogger) Log(schema.AccessLogRecord) {}
 type FileAndLoggregatorAccessLogger struct {
 	channel                chan schema.AccessLogRecord
 	stopCh                 chan struct{}
+	doneCh                 chan struct{}
 	writers                []CustomWriter
 	writerCount            int
 	disableXFFLogging      bool
 	disableSourceIPLogging bool
 	redactQueryParams      string
+	redactQueryParamNames  []string
+	zone                   string
+	instanceId             string
+	staticFields           map[string]string
+	logClientProtocol      bool
 	logger                 logger.Logger
 	logsender              schema.LogSender
 }
@@ -54,15 +60,27 @@ func CreateRunningAccessLogger(logger logger.Logger, logsender schema.LogSender,
 	accessLogger := &FileAndLoggregatorAccessLogger{
 		channel:                make(chan schema.AccessLogRecord, 1024),
 		stopCh:                 make(chan struct{}),
+		doneCh:                 make(chan struct{}),
 		disableXFFLogging:      config.Logging.DisableLogForwardedFor,
 		disableSourceIPLogging: config.Logging.DisableLogSourceIP,
 		redactQueryParams:      config.Logging.RedactQueryParams,
+		redactQueryParamNames:  config.Logging.RedactQueryParamNames,
+		zone:                   config.Logging.Zone,
+		instanceId:             config.Logging.InstanceId,
+		staticFields:           config.AccessLog.StaticFields,
+		logClientProtocol:      config.AccessLog.LogClientProtocol,
 		logger:                 logger,
 		logsender:              logsender,
 	}
 
 	if config.AccessLog.File != "" {
-		file, err := os.OpenFile(config.AccessLog.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+		var file io.Writer
+		var err error
+		if config.AccessLog.Rotation.Enabled() {
+			file, err = NewRotatingFileWriter(config.AccessLog.File, config.AccessLog.Rotation)
+		} else {
+			file, err = os.OpenFile(config.AccessLog.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+		}
 		if err != nil {
 			logger.Error("error-creating-accesslog-file", zap.String("filename", config.AccessLog.File), zap.Error(err))
 			return nil, err
@@ -86,18 +104,46 @@ func CreateRunningAccessLogger(logger logger.Logger, logsender schema.LogSender,
 }
 
 func (x *FileAndLoggregatorAccessLogger) Run() {
+	defer close(x.doneCh)
+
 	for {
 		select {
 		case record := <-x.channel:
-			for _, w := range x.writers {
-				_, err := record.WriteTo(w.Writer)
-				if err != nil {
-					x.logger.Error(fmt.Sprintf("error-emitting-access-log-to-writer-%s", w.Name), zap.Error(err))
+			x.write(record)
+		case <-x.stopCh:
+			for {
+				select {
+				case record := <-x.channel:
+					x.write(record)
+				default:
+					x.closeWriters()
+					return
 				}
 			}
-			record.SendLog(x.logsender)
-		case <-x.stopCh:
-			return
+		}
+	}
+}
+
+func (x *FileAndLoggregatorAccessLogger) write(record schema.AccessLogRecord) {
+	for _, w := range x.writers {
+		_, err := record.WriteTo(w.Writer)
+		if err != nil {
+			x.logger.Error(fmt.Sprintf("error-emitting-access-log-to-writer-%s", w.Name), zap.Error(err))
+		}
+	}
+	record.SendLog(x.logsender)
+}
+
+// closeWriters closes the writers that can be closed, which syncs the rotating
+// file writer's file to disk unless it is configured never to.
+func (x *FileAndLoggregatorAccessLogger) closeWriters() {
+	for _, w := range x.writers {
+		closer, ok := w.Writer.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			x.logger.Error(fmt.Sprintf("error-closing-access-log-writer-%s", w.Name), zap.Error(err))
 		}
 	}
 }
@@ -114,13 +160,21 @@ func (x *FileAndLoggregatorAccessLogger) WriterCount() int {
 	return x.writerCount
 }
 
+// Stop writes the records that are still queued, closes the writers and
+// waits for Run to return.
 func (x *FileAndLoggregatorAccessLogger) Stop() {
 	close(x.stopCh)
+	<-x.doneCh
 }
 
 func (x *FileAndLoggregatorAccessLogger) Log(r schema.AccessLogRecord) {
 	r.DisableXFFLogging = x.disableXFFLogging
 	r.DisableSourceIPLogging = x.disableSourceIPLogging
 	r.RedactQueryParams = x.redactQueryParams
+	r.RedactQueryParamNames = x.redactQueryParamNames
+	r.RouterZone = x.zone
+	r.RouterInstanceId = x.instanceId
+	r.StaticFields = x.staticFields
+	r.LogClientProtocol = x.logClientProtocol
 	x.channel <- r
 }
@@ -56,6 +56,70 @@ var _ = Describe("AccessLog", func() {
 
 				accessLogger.Stop()
 			})
+
+			It("tags logs with the zone and instance id of the router", func() {
+				cfg.Logging.LoggregatorEnabled = true
+				cfg.Logging.Zone = "z1"
+				cfg.Logging.InstanceId = "router-instance"
+
+				accessLogger, err := accesslog.CreateRunningAccessLogger(logger, ls, cfg)
+				Expect(err).ToNot(HaveOccurred())
+
+				record := *CreateAccessLogRecord()
+				record.RouteEndpoint.Tags = map[string]string{"component": "app"}
+				accessLogger.Log(record)
+
+				Eventually(ls.SendAppLogCallCount).Should(Equal(1))
+				_, message, tags := ls.SendAppLogArgsForCall(0)
+				Expect(message).To(ContainSubstring(`router_zone:"z1" router_instance_id:"router-instance"`))
+				Expect(tags).To(Equal(map[string]string{
+					"component":          "app",
+					"router_zone":        "z1",
+					"router_instance_id": "router-instance",
+				}))
+				Expect(record.RouteEndpoint.Tags).To(Equal(map[string]string{"component": "app"}))
+
+				accessLogger.Stop()
+			})
+
+			It("adds the static fields to the logs and their tags", func() {
+				cfg.Logging.LoggregatorEnabled = true
+				cfg.AccessLog.StaticFields = map[string]string{"environment": "production"}
+
+				accessLogger, err := accesslog.CreateRunningAccessLogger(logger, ls, cfg)
+				Expect(err).ToNot(HaveOccurred())
+
+				record := *CreateAccessLogRecord()
+				record.RouteEndpoint.Tags = map[string]string{"component": "app"}
+				accessLogger.Log(record)
+
+				Eventually(ls.SendAppLogCallCount).Should(Equal(1))
+				_, message, tags := ls.SendAppLogArgsForCall(0)
+				Expect(message).To(ContainSubstring(`environment:"production"`))
+				Expect(tags).To(Equal(map[string]string{
+					"component":   "app",
+					"environment": "production",
+				}))
+
+				accessLogger.Stop()
+			})
+
+			It("adds the client protocol to the logs when configured to", func() {
+				cfg.Logging.LoggregatorEnabled = true
+				cfg.AccessLog.LogClientProtocol = true
+
+				accessLogger, err := accesslog.CreateRunningAccessLogger(logger, ls, cfg)
+				Expect(err).ToNot(HaveOccurred())
+
+				record := *CreateAccessLogRecord()
+				accessLogger.Log(record)
+
+				Eventually(ls.SendAppLogCallCount).Should(Equal(1))
+				_, message, _ := ls.SendAppLogArgsForCall(0)
+				Expect(message).To(ContainSubstring(`client_protocol:"HTTP/1.1" client_alpn:"-"`))
+
+				accessLogger.Stop()
+			})
 		})
 
 		Context("When created without access log file", func() {
@@ -0,0 +1,158 @@
+package accesslog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mdimiceli/gorouter/config"
+)
+
+// RotatingFileWriter is an io.Writer that appends to a file and rotates it
+// once it grows beyond MaxSize bytes or has been open for longer than MaxAge.
+// Rotated files are renamed to <path>.1 through <path>.<MaxBackups>, with
+// <path>.1 being the most recent one. Writes are serialized so a single
+// writer can be shared between goroutines.
+type RotatingFileWriter struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	fsync      string
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func NewRotatingFileWriter(path string, rotation config.AccessLogRotation) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{
+		path:       path,
+		maxSize:    rotation.MaxSize,
+		maxAge:     rotation.MaxAge,
+		maxBackups: rotation.MaxBackups,
+		fsync:      rotation.Fsync,
+		now:        time.Now,
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	if w.fsync == config.FSYNC_ALWAYS {
+		if err := w.file.Sync(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Close flushes and closes the underlying file. Subsequent writes fail.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.closeFile()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingFileWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+n > w.maxSize {
+		return true
+	}
+	if w.maxAge > 0 && w.now().Sub(w.openedAt) >= w.maxAge {
+		return true
+	}
+	return false
+}
+
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+func (w *RotatingFileWriter) closeFile() error {
+	if w.fsync != config.FSYNC_NEVER {
+		if err := w.file.Sync(); err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	return w.file.Close()
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	if w.maxBackups == 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+
+	if err := os.Remove(w.backupName(w.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(w.backupName(i), w.backupName(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, w.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return w.open()
+}
+
+func (w *RotatingFileWriter) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
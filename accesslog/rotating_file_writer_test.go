@@ -0,0 +1,172 @@
+package accesslog_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mdimiceli/gorouter/accesslog"
+	schemaFakes "github.com/mdimiceli/gorouter/accesslog/schema/fakes"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/test_util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotatingFileWriter", func() {
+	var (
+		dir      string
+		path     string
+		rotation config.AccessLogRotation
+		writer   *accesslog.RotatingFileWriter
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "access-log-rotation")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "access.log")
+
+		rotation = config.AccessLogRotation{
+			MaxSize:    20,
+			MaxBackups: 2,
+			Fsync:      config.FSYNC_NEVER,
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = accesslog.NewRotatingFileWriter(path, rotation)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		writer.Close()
+		os.RemoveAll(dir)
+	})
+
+	readFile := func(name string) string {
+		b, err := os.ReadFile(name)
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	It("does not rotate while below the size threshold", func() {
+		_, err := writer.Write([]byte("0123456789\n"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = writer.Write([]byte("0123456\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readFile(path)).To(Equal("0123456789\n0123456\n"))
+		Expect(path + ".1").NotTo(BeAnExistingFile())
+	})
+
+	It("rotates once the size threshold would be exceeded", func() {
+		_, err := writer.Write([]byte("first-line-abcdefg\n"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = writer.Write([]byte("second-line\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readFile(path + ".1")).To(Equal("first-line-abcdefg\n"))
+		Expect(readFile(path)).To(Equal("second-line\n"))
+	})
+
+	It("keeps at most max_backups rotated files", func() {
+		for _, line := range []string{"aaaaaaaaaaaaaaaaaa\n", "bbbbbbbbbbbbbbbbbb\n", "cccccccccccccccccc\n", "dddddddddddddddddd\n"} {
+			_, err := writer.Write([]byte(line))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(readFile(path)).To(Equal("dddddddddddddddddd\n"))
+		Expect(readFile(path + ".1")).To(Equal("cccccccccccccccccc\n"))
+		Expect(readFile(path + ".2")).To(Equal("bbbbbbbbbbbbbbbbbb\n"))
+		Expect(path + ".3").NotTo(BeAnExistingFile())
+	})
+
+	It("takes the size of an existing file into account", func() {
+		Expect(writer.Close()).To(Succeed())
+		Expect(os.WriteFile(path, []byte("existing-contents\n"), 0666)).To(Succeed())
+
+		var err error
+		writer, err = accesslog.NewRotatingFileWriter(path, rotation)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = writer.Write([]byte("new\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readFile(path + ".1")).To(Equal("existing-contents\n"))
+		Expect(readFile(path)).To(Equal("new\n"))
+	})
+
+	Context("when written to concurrently", func() {
+		BeforeEach(func() {
+			rotation.MaxSize = 100
+			rotation.MaxBackups = 100
+		})
+
+		It("does not lose or interleave records", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					_, err := writer.Write([]byte("0123456789\n"))
+					Expect(err).NotTo(HaveOccurred())
+				}()
+			}
+			wg.Wait()
+
+			files, err := filepath.Glob(path + "*")
+			Expect(err).NotTo(HaveOccurred())
+
+			lines := 0
+			for _, f := range files {
+				contents := readFile(f)
+				Expect(len(contents)).To(BeNumerically("<=", 100))
+				for _, line := range strings.Split(strings.TrimSuffix(contents, "\n"), "\n") {
+					Expect(line).To(Equal("0123456789"))
+					lines++
+				}
+			}
+			Expect(lines).To(Equal(50))
+		})
+	})
+
+	Context("when the access logger is configured with rotation", func() {
+		It("writes records through the rotating writer", func() {
+			cfg, err := config.DefaultConfig()
+			Expect(err).NotTo(HaveOccurred())
+			cfg.AccessLog.File = filepath.Join(dir, "rotated.log")
+			cfg.AccessLog.Rotation.MaxSize = 1024
+
+			accessLogger, err := accesslog.CreateRunningAccessLogger(test_util.NewTestZapLogger("test"), &schemaFakes.FakeLogSender{}, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			writers := accessLogger.(*accesslog.FileAndLoggregatorAccessLogger).FileWriters()
+			Expect(writers).To(HaveLen(1))
+			Expect(writers[0].Writer).To(BeAssignableToTypeOf(&accesslog.RotatingFileWriter{}))
+			accessLogger.Stop()
+		})
+
+		It("writes the queued records and closes the rotating writer when stopped", func() {
+			cfg, err := config.DefaultConfig()
+			Expect(err).NotTo(HaveOccurred())
+			cfg.AccessLog.File = filepath.Join(dir, "rotated.log")
+			cfg.AccessLog.Rotation.MaxSize = 1024 * 1024
+
+			accessLogger, err := accesslog.CreateRunningAccessLogger(test_util.NewTestZapLogger("test"), &schemaFakes.FakeLogSender{}, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			for i := 0; i < 10; i++ {
+				accessLogger.Log(*CreateAccessLogRecord())
+			}
+			accessLogger.Stop()
+
+			Expect(strings.Count(readFile(cfg.AccessLog.File), "foo.bar")).To(Equal(10))
+
+			fileWriter := accessLogger.(*accesslog.FileAndLoggregatorAccessLogger).FileWriters()[0].Writer
+			_, err = fileWriter.Write([]byte("after stop\n"))
+			Expect(err).To(MatchError(os.ErrClosed))
+		})
+	})
+})
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -107,12 +109,21 @@ type AccessLogRecord struct {
 	DisableXFFLogging      bool
 	DisableSourceIPLogging bool
 	RedactQueryParams      string
+	RedactQueryParamNames  []string
+	RouterZone             string
+	RouterInstanceId       string
+	StaticFields           map[string]string
 	RouterError            string
 	LogAttemptsDetails     bool
+	LogClientProtocol      bool
 	FailedAttempts         int
 	RoundTripSuccessful    bool
 	record                 []byte
 
+	// Start marks a record logged when the request is received, before its
+	// response is known. It is logged in addition to the completion record.
+	Start bool
+
 	// See the handlers.RequestInfo struct for details on these timings.
 	ReceivedAt                  time.Time
 	AppRequestStartedAt         time.Time
@@ -127,6 +138,15 @@ type AccessLogRecord struct {
 	FinishedAt                  time.Time
 }
 
+// clientALPN returns the protocol negotiated with ALPN on the TLS connection
+// of the request, if any.
+func (r *AccessLogRecord) clientALPN() string {
+	if r.Request.TLS == nil {
+		return ""
+	}
+	return r.Request.TLS.NegotiatedProtocol
+}
+
 func (r *AccessLogRecord) formatStartedAt() string {
 	return r.ReceivedAt.Format("2006-01-02T15:04:05.000000000Z")
 }
@@ -303,6 +323,34 @@ func (r *AccessLogRecord) makeRecord(performTruncate bool) []byte {
 		b.WriteDashOrFloatValue(r.successfulAttemptTime())
 	}
 
+	if r.LogClientProtocol {
+		b.WriteString(`client_protocol:`)
+		b.WriteDashOrStringValue(r.Request.Proto)
+
+		b.WriteString(`client_alpn:`)
+		b.WriteDashOrStringValue(r.clientALPN())
+	}
+
+	if r.Start {
+		b.WriteString(`log_type:`)
+		b.WriteStringValues("start")
+	}
+
+	if r.RouterZone != "" {
+		b.WriteString(`router_zone:`)
+		b.WriteStringValues(r.RouterZone)
+	}
+
+	if r.RouterInstanceId != "" {
+		b.WriteString(`router_instance_id:`)
+		b.WriteStringValues(r.RouterInstanceId)
+	}
+
+	for _, key := range sortedKeys(r.StaticFields) {
+		b.WriteString(key + `:`)
+		b.WriteStringValues(r.StaticFields[key])
+	}
+
 	b.AppendSpaces(false)
 	b.WriteString(`x_cf_routererror:`)
 	b.WriteDashOrStringValue(r.RouterError)
@@ -320,23 +368,54 @@ func formatURI(r AccessLogRecord, performTruncate bool) string {
 	return uri
 }
 
-// Redact query parameters on GET requests that have a query part
+// Redact query parameters on GET requests that have a query part, and the
+// values of the named query parameters on any request
 func redactURI(r AccessLogRecord) string {
+	if r.Request.URL.RawQuery == "" {
+		return r.Request.URL.RequestURI()
+	}
+
+	// the request is still forwarded and must not be modified
+	u := *r.Request.URL
+	u.RawQuery = redactQueryParamValues(u.RawQuery, r.RedactQueryParamNames)
+
 	if r.Request.Method == http.MethodGet {
-		if r.Request.URL.RawQuery != "" {
-			switch r.RedactQueryParams {
-			case config.REDACT_QUERY_PARMS_ALL:
-				r.Request.URL.RawQuery = ""
-			case config.REDACT_QUERY_PARMS_HASH:
-				hash := sha1.New()
-				hash.Write([]byte(r.Request.URL.RawQuery))
-				hashString := hex.EncodeToString(hash.Sum(nil))
-				r.Request.URL.RawQuery = fmt.Sprintf("hash=%s", hashString)
-			}
+		switch r.RedactQueryParams {
+		case config.REDACT_QUERY_PARMS_ALL:
+			u.RawQuery = ""
+		case config.REDACT_QUERY_PARMS_HASH:
+			hash := sha1.New()
+			hash.Write([]byte(r.Request.URL.RawQuery))
+			hashString := hex.EncodeToString(hash.Sum(nil))
+			u.RawQuery = fmt.Sprintf("hash=%s", hashString)
 		}
 	}
 
-	return r.Request.URL.RequestURI()
+	return u.RequestURI()
+}
+
+// redactQueryParamValues replaces the values of the query parameters with one
+// of names, compared case-insensitively, keeping the query otherwise as is.
+func redactQueryParamValues(rawQuery string, names []string) string {
+	if len(names) == 0 {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				params[i], _, _ = strings.Cut(param, "=")
+				params[i] += "=<redacted>"
+				break
+			}
+		}
+	}
+	return strings.Join(params, "&")
 }
 
 func truncateToSize(value, name string, limit int) string {
@@ -391,7 +470,37 @@ func (r *AccessLogRecord) tags() map[string]string {
 		return nil
 	}
 
-	return r.RouteEndpoint.Tags
+	if r.RouterZone == "" && r.RouterInstanceId == "" && len(r.StaticFields) == 0 {
+		return r.RouteEndpoint.Tags
+	}
+
+	// the tags of the endpoint are shared between requests and must not be modified
+	tags := make(map[string]string, len(r.RouteEndpoint.Tags)+len(r.StaticFields)+2)
+	for k, v := range r.RouteEndpoint.Tags {
+		tags[k] = v
+	}
+	for k, v := range r.StaticFields {
+		tags[k] = v
+	}
+	if r.RouterZone != "" {
+		tags["router_zone"] = r.RouterZone
+	}
+	if r.RouterInstanceId != "" {
+		tags["router_instance_id"] = r.RouterInstanceId
+	}
+	return tags
+}
+
+func sortedKeys(m map[string]string) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (r *AccessLogRecord) addExtraHeaders(b *recordBuffer, performTruncate bool) {
@@ -2,6 +2,7 @@ package schema_test
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"strings"
 
@@ -178,6 +179,32 @@ var _ = Describe("AccessLogRecord", func() {
 			})
 		})
 
+		Context("when RedactQueryParamNames is set", func() {
+			BeforeEach(func() {
+				record.Request.URL.RawQuery = "page=2&token=secret&Access_Key=abc%2F123&filter=a%3Db"
+				record.RedactQueryParamNames = []string{"token", "access_key"}
+			})
+
+			It("redacts the values of the named query parameters", func() {
+				record.Request.Method = http.MethodPost
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`\?page=2&token=<redacted>&Access_Key=<redacted>&filter=a%3Db `))
+			})
+
+			It("does not modify the request", func() {
+				record.LogMessage()
+				Expect(record.Request.URL.RawQuery).To(Equal("page=2&token=secret&Access_Key=abc%2F123&filter=a%3Db"))
+			})
+
+			It("still applies RedactQueryParams to GET requests", func() {
+				record.Request.URL.RawQuery = "query=value"
+				record.RedactQueryParams = config.REDACT_QUERY_PARMS_HASH
+				record.Request.Method = http.MethodGet
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say("hash=9c9042adbe045596c2299990920eaa18536d66a1"))
+			})
+		})
+
 		Context("when DisableXFFLogging is specified", func() {
 			It("does not write x_forwarded_for as part of the access log", func() {
 				record.HeadersOverride = http.Header{
@@ -242,6 +269,72 @@ var _ = Describe("AccessLogRecord", func() {
 			})
 		})
 
+		Context("when the record is logged at the start of the request", func() {
+			It("marks the record as a start record", func() {
+				record.Start = true
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`vcap_request_id:"abc-123-xyz-pdq" `))
+				Eventually(r).Should(Say(`log_type:"start" x_cf_routererror:`))
+			})
+
+			It("does not mark completion records", func() {
+				Expect(record.LogMessage()).NotTo(ContainSubstring("log_type:"))
+			})
+		})
+
+		Context("when the zone and instance id of the router are set", func() {
+			BeforeEach(func() {
+				record.RouterZone = "z1"
+				record.RouterInstanceId = "router-instance"
+			})
+
+			It("adds them to the log line", func() {
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`router_zone:"z1" router_instance_id:"router-instance" x_cf_routererror:`))
+			})
+		})
+
+		Context("when the client protocol is logged", func() {
+			BeforeEach(func() {
+				record.LogClientProtocol = true
+			})
+
+			It("adds the protocol of HTTP/1.1 requests without ALPN", func() {
+				record.Request.Proto = "HTTP/1.1"
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`client_protocol:"HTTP/1.1" client_alpn:"-" x_cf_routererror:`))
+			})
+
+			It("adds the protocol of HTTP/2 requests and the negotiated ALPN", func() {
+				record.Request.Proto = "HTTP/2.0"
+				record.Request.TLS = &tls.ConnectionState{NegotiatedProtocol: "h2"}
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`client_protocol:"HTTP/2.0" client_alpn:"h2" x_cf_routererror:`))
+			})
+
+			It("adds an empty ALPN for TLS connections which did not negotiate one", func() {
+				record.Request.Proto = "HTTP/1.1"
+				record.Request.TLS = &tls.ConnectionState{}
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`client_protocol:"HTTP/1.1" client_alpn:"-" x_cf_routererror:`))
+			})
+		})
+
+		It("does not log the client protocol by default", func() {
+			Expect(record.LogMessage()).NotTo(ContainSubstring("client_protocol:"))
+		})
+
+		Context("when static fields are set", func() {
+			BeforeEach(func() {
+				record.StaticFields = map[string]string{"team": "platform", "environment": "production"}
+			})
+
+			It("adds them to the log line sorted by key", func() {
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`environment:"production" team:"platform" x_cf_routererror:`))
+			})
+		})
+
 		Context("with route endpoint missing", func() {
 			BeforeEach(func() {
 				record = &schema.AccessLogRecord{}
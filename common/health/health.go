@@ -2,6 +2,9 @@ package health
 
 import (
 	"sync"
+
+	"github.com/mdimiceli/gorouter/logger"
+	"go.uber.org/zap"
 )
 
 type Status uint64
@@ -14,11 +17,20 @@ const (
 
 type onDegradeCallback func()
 
+// TransitionReporter is notified whenever the health status changes.
+//
+//go:generate counterfeiter -o fakes/fake_transition_reporter.go . TransitionReporter
+type TransitionReporter interface {
+	CaptureHealthTransition(from, to Status)
+}
+
 type Health struct {
 	mu     sync.RWMutex // to lock health r/w
 	health Status
 
 	OnDegrade onDegradeCallback
+	Logger    logger.Logger
+	Reporter  TransitionReporter
 }
 
 func (h *Health) Health() Status {
@@ -35,16 +47,30 @@ func (h *Health) SetHealth(s Status) {
 		return
 	}
 
+	previous := h.health
 	h.health = s
 	h.mu.Unlock()
 
+	if previous != s {
+		if h.Logger != nil {
+			h.Logger.Info("health-status-changed", zap.Stringer("from", previous), zap.Stringer("to", s))
+		}
+		if h.Reporter != nil {
+			h.Reporter.CaptureHealthTransition(previous, s)
+		}
+	}
+
 	if h.OnDegrade != nil && s == Degraded {
 		h.OnDegrade()
 	}
 }
 
 func (h *Health) String() string {
-	switch h.Health() {
+	return h.Health().String()
+}
+
+func (s Status) String() string {
+	switch s {
 	case Initializing:
 		return "Initializing"
 	case Healthy:
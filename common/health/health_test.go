@@ -2,8 +2,12 @@ package health_test
 
 import (
 	. "github.com/mdimiceli/gorouter/common/health"
+	"github.com/mdimiceli/gorouter/common/health/fakes"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/test_util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Health", func() {
@@ -76,4 +80,46 @@ var _ = Describe("Health", func() {
 			})
 		})
 	})
+	Context("when the status changes", func() {
+		var (
+			testLogger logger.Logger
+			reporter   *fakes.FakeTransitionReporter
+		)
+
+		BeforeEach(func() {
+			testLogger = test_util.NewTestZapLogger("test")
+			reporter = &fakes.FakeTransitionReporter{}
+			h.Logger = testLogger
+			h.Reporter = reporter
+		})
+
+		It("logs and reports the transition", func() {
+			h.SetHealth(Healthy)
+
+			Expect(testLogger).To(gbytes.Say(`health-status-changed.*"from":"Initializing".*"to":"Healthy"`))
+			Expect(reporter.CaptureHealthTransitionCallCount()).To(Equal(1))
+			from, to := reporter.CaptureHealthTransitionArgsForCall(0)
+			Expect(from).To(Equal(Initializing))
+			Expect(to).To(Equal(Healthy))
+		})
+
+		It("does not log or report setting the same status again", func() {
+			h.SetHealth(Healthy)
+			h.SetHealth(Healthy)
+
+			Expect(reporter.CaptureHealthTransitionCallCount()).To(Equal(1))
+			Expect(testLogger).To(gbytes.Say("health-status-changed"))
+			Expect(testLogger).NotTo(gbytes.Say("health-status-changed"))
+		})
+
+		It("reports a degradation only once", func() {
+			h.SetHealth(Degraded)
+			h.SetHealth(Degraded)
+			h.SetHealth(Healthy)
+
+			Expect(reporter.CaptureHealthTransitionCallCount()).To(Equal(1))
+			_, to := reporter.CaptureHealthTransitionArgsForCall(0)
+			Expect(to).To(Equal(Degraded))
+		})
+	})
 })
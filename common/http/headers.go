@@ -10,6 +10,9 @@ const (
 	CfInstanceIdHeader    = "X-CF-InstanceID"
 	CfAppInstance         = "X-CF-APP-INSTANCE"
 	CfRouterError         = "X-Cf-RouterError"
+	CfInstanceHeader      = "X-Cf-Instance"
+
+	UpstreamResponseTimeHeader = "X-Upstream-Response-Time"
 )
 
 func SetTraceHeaders(responseWriter http.ResponseWriter, routerIp, addr string) {
@@ -4,9 +4,12 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base64"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 
 	"go.step.sm/crypto/pemutil"
 
@@ -35,13 +38,113 @@ const (
 	REDACT_QUERY_PARMS_NONE   string = "none"
 	REDACT_QUERY_PARMS_ALL    string = "all"
 	REDACT_QUERY_PARMS_HASH   string = "hash"
+	FSYNC_NEVER               string = "never"
+	FSYNC_ALWAYS              string = "always"
+	FSYNC_ON_ROTATE           string = "on_rotate"
+	HOST_PORT_STRIP_ANY       string = "any"
+	HOST_PORT_STRIP_LISTENER  string = "listener"
+	HOST_PORT_STRIP_NONE      string = "none"
+)
+
+const (
+	FULL_DUPLEX_FAILURE_PANIC   string = "panic"
+	FULL_DUPLEX_FAILURE_DEGRADE string = "degrade"
+)
+
+const (
+	FORWARDED_FOR_APPEND  string = "append"
+	FORWARDED_FOR_REPLACE string = "replace"
+	FORWARDED_FOR_REMOVE  string = "remove"
+)
+
+const (
+	X_REQUEST_START_PRESERVE  string = "preserve"
+	X_REQUEST_START_OVERWRITE string = "overwrite"
+)
+
+const (
+	PIPELINED_REQUESTS_ALLOW  string = "allow"
+	PIPELINED_REQUESTS_REJECT string = "reject"
+)
+
+const (
+	ABSOLUTE_FORM_REQUESTS_ALLOW  string = "allow"
+	ABSOLUTE_FORM_REQUESTS_REJECT string = "reject"
+)
+
+const (
+	ASTERISK_OPTIONS_RESPOND string = "respond"
+	ASTERISK_OPTIONS_FORWARD string = "forward"
+)
+
+const (
+	AUTH_NONE  string = "none"
+	AUTH_BASIC string = "basic"
+	AUTH_MTLS  string = "mtls"
+)
+
+const (
+	CONNECT_REQUESTS_REJECT string = "reject"
+	CONNECT_REQUESTS_TUNNEL string = "tunnel"
+)
+
+const (
+	REQUEST_RECEIVED_FORMAT_EPOCH_MS string = "epoch_ms"
+	REQUEST_RECEIVED_FORMAT_RFC3339  string = "rfc3339"
+)
+
+const (
+	LOG_ENCODING_JSON    string = "json"
+	LOG_ENCODING_CONSOLE string = "console"
+)
+
+const (
+	DUPLICATE_SET_COOKIE_PRESERVE  string = "preserve"
+	DUPLICATE_SET_COOKIE_KEEP_LAST string = "keep_last"
+)
+
+const (
+	ROUTE_CONFLICT_MERGE     string = "merge"
+	ROUTE_CONFLICT_REJECT    string = "reject"
+	ROUTE_CONFLICT_LAST_WINS string = "last_wins"
+)
+
+const (
+	TRAILING_SLASH_IGNORE          string = "ignore"
+	TRAILING_SLASH_REDIRECT_ADD    string = "redirect_add"
+	TRAILING_SLASH_REDIRECT_REMOVE string = "redirect_remove"
+)
+
+const (
+	TLS_HANDSHAKE_LIMIT_QUEUE  string = "queue"
+	TLS_HANDSHAKE_LIMIT_REJECT string = "reject"
 )
 
 var LoadBalancingStrategies = []string{LOAD_BALANCE_RR, LOAD_BALANCE_LC}
 var AZPreferences = []string{AZ_PREF_NONE, AZ_PREF_LOCAL}
 var AllowedShardingModes = []string{SHARD_ALL, SHARD_SEGMENTS, SHARD_SHARED_AND_SEGMENTS}
 var AllowedForwardedClientCertModes = []string{ALWAYS_FORWARD, FORWARD, SANITIZE_SET}
+var AllowedForwardedForModes = []string{FORWARDED_FOR_APPEND, FORWARDED_FOR_REPLACE, FORWARDED_FOR_REMOVE}
 var AllowedQueryParmRedactionModes = []string{REDACT_QUERY_PARMS_NONE, REDACT_QUERY_PARMS_ALL, REDACT_QUERY_PARMS_HASH}
+var AllowedAccessLogFsyncPolicies = []string{FSYNC_NEVER, FSYNC_ALWAYS, FSYNC_ON_ROTATE}
+var AllowedHostPortStripModes = []string{HOST_PORT_STRIP_ANY, HOST_PORT_STRIP_LISTENER, HOST_PORT_STRIP_NONE}
+var AllowedFullDuplexFailureModes = []string{FULL_DUPLEX_FAILURE_PANIC, FULL_DUPLEX_FAILURE_DEGRADE}
+var AllowedXRequestStartPolicies = []string{X_REQUEST_START_PRESERVE, X_REQUEST_START_OVERWRITE}
+var AllowedPipelinedRequestsModes = []string{PIPELINED_REQUESTS_ALLOW, PIPELINED_REQUESTS_REJECT}
+var AllowedAbsoluteFormRequestsModes = []string{ABSOLUTE_FORM_REQUESTS_ALLOW, ABSOLUTE_FORM_REQUESTS_REJECT}
+var AllowedAsteriskOptionsModes = []string{ASTERISK_OPTIONS_RESPOND, ASTERISK_OPTIONS_FORWARD}
+var AllowedAuthTypes = []string{AUTH_NONE, AUTH_BASIC, AUTH_MTLS}
+var AllowedConnectRequestsModes = []string{CONNECT_REQUESTS_REJECT, CONNECT_REQUESTS_TUNNEL}
+var AllowedRequestReceivedFormats = []string{REQUEST_RECEIVED_FORMAT_EPOCH_MS, REQUEST_RECEIVED_FORMAT_RFC3339}
+var AllowedLogEncodings = []string{LOG_ENCODING_JSON, LOG_ENCODING_CONSOLE}
+var AllowedDuplicateSetCookiePolicies = []string{DUPLICATE_SET_COOKIE_PRESERVE, DUPLICATE_SET_COOKIE_KEEP_LAST}
+var AllowedRouteConflictModes = []string{ROUTE_CONFLICT_MERGE, ROUTE_CONFLICT_REJECT, ROUTE_CONFLICT_LAST_WINS}
+var AllowedTLSHandshakeLimitModes = []string{TLS_HANDSHAKE_LIMIT_QUEUE, TLS_HANDSHAKE_LIMIT_REJECT}
+var AllowedTrailingSlashModes = []string{TRAILING_SLASH_IGNORE, TRAILING_SLASH_REDIRECT_ADD, TRAILING_SLASH_REDIRECT_REMOVE}
+
+// staticFieldKeyPattern matches the keys which can be written to the access
+// log in the key:"value" format without being misread.
+var staticFieldKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
 
 type StringSet map[string]struct{}
 
@@ -163,6 +266,57 @@ type BackendConfig struct {
 	MaxConns              int64            `yaml:"max_conns"`
 	MaxAttempts           int              `yaml:"max_attempts"`
 	TLSPem                `yaml:",inline"` // embed to get cert_chain and private_key for client authentication
+
+	// GzipRequestBodyMinSize is the minimum Content-Length of a request body
+	// to be compressed for routes that registered with gzip_request_body.
+	GzipRequestBodyMinSize int64 `yaml:"gzip_request_body_min_size"`
+
+	// RetryOnlyOnConnectionFailure restricts retries to failures to establish
+	// the connection, before any bytes of the request were written.
+	RetryOnlyOnConnectionFailure bool `yaml:"retry_only_on_connection_failure"`
+
+	// MaxRequestHeaderBytes caps the size of the request headers forwarded to
+	// backends, including the headers added by the router. Zero disables it.
+	MaxRequestHeaderBytes int `yaml:"max_request_header_bytes"`
+
+	// SlowStartWindow is how long it takes newly registered endpoints to
+	// receive their full share of the requests to their route. Their share
+	// rises linearly over the window. Zero disables slow start.
+	SlowStartWindow time.Duration `yaml:"slow_start_window"`
+
+	// RetryBodyBufferBytes is the largest request body buffered in memory so
+	// that requests with an idempotency key can be retried with their body.
+	// Requests with larger bodies are not retried. Zero disables buffering.
+	RetryBodyBufferBytes int64 `yaml:"retry_body_buffer_bytes"`
+
+	// RouteMaxConnsQueueTimeout is how long requests wait for a connection of
+	// a route that registered max_connections and has all of them in use,
+	// before they fail with a 503.
+	RouteMaxConnsQueueTimeout time.Duration `yaml:"route_max_conns_queue_timeout"`
+
+	// MaxHTTP2Requests limits the in-flight requests to each HTTP/2 backend
+	// instead of MaxConns, as they multiplex requests on few connections.
+	// Zero applies MaxConns to them too.
+	MaxHTTP2Requests int64 `yaml:"max_http2_requests"`
+
+	// DecompressResponseMaxBytes bounds the decompressed size of responses of
+	// routes that registered with decompress_response. Responses which
+	// decompress to more are cut off. Zero removes the bound.
+	DecompressResponseMaxBytes int64 `yaml:"decompress_response_max_bytes"`
+
+	// ExcludeAttemptedEndpoints keeps retries of a request from being sent
+	// to an endpoint that was already attempted, unless all were.
+	ExcludeAttemptedEndpoints bool `yaml:"exclude_attempted_endpoints"`
+
+	// ResponseDigestMaxBytes is the largest response body buffered to compute
+	// the Digest and ETag headers for routes that registered with
+	// response_digest. Larger responses are passed on without them.
+	ResponseDigestMaxBytes int64 `yaml:"response_digest_max_bytes"`
+
+	// RejectUnexpectedSwitchingProtocols fails requests with a 502 if their
+	// backend responds with 101 Switching Protocols although the client did
+	// not request an upgrade.
+	RejectUnexpectedSwitchingProtocols bool `yaml:"reject_unexpected_switching_protocols"`
 }
 
 type RouteServiceConfig struct {
@@ -172,17 +326,25 @@ type RouteServiceConfig struct {
 }
 
 type LoggingConfig struct {
-	Syslog                 string       `yaml:"syslog"`
-	SyslogAddr             string       `yaml:"syslog_addr"`
-	SyslogNetwork          string       `yaml:"syslog_network"`
-	Level                  string       `yaml:"level"`
-	LoggregatorEnabled     bool         `yaml:"loggregator_enabled"`
-	MetronAddress          string       `yaml:"metron_address"`
-	DisableLogForwardedFor bool         `yaml:"disable_log_forwarded_for"`
-	DisableLogSourceIP     bool         `yaml:"disable_log_source_ip"`
-	RedactQueryParams      string       `yaml:"redact_query_params"`
-	EnableAttemptsDetails  bool         `yaml:"enable_attempts_details"`
-	Format                 FormatConfig `yaml:"format"`
+	Syslog                      string       `yaml:"syslog"`
+	SyslogAddr                  string       `yaml:"syslog_addr"`
+	SyslogNetwork               string       `yaml:"syslog_network"`
+	Level                       string       `yaml:"level"`
+	LoggregatorEnabled          bool         `yaml:"loggregator_enabled"`
+	LoggregatorEmitterWorkers   int          `yaml:"loggregator_emitter_workers"`
+	LoggregatorEmitterQueueSize int          `yaml:"loggregator_emitter_queue_size"`
+	MetronAddress               string       `yaml:"metron_address"`
+	DisableLogForwardedFor      bool         `yaml:"disable_log_forwarded_for"`
+	DisableLogSourceIP          bool         `yaml:"disable_log_source_ip"`
+	RedactQueryParams           string       `yaml:"redact_query_params"`
+	RedactQueryParamNames       []string     `yaml:"redact_query_param_names"`
+	EnableAttemptsDetails       bool         `yaml:"enable_attempts_details"`
+	FailedAttemptsSummary       bool         `yaml:"failed_attempts_summary"`
+	BackendErrorFields          bool         `yaml:"backend_error_fields"`
+	RouteLookupMissesPerSecond  int          `yaml:"route_lookup_misses_per_second"`
+	Zone                        string       `yaml:"zone"`
+	InstanceId                  string       `yaml:"instance_id"`
+	Format                      FormatConfig `yaml:"format"`
 
 	// This field is populated by the `Process` function.
 	JobName string `yaml:"-"`
@@ -190,17 +352,263 @@ type LoggingConfig struct {
 
 type FormatConfig struct {
 	Timestamp string `yaml:"timestamp"`
+	// Encoding selects whether the router logs are written as JSON or in
+	// the human readable console format.
+	Encoding string `yaml:"encoding"`
 }
 
 type AccessLog struct {
-	File            string `yaml:"file"`
-	EnableStreaming bool   `yaml:"enable_streaming"`
+	File            string            `yaml:"file"`
+	EnableStreaming bool              `yaml:"enable_streaming"`
+	Rotation        AccessLogRotation `yaml:"rotation"`
+	LogRequestStart bool              `yaml:"log_request_start"`
+
+	// StaticFields are added to every access log record and as tags to the
+	// logs sent to loggregator.
+	StaticFields map[string]string `yaml:"static_fields"`
+
+	// LogClientProtocol adds the protocol of the client request and the
+	// protocol negotiated with ALPN on TLS connections to the access log.
+	LogClientProtocol bool `yaml:"log_client_protocol"`
+
+	OnlyErrors AccessLogOnlyErrors `yaml:"only_errors"`
+}
+
+// AccessLogOnlyErrors leaves the records of responses with a status code in
+// the suppressed range out of the access log, for all routes if enabled and
+// otherwise for routes which registered with access_log_only_errors. The
+// range defaults to 1xx to 3xx, so that only 4xx and 5xx responses are logged.
+type AccessLogOnlyErrors struct {
+	Enabled             bool `yaml:"enabled"`
+	SuppressedStatusMin int  `yaml:"suppressed_status_min"`
+	SuppressedStatusMax int  `yaml:"suppressed_status_max"`
+}
+
+// AccessLogRotation configures rotation of the access log file. Rotation is
+// disabled unless at least one of MaxSize or MaxAge is set.
+type AccessLogRotation struct {
+	MaxSize    int64         `yaml:"max_size"`
+	MaxAge     time.Duration `yaml:"max_age"`
+	MaxBackups int           `yaml:"max_backups"`
+	Fsync      string        `yaml:"fsync"`
+}
+
+func (r AccessLogRotation) Enabled() bool {
+	return r.MaxSize > 0 || r.MaxAge > 0
+}
+
+var defaultAccessLogConfig = AccessLog{
+	Rotation: AccessLogRotation{
+		MaxBackups: 5,
+		Fsync:      FSYNC_NEVER,
+	},
+	OnlyErrors: AccessLogOnlyErrors{
+		SuppressedStatusMin: 100,
+		SuppressedStatusMax: 399,
+	},
 }
 
 type Tracing struct {
-	EnableZipkin bool   `yaml:"enable_zipkin"`
-	EnableW3C    bool   `yaml:"enable_w3c"`
-	W3CTenantID  string `yaml:"w3c_tenant_id"`
+	EnableZipkin bool          `yaml:"enable_zipkin"`
+	EnableW3C    bool          `yaml:"enable_w3c"`
+	W3CTenantID  string        `yaml:"w3c_tenant_id"`
+	Baggage      BaggageConfig `yaml:"baggage"`
+}
+
+// BaggageConfig configures the validation of W3C baggage headers, which are
+// passed on to backends unless they are malformed or exceed the limits. Zero
+// limits default to those of the W3C specification, 64 entries and 8192
+// bytes. Log adds the baggage header to the access log.
+type BaggageConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	MaxEntries int  `yaml:"max_entries"`
+	MaxBytes   int  `yaml:"max_bytes"`
+	Log        bool `yaml:"log"`
+}
+
+// TLSPassthroughConfig configures a listener that forwards TLS connections to
+// backends without terminating them, routing on the ClientHello SNI server
+// name only. Only routes registered with tls_passthrough are forwarded.
+// IdleTimeout closes a connection once no data was sent through it in either
+// direction for that long; zero disables it. On shutdown, connections still
+// open after drain_timeout are closed.
+type TLSPassthroughConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Port               uint16        `yaml:"port"`
+	ClientHelloTimeout time.Duration `yaml:"client_hello_timeout"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout"`
+}
+
+var defaultTLSPassthroughConfig = TLSPassthroughConfig{
+	ClientHelloTimeout: 10 * time.Second,
+	IdleTimeout:        900 * time.Second,
+}
+
+// OCSPStaplingConfig configures stapling of OCSP responses for the
+// certificates served on the frontend TLS listener.
+type OCSPStaplingConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	FetchTimeout    time.Duration `yaml:"fetch_timeout"`
+}
+
+var defaultOCSPStaplingConfig = OCSPStaplingConfig{
+	RefreshInterval: 1 * time.Hour,
+	FetchTimeout:    10 * time.Second,
+}
+
+// ECHConfig configures Encrypted Client Hello on the frontend TLS listener.
+// Clients that do not use ECH, or whose ECH config cannot be decrypted, are
+// served using the outer ClientHello.
+type ECHConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Keys    []ECHKey `yaml:"keys"`
+
+	ServerKeys []tls.EncryptedClientHelloKey `yaml:"-"`
+}
+
+// ECHKey holds a base64 encoded ECHConfig, as published to clients, and the
+// base64 encoded HPKE private key of its KEM.
+type ECHKey struct {
+	Config      string `yaml:"config"`
+	PrivateKey  string `yaml:"private_key"`
+	SendAsRetry bool   `yaml:"send_as_retry"`
+}
+
+// ClientConnectionConfig tunes the sockets of the connections accepted on the
+// frontend listeners. Keep-alive values of zero keep the Go defaults.
+type ClientConnectionConfig struct {
+	TCPNoDelay        bool          `yaml:"tcp_nodelay"`
+	KeepAlive         bool          `yaml:"keep_alive"`
+	KeepAliveIdle     time.Duration `yaml:"keep_alive_idle"`
+	KeepAliveInterval time.Duration `yaml:"keep_alive_interval"`
+	KeepAliveCount    int           `yaml:"keep_alive_count"`
+}
+
+var defaultClientConnectionConfig = ClientConnectionConfig{
+	TCPNoDelay: true,
+	KeepAlive:  true,
+}
+
+// TLSHandshakeLimitConfig bounds the number of TLS handshakes in progress on
+// the frontend TLS listener. Connections beyond MaxConcurrent wait up to
+// QueueTimeout for a handshake to finish, or are closed right away in reject
+// mode. A handshake taking longer than HandshakeTimeout is aborted. Zero
+// MaxConcurrent disables the limit.
+type TLSHandshakeLimitConfig struct {
+	MaxConcurrent    int           `yaml:"max_concurrent"`
+	Mode             string        `yaml:"mode"`
+	QueueTimeout     time.Duration `yaml:"queue_timeout"`
+	HandshakeTimeout time.Duration `yaml:"handshake_timeout"`
+}
+
+var defaultTLSHandshakeLimitConfig = TLSHandshakeLimitConfig{
+	Mode:             TLS_HANDSHAKE_LIMIT_QUEUE,
+	QueueTimeout:     5 * time.Second,
+	HandshakeTimeout: 10 * time.Second,
+}
+
+// DebugTapConfig configures the debug tap, a stream of request metadata for
+// a route served on the routes endpoint.
+type DebugTapConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	MaxSubscribers int  `yaml:"max_subscribers"`
+	BufferSize     int  `yaml:"buffer_size"`
+}
+
+var defaultDebugTapConfig = DebugTapConfig{
+	MaxSubscribers: 5,
+	BufferSize:     100,
+}
+
+// StageTimingConfig configures recording the time each handler of the proxy
+// chain spends on a request. The breakdown is logged for requests taking at
+// least SlowRequestThreshold. Meant for debugging performance.
+type StageTimingConfig struct {
+	Enabled              bool          `yaml:"enabled"`
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold"`
+}
+
+var defaultStageTimingConfig = StageTimingConfig{
+	SlowRequestThreshold: 1 * time.Second,
+}
+
+// GeoIPConfig configures adding the country and autonomous system of the
+// client to requests, looked up in a GeoIP database by the client IP. The
+// database is a CSV file of network,country,asn lines, reloaded every
+// ReloadInterval if it changed. Enabled when DatabasePath is set.
+type GeoIPConfig struct {
+	DatabasePath   string        `yaml:"database_path"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+	CountryHeader  string        `yaml:"country_header"`
+	ASNHeader      string        `yaml:"asn_header"`
+}
+
+var defaultGeoIPConfig = GeoIPConfig{
+	ReloadInterval: 1 * time.Minute,
+	CountryHeader:  "X-Geo-Country",
+	ASNHeader:      "X-Geo-ASN",
+}
+
+// RegistrySnapshotConfig configures writing the routing table to a file every
+// Interval and on shutdown, and loading it on startup as a warm cache. Loaded
+// endpoints which are not registered again within TTL are removed by the first
+// pruning cycle after it. Enabled when Path is set.
+type RegistrySnapshotConfig struct {
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+	TTL      time.Duration `yaml:"ttl"`
+}
+
+var defaultRegistrySnapshotConfig = RegistrySnapshotConfig{
+	Interval: 30 * time.Second,
+	TTL:      30 * time.Second,
+}
+
+// PanicResponseConfig configures the response sent to the client when a
+// panic is recovered while handling its request.
+type PanicResponseConfig struct {
+	StatusCode      int  `yaml:"status_code"`
+	CloseConnection bool `yaml:"close_connection"`
+	UseErrorWriter  bool `yaml:"use_error_writer"`
+}
+
+var defaultPanicResponseConfig = PanicResponseConfig{
+	StatusCode:      http.StatusBadGateway,
+	CloseConnection: true,
+}
+
+// MaintenancePageConfig configures the response sent to the client for
+// routes that are down for maintenance.
+type MaintenancePageConfig struct {
+	StatusCode int    `yaml:"status_code"`
+	Message    string `yaml:"message"`
+}
+
+var defaultMaintenancePageConfig = MaintenancePageConfig{
+	StatusCode: http.StatusServiceUnavailable,
+	Message:    "Service is down for maintenance",
+}
+
+// AuthConfig selects the built-in authentication handler requests must pass
+// before they are proxied.
+type AuthConfig struct {
+	Type  string          `yaml:"type"`
+	Basic BasicAuthConfig `yaml:"basic"`
+}
+
+// BasicAuthConfig configures HTTP basic authentication. Users maps user names
+// to passwords.
+type BasicAuthConfig struct {
+	Realm string            `yaml:"realm"`
+	Users map[string]string `yaml:"users"`
+}
+
+var defaultAuthConfig = AuthConfig{
+	Type: AUTH_NONE,
+	Basic: BasicAuthConfig{
+		Realm: "gorouter",
+	},
 }
 
 type TLSPem struct {
@@ -209,12 +617,14 @@ type TLSPem struct {
 }
 
 var defaultLoggingConfig = LoggingConfig{
-	Level:                 "debug",
-	MetronAddress:         "localhost:3457",
-	Format:                FormatConfig{"unix-epoch"},
-	JobName:               "gorouter",
-	RedactQueryParams:     REDACT_QUERY_PARMS_NONE,
-	EnableAttemptsDetails: false,
+	Level:                       "debug",
+	MetronAddress:               "localhost:3457",
+	Format:                      FormatConfig{Timestamp: "unix-epoch", Encoding: LOG_ENCODING_JSON},
+	JobName:                     "gorouter",
+	RedactQueryParams:           REDACT_QUERY_PARMS_NONE,
+	EnableAttemptsDetails:       false,
+	LoggregatorEmitterWorkers:   2,
+	LoggregatorEmitterQueueSize: 4096,
 }
 
 type HeaderNameValue struct {
@@ -231,6 +641,20 @@ type HTTPRewriteResponses struct {
 	RemoveHeaders          []HeaderNameValue `yaml:"remove_headers,omitempty"`
 }
 
+// PathNormalizationConfig controls how request paths are normalized before
+// the route is looked up. In strict mode requests containing '..' segments
+// are rejected with a 400 instead of being resolved.
+type PathNormalizationConfig struct {
+	MergeSlashes             bool `yaml:"merge_slashes"`
+	RemoveDotSegments        bool `yaml:"remove_dot_segments"`
+	NormalizePercentEncoding bool `yaml:"normalize_percent_encoding"`
+	Strict                   bool `yaml:"strict"`
+}
+
+func (p PathNormalizationConfig) Enabled() bool {
+	return p.MergeSlashes || p.RemoveDotSegments || p.NormalizePercentEncoding || p.Strict
+}
+
 // VerifyClientCertificateMetadataRules defines verification rules for client certificates, which allow additional checks
 // for the certificates' subject.
 //
@@ -369,6 +793,10 @@ type Config struct {
 	GoMaxProcs                     int               `yaml:"go_max_procs,omitempty"`
 	Tracing                        Tracing           `yaml:"tracing,omitempty"`
 	TraceKey                       string            `yaml:"trace_key,omitempty"`
+	EmitBackendInstanceHeader      bool              `yaml:"emit_backend_instance_header,omitempty"`
+	EmitUpstreamResponseTimeHeader bool              `yaml:"emit_upstream_response_time_header,omitempty"`
+	OverrideBackendDateHeader      bool              `yaml:"override_backend_date_header,omitempty"`
+	StripResponseHeaders           []string          `yaml:"strip_response_headers,omitempty"`
 	AccessLog                      AccessLog         `yaml:"access_log,omitempty"`
 	DebugAddr                      string            `yaml:"debug_addr,omitempty"`
 	EnablePROXY                    bool              `yaml:"enable_proxy,omitempty"`
@@ -388,7 +816,11 @@ type Config struct {
 	ForwardedClientCert      string   `yaml:"forwarded_client_cert,omitempty"`
 	ForceForwardedProtoHttps bool     `yaml:"force_forwarded_proto_https,omitempty"`
 	SanitizeForwardedProto   bool     `yaml:"sanitize_forwarded_proto,omitempty"`
+	ForwardedForMode         string   `yaml:"forwarded_for_mode,omitempty"`
 	HopByHopHeadersToFilter  []string `yaml:"hop_by_hop_headers_to_filter"`
+	// ForceTETrailers sends `TE: trailers` to every backend, even when the
+	// client did not, for backends such as gRPC servers that require it.
+	ForceTETrailers          bool     `yaml:"force_te_trailers,omitempty"`
 	IsolationSegments        []string `yaml:"isolation_segments,omitempty"`
 	RoutingTableShardingMode string   `yaml:"routing_table_sharding_mode,omitempty"`
 
@@ -414,10 +846,32 @@ type Config struct {
 	StartResponseDelayInterval      time.Duration `yaml:"start_response_delay_interval,omitempty"`
 	EndpointTimeout                 time.Duration `yaml:"endpoint_timeout,omitempty"`
 	EndpointDialTimeout             time.Duration `yaml:"endpoint_dial_timeout,omitempty"`
+	BackendTimeToFirstByte          time.Duration `yaml:"backend_time_to_first_byte,omitempty"`
 	WebsocketDialTimeout            time.Duration `yaml:"websocket_dial_timeout,omitempty"`
 	EndpointKeepAliveProbeInterval  time.Duration `yaml:"endpoint_keep_alive_probe_interval,omitempty"`
 	RouteServiceTimeout             time.Duration `yaml:"route_services_timeout,omitempty"`
 	FrontendIdleTimeout             time.Duration `yaml:"frontend_idle_timeout,omitempty"`
+	FrontendReadTimeout             time.Duration `yaml:"frontend_read_timeout,omitempty"`
+
+	// FrontendWriteStallTimeout aborts a response when writing it to the
+	// client blocks for longer than this. Response bodies are copied from
+	// backends through a fixed size buffer, so a slow client throttles reads
+	// from the backend; this bounds how long it may hold the backend
+	// connection. Zero disables it.
+	FrontendWriteStallTimeout time.Duration `yaml:"frontend_write_stall_timeout,omitempty"`
+
+	// MaxClientConnectionAge closes client connections older than this after
+	// their next response, so that load balancers can rebalance keep-alive
+	// connections. HTTP/2 connections are shut down gracefully. Zero keeps
+	// connections open regardless of their age.
+	MaxClientConnectionAge time.Duration `yaml:"max_client_connection_age,omitempty"`
+
+	// RegistrationDebounceInterval coalesces registrations of an endpoint
+	// which are identical to its last one within the interval. It must be
+	// shorter than DropletStaleThreshold, and endpoints registered with a
+	// stale threshold which is not longer are never debounced. Zero disables
+	// it.
+	RegistrationDebounceInterval time.Duration `yaml:"registration_debounce_interval,omitempty"`
 
 	RouteLatencyMetricMuzzleDuration time.Duration `yaml:"route_latency_metric_muzzle_duration,omitempty"`
 
@@ -428,6 +882,22 @@ type Config struct {
 	StickySessionsForAuthNegotiate bool          `yaml:"sticky_sessions_for_auth_negotiate"`
 	HealthCheckUserAgent           string        `yaml:"healthcheck_user_agent,omitempty"`
 
+	// HealthCheckCacheTTL has the health evaluated at most once per TTL, so
+	// that storms of health checks do not each evaluate it. It must be
+	// shorter than a second. Zero evaluates the health for every check.
+	HealthCheckCacheTTL time.Duration `yaml:"healthcheck_cache_ttl,omitempty"`
+
+	// DrainOnTerm shuts the router down on SIGTERM and SIGINT the way it does
+	// on SIGUSR1: it fails the health check, keeps accepting connections for
+	// DrainWait so that load balancers can deregister it, stops accepting
+	// connections and waits up to DrainTimeout for the requests in flight.
+	// Otherwise these signals stop the router right away.
+	DrainOnTerm bool `yaml:"drain_on_term,omitempty"`
+	// DrainForceClose closes the connections which are still serving requests
+	// when the drain times out, instead of leaving them open until the
+	// process exits.
+	DrainForceClose bool `yaml:"drain_force_close,omitempty"`
+
 	OAuth                             OAuthConfig      `yaml:"oauth,omitempty"`
 	RoutingApi                        RoutingApiConfig `yaml:"routing_api,omitempty"`
 	RouteServiceSecret                string           `yaml:"route_services_secret,omitempty"`
@@ -458,18 +928,196 @@ type Config struct {
 	MaxIdleConns        int  `yaml:"max_idle_conns,omitempty"`
 	MaxIdleConnsPerHost int  `yaml:"max_idle_conns_per_host,omitempty"`
 	MaxHeaderBytes      int  `yaml:"max_header_bytes"`
+	MaxRequestURILength int  `yaml:"max_request_uri_length"`
+	// MaxHeaderCount caps the number of header fields of a request, on top of
+	// their total size. Zero disables it.
+	MaxHeaderCount int `yaml:"max_header_count"`
+	// AllowedUpgradeProtocols lists the protocols, e.g. websocket, clients may
+	// upgrade connections to. Empty allows any protocol.
+	AllowedUpgradeProtocols []string `yaml:"allowed_upgrade_protocols,omitempty"`
+	// AllowedHosts lists the hosts requests may be addressed to, regardless of
+	// the routes registered. An entry is either a host or a wildcard such as
+	// *.example.com, which matches any of its subdomains. Empty allows any host.
+	AllowedHosts []string `yaml:"allowed_hosts,omitempty"`
+
+	// KeepAliveHTTP10Clients keeps the connections of HTTP/1.0 clients that
+	// send Connection: keep-alive open after responses of a known length.
+	// When disabled, they are closed after every response. Responses to
+	// HTTP/1.0 clients are never chunked: a body of unknown length is
+	// delimited by closing the connection.
+	KeepAliveHTTP10Clients bool `yaml:"keep_alive_http10_clients"`
+
+	// NormalizeResponseConnectionHeader removes the tokens of the Connection
+	// header of responses which are not valid for the protocol of the client:
+	// HTTP/1.0 clients keep keep-alive and close, HTTP/1.1 clients only close,
+	// upgraded connections only upgrade, and HTTP/2 clients none at all.
+	NormalizeResponseConnectionHeader bool `yaml:"normalize_response_connection_header"`
+
+	// CaptureRequestHeaderCase records the spelling of the request header
+	// names clients send on HTTP/1 connections, before they are
+	// canonicalized, so that routes registered with preserve_header_case can
+	// forward them as sent. Routes with preserve_header_case are not
+	// registered unless it is set.
+	CaptureRequestHeaderCase bool `yaml:"capture_request_header_case"`
+
+	// StrictHeaderValidation rejects HTTP/1 requests with folded header lines
+	// (obs-fold) with a 400 and closes the connection. By default folded
+	// lines are joined into a single line, as RFC 9112 allows. Header values
+	// with a bare carriage return or other control characters, and header
+	// names which are not tokens, are always rejected with a 400 by the
+	// server, before the request is routed.
+	StrictHeaderValidation bool `yaml:"strict_header_validation"`
+
+	// StrictRequestFraming rejects HTTP/1 requests whose body length is
+	// ambiguous, as they can be used to smuggle requests, with a 400 and
+	// closes the connection: those with both a Content-Length and a
+	// Transfer-Encoding, or more than one Content-Length. It is enabled by
+	// default. When disabled, the server drops the Content-Length of requests
+	// with a Transfer-Encoding and merges identical Content-Length headers, so
+	// such requests are forwarded chunked or with a single Content-Length.
+	// Differing Content-Length headers are always rejected by the server.
+	StrictRequestFraming bool `yaml:"strict_request_framing"`
 
 	HTTPRewrite HTTPRewrite `yaml:"http_rewrite,omitempty"`
 
+	PathNormalization PathNormalizationConfig `yaml:"path_normalization,omitempty"`
+
+	// HostPortStripMode controls which ports are stripped from the Host
+	// header before the route is looked up: any port, only the ports the
+	// router listens on, or none, so that a Host with a port only matches
+	// routes registered with that port.
+	HostPortStripMode string `yaml:"host_port_strip_mode,omitempty"`
+
+	// TrailingSlashMode controls requests whose path is the path of the
+	// matched route with or without a trailing slash. Routes match either
+	// path, as their own trailing slash is not kept when registered. They
+	// can instead be redirected to the path with the slash added or removed.
+	TrailingSlashMode string `yaml:"trailing_slash_mode,omitempty"`
+
+	// CaseSensitiveRoutePaths matches the paths of routes case-sensitively.
+	// Hosts are always matched case-insensitively. By default paths are
+	// lowercased when routes are registered and looked up, which costs a
+	// copy of the path on every request.
+	CaseSensitiveRoutePaths bool `yaml:"case_sensitive_route_paths,omitempty"`
+
+	TLSPassthrough TLSPassthroughConfig `yaml:"tls_passthrough,omitempty"`
+
+	OCSPStapling OCSPStaplingConfig `yaml:"ocsp_stapling,omitempty"`
+
+	ECH ECHConfig `yaml:"ech,omitempty"`
+
+	ClientConnections ClientConnectionConfig `yaml:"client_connections,omitempty"`
+
+	TLSHandshakeLimit TLSHandshakeLimitConfig `yaml:"tls_handshake_limit,omitempty"`
+
+	// FullDuplexFailureMode controls what happens to a request for which
+	// full-duplex HTTP/1.1 cannot be enabled: fail it, or serve it in
+	// half-duplex mode.
+	FullDuplexFailureMode string `yaml:"full_duplex_failure_mode,omitempty"`
+
+	// XRequestStartPolicy controls whether an X-Request-Start header sent by
+	// the client is preserved, or always overwritten with the time at which
+	// the request is forwarded to the backend.
+	XRequestStartPolicy string `yaml:"x_request_start_policy,omitempty"`
+
+	// RequestReceivedHeader names a header sent to backends with the time at
+	// which gorouter received the request, in RequestReceivedFormat. Unlike
+	// X-Request-Start it is always set by gorouter. Empty disables it.
+	RequestReceivedHeader string `yaml:"request_received_header,omitempty"`
+	RequestReceivedFormat string `yaml:"request_received_format,omitempty"`
+
+	// ClientIPHeader names a header sent to backends with the IP of the
+	// client, independently of X-Forwarded-For. The client IP is taken from
+	// the X-Forwarded-For chain, skipping ClientIPTrustedProxyDepth trusted
+	// proxies in front of gorouter. Empty disables it.
+	ClientIPHeader            string `yaml:"client_ip_header,omitempty"`
+	ClientIPTrustedProxyDepth int    `yaml:"client_ip_trusted_proxy_depth,omitempty"`
+
+	// ResponseRequestIDHeader names a header sent to clients with the
+	// X-Vcap-Request-Id that was sent to the backend, replacing any value set
+	// by the backend, so that clients can refer to the request, e.g. in
+	// support tickets. Empty disables it.
+	ResponseRequestIDHeader string `yaml:"response_request_id_header,omitempty"`
+
+	// DuplicateSetCookiePolicy controls Set-Cookie headers of a response which
+	// set the same cookie: forward all of them, or only the last one.
+	DuplicateSetCookiePolicy string `yaml:"duplicate_set_cookie_policy,omitempty"`
+
+	// RouteConflictMode controls the registration of a route by an app while
+	// another app has endpoints registered for it: merge the endpoints of both
+	// apps, reject the registration, or replace the endpoints of the other app.
+	RouteConflictMode string `yaml:"route_conflict_mode,omitempty"`
+
+	// PipelinedRequests controls HTTP/1.1 requests a client sends before it
+	// has received the response to its previous request on the connection:
+	// serve them one after the other, or reject them and close the connection.
+	PipelinedRequests string `yaml:"pipelined_requests,omitempty"`
+
+	// AbsoluteFormRequests controls requests with an absolute-form target, as
+	// in GET http://host/path: route them by the host of the target, which
+	// replaces their Host header, or reject them. The HTTP/1.1 server drops
+	// the Host header of these requests, so it cannot be routed by instead.
+	AbsoluteFormRequests string `yaml:"absolute_form_requests,omitempty"`
+
+	// AsteriskOptionsRequests controls OPTIONS * requests, which ask about the
+	// capabilities of the server rather than of a resource: respond to them
+	// with a 200 carrying AsteriskOptionsAllow as Allow header, or forward
+	// them to a backend of the route for the root path of their host.
+	AsteriskOptionsRequests string `yaml:"asterisk_options_requests,omitempty"`
+	AsteriskOptionsAllow    string `yaml:"asterisk_options_allow,omitempty"`
+
+	// ConnectRequests controls CONNECT requests: reject all of them with a
+	// 405, or tunnel them to a backend of routes which opted in to tunneling.
+	ConnectRequests string `yaml:"connect_requests,omitempty"`
+	// ConnectTunnelIdleTimeout closes tunnels of CONNECT requests through
+	// which no data was sent in either direction for that long. Zero disables
+	// it.
+	ConnectTunnelIdleTimeout time.Duration `yaml:"connect_tunnel_idle_timeout,omitempty"`
+
+	DebugTap DebugTapConfig `yaml:"debug_tap,omitempty"`
+
+	StageTiming StageTimingConfig `yaml:"stage_timing,omitempty"`
+
+	GeoIP GeoIPConfig `yaml:"geo_ip,omitempty"`
+
+	RegistrySnapshot RegistrySnapshotConfig `yaml:"registry_snapshot,omitempty"`
+
+	PanicResponse PanicResponseConfig `yaml:"panic_response,omitempty"`
+
+	MaintenancePage MaintenancePageConfig `yaml:"maintenance_page,omitempty"`
+
+	// Auth selects how requests are authenticated before they are proxied.
+	// Requests are authenticated once their route is looked up, so requests
+	// for unknown routes are rejected without authentication.
+	Auth AuthConfig `yaml:"auth,omitempty"`
+
+	// RouteBasicAuth holds named basic auth credentials. Routes opt in to
+	// basic authentication by naming one of them when they register.
+	RouteBasicAuth map[string]BasicAuthConfig `yaml:"route_basic_auth,omitempty"`
+
 	EmptyPoolResponseCode503 bool          `yaml:"empty_pool_response_code_503,omitempty"`
 	EmptyPoolTimeout         time.Duration `yaml:"empty_pool_timeout,omitempty"`
 
 	HTMLErrorTemplateFile string `yaml:"html_error_template_file,omitempty"`
+	// ErrorCacheControl is the Cache-Control header of 5xx responses
+	// generated by gorouter itself, so that caches in front of it do not
+	// store them. Empty leaves the header unset.
+	ErrorCacheControl string `yaml:"error_cache_control"`
 
 	// Old metric, to eventually be replaced by prometheus reporting
 	// reports latency under gorouter sourceid, and with and without component name
 	PerRequestMetricsReporting bool `yaml:"per_request_metrics_reporting,omitempty"`
 
+	// RegistryChurnMetricsReporting reports the number of endpoints in the
+	// routing table and the rate at which endpoints are registered and
+	// unregistered.
+	RegistryChurnMetricsReporting bool `yaml:"registry_churn_metrics_reporting,omitempty"`
+
+	// ConcurrentConnectionsMetricReporting reports the number of client
+	// connections currently open every second, e.g. to size file descriptor
+	// limits and detect connection leaks.
+	ConcurrentConnectionsMetricReporting bool `yaml:"concurrent_connections_metric_reporting,omitempty"`
+
 	// Old metric, to eventually be replaced by prometheus reporting
 	SendHttpStartStopServerEvent bool `yaml:"send_http_start_stop_server_event,omitempty"`
 
@@ -486,6 +1134,7 @@ var defaultConfig = Config{
 	Status:                         defaultStatusConfig,
 	Nats:                           defaultNatsConfig,
 	Logging:                        defaultLoggingConfig,
+	AccessLog:                      defaultAccessLogConfig,
 	Port:                           8081,
 	Index:                          0,
 	GoMaxProcs:                     -1,
@@ -498,9 +1147,29 @@ var defaultConfig = Config{
 	MinTLSVersion:                  tls.VersionTLS12,
 	MaxTLSVersion:                  tls.VersionTLS12,
 	RouteServicesServerPort:        7070,
+	Backends: BackendConfig{
+		GzipRequestBodyMinSize:     1024,
+		RouteMaxConnsQueueTimeout:  500 * time.Millisecond,
+		DecompressResponseMaxBytes: 10 * 1024 * 1024,
+		ExcludeAttemptedEndpoints:  true,
+		ResponseDigestMaxBytes:     64 * 1024,
+
+		RejectUnexpectedSwitchingProtocols: true,
+	},
 
 	EndpointTimeout:                60 * time.Second,
 	EndpointDialTimeout:            5 * time.Second,
+	TLSPassthrough:                 defaultTLSPassthroughConfig,
+	OCSPStapling:                   defaultOCSPStaplingConfig,
+	ClientConnections:              defaultClientConnectionConfig,
+	TLSHandshakeLimit:              defaultTLSHandshakeLimitConfig,
+	DebugTap:                       defaultDebugTapConfig,
+	StageTiming:                    defaultStageTimingConfig,
+	GeoIP:                          defaultGeoIPConfig,
+	RegistrySnapshot:               defaultRegistrySnapshotConfig,
+	PanicResponse:                  defaultPanicResponseConfig,
+	MaintenancePage:                defaultMaintenancePageConfig,
+	Auth:                           defaultAuthConfig,
 	EndpointKeepAliveProbeInterval: 1 * time.Second,
 	RouteServiceTimeout:            60 * time.Second,
 	TLSHandshakeTimeout:            10 * time.Second,
@@ -530,12 +1199,29 @@ var defaultConfig = Config{
 	LoadBalanceAZPreference: AZ_PREF_NONE,
 
 	ForwardedClientCert:      "always_forward",
+	ForwardedForMode:         FORWARDED_FOR_APPEND,
 	RoutingTableShardingMode: "all",
+	HostPortStripMode:        HOST_PORT_STRIP_ANY,
+	TrailingSlashMode:        TRAILING_SLASH_IGNORE,
+	FullDuplexFailureMode:    FULL_DUPLEX_FAILURE_PANIC,
+	XRequestStartPolicy:      X_REQUEST_START_PRESERVE,
+	PipelinedRequests:        PIPELINED_REQUESTS_ALLOW,
+	AbsoluteFormRequests:     ABSOLUTE_FORM_REQUESTS_ALLOW,
+	AsteriskOptionsRequests:  ASTERISK_OPTIONS_RESPOND,
+	ConnectRequests:          CONNECT_REQUESTS_REJECT,
+	ConnectTunnelIdleTimeout: 900 * time.Second,
+	RequestReceivedFormat:    REQUEST_RECEIVED_FORMAT_EPOCH_MS,
+	DuplicateSetCookiePolicy: DUPLICATE_SET_COOKIE_PRESERVE,
+	RouteConflictMode:        ROUTE_CONFLICT_MERGE,
+	ErrorCacheControl:        "no-store",
 
 	DisableKeepAlives:   true,
 	MaxIdleConns:        100,
 	MaxIdleConnsPerHost: 2,
 
+	StrictRequestFraming:   true,
+	KeepAliveHTTP10Clients: true,
+
 	StickySessionCookieNames:       StringSet{"JSESSIONID": struct{}{}},
 	StickySessionsForAuthNegotiate: false,
 
@@ -565,6 +1251,13 @@ func (c *Config) Process() error {
 		c.DropletStaleThreshold = c.StartResponseDelayInterval
 	}
 
+	if c.RegistrationDebounceInterval < 0 {
+		return fmt.Errorf("registration_debounce_interval must not be negative")
+	}
+	if c.RegistrationDebounceInterval > 0 && c.RegistrationDebounceInterval >= c.DropletStaleThreshold {
+		return fmt.Errorf("registration_debounce_interval must be shorter than droplet_stale_threshold")
+	}
+
 	if c.DrainTimeout == 0 {
 		c.DrainTimeout = c.EndpointTimeout
 	}
@@ -650,6 +1343,102 @@ func (c *Config) Process() error {
 		c.Status.TLSCert = certificate
 	}
 
+	if c.TLSPassthrough.Enabled {
+		if c.TLSPassthrough.Port == 0 {
+			return fmt.Errorf("tls_passthrough.port must not be 0")
+		}
+		if c.TLSPassthrough.Port == c.Port || (c.EnableSSL && c.TLSPassthrough.Port == c.SSLPort) {
+			return fmt.Errorf("tls_passthrough.port must differ from port and ssl_port")
+		}
+	}
+
+	if c.ClientConnections.KeepAliveIdle < 0 || c.ClientConnections.KeepAliveInterval < 0 || c.ClientConnections.KeepAliveCount < 0 {
+		return fmt.Errorf("client_connections keep-alive settings must not be negative")
+	}
+
+	if c.DebugTap.Enabled && (c.DebugTap.MaxSubscribers <= 0 || c.DebugTap.BufferSize <= 0) {
+		return fmt.Errorf("debug_tap.max_subscribers and debug_tap.buffer_size must be greater than 0")
+	}
+
+	if c.TLSHandshakeLimit.MaxConcurrent > 0 {
+		if c.TLSHandshakeLimit.Mode == "" {
+			c.TLSHandshakeLimit.Mode = TLS_HANDSHAKE_LIMIT_QUEUE
+		}
+		validTLSHandshakeLimitMode := false
+		for _, mode := range AllowedTLSHandshakeLimitModes {
+			if c.TLSHandshakeLimit.Mode == mode {
+				validTLSHandshakeLimitMode = true
+				break
+			}
+		}
+		if !validTLSHandshakeLimitMode {
+			errMsg := fmt.Sprintf("Invalid TLS handshake limit mode: %s. Allowed values are %s", c.TLSHandshakeLimit.Mode, AllowedTLSHandshakeLimitModes)
+			return fmt.Errorf(errMsg)
+		}
+		if c.TLSHandshakeLimit.HandshakeTimeout <= 0 {
+			return fmt.Errorf("tls_handshake_limit.handshake_timeout must be greater than 0")
+		}
+	}
+
+	if c.StageTiming.Enabled && c.StageTiming.SlowRequestThreshold < 0 {
+		return fmt.Errorf("stage_timing.slow_request_threshold must not be negative")
+	}
+
+	if c.GeoIP.DatabasePath != "" {
+		if c.GeoIP.ReloadInterval < 0 {
+			return fmt.Errorf("geo_ip.reload_interval must not be negative")
+		}
+		if c.GeoIP.CountryHeader == "" && c.GeoIP.ASNHeader == "" {
+			return fmt.Errorf("geo_ip requires a country_header or an asn_header")
+		}
+	}
+
+	if c.RegistrySnapshot.Path != "" {
+		if c.RegistrySnapshot.Interval <= 0 {
+			return fmt.Errorf("registry_snapshot.interval must be positive")
+		}
+		// loaded endpoints are told apart from registered ones by their
+		// shorter stale threshold, which has a resolution of seconds
+		if c.RegistrySnapshot.TTL < time.Second || c.RegistrySnapshot.TTL >= c.DropletStaleThreshold {
+			return fmt.Errorf("registry_snapshot.ttl must be at least 1s and shorter than droplet_stale_threshold")
+		}
+	}
+
+	if c.FrontendWriteStallTimeout < 0 {
+		return fmt.Errorf("frontend_write_stall_timeout must not be negative")
+	}
+
+	for _, host := range c.AllowedHosts {
+		if host == "" || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return fmt.Errorf("allowed_hosts entries must be a host or a wildcard of the form *.example.com")
+		}
+	}
+
+	if c.PanicResponse.StatusCode < 500 || c.PanicResponse.StatusCode > 599 {
+		return fmt.Errorf("panic_response.status_code must be a 5xx status code")
+	}
+
+	if c.Tracing.Baggage.MaxEntries < 0 || c.Tracing.Baggage.MaxBytes < 0 {
+		return fmt.Errorf("tracing.baggage limits must not be negative")
+	}
+
+	if c.HealthCheckCacheTTL < 0 || c.HealthCheckCacheTTL >= time.Second {
+		return fmt.Errorf("healthcheck_cache_ttl must be between 0 and 1s")
+	}
+
+	if c.MaintenancePage.StatusCode < 400 || c.MaintenancePage.StatusCode > 599 {
+		return fmt.Errorf("maintenance_page.status_code must be a 4xx or 5xx status code")
+	}
+
+	if c.OCSPStapling.Enabled {
+		if c.OCSPStapling.RefreshInterval <= 0 {
+			return fmt.Errorf("ocsp_stapling.refresh_interval must be greater than 0")
+		}
+		if c.OCSPStapling.FetchTimeout <= 0 {
+			return fmt.Errorf("ocsp_stapling.fetch_timeout must be greater than 0")
+		}
+	}
+
 	if c.EnableSSL {
 		switch c.ClientCertificateValidationString {
 		case "none":
@@ -684,6 +1473,12 @@ func (c *Config) Process() error {
 			return fmt.Errorf(`router.max_tls_version should be one of "TLSv1.2" or "TLSv1.3"`)
 		}
 
+		if c.ECH.Enabled {
+			if err := c.processECHKeys(); err != nil {
+				return err
+			}
+		}
+
 		if len(c.TLSPEM) == 0 {
 			return fmt.Errorf("router.tls_pem must be provided if router.enable_ssl is set to true")
 		}
@@ -759,6 +1554,21 @@ func (c *Config) Process() error {
 		return fmt.Errorf(errMsg)
 	}
 
+	if c.ForwardedForMode == "" {
+		c.ForwardedForMode = FORWARDED_FOR_APPEND
+	}
+	validForwardedForMode := false
+	for _, m := range AllowedForwardedForModes {
+		if c.ForwardedForMode == m {
+			validForwardedForMode = true
+			break
+		}
+	}
+	if !validForwardedForMode {
+		errMsg := fmt.Sprintf("Invalid forwarded for mode: %s. Allowed values are %s", c.ForwardedForMode, AllowedForwardedForModes)
+		return fmt.Errorf(errMsg)
+	}
+
 	validShardMode := false
 	for _, sm := range AllowedShardingModes {
 		if c.RoutingTableShardingMode == sm {
@@ -771,6 +1581,216 @@ func (c *Config) Process() error {
 		return fmt.Errorf(errMsg)
 	}
 
+	if c.HostPortStripMode == "" {
+		c.HostPortStripMode = HOST_PORT_STRIP_ANY
+	}
+	validHostPortStripMode := false
+	for _, m := range AllowedHostPortStripModes {
+		if c.HostPortStripMode == m {
+			validHostPortStripMode = true
+			break
+		}
+	}
+	if !validHostPortStripMode {
+		errMsg := fmt.Sprintf("Invalid host port strip mode: %s. Allowed values are %s", c.HostPortStripMode, AllowedHostPortStripModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.TrailingSlashMode == "" {
+		c.TrailingSlashMode = TRAILING_SLASH_IGNORE
+	}
+	validTrailingSlashMode := false
+	for _, m := range AllowedTrailingSlashModes {
+		if c.TrailingSlashMode == m {
+			validTrailingSlashMode = true
+			break
+		}
+	}
+	if !validTrailingSlashMode {
+		errMsg := fmt.Sprintf("Invalid trailing slash mode: %s. Allowed values are %s", c.TrailingSlashMode, AllowedTrailingSlashModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.FullDuplexFailureMode == "" {
+		c.FullDuplexFailureMode = FULL_DUPLEX_FAILURE_PANIC
+	}
+	validFullDuplexFailureMode := false
+	for _, m := range AllowedFullDuplexFailureModes {
+		if c.FullDuplexFailureMode == m {
+			validFullDuplexFailureMode = true
+			break
+		}
+	}
+	if !validFullDuplexFailureMode {
+		errMsg := fmt.Sprintf("Invalid full duplex failure mode: %s. Allowed values are %s", c.FullDuplexFailureMode, AllowedFullDuplexFailureModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.XRequestStartPolicy == "" {
+		c.XRequestStartPolicy = X_REQUEST_START_PRESERVE
+	}
+	validXRequestStartPolicy := false
+	for _, policy := range AllowedXRequestStartPolicies {
+		if c.XRequestStartPolicy == policy {
+			validXRequestStartPolicy = true
+			break
+		}
+	}
+	if !validXRequestStartPolicy {
+		errMsg := fmt.Sprintf("Invalid X-Request-Start policy: %s. Allowed values are %s", c.XRequestStartPolicy, AllowedXRequestStartPolicies)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.PipelinedRequests == "" {
+		c.PipelinedRequests = PIPELINED_REQUESTS_ALLOW
+	}
+	validPipelinedRequestsMode := false
+	for _, mode := range AllowedPipelinedRequestsModes {
+		if c.PipelinedRequests == mode {
+			validPipelinedRequestsMode = true
+			break
+		}
+	}
+	if !validPipelinedRequestsMode {
+		errMsg := fmt.Sprintf("Invalid pipelined requests mode: %s. Allowed values are %s", c.PipelinedRequests, AllowedPipelinedRequestsModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.AbsoluteFormRequests == "" {
+		c.AbsoluteFormRequests = ABSOLUTE_FORM_REQUESTS_ALLOW
+	}
+	validAbsoluteFormRequestsMode := false
+	for _, mode := range AllowedAbsoluteFormRequestsModes {
+		if c.AbsoluteFormRequests == mode {
+			validAbsoluteFormRequestsMode = true
+			break
+		}
+	}
+	if !validAbsoluteFormRequestsMode {
+		errMsg := fmt.Sprintf("Invalid absolute-form requests mode: %s. Allowed values are %s", c.AbsoluteFormRequests, AllowedAbsoluteFormRequestsModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.AsteriskOptionsRequests == "" {
+		c.AsteriskOptionsRequests = ASTERISK_OPTIONS_RESPOND
+	}
+	validAsteriskOptionsMode := false
+	for _, mode := range AllowedAsteriskOptionsModes {
+		if c.AsteriskOptionsRequests == mode {
+			validAsteriskOptionsMode = true
+			break
+		}
+	}
+	if !validAsteriskOptionsMode {
+		errMsg := fmt.Sprintf("Invalid asterisk options requests mode: %s. Allowed values are %s", c.AsteriskOptionsRequests, AllowedAsteriskOptionsModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.Logging.Format.Encoding == "" {
+		c.Logging.Format.Encoding = LOG_ENCODING_JSON
+	}
+	validLogEncoding := false
+	for _, encoding := range AllowedLogEncodings {
+		if c.Logging.Format.Encoding == encoding {
+			validLogEncoding = true
+			break
+		}
+	}
+	if !validLogEncoding {
+		errMsg := fmt.Sprintf("Invalid log encoding: %s. Allowed values are %s", c.Logging.Format.Encoding, AllowedLogEncodings)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.RequestReceivedFormat == "" {
+		c.RequestReceivedFormat = REQUEST_RECEIVED_FORMAT_EPOCH_MS
+	}
+	validRequestReceivedFormat := false
+	for _, format := range AllowedRequestReceivedFormats {
+		if c.RequestReceivedFormat == format {
+			validRequestReceivedFormat = true
+			break
+		}
+	}
+	if !validRequestReceivedFormat {
+		errMsg := fmt.Sprintf("Invalid request received format: %s. Allowed values are %s", c.RequestReceivedFormat, AllowedRequestReceivedFormats)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.DuplicateSetCookiePolicy == "" {
+		c.DuplicateSetCookiePolicy = DUPLICATE_SET_COOKIE_PRESERVE
+	}
+	validDuplicateSetCookiePolicy := false
+	for _, policy := range AllowedDuplicateSetCookiePolicies {
+		if c.DuplicateSetCookiePolicy == policy {
+			validDuplicateSetCookiePolicy = true
+			break
+		}
+	}
+	if !validDuplicateSetCookiePolicy {
+		errMsg := fmt.Sprintf("Invalid duplicate Set-Cookie policy: %s. Allowed values are %s", c.DuplicateSetCookiePolicy, AllowedDuplicateSetCookiePolicies)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.RouteConflictMode == "" {
+		c.RouteConflictMode = ROUTE_CONFLICT_MERGE
+	}
+	validRouteConflictMode := false
+	for _, m := range AllowedRouteConflictModes {
+		if c.RouteConflictMode == m {
+			validRouteConflictMode = true
+			break
+		}
+	}
+	if !validRouteConflictMode {
+		errMsg := fmt.Sprintf("Invalid route conflict mode: %s. Allowed values are %s", c.RouteConflictMode, AllowedRouteConflictModes)
+		return fmt.Errorf(errMsg)
+	}
+
+	if c.ClientIPTrustedProxyDepth < 0 {
+		return fmt.Errorf("client_ip_trusted_proxy_depth must not be negative")
+	}
+
+	if c.ConnectRequests == "" {
+		c.ConnectRequests = CONNECT_REQUESTS_REJECT
+	}
+	validConnectRequestsMode := false
+	for _, mode := range AllowedConnectRequestsModes {
+		if c.ConnectRequests == mode {
+			validConnectRequestsMode = true
+			break
+		}
+	}
+	if !validConnectRequestsMode {
+		errMsg := fmt.Sprintf("Invalid CONNECT requests mode: %s. Allowed values are %s", c.ConnectRequests, AllowedConnectRequestsModes)
+		return fmt.Errorf(errMsg)
+	}
+	if c.ConnectTunnelIdleTimeout < 0 {
+		return fmt.Errorf("connect_tunnel_idle_timeout must not be negative")
+	}
+
+	if c.Auth.Type == "" {
+		c.Auth.Type = AUTH_NONE
+	}
+	validAuthType := false
+	for _, authType := range AllowedAuthTypes {
+		if c.Auth.Type == authType {
+			validAuthType = true
+			break
+		}
+	}
+	if !validAuthType {
+		errMsg := fmt.Sprintf("Invalid auth type: %s. Allowed values are %s", c.Auth.Type, AllowedAuthTypes)
+		return fmt.Errorf(errMsg)
+	}
+	if c.Auth.Type == AUTH_BASIC && len(c.Auth.Basic.Users) == 0 {
+		return fmt.Errorf("auth.basic.users must not be empty when auth.type is basic")
+	}
+	for name, basicAuth := range c.RouteBasicAuth {
+		if len(basicAuth.Users) == 0 {
+			return fmt.Errorf("route_basic_auth.%s.users must not be empty", name)
+		}
+	}
+
 	if c.RoutingTableShardingMode == SHARD_SEGMENTS && len(c.IsolationSegments) == 0 {
 		return fmt.Errorf("Expected isolation segments; routing table sharding mode set to segments and none provided.")
 	}
@@ -787,6 +1807,50 @@ func (c *Config) Process() error {
 		return fmt.Errorf(errMsg)
 	}
 
+	if c.Logging.LoggregatorEmitterWorkers < 0 || c.Logging.LoggregatorEmitterQueueSize < 0 {
+		return fmt.Errorf("logging.loggregator_emitter_workers and logging.loggregator_emitter_queue_size must not be negative")
+	}
+
+	if c.Logging.RouteLookupMissesPerSecond < 0 {
+		return fmt.Errorf("logging.route_lookup_misses_per_second must not be negative")
+	}
+
+	for key := range c.AccessLog.StaticFields {
+		if !staticFieldKeyPattern.MatchString(key) {
+			return fmt.Errorf("access_log.static_fields key %q must start with a letter and contain only letters, digits and underscores", key)
+		}
+	}
+
+	if c.AccessLog.Rotation.MaxSize < 0 || c.AccessLog.Rotation.MaxAge < 0 || c.AccessLog.Rotation.MaxBackups < 0 {
+		return fmt.Errorf("access_log.rotation.max_size, max_age and max_backups must not be negative")
+	}
+
+	if c.AccessLog.OnlyErrors.SuppressedStatusMin == 0 && c.AccessLog.OnlyErrors.SuppressedStatusMax == 0 {
+		c.AccessLog.OnlyErrors.SuppressedStatusMin = 100
+		c.AccessLog.OnlyErrors.SuppressedStatusMax = 399
+	}
+
+	onlyErrors := c.AccessLog.OnlyErrors
+	if onlyErrors.SuppressedStatusMin < 100 || onlyErrors.SuppressedStatusMax > 599 || onlyErrors.SuppressedStatusMin > onlyErrors.SuppressedStatusMax {
+		return fmt.Errorf("access_log.only_errors suppressed status range must be a range of status codes from 100 to 599")
+	}
+
+	if c.AccessLog.Rotation.Fsync == "" {
+		c.AccessLog.Rotation.Fsync = FSYNC_NEVER
+	}
+
+	validFsyncPolicy := false
+	for _, fp := range AllowedAccessLogFsyncPolicies {
+		if c.AccessLog.Rotation.Fsync == fp {
+			validFsyncPolicy = true
+			break
+		}
+	}
+	if !validFsyncPolicy {
+		errMsg := fmt.Sprintf("Invalid access log fsync policy: %s. Allowed values are %s", c.AccessLog.Rotation.Fsync, AllowedAccessLogFsyncPolicies)
+		return fmt.Errorf(errMsg)
+	}
+
 	if err := c.buildCertPool(); err != nil {
 		return err
 	}
@@ -796,6 +1860,33 @@ func (c *Config) Process() error {
 	return nil
 }
 
+func (c *Config) processECHKeys() error {
+	if c.MaxTLSVersion < tls.VersionTLS13 {
+		return fmt.Errorf("ech requires router.max_tls_version to be TLSv1.3")
+	}
+	if len(c.ECH.Keys) == 0 {
+		return fmt.Errorf("ech.keys must be provided if ech is enabled")
+	}
+
+	c.ECH.ServerKeys = nil
+	for i, key := range c.ECH.Keys {
+		echConfig, err := base64.StdEncoding.DecodeString(key.Config)
+		if err != nil {
+			return fmt.Errorf("Error decoding ech.keys[%d].config: %s", i, err)
+		}
+		privateKey, err := base64.StdEncoding.DecodeString(key.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("Error decoding ech.keys[%d].private_key: %s", i, err)
+		}
+		c.ECH.ServerKeys = append(c.ECH.ServerKeys, tls.EncryptedClientHelloKey{
+			Config:      echConfig,
+			PrivateKey:  privateKey,
+			SendAsRetry: key.SendAsRetry,
+		})
+	}
+	return nil
+}
+
 func (c *Config) processCipherSuites() ([]uint16, error) {
 	// legacy/openssl formatted values that we've supported in the past
 	cipherMap := map[string]uint16{
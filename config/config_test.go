@@ -3,6 +3,7 @@ package config_test
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"strings"
@@ -213,6 +214,170 @@ max_header_bytes: 10
 			Expect(config.MaxHeaderBytes).To(Equal(10))
 		})
 
+		It("keeps the connections of HTTP/1.0 clients alive by default", func() {
+			Expect(config.KeepAliveHTTP10Clients).To(BeTrue())
+		})
+
+		It("sets KeepAliveHTTP10Clients", func() {
+			var b = []byte("keep_alive_http10_clients: false")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.KeepAliveHTTP10Clients).To(BeFalse())
+		})
+
+		It("does not normalize the Connection header of responses by default", func() {
+			Expect(config.NormalizeResponseConnectionHeader).To(BeFalse())
+		})
+
+		It("sets NormalizeResponseConnectionHeader", func() {
+			var b = []byte("normalize_response_connection_header: true")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.NormalizeResponseConnectionHeader).To(BeTrue())
+		})
+
+		It("does not capture the case of request header names by default", func() {
+			Expect(config.CaptureRequestHeaderCase).To(BeFalse())
+		})
+
+		It("sets CaptureRequestHeaderCase", func() {
+			var b = []byte("capture_request_header_case: true")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.CaptureRequestHeaderCase).To(BeTrue())
+		})
+
+		It("does not validate request headers strictly by default", func() {
+			Expect(config.StrictHeaderValidation).To(BeFalse())
+		})
+
+		It("sets StrictHeaderValidation", func() {
+			var b = []byte("strict_header_validation: true")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.StrictHeaderValidation).To(BeTrue())
+		})
+
+		It("enables strict request framing by default", func() {
+			Expect(config.StrictRequestFraming).To(BeTrue())
+		})
+
+		It("sets StrictRequestFraming", func() {
+			var b = []byte("strict_request_framing: false")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.StrictRequestFraming).To(BeFalse())
+		})
+
+		It("stops the router right away on SIGTERM by default", func() {
+			Expect(config.DrainOnTerm).To(BeFalse())
+			Expect(config.DrainForceClose).To(BeFalse())
+		})
+
+		It("sets DrainOnTerm and DrainForceClose", func() {
+			var b = []byte(`
+drain_on_term: true
+drain_force_close: true
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.DrainOnTerm).To(BeTrue())
+			Expect(config.DrainForceClose).To(BeTrue())
+		})
+
+		It("sets MaxRequestURILength", func() {
+			var b = []byte(`
+max_request_uri_length: 8192
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.MaxRequestURILength).To(Equal(8192))
+		})
+
+		It("sets ErrorCacheControl to no-store by default", func() {
+			Expect(config.ErrorCacheControl).To(Equal("no-store"))
+		})
+
+		It("sets ErrorCacheControl", func() {
+			var b = []byte(`
+error_cache_control: "no-cache, private"
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.ErrorCacheControl).To(Equal("no-cache, private"))
+		})
+
+		It("sets MaxHeaderCount", func() {
+			var b = []byte(`
+max_header_count: 100
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.MaxHeaderCount).To(Equal(100))
+		})
+
+		It("sets AllowedUpgradeProtocols", func() {
+			var b = []byte(`
+allowed_upgrade_protocols:
+- websocket
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AllowedUpgradeProtocols).To(Equal([]string{"websocket"}))
+		})
+
+		It("sets AllowedHosts", func() {
+			var b = []byte(`
+allowed_hosts:
+- example.com
+- "*.apps.example.com"
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AllowedHosts).To(Equal([]string{"example.com", "*.apps.example.com"}))
+		})
+
+		It("sets path normalization config", func() {
+			var b = []byte(`
+path_normalization:
+  merge_slashes: true
+  remove_dot_segments: true
+  normalize_percent_encoding: true
+  strict: true
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.PathNormalization).To(Equal(PathNormalizationConfig{
+				MergeSlashes:             true,
+				RemoveDotSegments:        true,
+				NormalizePercentEncoding: true,
+				Strict:                   true,
+			}))
+			Expect(config.PathNormalization.Enabled()).To(BeTrue())
+		})
+
+		It("disables path normalization by default", func() {
+			Expect(config.PathNormalization.Enabled()).To(BeFalse())
+		})
+
 		It("sets prometheus endpoint config", func() {
 			var b = []byte(`
 prometheus:
@@ -246,6 +411,51 @@ frontend_idle_timeout: 5s
 			Expect(config.FrontendIdleTimeout).To(Equal(5 * time.Second))
 		})
 
+		It("does not limit the age of client connections by default", func() {
+			Expect(config.MaxClientConnectionAge).To(BeZero())
+		})
+
+		It("sets the maximum age of client connections", func() {
+			var b = []byte(`
+max_client_connection_age: 10m
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.MaxClientConnectionAge).To(Equal(10 * time.Minute))
+		})
+
+		It("does not limit how long writes to clients may stall by default", func() {
+			Expect(config.FrontendWriteStallTimeout).To(BeZero())
+		})
+
+		It("sets the frontend write stall timeout", func() {
+			var b = []byte(`
+frontend_write_stall_timeout: 30s
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.FrontendWriteStallTimeout).To(Equal(30 * time.Second))
+		})
+
+		It("does not set a frontend read timeout by default", func() {
+			Expect(config.FrontendReadTimeout).To(BeZero())
+		})
+
+		It("sets frontend read timeout", func() {
+			var b = []byte(`
+frontend_read_timeout: 30s
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.FrontendReadTimeout).To(Equal(30 * time.Second))
+		})
+
 		It("sets endpoint timeout", func() {
 			var b = []byte(`
 endpoint_timeout: 10s
@@ -268,6 +478,17 @@ endpoint_dial_timeout: 6s
 			Expect(config.EndpointDialTimeout).To(Equal(6 * time.Second))
 		})
 
+		It("sets backend time to first byte", func() {
+			var b = []byte(`
+backend_time_to_first_byte: 3s
+`)
+
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.BackendTimeToFirstByte).To(Equal(3 * time.Second))
+		})
+
 		It("sets websocket dial timeout", func() {
 			var b = []byte(`
 websocket_dial_timeout: 6s
@@ -417,18 +638,28 @@ suspend_pruning_if_nats_unavailable: true
 			Expect(config.Logging.RedactQueryParams).To(Equal("none"))
 			Expect(config.Logging.Format.Timestamp).To(Equal("unix-epoch"))
 			Expect(config.Logging.EnableAttemptsDetails).To(BeFalse())
+			Expect(config.Logging.FailedAttemptsSummary).To(BeFalse())
+			Expect(config.Logging.RouteLookupMissesPerSecond).To(BeZero())
+			Expect(config.Logging.LoggregatorEmitterWorkers).To(Equal(2))
+			Expect(config.Logging.LoggregatorEmitterQueueSize).To(Equal(4096))
 		})
 
 		It("sets default access log config", func() {
 			// access entries not present in config
 			Expect(config.AccessLog.File).To(Equal(""))
 			Expect(config.AccessLog.EnableStreaming).To(BeFalse())
+			Expect(config.AccessLog.Rotation.Enabled()).To(BeFalse())
+			Expect(config.AccessLog.Rotation.Fsync).To(Equal(FSYNC_NEVER))
 		})
 
 		It("sets default sharding mode config", func() {
 			Expect(config.RoutingTableShardingMode).To(Equal("all"))
 		})
 
+		It("sets default host port strip mode", func() {
+			Expect(config.HostPortStripMode).To(Equal(HOST_PORT_STRIP_ANY))
+		})
+
 		It("sets the load_balancer_healthy_threshold configuration", func() {
 			var b = []byte(`
 load_balancer_healthy_threshold: 20s
@@ -449,6 +680,7 @@ access_log:
 
 			Expect(config.AccessLog.File).To(Equal("/var/vcap/sys/log/gorouter/access.log"))
 			Expect(config.AccessLog.EnableStreaming).To(BeFalse())
+			Expect(config.AccessLog.LogRequestStart).To(BeFalse())
 		})
 
 		It("sets access log config to file and no streaming", func() {
@@ -477,6 +709,93 @@ access_log:
 			Expect(config.AccessLog.EnableStreaming).To(BeTrue())
 		})
 
+		It("sets access log config to log request starts", func() {
+			var b = []byte(`
+access_log:
+  file: "/var/vcap/sys/log/gorouter/access.log"
+  log_request_start: true
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.LogRequestStart).To(BeTrue())
+		})
+
+		It("sets access log static fields", func() {
+			var b = []byte(`
+access_log:
+  static_fields:
+    environment: production
+    team: platform
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.StaticFields).To(Equal(map[string]string{
+				"environment": "production",
+				"team":        "platform",
+			}))
+		})
+
+		It("sets access log client protocol logging", func() {
+			var b = []byte(`
+access_log:
+  log_client_protocol: true
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.LogClientProtocol).To(BeTrue())
+		})
+
+		It("defaults access log only errors config", func() {
+			err := config.Initialize([]byte(""))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.OnlyErrors).To(Equal(AccessLogOnlyErrors{
+				SuppressedStatusMin: 100,
+				SuppressedStatusMax: 399,
+			}))
+		})
+
+		It("sets access log only errors config", func() {
+			var b = []byte(`
+access_log:
+  only_errors:
+    enabled: true
+    suppressed_status_min: 200
+    suppressed_status_max: 499
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.OnlyErrors).To(Equal(AccessLogOnlyErrors{
+				Enabled:             true,
+				SuppressedStatusMin: 200,
+				SuppressedStatusMax: 499,
+			}))
+		})
+
+		It("sets access log rotation config", func() {
+			var b = []byte(`
+access_log:
+  file: "/var/vcap/sys/log/gorouter/access.log"
+  rotation:
+    max_size: 1048576
+    max_age: 24h
+    max_backups: 3
+    fsync: on_rotate
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.Rotation.Enabled()).To(BeTrue())
+			Expect(config.AccessLog.Rotation.MaxSize).To(Equal(int64(1048576)))
+			Expect(config.AccessLog.Rotation.MaxAge).To(Equal(24 * time.Hour))
+			Expect(config.AccessLog.Rotation.MaxBackups).To(Equal(3))
+			Expect(config.AccessLog.Rotation.Fsync).To(Equal(FSYNC_ON_ROTATE))
+		})
+
 		It("sets logging config", func() {
 			var b = []byte(`
 logging:
@@ -484,6 +803,12 @@ logging:
   level: debug2
   loggregator_enabled: true
   enable_attempts_details: true
+  failed_attempts_summary: true
+  backend_error_fields: true
+  route_lookup_misses_per_second: 10
+  redact_query_param_names: [token, access_key]
+  zone: z1
+  instance_id: router-instance
   format:
     timestamp: just_log_something
 `)
@@ -496,6 +821,12 @@ logging:
 			Expect(config.Logging.JobName).To(Equal("gorouter"))
 			Expect(config.Logging.Format.Timestamp).To(Equal("just_log_something"))
 			Expect(config.Logging.EnableAttemptsDetails).To(BeTrue())
+			Expect(config.Logging.FailedAttemptsSummary).To(BeTrue())
+			Expect(config.Logging.BackendErrorFields).To(BeTrue())
+			Expect(config.Logging.RouteLookupMissesPerSecond).To(Equal(10))
+			Expect(config.Logging.RedactQueryParamNames).To(Equal([]string{"token", "access_key"}))
+			Expect(config.Logging.Zone).To(Equal("z1"))
+			Expect(config.Logging.InstanceId).To(Equal("router-instance"))
 		})
 
 		It("sets the rest of config", func() {
@@ -504,6 +835,10 @@ port: 8082
 index: 1
 go_max_procs: 2
 trace_key: "foo"
+emit_backend_instance_header: true
+emit_upstream_response_time_header: true
+override_backend_date_header: true
+strip_response_headers: [X-Powered-By, x-debug-info]
 access_log:
     file: "/tmp/access_log"
 ssl_port: 4443
@@ -519,6 +854,10 @@ routing_table_sharding_mode: "segments"
 			Expect(config.Index).To(Equal(uint(1)))
 			Expect(config.GoMaxProcs).To(Equal(2))
 			Expect(config.TraceKey).To(Equal("foo"))
+			Expect(config.EmitBackendInstanceHeader).To(BeTrue())
+			Expect(config.EmitUpstreamResponseTimeHeader).To(BeTrue())
+			Expect(config.OverrideBackendDateHeader).To(BeTrue())
+			Expect(config.StripResponseHeaders).To(Equal([]string{"X-Powered-By", "x-debug-info"}))
 			Expect(config.AccessLog.File).To(Equal("/tmp/access_log"))
 			Expect(config.AccessLog.EnableStreaming).To(BeFalse())
 			Expect(config.EnableSSL).To(Equal(true))
@@ -741,6 +1080,14 @@ enable_proxy: true
 			Expect(config.HealthCheckUserAgent).To(Equal("HTTP-Monitor/1.1"))
 		})
 
+		It("sets the healthcheck cache TTL", func() {
+			var b = []byte("healthcheck_cache_ttl: 250ms")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.HealthCheckCacheTTL).To(Equal(250 * time.Millisecond))
+		})
+
 		It("sets Tracing.EnableZipkin", func() {
 			var b = []byte("tracing:\n  enable_zipkin: true")
 			err := config.Initialize(b)
@@ -792,6 +1139,19 @@ enable_proxy: true
 			Expect(config.Tracing.W3CTenantID).To(BeEmpty())
 		})
 
+		It("sets Tracing.Baggage", func() {
+			var b = []byte("tracing:\n  baggage:\n    enabled: true\n    max_entries: 10\n    max_bytes: 1024\n    log: true")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Tracing.Baggage).To(Equal(BaggageConfig{
+				Enabled:    true,
+				MaxEntries: 10,
+				MaxBytes:   1024,
+				Log:        true,
+			}))
+		})
+
 		It("sets the proxy forwarded proto header", func() {
 			var b = []byte("force_forwarded_proto_https: true")
 			config.Initialize(b)
@@ -832,96 +1192,283 @@ backends:
 			Expect(config.Backends.MaxConns).To(Equal(int64(10)))
 		})
 
-		It("defaults MaxIdleConnsPerHost to 2", func() {
-			var b = []byte("")
+		It("sets MaxHTTP2Requests", func() {
+			var b = []byte(`
+backends:
+  max_http2_requests: 100`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(config.MaxIdleConnsPerHost).To(Equal(2))
+			Expect(config.Backends.MaxHTTP2Requests).To(Equal(int64(100)))
 		})
 
-		It("sets DisableKeepAlives", func() {
-			var b = []byte("disable_keep_alives: false")
-			err := config.Initialize(b)
+		It("defaults DecompressResponseMaxBytes to 10MB", func() {
+			err := config.Initialize([]byte(""))
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(config.DisableKeepAlives).To(BeFalse())
+			Expect(config.Backends.DecompressResponseMaxBytes).To(Equal(int64(10 * 1024 * 1024)))
 		})
 
-		It("sets MaxIdleConns", func() {
-			var b = []byte("max_idle_conns: 200")
-			err := config.Initialize(b)
+		It("defaults ResponseDigestMaxBytes to 64KB", func() {
+			err := config.Initialize([]byte(""))
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(config.MaxIdleConns).To(Equal(200))
+			Expect(config.Backends.ResponseDigestMaxBytes).To(Equal(int64(64 * 1024)))
 		})
 
-		It("sets MaxIdleConnsPerHost", func() {
-			var b = []byte("max_idle_conns_per_host: 10")
+		It("sets ResponseDigestMaxBytes", func() {
+			var b = []byte(`
+backends:
+  response_digest_max_bytes: 1024`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(config.MaxIdleConnsPerHost).To(Equal(10))
+			Expect(config.Backends.ResponseDigestMaxBytes).To(Equal(int64(1024)))
 		})
 
-		It("defaults DisableHTTP to false", func() {
-			Expect(config.DisableHTTP).To(BeFalse())
+		It("defaults RejectUnexpectedSwitchingProtocols to true", func() {
+			err := config.Initialize([]byte(""))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Backends.RejectUnexpectedSwitchingProtocols).To(BeTrue())
 		})
 
-		It("sets DisableHTTP", func() {
-			var b = []byte("disable_http: true")
+		It("sets RejectUnexpectedSwitchingProtocols", func() {
+			var b = []byte(`
+backends:
+  reject_unexpected_switching_protocols: false`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(config.DisableHTTP).To(BeTrue())
+
+			Expect(config.Backends.RejectUnexpectedSwitchingProtocols).To(BeFalse())
 		})
 
-		It("defaults HTMLErrorTemplateFile to empty", func() {
-			Expect(config.HTMLErrorTemplateFile).To(Equal(""))
+		It("defaults ExcludeAttemptedEndpoints to true", func() {
+			err := config.Initialize([]byte(""))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Backends.ExcludeAttemptedEndpoints).To(BeTrue())
 		})
 
-		It("sets HTMLErrorTemplateFile", func() {
-			var b = []byte(`html_error_template_file: "/path/to/file"`)
+		It("sets ExcludeAttemptedEndpoints", func() {
+			var b = []byte(`
+backends:
+  exclude_attempted_endpoints: false`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(config.HTMLErrorTemplateFile).To(Equal("/path/to/file"))
-		})
 
-		It("defaults PerRequestMetricsReporting to true", func() {
-			Expect(config.PerRequestMetricsReporting).To(Equal(true))
+			Expect(config.Backends.ExcludeAttemptedEndpoints).To(BeFalse())
 		})
 
-		It("sets PerRequestMetricsReporting", func() {
-			var b = []byte(`per_request_metrics_reporting: false`)
+		It("sets DecompressResponseMaxBytes", func() {
+			var b = []byte(`
+backends:
+  decompress_response_max_bytes: 1024`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(config.PerRequestMetricsReporting).To(BeFalse())
-		})
 
-		It("defaults SendHttpStartStopServerEvent to true", func() {
-			Expect(config.SendHttpStartStopServerEvent).To(Equal(true))
+			Expect(config.Backends.DecompressResponseMaxBytes).To(Equal(int64(1024)))
 		})
 
-		It("sets SendHttpStartStopServerEvent", func() {
-			var b = []byte(`send_http_start_stop_server_event: false`)
+		It("sets MaxRequestHeaderBytes", func() {
+			var b = []byte(`
+backends:
+  max_request_header_bytes: 8192`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(config.SendHttpStartStopServerEvent).To(BeFalse())
-		})
 
-		It("defaults SendHttpStartStopClientEvent to true", func() {
-			Expect(config.SendHttpStartStopClientEvent).To(Equal(true))
+			Expect(config.Backends.MaxRequestHeaderBytes).To(Equal(8192))
 		})
 
-		It("sets SendHttpStartStopClientEvent", func() {
-			var b = []byte(`send_http_start_stop_client_event: false`)
+		It("sets SlowStartWindow", func() {
+			var b = []byte(`
+backends:
+  slow_start_window: 30s`)
 			err := config.Initialize(b)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(config.SendHttpStartStopClientEvent).To(BeFalse())
-		})
 
-	})
+			Expect(config.Backends.SlowStartWindow).To(Equal(30 * time.Second))
+		})
+
+		It("sets RetryBodyBufferBytes", func() {
+			var b = []byte(`
+backends:
+  retry_body_buffer_bytes: 65536`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Backends.RetryBodyBufferBytes).To(Equal(int64(65536)))
+		})
+
+		It("sets RouteMaxConnsQueueTimeout", func() {
+			Expect(config.Backends.RouteMaxConnsQueueTimeout).To(Equal(500 * time.Millisecond))
+
+			var b = []byte(`
+backends:
+  route_max_conns_queue_timeout: 2s`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Backends.RouteMaxConnsQueueTimeout).To(Equal(2 * time.Second))
+		})
+
+		It("sets RetryOnlyOnConnectionFailure", func() {
+			var b = []byte(`
+backends:
+  retry_only_on_connection_failure: true`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Backends.RetryOnlyOnConnectionFailure).To(BeTrue())
+		})
+
+		It("defaults MaxIdleConnsPerHost to 2", func() {
+			var b = []byte("")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.MaxIdleConnsPerHost).To(Equal(2))
+		})
+
+		It("sets DisableKeepAlives", func() {
+			var b = []byte("disable_keep_alives: false")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.DisableKeepAlives).To(BeFalse())
+		})
+
+		It("sets MaxIdleConns", func() {
+			var b = []byte("max_idle_conns: 200")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.MaxIdleConns).To(Equal(200))
+		})
+
+		It("sets MaxIdleConnsPerHost", func() {
+			var b = []byte("max_idle_conns_per_host: 10")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.MaxIdleConnsPerHost).To(Equal(10))
+		})
+
+		It("defaults DisableHTTP to false", func() {
+			Expect(config.DisableHTTP).To(BeFalse())
+		})
+
+		It("sets DisableHTTP", func() {
+			var b = []byte("disable_http: true")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.DisableHTTP).To(BeTrue())
+		})
+
+		It("defaults HTMLErrorTemplateFile to empty", func() {
+			Expect(config.HTMLErrorTemplateFile).To(Equal(""))
+		})
+
+		It("sets HTMLErrorTemplateFile", func() {
+			var b = []byte(`html_error_template_file: "/path/to/file"`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.HTMLErrorTemplateFile).To(Equal("/path/to/file"))
+		})
+
+		It("defaults PerRequestMetricsReporting to true", func() {
+			Expect(config.PerRequestMetricsReporting).To(Equal(true))
+		})
+
+		It("sets PerRequestMetricsReporting", func() {
+			var b = []byte(`per_request_metrics_reporting: false`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.PerRequestMetricsReporting).To(BeFalse())
+		})
+
+		It("defaults RegistryChurnMetricsReporting to false", func() {
+			Expect(config.RegistryChurnMetricsReporting).To(BeFalse())
+		})
+
+		It("sets RegistryChurnMetricsReporting", func() {
+			var b = []byte(`registry_churn_metrics_reporting: true`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.RegistryChurnMetricsReporting).To(BeTrue())
+		})
+
+		It("defaults ConcurrentConnectionsMetricReporting to false", func() {
+			Expect(config.ConcurrentConnectionsMetricReporting).To(BeFalse())
+		})
+
+		It("sets ConcurrentConnectionsMetricReporting", func() {
+			var b = []byte(`concurrent_connections_metric_reporting: true`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.ConcurrentConnectionsMetricReporting).To(BeTrue())
+		})
+
+		It("defaults SendHttpStartStopServerEvent to true", func() {
+			Expect(config.SendHttpStartStopServerEvent).To(Equal(true))
+		})
+
+		It("sets SendHttpStartStopServerEvent", func() {
+			var b = []byte(`send_http_start_stop_server_event: false`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.SendHttpStartStopServerEvent).To(BeFalse())
+		})
+
+		It("defaults SendHttpStartStopClientEvent to true", func() {
+			Expect(config.SendHttpStartStopClientEvent).To(Equal(true))
+		})
+
+		It("sets SendHttpStartStopClientEvent", func() {
+			var b = []byte(`send_http_start_stop_client_event: false`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.SendHttpStartStopClientEvent).To(BeFalse())
+		})
+
+	})
 
 	Describe("Process", func() {
+		Context("registration_debounce_interval", func() {
+			It("is disabled by default", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RegistrationDebounceInterval).To(BeZero())
+			})
+
+			It("can be set", func() {
+				cfgForSnippet.RegistrationDebounceInterval = 5 * time.Second
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RegistrationDebounceInterval).To(Equal(5 * time.Second))
+			})
+
+			It("must be shorter than droplet_stale_threshold", func() {
+				cfgForSnippet.RegistrationDebounceInterval = 10 * time.Minute
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("registration_debounce_interval must be shorter than droplet_stale_threshold"))
+			})
+
+			It("must not be negative", func() {
+				cfgForSnippet.RegistrationDebounceInterval = -time.Second
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("registration_debounce_interval must not be negative"))
+			})
+		})
+
 		It("converts intervals to durations", func() {
 			b := createYMLSnippet(cfgForSnippet)
 			b = append(b, []byte(`
@@ -1306,6 +1853,69 @@ load_balancer_healthy_threshold: 10s
 				})
 			})
 
+			Context("when ech is enabled", func() {
+				BeforeEach(func() {
+					configSnippet.MaxTLSVersionString = "TLSv1.3"
+					configSnippet.ECH.Enabled = true
+					configSnippet.ECH.Keys = []ECHKey{{
+						Config:      base64.StdEncoding.EncodeToString([]byte("ech-config")),
+						PrivateKey:  base64.StdEncoding.EncodeToString([]byte("ech-private-key")),
+						SendAsRetry: true,
+					}}
+				})
+
+				It("decodes the ech keys", func() {
+					configBytes := createYMLSnippet(configSnippet)
+					err := config.Initialize(configBytes)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.Process()).To(Succeed())
+					Expect(config.ECH.ServerKeys).To(Equal([]tls.EncryptedClientHelloKey{{
+						Config:      []byte("ech-config"),
+						PrivateKey:  []byte("ech-private-key"),
+						SendAsRetry: true,
+					}}))
+				})
+
+				Context("and max_tls_version is TLSv1.2", func() {
+					BeforeEach(func() {
+						configSnippet.MaxTLSVersionString = "TLSv1.2"
+					})
+
+					It("returns a meaningful error", func() {
+						configBytes := createYMLSnippet(configSnippet)
+						err := config.Initialize(configBytes)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(config.Process()).To(MatchError("ech requires router.max_tls_version to be TLSv1.3"))
+					})
+				})
+
+				Context("and no keys are provided", func() {
+					BeforeEach(func() {
+						configSnippet.ECH.Keys = nil
+					})
+
+					It("returns a meaningful error", func() {
+						configBytes := createYMLSnippet(configSnippet)
+						err := config.Initialize(configBytes)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(config.Process()).To(MatchError("ech.keys must be provided if ech is enabled"))
+					})
+				})
+
+				Context("and a key is not base64 encoded", func() {
+					BeforeEach(func() {
+						configSnippet.ECH.Keys[0].PrivateKey = "not base64!"
+					})
+
+					It("returns a meaningful error", func() {
+						configBytes := createYMLSnippet(configSnippet)
+						err := config.Initialize(configBytes)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(config.Process()).To(MatchError(ContainSubstring("Error decoding ech.keys[0].private_key")))
+					})
+				})
+			})
+
 			Context("when a valid CACerts is provided", func() {
 				BeforeEach(func() {
 					configSnippet.CACerts = []string{
@@ -1761,6 +2371,20 @@ load_balancer_healthy_threshold: 10s
 			})
 		})
 
+		Context("force_te_trailers", func() {
+			It("defaults to false", func() {
+				Expect(config.ForceTETrailers).To(BeFalse())
+			})
+
+			It("can be enabled", func() {
+				cfgForSnippet.ForceTETrailers = true
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Process()).To(Succeed())
+				Expect(config.ForceTETrailers).To(BeTrue())
+			})
+		})
+
 		Context("When given a routing_table_sharding_mode that is supported ", func() {
 			Context("sharding mode `all`", func() {
 				BeforeEach(func() {
@@ -1831,6 +2455,999 @@ load_balancer_healthy_threshold: 10s
 			})
 		})
 
+		Context("When tls passthrough is enabled", func() {
+			BeforeEach(func() {
+				cfgForSnippet.TLSPassthrough.Enabled = true
+			})
+
+			It("requires a port", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tls_passthrough.port must not be 0"))
+			})
+
+			It("rejects the http port", func() {
+				cfgForSnippet.Port = 8081
+				cfgForSnippet.TLSPassthrough.Port = 8081
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tls_passthrough.port must differ from port and ssl_port"))
+			})
+
+			It("accepts a dedicated port", func() {
+				cfgForSnippet.TLSPassthrough.Port = 9443
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.TLSPassthrough.ClientHelloTimeout).To(Equal(10 * time.Second))
+				Expect(config.TLSPassthrough.IdleTimeout).To(Equal(900 * time.Second))
+			})
+		})
+
+		Context("When client connection settings are provided", func() {
+			It("applies them", func() {
+				cfgForSnippet.ClientConnections = ClientConnectionConfig{
+					TCPNoDelay:        false,
+					KeepAlive:         true,
+					KeepAliveIdle:     30 * time.Second,
+					KeepAliveInterval: 10 * time.Second,
+					KeepAliveCount:    3,
+				}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.ClientConnections).To(Equal(cfgForSnippet.ClientConnections))
+			})
+
+			It("rejects negative keep-alive settings", func() {
+				cfgForSnippet.ClientConnections.KeepAliveInterval = -1 * time.Second
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("client_connections keep-alive settings must not be negative"))
+			})
+		})
+
+		Context("When ocsp stapling is enabled", func() {
+			BeforeEach(func() {
+				cfgForSnippet.OCSPStapling.Enabled = true
+				cfgForSnippet.OCSPStapling.RefreshInterval = 30 * time.Minute
+				cfgForSnippet.OCSPStapling.FetchTimeout = 5 * time.Second
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.OCSPStapling.RefreshInterval).To(Equal(30 * time.Minute))
+			})
+
+			It("requires a refresh interval", func() {
+				cfgForSnippet.OCSPStapling.RefreshInterval = 0
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("ocsp_stapling.refresh_interval must be greater than 0"))
+			})
+
+			It("requires a fetch timeout", func() {
+				cfgForSnippet.OCSPStapling.FetchTimeout = 0
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("ocsp_stapling.fetch_timeout must be greater than 0"))
+			})
+		})
+
+		Context("When given a full_duplex_failure_mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.FullDuplexFailureMode = "ignore"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid full duplex failure mode: ignore. Allowed values are [panic degrade]"))
+			})
+		})
+
+		Context("When full_duplex_failure_mode is degrade", func() {
+			BeforeEach(func() {
+				cfgForSnippet.FullDuplexFailureMode = FULL_DUPLEX_FAILURE_DEGRADE
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.FullDuplexFailureMode).To(Equal(FULL_DUPLEX_FAILURE_DEGRADE))
+			})
+		})
+
+		It("appends to X-Forwarded-For by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.ForwardedForMode).To(Equal(FORWARDED_FOR_APPEND))
+		})
+
+		Context("When forwarded_for_mode is replace", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ForwardedForMode = FORWARDED_FOR_REPLACE
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.ForwardedForMode).To(Equal(FORWARDED_FOR_REPLACE))
+			})
+		})
+
+		Context("When given a forwarded_for_mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ForwardedForMode = "prepend"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid forwarded for mode: prepend. Allowed values are [append replace remove]"))
+			})
+		})
+
+		It("preserves X-Request-Start headers of clients by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.XRequestStartPolicy).To(Equal(X_REQUEST_START_PRESERVE))
+		})
+
+		Context("When x_request_start_policy is overwrite", func() {
+			BeforeEach(func() {
+				cfgForSnippet.XRequestStartPolicy = X_REQUEST_START_OVERWRITE
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.XRequestStartPolicy).To(Equal(X_REQUEST_START_OVERWRITE))
+			})
+		})
+
+		Context("When given an x_request_start_policy that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.XRequestStartPolicy = "ignore"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid X-Request-Start policy: ignore. Allowed values are [preserve overwrite]"))
+			})
+		})
+
+		It("allows pipelined requests by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.PipelinedRequests).To(Equal(PIPELINED_REQUESTS_ALLOW))
+		})
+
+		Context("When pipelined_requests is reject", func() {
+			BeforeEach(func() {
+				cfgForSnippet.PipelinedRequests = PIPELINED_REQUESTS_REJECT
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.PipelinedRequests).To(Equal(PIPELINED_REQUESTS_REJECT))
+			})
+		})
+
+		Context("When given a pipelined_requests mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.PipelinedRequests = "queue"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid pipelined requests mode: queue. Allowed values are [allow reject]"))
+			})
+		})
+
+		It("allows absolute-form requests by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.AbsoluteFormRequests).To(Equal(ABSOLUTE_FORM_REQUESTS_ALLOW))
+		})
+
+		Context("When absolute_form_requests is reject", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AbsoluteFormRequests = ABSOLUTE_FORM_REQUESTS_REJECT
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.AbsoluteFormRequests).To(Equal(ABSOLUTE_FORM_REQUESTS_REJECT))
+			})
+		})
+
+		Context("When given an absolute_form_requests mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AbsoluteFormRequests = "host_header"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid absolute-form requests mode: host_header. Allowed values are [allow reject]"))
+			})
+		})
+
+		It("responds to OPTIONS * requests by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.AsteriskOptionsRequests).To(Equal(ASTERISK_OPTIONS_RESPOND))
+			Expect(config.AsteriskOptionsAllow).To(BeEmpty())
+		})
+
+		Context("When asterisk_options_requests is forward", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AsteriskOptionsRequests = ASTERISK_OPTIONS_FORWARD
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.AsteriskOptionsRequests).To(Equal(ASTERISK_OPTIONS_FORWARD))
+			})
+		})
+
+		Context("When given an asterisk_options_requests mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AsteriskOptionsRequests = "reject"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid asterisk options requests mode: reject. Allowed values are [respond forward]"))
+			})
+		})
+
+		It("formats the request received header as epoch milliseconds by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.RequestReceivedHeader).To(BeEmpty())
+			Expect(config.RequestReceivedFormat).To(Equal(REQUEST_RECEIVED_FORMAT_EPOCH_MS))
+		})
+
+		Context("When a request received header is configured", func() {
+			BeforeEach(func() {
+				cfgForSnippet.RequestReceivedHeader = "X-Gorouter-Received-At"
+				cfgForSnippet.RequestReceivedFormat = REQUEST_RECEIVED_FORMAT_RFC3339
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RequestReceivedHeader).To(Equal("X-Gorouter-Received-At"))
+				Expect(config.RequestReceivedFormat).To(Equal(REQUEST_RECEIVED_FORMAT_RFC3339))
+			})
+		})
+
+		Context("When given a log encoding", func() {
+			It("defaults to json", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Logging.Format.Encoding).To(Equal(LOG_ENCODING_JSON))
+			})
+
+			It("applies the console encoding", func() {
+				cfgForSnippet.Logging.Format.Encoding = LOG_ENCODING_CONSOLE
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Logging.Format.Encoding).To(Equal(LOG_ENCODING_CONSOLE))
+			})
+
+			It("returns a meaningful error for unsupported encodings", func() {
+				cfgForSnippet.Logging.Format.Encoding = "xml"
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid log encoding: xml. Allowed values are [json console]"))
+			})
+		})
+
+		Context("When given a request received format that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.RequestReceivedFormat = "epoch_ns"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid request received format: epoch_ns. Allowed values are [epoch_ms rfc3339]"))
+			})
+		})
+
+		It("does not send the request id to clients by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.ResponseRequestIDHeader).To(BeEmpty())
+		})
+
+		Context("When a response request id header is configured", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ResponseRequestIDHeader = "X-Request-Id"
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.ResponseRequestIDHeader).To(Equal("X-Request-Id"))
+			})
+		})
+
+		It("preserves duplicate Set-Cookie headers by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.DuplicateSetCookiePolicy).To(Equal(DUPLICATE_SET_COOKIE_PRESERVE))
+		})
+
+		Context("When duplicate_set_cookie_policy is keep_last", func() {
+			BeforeEach(func() {
+				cfgForSnippet.DuplicateSetCookiePolicy = DUPLICATE_SET_COOKIE_KEEP_LAST
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.DuplicateSetCookiePolicy).To(Equal(DUPLICATE_SET_COOKIE_KEEP_LAST))
+			})
+		})
+
+		Context("When given a duplicate_set_cookie_policy that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.DuplicateSetCookiePolicy = "keep_first"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid duplicate Set-Cookie policy: keep_first. Allowed values are [preserve keep_last]"))
+			})
+		})
+
+		It("merges the endpoints of conflicting routes by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.RouteConflictMode).To(Equal(ROUTE_CONFLICT_MERGE))
+		})
+
+		Context("When route_conflict_mode is reject", func() {
+			BeforeEach(func() {
+				cfgForSnippet.RouteConflictMode = ROUTE_CONFLICT_REJECT
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RouteConflictMode).To(Equal(ROUTE_CONFLICT_REJECT))
+			})
+		})
+
+		Context("When given a route_conflict_mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.RouteConflictMode = "first_wins"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid route conflict mode: first_wins. Allowed values are [merge reject last_wins]"))
+			})
+		})
+
+		Context("When a client IP header is configured", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ClientIPHeader = "True-Client-IP"
+				cfgForSnippet.ClientIPTrustedProxyDepth = 1
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.ClientIPHeader).To(Equal("True-Client-IP"))
+				Expect(config.ClientIPTrustedProxyDepth).To(Equal(1))
+			})
+
+			It("rejects a negative trusted proxy depth", func() {
+				cfgForSnippet.ClientIPTrustedProxyDepth = -1
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("client_ip_trusted_proxy_depth must not be negative"))
+			})
+		})
+
+		It("rejects CONNECT requests by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.ConnectRequests).To(Equal(CONNECT_REQUESTS_REJECT))
+		})
+
+		Context("When connect_requests is tunnel", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ConnectRequests = CONNECT_REQUESTS_TUNNEL
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.ConnectRequests).To(Equal(CONNECT_REQUESTS_TUNNEL))
+			})
+		})
+
+		It("closes idle CONNECT tunnels after 15 minutes by default", func() {
+			err := config.Initialize(createYMLSnippet(cfgForSnippet))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Process()).To(Succeed())
+			Expect(config.ConnectTunnelIdleTimeout).To(Equal(900 * time.Second))
+		})
+
+		Context("When connect_tunnel_idle_timeout is negative", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ConnectTunnelIdleTimeout = -time.Second
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("connect_tunnel_idle_timeout must not be negative"))
+			})
+		})
+
+		Context("When given a connect_requests mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.ConnectRequests = "proxy"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid CONNECT requests mode: proxy. Allowed values are [reject tunnel]"))
+			})
+		})
+
+		Context("When allowed hosts are configured", func() {
+			It("accepts hosts and leading wildcards", func() {
+				cfgForSnippet.AllowedHosts = []string{"example.com", "*.apps.example.com"}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+			})
+
+			It("rejects wildcards which are not leading", func() {
+				cfgForSnippet.AllowedHosts = []string{"apps.*.example.com"}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("allowed_hosts entries must be a host or a wildcard of the form *.example.com"))
+			})
+
+			It("rejects empty entries", func() {
+				cfgForSnippet.AllowedHosts = []string{""}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("allowed_hosts entries must be a host or a wildcard of the form *.example.com"))
+			})
+		})
+
+		Context("When a panic response is configured", func() {
+			It("applies it", func() {
+				cfgForSnippet.PanicResponse = PanicResponseConfig{
+					StatusCode:     503,
+					UseErrorWriter: true,
+				}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.PanicResponse).To(Equal(cfgForSnippet.PanicResponse))
+			})
+
+			It("rejects status codes that are not 5xx", func() {
+				cfgForSnippet.PanicResponse.StatusCode = 200
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("panic_response.status_code must be a 5xx status code"))
+			})
+		})
+
+		Context("When baggage limits are configured", func() {
+			It("rejects negative limits", func() {
+				cfgForSnippet.Tracing.Baggage.MaxBytes = -1
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tracing.baggage limits must not be negative"))
+			})
+		})
+
+		Context("When a healthcheck cache TTL is configured", func() {
+			It("rejects TTLs of a second or more", func() {
+				cfgForSnippet.HealthCheckCacheTTL = time.Second
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("healthcheck_cache_ttl must be between 0 and 1s"))
+			})
+
+			It("rejects negative TTLs", func() {
+				cfgForSnippet.HealthCheckCacheTTL = -time.Millisecond
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("healthcheck_cache_ttl must be between 0 and 1s"))
+			})
+		})
+
+		Context("When a maintenance page is configured", func() {
+			It("defaults to a 503", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.MaintenancePage.StatusCode).To(Equal(503))
+				Expect(config.MaintenancePage.Message).To(Equal("Service is down for maintenance"))
+			})
+
+			It("applies it", func() {
+				cfgForSnippet.MaintenancePage = MaintenancePageConfig{
+					StatusCode: 410,
+					Message:    "Back soon",
+				}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.MaintenancePage).To(Equal(cfgForSnippet.MaintenancePage))
+			})
+
+			It("rejects status codes that are not errors", func() {
+				cfgForSnippet.MaintenancePage.StatusCode = 200
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("maintenance_page.status_code must be a 4xx or 5xx status code"))
+			})
+		})
+
+		Context("When authentication is configured", func() {
+			It("defaults to none", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Auth.Type).To(Equal(AUTH_NONE))
+				Expect(config.Auth.Basic.Realm).To(Equal("gorouter"))
+			})
+
+			It("applies basic authentication", func() {
+				cfgForSnippet.Auth = AuthConfig{
+					Type: AUTH_BASIC,
+					Basic: BasicAuthConfig{
+						Realm: "operators",
+						Users: map[string]string{"alice": "secret"},
+					},
+				}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Auth).To(Equal(cfgForSnippet.Auth))
+			})
+
+			It("requires users for basic authentication", func() {
+				cfgForSnippet.Auth.Type = AUTH_BASIC
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("auth.basic.users must not be empty when auth.type is basic"))
+			})
+
+			It("rejects unknown types", func() {
+				cfgForSnippet.Auth.Type = "jwt"
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid auth type: jwt. Allowed values are [none basic mtls]"))
+			})
+
+			It("applies route basic auth credentials", func() {
+				cfgForSnippet.RouteBasicAuth = map[string]BasicAuthConfig{
+					"operators": {Realm: "operators", Users: map[string]string{"alice": "secret"}},
+				}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RouteBasicAuth).To(Equal(cfgForSnippet.RouteBasicAuth))
+			})
+
+			It("requires users for route basic auth credentials", func() {
+				cfgForSnippet.RouteBasicAuth = map[string]BasicAuthConfig{
+					"operators": {Realm: "operators"},
+				}
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("route_basic_auth.operators.users must not be empty"))
+			})
+		})
+
+		Context("When the debug tap is enabled", func() {
+			BeforeEach(func() {
+				cfgForSnippet.DebugTap.Enabled = true
+				cfgForSnippet.DebugTap.MaxSubscribers = 2
+				cfgForSnippet.DebugTap.BufferSize = 50
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.DebugTap).To(Equal(cfgForSnippet.DebugTap))
+			})
+
+			It("requires a buffer size", func() {
+				cfgForSnippet.DebugTap.BufferSize = 0
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("debug_tap.max_subscribers and debug_tap.buffer_size must be greater than 0"))
+			})
+		})
+
+		Context("When TLS handshakes are limited", func() {
+			BeforeEach(func() {
+				cfgForSnippet.TLSHandshakeLimit.MaxConcurrent = 100
+				cfgForSnippet.TLSHandshakeLimit.QueueTimeout = 1 * time.Second
+				cfgForSnippet.TLSHandshakeLimit.HandshakeTimeout = 2 * time.Second
+			})
+
+			It("queues handshakes beyond the limit by default", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.TLSHandshakeLimit).To(Equal(TLSHandshakeLimitConfig{
+					MaxConcurrent:    100,
+					Mode:             TLS_HANDSHAKE_LIMIT_QUEUE,
+					QueueTimeout:     1 * time.Second,
+					HandshakeTimeout: 2 * time.Second,
+				}))
+			})
+
+			It("rejects unknown modes", func() {
+				cfgForSnippet.TLSHandshakeLimit.Mode = "drop"
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid TLS handshake limit mode: drop. Allowed values are [queue reject]"))
+			})
+
+			It("requires a handshake timeout", func() {
+				cfgForSnippet.TLSHandshakeLimit.HandshakeTimeout = 0
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tls_handshake_limit.handshake_timeout must be greater than 0"))
+			})
+		})
+
+		Context("When stage timing is enabled", func() {
+			BeforeEach(func() {
+				cfgForSnippet.StageTiming.Enabled = true
+				cfgForSnippet.StageTiming.SlowRequestThreshold = 500 * time.Millisecond
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.StageTiming).To(Equal(cfgForSnippet.StageTiming))
+			})
+
+			It("rejects a negative threshold", func() {
+				cfgForSnippet.StageTiming.SlowRequestThreshold = -1
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("stage_timing.slow_request_threshold must not be negative"))
+			})
+		})
+
+		Context("When a GeoIP database is configured", func() {
+			BeforeEach(func() {
+				cfgForSnippet.GeoIP = config.GeoIPConfig{
+					DatabasePath:   "/var/vcap/jobs/gorouter/config/geoip.csv",
+					ReloadInterval: 30 * time.Second,
+					CountryHeader:  "X-Country",
+					ASNHeader:      "X-ASN",
+				}
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.GeoIP).To(Equal(cfgForSnippet.GeoIP))
+			})
+
+			It("rejects a negative reload interval", func() {
+				cfgForSnippet.GeoIP.ReloadInterval = -1
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("geo_ip.reload_interval must not be negative"))
+			})
+
+			It("requires a header to add", func() {
+				cfgForSnippet.GeoIP.CountryHeader = ""
+				cfgForSnippet.GeoIP.ASNHeader = ""
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("geo_ip requires a country_header or an asn_header"))
+			})
+		})
+
+		Context("When a registry snapshot is configured", func() {
+			BeforeEach(func() {
+				cfgForSnippet.RegistrySnapshot = config.RegistrySnapshotConfig{
+					Path:     "/var/vcap/data/gorouter/routes.json",
+					Interval: 10 * time.Second,
+					TTL:      20 * time.Second,
+				}
+			})
+
+			It("succeeds", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RegistrySnapshot).To(Equal(cfgForSnippet.RegistrySnapshot))
+			})
+
+			It("requires a positive interval", func() {
+				cfgForSnippet.RegistrySnapshot.Interval = 0
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("registry_snapshot.interval must be positive"))
+			})
+
+			It("requires a TTL shorter than the droplet stale threshold", func() {
+				cfgForSnippet.RegistrySnapshot.TTL = 10 * time.Minute
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("registry_snapshot.ttl must be at least 1s and shorter than droplet_stale_threshold"))
+			})
+		})
+
+		Context("When route lookup misses per second is negative", func() {
+			BeforeEach(func() {
+				cfgForSnippet.Logging.RouteLookupMissesPerSecond = -1
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("logging.route_lookup_misses_per_second must not be negative"))
+			})
+		})
+
+		Context("When the frontend write stall timeout is negative", func() {
+			BeforeEach(func() {
+				cfgForSnippet.FrontendWriteStallTimeout = -1
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("frontend_write_stall_timeout must not be negative"))
+			})
+		})
+
+		Context("When given a host_port_strip_mode of none", func() {
+			BeforeEach(func() {
+				cfgForSnippet.HostPortStripMode = "none"
+			})
+
+			It("sets the mode", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.HostPortStripMode).To(Equal(HOST_PORT_STRIP_NONE))
+			})
+		})
+
+		Context("When given a host_port_strip_mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.HostPortStripMode = "some"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid host port strip mode: some. Allowed values are [any listener none]"))
+			})
+		})
+
+		Context("defaults trailing_slash_mode to ignore", func() {
+			It("correctly sets the value", func() {
+				Expect(config.TrailingSlashMode).To(Equal("ignore"))
+			})
+		})
+
+		Context("defaults case_sensitive_route_paths to false", func() {
+			It("correctly sets the value", func() {
+				Expect(config.CaseSensitiveRoutePaths).To(BeFalse())
+			})
+		})
+
+		Context("When case_sensitive_route_paths is set", func() {
+			It("sets the value", func() {
+				err := config.Initialize([]byte(`case_sensitive_route_paths: true`))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(config.CaseSensitiveRoutePaths).To(BeTrue())
+			})
+		})
+
+		Context("When given a supported trailing_slash_mode", func() {
+			BeforeEach(func() {
+				cfgForSnippet.TrailingSlashMode = "redirect_remove"
+			})
+
+			It("sets the mode", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.TrailingSlashMode).To(Equal(TRAILING_SLASH_REDIRECT_REMOVE))
+			})
+		})
+
+		Context("When given a trailing_slash_mode that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.TrailingSlashMode = "exact"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid trailing slash mode: exact. Allowed values are [ignore redirect_add redirect_remove]"))
+			})
+		})
+
+		Context("When given an access log static field key that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AccessLog.StaticFields = map[string]string{"cost center": "42"}
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError(`access_log.static_fields key "cost center" must start with a letter and contain only letters, digits and underscores`))
+			})
+		})
+
+		Context("When given an access log fsync policy that is not supported", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AccessLog.Rotation.Fsync = "sometimes"
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid access log fsync policy: sometimes. Allowed values are [never always on_rotate]"))
+			})
+		})
+
+		Context("When given an access log suppressed status range that is inverted", func() {
+			BeforeEach(func() {
+				cfgForSnippet.AccessLog.OnlyErrors.SuppressedStatusMin = 400
+				cfgForSnippet.AccessLog.OnlyErrors.SuppressedStatusMax = 200
+			})
+
+			It("returns a meaningful error", func() {
+				err := config.Initialize(createYMLSnippet(cfgForSnippet))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("access_log.only_errors suppressed status range must be a range of status codes from 100 to 599"))
+			})
+		})
+
 		Context("defaults forwarded_client_cert value to always_forward", func() {
 			It("correctly sets the value", func() {
 				Expect(config.ForwardedClientCert).To(Equal("always_forward"))
@@ -121,3 +121,29 @@ func (ew *htmlErrorWriter) WriteError(
 	rw.WriteHeader(code)
 	rw.Write(respBytes)
 }
+
+type cacheControlErrorWriter struct {
+	ErrorWriter
+	cacheControl string
+}
+
+// NewCacheControlErrorWriter wraps ew so that the 5xx responses it writes
+// carry the given Cache-Control header, replacing any set before.
+func NewCacheControlErrorWriter(ew ErrorWriter, cacheControl string) ErrorWriter {
+	return &cacheControlErrorWriter{
+		ErrorWriter:  ew,
+		cacheControl: cacheControl,
+	}
+}
+
+func (ew *cacheControlErrorWriter) WriteError(
+	rw http.ResponseWriter,
+	code int,
+	message string,
+	logger logger.Logger,
+) {
+	if code >= http.StatusInternalServerError {
+		rw.Header().Set("Cache-Control", ew.cacheControl)
+	}
+	ew.ErrorWriter.WriteError(rw, code, message, logger)
+}
@@ -291,3 +291,46 @@ var _ = Describe("HTML ErrorWriter", func() {
 		})
 	})
 })
+
+var _ = Describe("Cache-Control ErrorWriter", func() {
+	var (
+		errorWriter ErrorWriter
+		recorder    *httptest.ResponseRecorder
+
+		log *loggerfakes.FakeLogger
+	)
+
+	BeforeEach(func() {
+		errorWriter = NewCacheControlErrorWriter(NewPlaintextErrorWriter(), "no-store")
+		recorder = httptest.NewRecorder()
+
+		log = new(loggerfakes.FakeLogger)
+	})
+
+	Context("when the response is a server error", func() {
+		BeforeEach(func() {
+			recorder.Header().Set("Cache-Control", "public,max-age=2")
+			errorWriter.WriteError(recorder, http.StatusBadGateway, "bad gateway", log)
+		})
+
+		It("should set the Cache-Control header", func() {
+			Expect(recorder.Result().Header.Get("Cache-Control")).To(Equal("no-store"))
+		})
+
+		It("should write the response of the wrapped error writer", func() {
+			Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadGateway))
+			Eventually(BufferReader(recorder.Result().Body)).Should(Say("502 Bad Gateway: bad gateway"))
+		})
+	})
+
+	Context("when the response is a client error", func() {
+		BeforeEach(func() {
+			recorder.Header().Set("Cache-Control", "public,max-age=2")
+			errorWriter.WriteError(recorder, http.StatusBadRequest, "bad", log)
+		})
+
+		It("should keep the Cache-Control header", func() {
+			Expect(recorder.Result().Header.Get("Cache-Control")).To(Equal("public,max-age=2"))
+		})
+	})
+})
@@ -0,0 +1,172 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mdimiceli/gorouter/logger"
+)
+
+// Record is what the database knows about the network of an IP.
+type Record struct {
+	Country string
+	ASN     string
+}
+
+// Database maps networks to their Record. It is read into memory from a CSV
+// file of network,country,asn lines, e.g. "203.0.113.0/24,NL,64496". Lines
+// starting with '#' are comments. The most specific network containing an IP
+// wins.
+type Database struct {
+	records map[netip.Prefix]Record
+	// prefixBits holds the distinct prefix lengths of records, longest first
+	prefixBits []int
+}
+
+// Load reads the database from the CSV file at path.
+func Load(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads the database from CSV.
+func Parse(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	db := &Database{records: map[netip.Prefix]Record{}}
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", fields[0], err)
+		}
+		prefix = prefix.Masked()
+		if !slices.Contains(db.prefixBits, prefix.Bits()) {
+			db.prefixBits = append(db.prefixBits, prefix.Bits())
+		}
+		db.records[prefix] = Record{
+			Country: strings.TrimSpace(fields[1]),
+			ASN:     strings.TrimSpace(fields[2]),
+		}
+	}
+	slices.Sort(db.prefixBits)
+	slices.Reverse(db.prefixBits)
+
+	return db, nil
+}
+
+// Lookup returns the record of the most specific network containing ip.
+func (db *Database) Lookup(ip netip.Addr) (Record, bool) {
+	ip = ip.Unmap()
+	for _, bits := range db.prefixBits {
+		if bits > ip.BitLen() {
+			continue
+		}
+		prefix, err := ip.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if record, ok := db.records[prefix]; ok {
+			return record, true
+		}
+	}
+	return Record{}, false
+}
+
+// Reloader serves lookups from the database file at a path, reloading it
+// every interval if the file was modified. A database which fails to load is
+// logged and the previous one is kept.
+type Reloader struct {
+	path     string
+	interval time.Duration
+	logger   logger.Logger
+
+	db      atomic.Pointer[Database]
+	modTime time.Time
+}
+
+// NewReloader loads the database at path. An interval of zero disables
+// reloading.
+func NewReloader(path string, interval time.Duration, logger logger.Logger) (*Reloader, error) {
+	r := &Reloader{
+		path:     path,
+		interval: interval,
+		logger:   logger,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Lookup returns the record of the most specific network containing ip in
+// the current database.
+func (r *Reloader) Lookup(ip netip.Addr) (Record, bool) {
+	return r.db.Load().Lookup(ip)
+}
+
+func (r *Reloader) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+	if r.interval <= 0 {
+		<-signals
+		return nil
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.reload(); err != nil {
+				r.logger.Error("geoip-database-reload-failed", zap.String("path", r.path), zap.Error(err))
+			}
+		case <-signals:
+			r.logger.Info("exited")
+			return nil
+		}
+	}
+}
+
+func (r *Reloader) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	if r.db.Load() != nil && info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+
+	db, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	r.db.Store(db)
+	r.modTime = info.ModTime()
+	r.logger.Info("geoip-database-loaded", zap.String("path", r.path), zap.Int("networks", len(db.records)))
+	return nil
+}
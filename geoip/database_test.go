@@ -0,0 +1,118 @@
+package geoip_test
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mdimiceli/gorouter/geoip"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Database", func() {
+	var db *geoip.Database
+
+	lookup := func(ip string) geoip.Record {
+		record, ok := db.Lookup(netip.MustParseAddr(ip))
+		Expect(ok).To(BeTrue())
+		return record
+	}
+
+	BeforeEach(func() {
+		var err error
+		db, err = geoip.Parse(strings.NewReader(`# network,country,asn
+203.0.113.0/24,NL,64496
+203.0.113.128/25,DE,64497
+2001:db8::/32,US,64498
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("finds the record of the network containing an IP", func() {
+		Expect(lookup("203.0.113.7")).To(Equal(geoip.Record{Country: "NL", ASN: "64496"}))
+		Expect(lookup("2001:db8::1")).To(Equal(geoip.Record{Country: "US", ASN: "64498"}))
+	})
+
+	It("prefers the most specific network", func() {
+		Expect(lookup("203.0.113.200")).To(Equal(geoip.Record{Country: "DE", ASN: "64497"}))
+	})
+
+	It("finds IPv4-mapped IPv6 addresses", func() {
+		Expect(lookup("::ffff:203.0.113.7")).To(Equal(geoip.Record{Country: "NL", ASN: "64496"}))
+	})
+
+	It("misses IPs outside of all networks", func() {
+		_, ok := db.Lookup(netip.MustParseAddr("198.51.100.1"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("fails to parse invalid networks", func() {
+		_, err := geoip.Parse(strings.NewReader("not-a-network,NL,64496\n"))
+		Expect(err).To(MatchError(ContainSubstring("invalid network")))
+	})
+})
+
+var _ = Describe("Reloader", func() {
+	var (
+		path     string
+		reloader *geoip.Reloader
+		signals  chan os.Signal
+		done     chan error
+	)
+
+	writeDatabase := func(contents string, modTime time.Time) {
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		Expect(os.Chtimes(path, modTime, modTime)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "geoip.csv")
+		writeDatabase("203.0.113.0/24,NL,64496\n", time.Now().Add(-time.Hour))
+
+		var err error
+		reloader, err = geoip.NewReloader(path, 10*time.Millisecond, test_util.NewTestZapLogger("test"))
+		Expect(err).ToNot(HaveOccurred())
+
+		signals = make(chan os.Signal)
+		done = make(chan error, 1)
+		ready := make(chan struct{})
+		go func() {
+			done <- reloader.Run(signals, ready)
+		}()
+		Eventually(ready).Should(BeClosed())
+	})
+
+	AfterEach(func() {
+		close(signals)
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	lookup := func() string {
+		record, _ := reloader.Lookup(netip.MustParseAddr("203.0.113.7"))
+		return record.Country
+	}
+
+	It("serves lookups from the loaded database", func() {
+		Expect(lookup()).To(Equal("NL"))
+	})
+
+	It("reloads the database when the file changes", func() {
+		writeDatabase("203.0.113.0/24,DE,64496\n", time.Now())
+		Eventually(lookup).Should(Equal("DE"))
+	})
+
+	It("keeps the previous database when the file cannot be loaded", func() {
+		writeDatabase("broken", time.Now())
+		Consistently(lookup, 100*time.Millisecond).Should(Equal("NL"))
+	})
+
+	It("fails to start without a database", func() {
+		_, err := geoip.NewReloader(filepath.Join(filepath.Dir(path), "missing.csv"), time.Minute, test_util.NewTestZapLogger("test"))
+		Expect(err).To(HaveOccurred())
+	})
+})
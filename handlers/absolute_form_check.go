@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type absoluteFormCheck struct {
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewAbsoluteFormCheck creates a handler that rejects requests with an
+// absolute-form target, as in GET http://host/path, with a 400.
+//
+// The HTTP/1.1 server of the standard library routes these requests by the
+// host of their target, as RFC 9112 requires: it replaces the Host header
+// with it. This handler rejects them instead, for deployments which do not
+// expect to be addressed as a forward proxy.
+func NewAbsoluteFormCheck(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &absoluteFormCheck{
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (a *absoluteFormCheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if isAbsoluteForm(r) {
+		logger := LoggerWithTraceInfo(a.logger, r)
+		logger.Info("absolute-form-request-rejected", zap.String("host", r.Host))
+
+		AddRouterErrorHeader(rw, "absolute_form_request")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		a.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Absolute-form request targets are not supported",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+// isAbsoluteForm reports whether the target of the request is in
+// absolute-form. The targets of CONNECT requests are in authority-form.
+func isAbsoluteForm(r *http.Request) bool {
+	return r.Method != http.MethodConnect && r.URL != nil && r.URL.IsAbs()
+}
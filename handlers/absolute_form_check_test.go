@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("AbsoluteFormCheck", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		requestURI string
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		requestURI = "/path"
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewAbsoluteFormCheck(test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(
+			"GET " + requestURI + " HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		)))
+		Expect(err).ToNot(HaveOccurred())
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request target is in origin-form", func() {
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request target is in absolute-form", func() {
+		BeforeEach(func() {
+			requestURI = "http://other.com/path"
+		})
+
+		It("responds with a 400", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("absolute_form_request"))
+			Expect(resp.Body.String()).To(ContainSubstring("Absolute-form request targets are not supported"))
+		})
+	})
+})
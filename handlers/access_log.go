@@ -9,8 +9,10 @@ import (
 	"github.com/mdimiceli/gorouter/accesslog"
 	"github.com/mdimiceli/gorouter/accesslog/schema"
 	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/logger"
 	"github.com/mdimiceli/gorouter/proxy/utils"
+	"github.com/mdimiceli/gorouter/route"
 
 	"go.uber.org/zap"
 	"github.com/urfave/negroni/v3"
@@ -20,6 +22,8 @@ type accessLog struct {
 	accessLogger       accesslog.AccessLogger
 	extraHeadersToLog  []string
 	logAttemptsDetails bool
+	logRequestStart    bool
+	onlyErrors         config.AccessLogOnlyErrors
 	logger             logger.Logger
 }
 
@@ -29,12 +33,16 @@ func NewAccessLog(
 	accessLogger accesslog.AccessLogger,
 	extraHeadersToLog []string,
 	logAttemptsDetails bool,
+	logRequestStart bool,
+	onlyErrors config.AccessLogOnlyErrors,
 	logger logger.Logger,
 ) negroni.Handler {
 	return &accessLog{
 		accessLogger:       accessLogger,
 		extraHeadersToLog:  extraHeadersToLog,
 		logAttemptsDetails: logAttemptsDetails,
+		logRequestStart:    logRequestStart,
+		onlyErrors:         onlyErrors,
 		logger:             logger,
 	}
 }
@@ -51,6 +59,10 @@ func (a *accessLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http
 	requestBodyCounter := &countingReadCloser{delegate: r.Body}
 	r.Body = requestBodyCounter
 
+	if a.logRequestStart {
+		a.logStart(r)
+	}
+
 	next(rw, r)
 
 	reqInfo, err := ContextRequestInfo(r)
@@ -82,9 +94,42 @@ func (a *accessLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http
 	alr.AppRequestFinishedAt = reqInfo.AppRequestFinishedAt
 	alr.FinishedAt = reqInfo.FinishedAt
 
+	if a.suppressed(reqInfo.RoutePool, alr.StatusCode) {
+		return
+	}
+
 	a.accessLogger.Log(*alr)
 }
 
+// suppressed reports whether the record of a response with the status code is
+// left out of the access log, as only errors are logged for all routes or for
+// the route of the request.
+func (a *accessLog) suppressed(pool *route.EndpointPool, statusCode int) bool {
+	if !a.onlyErrors.Enabled && (pool == nil || !pool.AccessLogOnlyErrors()) {
+		return false
+	}
+	return statusCode >= a.onlyErrors.SuppressedStatusMin && statusCode <= a.onlyErrors.SuppressedStatusMax
+}
+
+// logStart logs a record for the request as it is received, so long-lived
+// requests show up in the access log before they complete.
+func (a *accessLog) logStart(r *http.Request) {
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil {
+		a.logger.Panic("request-info-err", zap.Error(err))
+		return
+	}
+
+	// the record is written asynchronously while the request is still being
+	// handled, so it must not share the request with the handlers
+	a.accessLogger.Log(schema.AccessLogRecord{
+		Request:           r.Clone(r.Context()),
+		ExtraHeadersToLog: a.extraHeadersToLog,
+		ReceivedAt:        reqInfo.ReceivedAt,
+		Start:             true,
+	})
+}
+
 type countingReadCloser struct {
 	delegate io.ReadCloser
 	count    uint32
@@ -5,8 +5,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/mdimiceli/gorouter/accesslog/fakes"
+	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/handlers"
 	logger_fakes "github.com/mdimiceli/gorouter/logger/fakes"
 	"github.com/mdimiceli/gorouter/proxy/utils"
@@ -82,7 +84,7 @@ var _ = Describe("AccessLog", func() {
 		handler = negroni.New()
 		handler.Use(handlers.NewRequestInfo())
 		handler.Use(handlers.NewProxyWriter(fakeLogger))
-		handler.Use(handlers.NewAccessLog(accessLogger, extraHeadersToLog, false, fakeLogger))
+		handler.Use(handlers.NewAccessLog(accessLogger, extraHeadersToLog, false, false, config.AccessLogOnlyErrors{}, fakeLogger))
 		handler.Use(nextHandler)
 
 		reqChan = make(chan *http.Request, 1)
@@ -143,11 +145,149 @@ var _ = Describe("AccessLog", func() {
 		})
 	})
 
+	Context("when logging the request start is enabled", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.VcapRequestIdHeader, "some-request-id")
+
+			handler = negroni.New()
+			handler.Use(handlers.NewRequestInfo())
+			handler.Use(handlers.NewProxyWriter(fakeLogger))
+			handler.Use(handlers.NewAccessLog(accessLogger, extraHeadersToLog, false, true, config.AccessLogOnlyErrors{}, fakeLogger))
+			handler.Use(nextHandler)
+		})
+
+		It("logs a start record before the completion record", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(accessLogger.LogCallCount()).To(Equal(2))
+
+			start := accessLogger.LogArgsForCall(0)
+			Expect(start.Start).To(BeTrue())
+			Expect(start.ReceivedAt).ToNot(BeZero())
+			Expect(start.FinishedAt).To(BeZero())
+			Expect(start.StatusCode).To(BeZero())
+
+			done := accessLogger.LogArgsForCall(1)
+			Expect(done.Start).To(BeFalse())
+			Expect(done.StatusCode).To(Equal(http.StatusTeapot))
+			Expect(done.ReceivedAt).To(Equal(start.ReceivedAt))
+
+			Expect(start.Request.Header.Get(handlers.VcapRequestIdHeader)).To(Equal("some-request-id"))
+			Expect(done.Request.Header.Get(handlers.VcapRequestIdHeader)).To(Equal("some-request-id"))
+		})
+	})
+
+	Context("when only errors are logged", func() {
+		var (
+			onlyErrors  config.AccessLogOnlyErrors
+			statusCode  int
+			routeErrors bool
+		)
+
+		BeforeEach(func() {
+			onlyErrors = config.AccessLogOnlyErrors{
+				SuppressedStatusMin: 100,
+				SuppressedStatusMax: 399,
+			}
+			routeErrors = false
+		})
+
+		JustBeforeEach(func() {
+			pool := route.NewPool(&route.PoolOpts{
+				Logger:            test_util.NewTestZapLogger("test"),
+				RetryAfterFailure: 1 * time.Second,
+				Host:              "example.com",
+			})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:                "1.1.1.1",
+				Port:                8080,
+				AccessLogOnlyErrors: routeErrors,
+			}))
+
+			handler = negroni.New()
+			handler.Use(handlers.NewRequestInfo())
+			handler.Use(handlers.NewProxyWriter(fakeLogger))
+			handler.Use(handlers.NewAccessLog(accessLogger, extraHeadersToLog, false, false, onlyErrors, fakeLogger))
+			handler.UseHandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				reqInfo, err := handlers.ContextRequestInfo(req)
+				Expect(err).ToNot(HaveOccurred())
+				reqInfo.RoutePool = pool
+				rw.WriteHeader(statusCode)
+				nextCalled = true
+			})
+
+			handler.ServeHTTP(resp, req)
+		})
+
+		Context("for all routes", func() {
+			BeforeEach(func() {
+				onlyErrors.Enabled = true
+			})
+
+			Context("when the response is successful", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusOK
+				})
+
+				It("does not log it", func() {
+					Expect(accessLogger.LogCallCount()).To(BeZero())
+				})
+			})
+
+			Context("when the response is a server error", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusInternalServerError
+				})
+
+				It("logs it", func() {
+					Expect(accessLogger.LogCallCount()).To(Equal(1))
+					Expect(accessLogger.LogArgsForCall(0).StatusCode).To(Equal(http.StatusInternalServerError))
+				})
+			})
+		})
+
+		Context("for the route", func() {
+			BeforeEach(func() {
+				routeErrors = true
+			})
+
+			Context("when the response is successful", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusNoContent
+				})
+
+				It("does not log it", func() {
+					Expect(accessLogger.LogCallCount()).To(BeZero())
+				})
+			})
+
+			Context("when the response is a server error", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusBadGateway
+				})
+
+				It("logs it", func() {
+					Expect(accessLogger.LogCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("for neither all routes nor the route", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusOK
+			})
+
+			It("logs successful responses", func() {
+				Expect(accessLogger.LogCallCount()).To(Equal(1))
+			})
+		})
+	})
+
 	Context("when request info is not set on the request context", func() {
 		BeforeEach(func() {
 			handler = negroni.New()
 			handler.UseFunc(testProxyWriterHandler)
-			handler.Use(handlers.NewAccessLog(accessLogger, extraHeadersToLog, false, fakeLogger))
+			handler.Use(handlers.NewAccessLog(accessLogger, extraHeadersToLog, false, false, config.AccessLogOnlyErrors{}, fakeLogger))
 			handler.Use(nextHandler)
 		})
 		It("calls Panic on the logger", func() {
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/urfave/negroni/v3"
+)
+
+type asteriskOptions struct {
+	allow string
+}
+
+// NewAsteriskOptions creates a handler that responds to OPTIONS * requests
+// with a 200, carrying allow as Allow header unless it is empty. These
+// requests are about the server as a whole, so they are not routed.
+func NewAsteriskOptions(allow string) negroni.Handler {
+	return &asteriskOptions{allow: allow}
+}
+
+func (a *asteriskOptions) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !isAsteriskForm(r) {
+		next(rw, r)
+		return
+	}
+
+	if a.allow != "" {
+		rw.Header().Set("Allow", a.allow)
+	}
+	rw.Header().Set("Content-Length", "0")
+	rw.WriteHeader(http.StatusOK)
+}
+
+// isAsteriskForm reports whether the request is an OPTIONS * request.
+func isAsteriskForm(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.RequestURI == "*"
+}
@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/handlers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("AsteriskOptions", func() {
+	var (
+		handler     *negroni.Negroni
+		resp        *httptest.ResponseRecorder
+		requestLine string
+		allow       string
+		nextCalled  bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		requestLine = "OPTIONS * HTTP/1.1"
+		allow = ""
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewAsteriskOptions(allow))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(
+			requestLine + "\r\nHost: example.com\r\n\r\n",
+		)))
+		Expect(err).ToNot(HaveOccurred())
+		handler.ServeHTTP(resp, req)
+	})
+
+	It("responds to OPTIONS * requests with a 200", func() {
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Length")).To(Equal("0"))
+		Expect(resp.Header()).NotTo(HaveKey("Allow"))
+	})
+
+	Context("when an Allow header is configured", func() {
+		BeforeEach(func() {
+			allow = "GET, OPTIONS"
+		})
+
+		It("sends it in the response", func() {
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("Allow")).To(Equal("GET, OPTIONS"))
+		})
+	})
+
+	Context("when the OPTIONS request is for a path", func() {
+		BeforeEach(func() {
+			requestLine = "OPTIONS /path HTTP/1.1"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+// AuthHandler authenticates requests before they are proxied. Authenticate
+// returns an error for requests that must not be proxied; an *AuthError
+// controls the response sent to the client.
+type AuthHandler interface {
+	Authenticate(r *http.Request) error
+}
+
+// AuthError is returned by an AuthHandler to reject a request with a specific
+// status code and message. Challenge is sent in the WWW-Authenticate header
+// when set.
+type AuthError struct {
+	StatusCode int
+	Message    string
+	Challenge  string
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
+
+// NewAuthHandler returns the built-in AuthHandler selected by cfg, or nil if
+// requests are not authenticated.
+func NewAuthHandler(cfg config.AuthConfig) AuthHandler {
+	switch cfg.Type {
+	case config.AUTH_BASIC:
+		return NewBasicAuthHandler(cfg.Basic.Realm, cfg.Basic.Users)
+	case config.AUTH_MTLS:
+		return NewMTLSAuthHandler()
+	default:
+		return nil
+	}
+}
+
+type auth struct {
+	authHandler AuthHandler
+	skipAuth    func(req *http.Request) bool
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewAuth creates a handler that passes on requests accepted by authHandler
+// and responds to all others without calling the next handler. Rejections
+// default to a 401. Requests for which skipAuth returns true, such as requests
+// coming back from a route service, are passed on without authentication.
+func NewAuth(authHandler AuthHandler, skipAuth func(req *http.Request) bool, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &auth{
+		authHandler: authHandler,
+		skipAuth:    skipAuth,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (a *auth) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	// only the request of the client is authenticated; the route service does
+	// not hold its credentials or client certificate
+	if a.skipAuth(r) {
+		next(rw, r)
+		return
+	}
+
+	err := a.authHandler.Authenticate(r)
+	if err == nil {
+		next(rw, r)
+		return
+	}
+
+	writeAuthError(rw, r, err, a.logger, a.errorWriter)
+}
+
+// writeAuthError responds to a request an AuthHandler rejected with err.
+func writeAuthError(rw http.ResponseWriter, r *http.Request, err error, logger logger.Logger, errorWriter errorwriter.ErrorWriter) {
+	authErr := &AuthError{
+		StatusCode: http.StatusUnauthorized,
+		Message:    http.StatusText(http.StatusUnauthorized),
+	}
+	errors.As(err, &authErr)
+
+	logger = LoggerWithTraceInfo(logger, r)
+	logger.Info("request-unauthenticated", zap.Error(err), zap.Int("status-code", authErr.StatusCode))
+
+	AddRouterErrorHeader(rw, "unauthenticated")
+	addInvalidResponseCacheControlHeader(rw)
+	if authErr.Challenge != "" {
+		rw.Header().Set("WWW-Authenticate", authErr.Challenge)
+	}
+
+	errorWriter.WriteError(
+		rw,
+		authErr.StatusCode,
+		authErr.Message,
+		logger,
+	)
+}
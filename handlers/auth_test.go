@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+type fakeAuthHandler struct {
+	err    error
+	called bool
+}
+
+func (f *fakeAuthHandler) Authenticate(r *http.Request) error {
+	f.called = true
+	return f.err
+}
+
+var _ = Describe("Auth", func() {
+	var (
+		handler     *negroni.Negroni
+		resp        *httptest.ResponseRecorder
+		req         *http.Request
+		authHandler *fakeAuthHandler
+		nextCalled  bool
+		skipAuth    bool
+	)
+
+	BeforeEach(func() {
+		authHandler = &fakeAuthHandler{}
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		skipAuth = false
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewAuth(authHandler, func(*http.Request) bool { return skipAuth }, test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request is authenticated", func() {
+		It("calls the next handler", func() {
+			Expect(authHandler.called).To(BeTrue())
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the authentication is skipped for the request", func() {
+		BeforeEach(func() {
+			skipAuth = true
+			authHandler.err = errors.New("unauthenticated")
+		})
+
+		It("calls the next handler without authenticating the request", func() {
+			Expect(authHandler.called).To(BeFalse())
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when authentication fails with an AuthError", func() {
+		BeforeEach(func() {
+			authHandler.err = &handlers.AuthError{
+				StatusCode: http.StatusForbidden,
+				Message:    "Forbidden",
+				Challenge:  `Bearer realm="example"`,
+			}
+		})
+
+		It("responds with its status code and challenge without calling the next handler", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusForbidden))
+			Expect(resp.Header().Get("WWW-Authenticate")).To(Equal(`Bearer realm="example"`))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("unauthenticated"))
+			Expect(resp.Body.String()).To(ContainSubstring("Forbidden"))
+		})
+	})
+
+	Context("when authentication fails with any other error", func() {
+		BeforeEach(func() {
+			authHandler.err = errors.New("boom")
+		})
+
+		It("responds with a 401 without calling the next handler", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(resp.Header().Get("WWW-Authenticate")).To(BeEmpty())
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("unauthenticated"))
+		})
+	})
+})
+
+var _ = Describe("NewAuthHandler", func() {
+	It("returns nil when authentication is disabled", func() {
+		Expect(handlers.NewAuthHandler(config.AuthConfig{Type: config.AUTH_NONE})).To(BeNil())
+	})
+
+	Context("basic", func() {
+		var (
+			authHandler handlers.AuthHandler
+			req         *http.Request
+		)
+
+		BeforeEach(func() {
+			authHandler = handlers.NewAuthHandler(config.AuthConfig{
+				Type: config.AUTH_BASIC,
+				Basic: config.BasicAuthConfig{
+					Realm: "gorouter",
+					Users: map[string]string{"alice": "secret"},
+				},
+			})
+			req = test_util.NewRequest("GET", "example.com", "/", nil)
+		})
+
+		It("accepts valid credentials and removes them from the request", func() {
+			req.SetBasicAuth("alice", "secret")
+			Expect(authHandler.Authenticate(req)).To(Succeed())
+			Expect(req.Header.Get("Authorization")).To(BeEmpty())
+		})
+
+		It("rejects invalid credentials with a challenge", func() {
+			req.SetBasicAuth("alice", "wrong")
+			err := authHandler.Authenticate(req)
+
+			var authErr *handlers.AuthError
+			Expect(errors.As(err, &authErr)).To(BeTrue())
+			Expect(authErr.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(authErr.Challenge).To(Equal(`Basic realm="gorouter"`))
+		})
+
+		It("rejects unknown users", func() {
+			req.SetBasicAuth("bob", "secret")
+			Expect(authHandler.Authenticate(req)).To(HaveOccurred())
+		})
+
+		It("rejects requests without credentials", func() {
+			Expect(authHandler.Authenticate(req)).To(HaveOccurred())
+		})
+	})
+
+	Context("mtls", func() {
+		var (
+			authHandler handlers.AuthHandler
+			req         *http.Request
+		)
+
+		BeforeEach(func() {
+			authHandler = handlers.NewAuthHandler(config.AuthConfig{Type: config.AUTH_MTLS})
+			req = test_util.NewRequest("GET", "example.com", "/", nil)
+		})
+
+		It("accepts requests with a client certificate", func() {
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+			Expect(authHandler.Authenticate(req)).To(Succeed())
+		})
+
+		It("rejects TLS requests without a client certificate", func() {
+			req.TLS = &tls.ConnectionState{}
+			Expect(authHandler.Authenticate(req)).To(HaveOccurred())
+		})
+
+		It("rejects plaintext requests", func() {
+			Expect(authHandler.Authenticate(req)).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type connectCheck struct {
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewConnectCheck creates a handler that rejects CONNECT requests with a 405,
+// so they never reach the route lookup.
+func NewConnectCheck(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &connectCheck{
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (c *connectCheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodConnect {
+		next(rw, r)
+		return
+	}
+
+	writeConnectNotAllowed(rw, r, LoggerWithTraceInfo(c.logger, r), c.errorWriter)
+}
+
+type connectTunnel struct {
+	cfg         *config.Config
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewConnectTunnel creates a handler that tunnels CONNECT requests to a
+// plain backend of their route. CONNECT requests to routes which did not opt
+// in to tunneling or are bound to a route service, or which are not sent over
+// HTTP/1.1, are rejected with a 405. Other requests are passed on.
+func NewConnectTunnel(cfg *config.Config, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &connectTunnel{
+		cfg:         cfg,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (c *connectTunnel) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodConnect {
+		next(rw, r)
+		return
+	}
+
+	logger := LoggerWithTraceInfo(c.logger, r)
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil {
+		logger.Panic("request-info-err", zap.Error(err))
+		return
+	}
+	if reqInfo.RoutePool == nil {
+		logger.Panic("request-info-err", zap.Error(errors.New("failed-to-access-RoutePool")))
+		return
+	}
+
+	// the tunnel would bypass the route service
+	hijacker, ok := rw.(http.Hijacker)
+	if !reqInfo.RoutePool.ConnectTunnel() || reqInfo.RoutePool.RouteServiceUrl() != "" || r.ProtoMajor != 1 || !ok {
+		writeConnectNotAllowed(rw, r, logger, c.errorWriter)
+		return
+	}
+
+	backendConn, endpoint, iter := c.dialBackend(reqInfo.RoutePool, logger)
+	if backendConn == nil {
+		AddRouterErrorHeader(rw, "endpoint_failure")
+		c.errorWriter.WriteError(rw, http.StatusBadGateway, "Failed to establish tunnel", logger)
+		return
+	}
+	defer backendConn.Close()
+	reqInfo.RouteEndpoint = endpoint
+	logger = logger.With(zap.String("backend", endpoint.CanonicalAddr()))
+
+	// the tunnel counts as a request for the least-connection balancing
+	iter.PreRequest(endpoint)
+	defer iter.PostRequest(endpoint)
+
+	clientConn, buf, err := hijacker.Hijack()
+	if err != nil {
+		logger.Error("connect-tunnel-hijack-failed", zap.Error(err))
+		return
+	}
+	defer clientConn.Close()
+
+	_, err = buf.WriteString("HTTP/1.1 200 Connection Established\r\n\r\n")
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		logger.Error("connect-tunnel-write-failed", zap.Error(err))
+		return
+	}
+	logger.Info("connect-tunnel-established")
+
+	deadline := tunnelDeadline{
+		conns:   []net.Conn{clientConn, backendConn},
+		timeout: c.cfg.ConnectTunnelIdleTimeout,
+	}
+	deadline.extend()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// Bytes the client sent right after the request may already be
+		// buffered, so the client is read through its buffered reader.
+		io.Copy(backendConn, idleReader{Reader: buf.Reader, deadline: deadline})
+		closeWrite(backendConn)
+	}()
+	io.Copy(clientConn, idleReader{Reader: backendConn, deadline: deadline})
+	closeWrite(clientConn)
+	wg.Wait()
+}
+
+// dialBackend dials the next plain endpoint of the pool that accepts a
+// connection. TLS endpoints are skipped, as the tunnel does not terminate TLS
+// to them.
+func (c *connectTunnel) dialBackend(pool *route.EndpointPool, logger logger.Logger) (net.Conn, *route.Endpoint, route.EndpointIterator) {
+	iter := pool.Endpoints(logger, c.cfg.LoadBalance, "", false, c.cfg.LoadBalanceAZPreference, c.cfg.Zone)
+
+	for attempt := 1; attempt <= pool.NumEndpoints(); attempt++ {
+		endpoint := iter.Next(attempt)
+		if endpoint == nil {
+			break
+		}
+		if endpoint.IsTLS() {
+			continue
+		}
+
+		conn, err := net.DialTimeout("tcp", endpoint.CanonicalAddr(), c.cfg.EndpointDialTimeout)
+		if err != nil {
+			logger.Error("connect-tunnel-dial-failed", zap.String("backend", endpoint.CanonicalAddr()), zap.Error(err))
+			iter.EndpointFailed(err)
+			continue
+		}
+		return conn, endpoint, iter
+	}
+
+	return nil, nil, nil
+}
+
+// tunnelDeadline closes both ends of a tunnel once no data was sent through
+// it in either direction for the timeout.
+type tunnelDeadline struct {
+	conns   []net.Conn
+	timeout time.Duration
+}
+
+func (t tunnelDeadline) extend() {
+	if t.timeout <= 0 {
+		return
+	}
+	deadline := time.Now().Add(t.timeout)
+	for _, conn := range t.conns {
+		conn.SetDeadline(deadline)
+	}
+}
+
+// idleReader extends the deadline of the tunnel whenever data is read.
+type idleReader struct {
+	io.Reader
+	deadline tunnelDeadline
+}
+
+func (r idleReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.deadline.extend()
+	}
+	return n, err
+}
+
+func closeWrite(conn net.Conn) {
+	if tcpConn, ok := conn.(interface{ CloseWrite() error }); ok {
+		tcpConn.CloseWrite()
+	} else {
+		conn.Close()
+	}
+}
+
+func writeConnectNotAllowed(rw http.ResponseWriter, r *http.Request, logger logger.Logger, errorWriter errorwriter.ErrorWriter) {
+	logger.Info("connect-request-rejected")
+
+	AddRouterErrorHeader(rw, "connect_not_allowed")
+	addInvalidResponseCacheControlHeader(rw)
+	r.Close = true
+
+	errorWriter.WriteError(
+		rw,
+		http.StatusMethodNotAllowed,
+		"CONNECT requests are not allowed",
+		logger,
+	)
+}
@@ -0,0 +1,254 @@
+package handlers_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("ConnectCheck", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewConnectCheck(test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request is a CONNECT request", func() {
+		BeforeEach(func() {
+			req = test_util.NewRequest(http.MethodConnect, "example.com:443", "", nil)
+		})
+
+		It("responds with a 405 without calling the next handler", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("connect_not_allowed"))
+		})
+	})
+
+	Context("when the request is not a CONNECT request", func() {
+		BeforeEach(func() {
+			req = test_util.NewRequest(http.MethodGet, "example.com", "/", nil)
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+})
+
+var _ = Describe("ConnectTunnel", func() {
+	var (
+		server          *httptest.Server
+		backend         net.Listener
+		endpoint        *route.Endpoint
+		connectTunnel   bool
+		useTLS          bool
+		routeServiceURL string
+		idleTimeout     time.Duration
+		nextCalled      bool
+	)
+
+	BeforeEach(func() {
+		connectTunnel = true
+		useTLS = false
+		routeServiceURL = ""
+		idleTimeout = time.Minute
+		nextCalled = false
+
+		var err error
+		backend, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			conn, err := backend.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+	})
+
+	AfterEach(func() {
+		backend.Close()
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		logger := test_util.NewTestZapLogger("test")
+		host, portStr, err := net.SplitHostPort(backend.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		port, err := strconv.Atoi(portStr)
+		Expect(err).ToNot(HaveOccurred())
+
+		routePool := route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 1 * time.Second,
+			Host:              "example.com",
+		})
+		endpoint = route.NewEndpoint(&route.EndpointOpts{
+			Host:            host,
+			Port:            uint16(port),
+			UseTLS:          useTLS,
+			RouteServiceUrl: routeServiceURL,
+			ConnectTunnel:   connectTunnel,
+		})
+		routePool.Put(endpoint)
+
+		cfg, err := config.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		cfg.ConnectTunnelIdleTimeout = idleTimeout
+
+		handler := negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.UseFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).ToNot(HaveOccurred())
+			reqInfo.RoutePool = routePool
+			next(rw, req)
+		})
+		handler.Use(handlers.NewConnectTunnel(cfg, logger, errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+		server = httptest.NewServer(handler)
+	})
+
+	connect := func() (net.Conn, *bufio.Reader, *http.Response) {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = conn.Write([]byte("CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		reader := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+		Expect(err).ToNot(HaveOccurred())
+		return conn, reader, resp
+	}
+
+	Context("when the route opted in to tunneling", func() {
+		It("tunnels the connection to a backend", func() {
+			conn, reader, resp := connect()
+			defer conn.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			_, err := conn.Write([]byte("ping"))
+			Expect(err).ToNot(HaveOccurred())
+
+			buf := make([]byte, 4)
+			_, err = io.ReadFull(reader, buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buf)).To(Equal("ping"))
+			Expect(nextCalled).To(BeFalse())
+		})
+
+		It("counts the tunnel as a connection to the backend while it is open", func() {
+			conn, _, resp := connect()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Eventually(endpoint.Stats.NumberConnections.Count).Should(Equal(int64(1)))
+
+			conn.Close()
+			Eventually(endpoint.Stats.NumberConnections.Count).Should(Equal(int64(0)))
+		})
+
+		Context("when no data is sent through the tunnel", func() {
+			BeforeEach(func() {
+				idleTimeout = 100 * time.Millisecond
+			})
+
+			It("closes the tunnel after the idle timeout", func() {
+				conn, reader, resp := connect()
+				defer conn.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, err := reader.ReadByte()
+				Expect(err).To(MatchError(io.EOF))
+			})
+		})
+	})
+
+	Context("when the route is bound to a route service", func() {
+		BeforeEach(func() {
+			routeServiceURL = "https://route-service.example.com"
+		})
+
+		It("responds with a 405", func() {
+			conn, _, resp := connect()
+			defer conn.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+			Expect(nextCalled).To(BeFalse())
+		})
+	})
+
+	Context("when the backends of the route serve TLS", func() {
+		BeforeEach(func() {
+			useTLS = true
+		})
+
+		It("responds with a 502 without dialing them", func() {
+			conn, _, resp := connect()
+			defer conn.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(resp.Header.Get(router_http.CfRouterError)).To(Equal("endpoint_failure"))
+		})
+	})
+
+	Context("when the route did not opt in to tunneling", func() {
+		BeforeEach(func() {
+			connectTunnel = false
+		})
+
+		It("responds with a 405", func() {
+			conn, _, resp := connect()
+			defer conn.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+			Expect(nextCalled).To(BeFalse())
+		})
+	})
+
+	Context("when the request is not a CONNECT request", func() {
+		It("calls the next handler", func() {
+			resp, err := http.Get(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusTeapot))
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type contentTypeAllowlist struct {
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewContentTypeAllowlist creates a handler that rejects requests with a body
+// whose Content-Type is not in the allowlist of the route with a 415, whatever
+// their method. Routes without an allowlist accept any content type.
+func NewContentTypeAllowlist(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &contentTypeAllowlist{
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (c *contentTypeAllowlist) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	logger := LoggerWithTraceInfo(c.logger, r)
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil {
+		logger.Panic("request-info-err", zap.Error(err))
+		return
+	}
+	if reqInfo.RoutePool == nil {
+		logger.Panic("request-info-err", zap.Error(errors.New("failed-to-access-RoutePool")))
+		return
+	}
+
+	allowed := reqInfo.RoutePool.AllowedContentTypes()
+	if len(allowed) == 0 || !hasBody(r) {
+		next(rw, r)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if !contentTypeAllowed(contentType, allowed) {
+		logger.Info("disallowed-content-type", zap.String("content-type", contentType))
+
+		AddRouterErrorHeader(rw, "disallowed_content_type")
+		addInvalidResponseCacheControlHeader(rw)
+
+		c.errorWriter.WriteError(
+			rw,
+			http.StatusUnsupportedMediaType,
+			"Content type not allowed",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+// hasBody reports whether the request carries a body, which it does when it
+// has a non-zero or unknown Content-Length or is chunked.
+func hasBody(r *http.Request) bool {
+	return r.ContentLength != 0 || slices.Contains(r.TransferEncoding, "chunked")
+}
+
+// contentTypeAllowed reports whether the media type of contentType is one of
+// allowed, ignoring parameters such as the charset on either side. A missing
+// or malformed content type is not allowed.
+func contentTypeAllowed(contentType string, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		allowedType, _, _ := strings.Cut(a, ";")
+		if strings.EqualFold(strings.TrimSpace(allowedType), mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("ContentTypeAllowlist", func() {
+	var (
+		handler     *negroni.Negroni
+		resp        *httptest.ResponseRecorder
+		routePool   *route.EndpointPool
+		allowed     []string
+		method      string
+		body        io.Reader
+		chunked     bool
+		contentType string
+		nextCalled  bool
+	)
+
+	BeforeEach(func() {
+		allowed = []string{"application/json", "text/plain; charset=utf-8"}
+		method = "POST"
+		body = strings.NewReader("{}")
+		chunked = false
+		contentType = ""
+		resp = httptest.NewRecorder()
+		nextCalled = false
+	})
+
+	JustBeforeEach(func() {
+		logger := test_util.NewTestZapLogger("test")
+		routePool = route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 1 * time.Second,
+			Host:              "example.com",
+		})
+		routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+			Host:                "1.1.1.1",
+			Port:                8080,
+			AllowedContentTypes: allowed,
+		}))
+
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.UseFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).ToNot(HaveOccurred())
+			reqInfo.RoutePool = routePool
+			next(rw, req)
+		})
+		handler.Use(handlers.NewContentTypeAllowlist(logger, errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req, err := http.NewRequest(method, "http://example.com/", body)
+		Expect(err).ToNot(HaveOccurred())
+		if chunked {
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the content type is allowed", func() {
+		BeforeEach(func() {
+			contentType = "application/json"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the allowed content type has parameters", func() {
+		BeforeEach(func() {
+			contentType = "Application/JSON; charset=UTF-8"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("when the allowlist entry has parameters", func() {
+		BeforeEach(func() {
+			contentType = "text/plain"
+		})
+
+		It("matches the media type only", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("when the content type is not allowed", func() {
+		BeforeEach(func() {
+			contentType = "application/xml; charset=utf-8"
+		})
+
+		It("responds with a 415", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("disallowed_content_type"))
+		})
+	})
+
+	Context("when the content type is malformed", func() {
+		BeforeEach(func() {
+			contentType = "application/"
+		})
+
+		It("responds with a 415", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+		})
+	})
+
+	Context("when the content type is missing", func() {
+		It("responds with a 415", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+		})
+
+		Context("when the request has no body", func() {
+			BeforeEach(func() {
+				body = nil
+			})
+
+			It("calls the next handler", func() {
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+
+		Context("when a request with a method which usually has no body carries one", func() {
+			BeforeEach(func() {
+				method = "DELETE"
+			})
+
+			It("responds with a 415", func() {
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+			})
+		})
+
+		Context("when the body is chunked", func() {
+			BeforeEach(func() {
+				chunked = true
+			})
+
+			It("responds with a 415", func() {
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+			})
+		})
+	})
+
+	Context("when the route has no allowlist", func() {
+		BeforeEach(func() {
+			allowed = nil
+			contentType = "application/xml"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/urfave/negroni/v3"
+
+	"github.com/mdimiceli/gorouter/proxy/utils"
+	"github.com/mdimiceli/gorouter/route"
+)
+
+var ErrTooManyDebugTapSubscribers = errors.New("too many debug tap subscribers")
+
+// DebugTapEvent holds the metadata of a proxied request, as streamed to debug
+// tap subscribers. Request and response bodies are never included.
+type DebugTapEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Route     string    `json:"route"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Latency   float64   `json:"latency"`
+	Backend   string    `json:"backend,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"`
+}
+
+// DebugTap fans out events for proxied requests to the subscribers of their
+// route. Events are dropped for subscribers that do not keep up, so memory
+// use is bounded by the number of subscribers and their buffer size.
+type DebugTap struct {
+	maxSubscribers int
+	bufferSize     int
+
+	active      atomic.Int32
+	lock        sync.RWMutex
+	subscribers map[*DebugTapSubscription]struct{}
+}
+
+// DebugTapSubscription receives the events of one route until it is
+// unsubscribed.
+type DebugTapSubscription struct {
+	tap        *DebugTap
+	route      route.Uri
+	sampleRate float64
+	events     chan DebugTapEvent
+	dropped    atomic.Uint64
+}
+
+func NewDebugTap(maxSubscribers, bufferSize int) *DebugTap {
+	return &DebugTap{
+		maxSubscribers: maxSubscribers,
+		bufferSize:     bufferSize,
+		subscribers:    map[*DebugTapSubscription]struct{}{},
+	}
+}
+
+// Subscribe registers a subscriber for the events of routeUri. Only a
+// sampleRate fraction of the matching requests is sent.
+func (t *DebugTap) Subscribe(routeUri string, sampleRate float64) (*DebugTapSubscription, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.subscribers) >= t.maxSubscribers {
+		return nil, ErrTooManyDebugTapSubscribers
+	}
+
+	s := &DebugTapSubscription{
+		tap:        t,
+		route:      route.Uri(strings.TrimSuffix(routeUri, "/")).RouteKey(),
+		sampleRate: sampleRate,
+		events:     make(chan DebugTapEvent, t.bufferSize),
+	}
+	t.subscribers[s] = struct{}{}
+	t.active.Add(1)
+	return s, nil
+}
+
+// Active reports whether any subscriber is attached.
+func (t *DebugTap) Active() bool {
+	return t != nil && t.active.Load() > 0
+}
+
+func (t *DebugTap) publish(routeKey route.Uri, event DebugTapEvent) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	for s := range t.subscribers {
+		if s.route != routeKey || (s.sampleRate < 1 && rand.Float64() >= s.sampleRate) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription is unsubscribed.
+func (s *DebugTapSubscription) Events() <-chan DebugTapEvent {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (s *DebugTapSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *DebugTapSubscription) Unsubscribe() {
+	s.tap.lock.Lock()
+	defer s.tap.lock.Unlock()
+
+	if _, ok := s.tap.subscribers[s]; !ok {
+		return
+	}
+	delete(s.tap.subscribers, s)
+	s.tap.active.Add(-1)
+	close(s.events)
+}
+
+type debugTapHandler struct {
+	tap *DebugTap
+}
+
+// NewDebugTapHandler creates a handler that publishes the metadata of
+// requests to the debug tap. It does nothing while no one is subscribed.
+func NewDebugTapHandler(tap *DebugTap) negroni.Handler {
+	return &debugTapHandler{tap: tap}
+}
+
+func (h *debugTapHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !h.tap.Active() {
+		next(rw, r)
+		return
+	}
+
+	start := time.Now()
+	next(rw, r)
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil || reqInfo.RoutePool == nil {
+		return
+	}
+
+	routeKey := route.Uri(reqInfo.RoutePool.Host() + strings.TrimSuffix(reqInfo.RoutePool.ContextPath(), "/")).RouteKey()
+	event := DebugTapEvent{
+		Timestamp: start,
+		Route:     routeKey.String(),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Status:    rw.(utils.ProxyResponseWriter).Status(),
+		Latency:   time.Since(start).Seconds(),
+		TraceID:   reqInfo.TraceInfo.TraceID,
+	}
+	if reqInfo.RouteEndpoint != nil {
+		event.Backend = reqInfo.RouteEndpoint.CanonicalAddr()
+	}
+	h.tap.publish(routeKey, event)
+}
@@ -0,0 +1,129 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("DebugTap", func() {
+	var (
+		tap     *handlers.DebugTap
+		handler *negroni.Negroni
+		pools   map[string]*route.EndpointPool
+	)
+
+	serve := func(host string) {
+		req, err := http.NewRequest("GET", "http://"+host+"/some/path", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	BeforeEach(func() {
+		tap = handlers.NewDebugTap(2, 1)
+		logger := test_util.NewTestZapLogger("test")
+
+		pools = map[string]*route.EndpointPool{}
+		for _, host := range []string{"app.example.com", "other.example.com"} {
+			pools[host] = route.NewPool(&route.PoolOpts{
+				Logger:      logger,
+				Host:        host,
+				ContextPath: "/",
+			})
+		}
+
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.Use(handlers.NewProxyWriter(logger))
+		handler.Use(handlers.NewDebugTapHandler(tap))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).NotTo(HaveOccurred())
+			reqInfo.RoutePool = pools[req.Host]
+			reqInfo.RouteEndpoint = route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080})
+			reqInfo.TraceInfo.TraceID = "some-trace-id"
+			rw.WriteHeader(http.StatusTeapot)
+		})
+	})
+
+	It("is inactive without subscribers", func() {
+		Expect(tap.Active()).To(BeFalse())
+	})
+
+	Context("when subscribed to a route", func() {
+		var sub *handlers.DebugTapSubscription
+
+		BeforeEach(func() {
+			var err error
+			sub, err = tap.Subscribe("App.Example.com/", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tap.Active()).To(BeTrue())
+		})
+
+		AfterEach(func() {
+			sub.Unsubscribe()
+		})
+
+		It("streams the metadata of matching requests", func() {
+			serve("app.example.com")
+
+			var event handlers.DebugTapEvent
+			Eventually(sub.Events()).Should(Receive(&event))
+			Expect(event.Route).To(Equal("app.example.com"))
+			Expect(event.Method).To(Equal("GET"))
+			Expect(event.Path).To(Equal("/some/path"))
+			Expect(event.Status).To(Equal(http.StatusTeapot))
+			Expect(event.Backend).To(Equal("10.0.0.1:8080"))
+			Expect(event.TraceID).To(Equal("some-trace-id"))
+			Expect(event.Latency).To(BeNumerically(">=", 0))
+			Expect(event.Timestamp).To(BeTemporally("~", time.Now(), time.Second))
+		})
+
+		It("does not stream requests to other routes", func() {
+			serve("other.example.com")
+			Consistently(sub.Events()).ShouldNot(Receive())
+		})
+
+		It("drops events instead of buffering beyond the buffer size", func() {
+			serve("app.example.com")
+			serve("app.example.com")
+			serve("app.example.com")
+
+			Expect(sub.Events()).To(HaveLen(1))
+			Expect(sub.Dropped()).To(Equal(uint64(2)))
+		})
+
+		It("limits the number of subscribers", func() {
+			other, err := tap.Subscribe("other.example.com", 1)
+			Expect(err).NotTo(HaveOccurred())
+			defer other.Unsubscribe()
+
+			_, err = tap.Subscribe("app.example.com", 1)
+			Expect(err).To(MatchError(handlers.ErrTooManyDebugTapSubscribers))
+		})
+
+		Context("and unsubscribed", func() {
+			BeforeEach(func() {
+				sub.Unsubscribe()
+			})
+
+			It("closes the event channel and deactivates the tap", func() {
+				Eventually(sub.Events()).Should(BeClosed())
+				Expect(tap.Active()).To(BeFalse())
+			})
+
+			It("can be unsubscribed again", func() {
+				sub.Unsubscribe()
+				Expect(tap.Active()).To(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/geoip"
+	"github.com/urfave/negroni/v3"
+)
+
+// GeoLookup finds the geoip.Record of the network an IP belongs to.
+type GeoLookup interface {
+	Lookup(ip netip.Addr) (geoip.Record, bool)
+}
+
+type geoIP struct {
+	lookup            GeoLookup
+	countryHeader     string
+	asnHeader         string
+	trustedProxyDepth int
+}
+
+// NewGeoIP creates a handler that adds the country and autonomous system of
+// the client to requests, replacing any value sent by the client. The client
+// IP is derived as by ClientIP. Headers are left out when the IP is not found.
+func NewGeoIP(lookup GeoLookup, cfg config.GeoIPConfig, trustedProxyDepth int) negroni.Handler {
+	return &geoIP{
+		lookup:            lookup,
+		countryHeader:     cfg.CountryHeader,
+		asnHeader:         cfg.ASNHeader,
+		trustedProxyDepth: trustedProxyDepth,
+	}
+}
+
+func (g *geoIP) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if g.countryHeader != "" {
+		r.Header.Del(g.countryHeader)
+	}
+	if g.asnHeader != "" {
+		r.Header.Del(g.asnHeader)
+	}
+
+	if record, ok := g.lookupClient(r); ok {
+		if g.countryHeader != "" && record.Country != "" {
+			r.Header.Set(g.countryHeader, record.Country)
+		}
+		if g.asnHeader != "" && record.ASN != "" {
+			r.Header.Set(g.asnHeader, record.ASN)
+		}
+	}
+
+	next(rw, r)
+}
+
+func (g *geoIP) lookupClient(r *http.Request) (geoip.Record, bool) {
+	clientIP, ok := ClientIP(r, g.trustedProxyDepth)
+	if !ok {
+		return geoip.Record{}, false
+	}
+	ip, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return geoip.Record{}, false
+	}
+	return g.lookup.Lookup(ip)
+}
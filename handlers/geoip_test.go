@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/geoip"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+type stubGeoLookup map[netip.Addr]geoip.Record
+
+func (s stubGeoLookup) Lookup(ip netip.Addr) (geoip.Record, bool) {
+	record, ok := s[ip]
+	return record, ok
+}
+
+var _ = Describe("GeoIP", func() {
+	var (
+		cfg               config.GeoIPConfig
+		trustedProxyDepth int
+		req               *http.Request
+		nextReq           *http.Request
+	)
+
+	BeforeEach(func() {
+		cfg = config.GeoIPConfig{CountryHeader: "X-Geo-Country", ASNHeader: "X-Geo-ASN"}
+		trustedProxyDepth = 0
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+		req.RemoteAddr = "203.0.113.7:52000"
+		nextReq = nil
+	})
+
+	JustBeforeEach(func() {
+		lookup := stubGeoLookup{
+			netip.MustParseAddr("203.0.113.7"):  {Country: "NL", ASN: "64496"},
+			netip.MustParseAddr("198.51.100.1"): {Country: "DE"},
+		}
+
+		handler := negroni.New()
+		handler.Use(handlers.NewGeoIP(lookup, cfg, trustedProxyDepth))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextReq = r
+			rw.WriteHeader(http.StatusTeapot)
+		})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		Expect(nextReq).ToNot(BeNil())
+	})
+
+	It("adds the country and ASN of the client", func() {
+		Expect(nextReq.Header.Get("X-Geo-Country")).To(Equal("NL"))
+		Expect(nextReq.Header.Get("X-Geo-ASN")).To(Equal("64496"))
+	})
+
+	Context("when the client IP is not in the database", func() {
+		BeforeEach(func() {
+			req.RemoteAddr = "192.0.2.1:52000"
+		})
+
+		It("does not add the headers", func() {
+			Expect(nextReq.Header).NotTo(HaveKey("X-Geo-Country"))
+			Expect(nextReq.Header).NotTo(HaveKey("X-Geo-ASN"))
+		})
+	})
+
+	Context("when the client sends the headers itself", func() {
+		BeforeEach(func() {
+			req.RemoteAddr = "192.0.2.1:52000"
+			req.Header.Set("X-Geo-Country", "US")
+			req.Header.Set("X-Geo-ASN", "1")
+		})
+
+		It("removes them", func() {
+			Expect(nextReq.Header).NotTo(HaveKey("X-Geo-Country"))
+			Expect(nextReq.Header).NotTo(HaveKey("X-Geo-ASN"))
+		})
+	})
+
+	Context("when the record has no ASN", func() {
+		BeforeEach(func() {
+			req.RemoteAddr = "198.51.100.1:52000"
+		})
+
+		It("adds only the country", func() {
+			Expect(nextReq.Header.Get("X-Geo-Country")).To(Equal("DE"))
+			Expect(nextReq.Header).NotTo(HaveKey("X-Geo-ASN"))
+		})
+	})
+
+	Context("when the request passed trusted proxies", func() {
+		BeforeEach(func() {
+			trustedProxyDepth = 1
+			req.RemoteAddr = "10.0.0.1:52000"
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		})
+
+		It("looks up the IP of the client before the proxies", func() {
+			Expect(nextReq.Header.Get("X-Geo-Country")).To(Equal("DE"))
+		})
+	})
+
+	Context("when a header is not configured", func() {
+		BeforeEach(func() {
+			cfg.ASNHeader = ""
+		})
+
+		It("does not add it", func() {
+			Expect(nextReq.Header.Get("X-Geo-Country")).To(Equal("NL"))
+			Expect(nextReq.Header).NotTo(HaveKey("X-Geo-ASN"))
+		})
+	})
+})
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+const HeaderCaseRecorderCtxKey key = "HeaderCaseRecorder"
+
+// HeaderCaseRecorder returns the spelling a client used for the name of a
+// header of its request, given the canonical name, or an empty string if the
+// client used the canonical spelling or it was not recorded.
+type HeaderCaseRecorder interface {
+	HeaderCase(canonicalName string) string
+}
+
+// ContextWithHeaderCaseRecorder attaches the HeaderCaseRecorder of a request
+// to the context it is served with.
+func ContextWithHeaderCaseRecorder(ctx context.Context, r HeaderCaseRecorder) context.Context {
+	return context.WithValue(ctx, HeaderCaseRecorderCtxKey, r)
+}
+
+// GetHeaderCaseRecorder returns the HeaderCaseRecorder of the request, if it
+// has one.
+func GetHeaderCaseRecorder(r *http.Request) (HeaderCaseRecorder, bool) {
+	recorder, ok := r.Context().Value(HeaderCaseRecorderCtxKey).(HeaderCaseRecorder)
+	return recorder, ok
+}
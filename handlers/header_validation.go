@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+)
+
+type headerValidation struct {
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewHeaderValidation creates a handler that rejects HTTP/1 requests with
+// folded header lines with a 400 and closes the connection. Requests without
+// a RawRequestHead are passed on.
+func NewHeaderValidation(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &headerValidation{
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (h *headerValidation) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.ProtoMajor == 1 && isObsFolded(r) {
+		logger := LoggerWithTraceInfo(h.logger, r)
+		logger.Info("malformed-request-header")
+
+		AddRouterErrorHeader(rw, "malformed-request-header")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		h.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Request has a folded header",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+func isObsFolded(r *http.Request) bool {
+	head, ok := GetRawRequestHead(r)
+	return ok && head.ObsFolded()
+}
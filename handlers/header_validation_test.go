@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+type fakeRawRequestHead struct {
+	obsFolded          bool
+	conflictingFraming bool
+}
+
+func (h fakeRawRequestHead) ObsFolded() bool {
+	return h.obsFolded
+}
+
+func (h fakeRawRequestHead) ConflictingFraming() bool {
+	return h.conflictingFraming
+}
+
+var _ = Describe("HeaderValidation", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+
+		handler = negroni.New()
+		handler.Use(handlers.NewHeaderValidation(test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	withHead := func(head fakeRawRequestHead) {
+		req = req.WithContext(handlers.ContextWithRawRequestHead(req.Context(), head))
+	}
+
+	It("calls the next handler when the request has no raw head", func() {
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+	})
+
+	It("calls the next handler when no header line was folded", func() {
+		withHead(fakeRawRequestHead{conflictingFraming: true})
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+	})
+
+	Context("when a header line was folded", func() {
+		BeforeEach(func() {
+			withHead(fakeRawRequestHead{obsFolded: true})
+		})
+
+		It("responds with a 400 and closes the connection", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("malformed-request-header"))
+			Expect(resp.Body.String()).To(ContainSubstring("Request has a folded header"))
+			Expect(req.Close).To(BeTrue())
+		})
+
+		It("calls the next handler for HTTP/2 requests", func() {
+			req.ProtoMajor = 2
+			handler.ServeHTTP(resp, req)
+
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
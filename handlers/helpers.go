@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
@@ -46,6 +47,29 @@ func hostWithoutPort(reqHost string) string {
 	return host
 }
 
+// ClientIP returns the IP of the client. The IP is taken from the
+// X-Forwarded-For chain the request was received with, followed by the address
+// of the peer, skipping trustedProxyDepth trusted proxies from its end. The
+// first IP of the chain is used if it has fewer entries.
+func ClientIP(request *http.Request, trustedProxyDepth int) (string, bool) {
+	var chain []string
+	for _, value := range request.Header.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(value, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				chain = append(chain, ip)
+			}
+		}
+	}
+	if peerIP, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+		chain = append(chain, peerIP)
+	}
+	if len(chain) == 0 {
+		return "", false
+	}
+
+	return chain[max(len(chain)-1-trustedProxyDepth, 0)], true
+}
+
 func IsWebSocketUpgrade(request *http.Request) bool {
 	// websocket should be case insensitive per RFC6455 4.2.1
 	return strings.ToLower(upgradeHeader(request)) == "websocket"
@@ -13,7 +13,11 @@ type HopByHop struct {
 	logger logger.Logger
 }
 
-// NewHopByHop creates a new handler that sanitizes hop-by-hop headers based on the HopByHopHeadersToFilter config
+// NewHopByHop creates a new handler that sanitizes hop-by-hop headers based on the HopByHopHeadersToFilter config.
+//
+// TE is hop-by-hop, but the reverse proxy keeps forwarding `TE: trailers` when
+// the client sent it, so backends know trailers are supported. With the
+// ForceTETrailers config, it is set on every request.
 func NewHopByHop(cfg *config.Config, logger logger.Logger) *HopByHop {
 	return &HopByHop{
 		logger: logger,
@@ -23,6 +27,9 @@ func NewHopByHop(cfg *config.Config, logger logger.Logger) *HopByHop {
 
 func (h *HopByHop) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	h.SanitizeRequestConnection(r)
+	if h.cfg.ForceTETrailers {
+		r.Header.Set("TE", "trailers")
+	}
 	next(rw, r)
 }
 
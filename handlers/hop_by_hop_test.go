@@ -156,4 +156,23 @@ var _ = Describe("HopByHop", func() {
 		})
 	})
 
+	Context("when ForceTETrailers is set", func() {
+		BeforeEach(func() {
+			cfg.ForceTETrailers = true
+			header.Add("TE", "gzip")
+		})
+
+		It("sets TE to trailers", func() {
+			handleRequest()
+			Expect(resp.Header().Values("TE")).To(Equal([]string{"trailers"}))
+		})
+	})
+
+	Context("when ForceTETrailers is not set", func() {
+		It("does not add TE", func() {
+			handleRequest()
+			Expect(resp.Header()).NotTo(HaveKey("Te"))
+		})
+	})
+
 })
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type hostAllowlist struct {
+	hosts       map[string]struct{}
+	wildcards   []string
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewHostAllowlist creates a handler that rejects requests whose host is not
+// in allowed with a 400. Entries of the form *.example.com match any subdomain
+// of example.com, but not example.com itself. Hosts are matched
+// case-insensitively, ignoring the port.
+func NewHostAllowlist(allowed []string, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	h := &hostAllowlist{
+		hosts:       map[string]struct{}{},
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+	for _, host := range allowed {
+		host = strings.ToLower(host)
+		if strings.HasPrefix(host, "*.") {
+			h.wildcards = append(h.wildcards, host[1:])
+		} else {
+			h.hosts[host] = struct{}{}
+		}
+	}
+	return h
+}
+
+func (h *hostAllowlist) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if h.isAllowed(r.Host) {
+		next(rw, r)
+		return
+	}
+
+	logger := LoggerWithTraceInfo(h.logger, r)
+	logger.Info("host-not-allowed", zap.String("host", r.Host))
+
+	AddRouterErrorHeader(rw, "host_not_allowed")
+	addInvalidResponseCacheControlHeader(rw)
+	r.Close = true
+
+	h.errorWriter.WriteError(
+		rw,
+		http.StatusBadRequest,
+		"Host not allowed",
+		logger,
+	)
+}
+
+func (h *hostAllowlist) isAllowed(reqHost string) bool {
+	host := strings.TrimSuffix(strings.ToLower(hostWithoutPort(reqHost)), ".")
+	if host == "" {
+		return false
+	}
+	if _, ok := h.hosts[host]; ok {
+		return true
+	}
+	for _, suffix := range h.wildcards {
+		if len(host) > len(suffix) && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
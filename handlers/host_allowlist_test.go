@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("HostAllowlist", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewHostAllowlist(
+			[]string{"example.com", "*.apps.example.com"},
+			test_util.NewTestZapLogger("test"),
+			errorwriter.NewPlaintextErrorWriter(),
+		))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the host is allowed exactly", func() {
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+
+		Context("with a port and a different case", func() {
+			BeforeEach(func() {
+				req.Host = "Example.COM:8080"
+			})
+
+			It("calls the next handler", func() {
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+	})
+
+	Context("when the host matches a wildcard", func() {
+		BeforeEach(func() {
+			req.Host = "myapp.apps.example.com"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the host is the domain of a wildcard", func() {
+		BeforeEach(func() {
+			req.Host = "apps.example.com"
+		})
+
+		It("rejects the request", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when the host is not allowed", func() {
+		BeforeEach(func() {
+			req.Host = "evil.com"
+		})
+
+		It("rejects the request with a 400", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("host_not_allowed"))
+			Expect(resp.Body.String()).To(ContainSubstring("Host not allowed"))
+		})
+	})
+
+	Context("when the host is empty", func() {
+		BeforeEach(func() {
+			req.Host = ""
+		})
+
+		It("rejects the request", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
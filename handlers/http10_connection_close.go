@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/urfave/negroni/v3"
+)
+
+type http10ConnectionClose struct{}
+
+// NewHTTP10ConnectionClose creates a handler that closes the connections of
+// HTTP/1.0 clients after every response, even when they asked to keep them
+// alive.
+func NewHTTP10ConnectionClose() negroni.Handler {
+	return &http10ConnectionClose{}
+}
+
+func (h *http10ConnectionClose) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.ProtoMajor == 1 && r.ProtoMinor == 0 {
+		// the server closes the connection after a response that carries
+		// Connection: close, which the reverse proxy does not remove
+		rw.Header().Set("Connection", "close")
+	}
+
+	next(rw, r)
+}
@@ -0,0 +1,52 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("HTTP10ConnectionClose", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+
+		handler = negroni.New()
+		handler.Use(handlers.NewHTTP10ConnectionClose())
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	It("closes the connection of HTTP/1.0 clients", func() {
+		req.ProtoMinor = 0
+		req.Header.Set("Connection", "keep-alive")
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header().Get("Connection")).To(Equal("close"))
+	})
+
+	It("leaves the connection of HTTP/1.1 clients alone", func() {
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header()).NotTo(HaveKey("Connection"))
+	})
+})
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"fmt"
 
 	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/errorwriter"
 	"github.com/mdimiceli/gorouter/logger"
 	"github.com/mdimiceli/gorouter/metrics"
@@ -33,22 +39,83 @@ type lookupHandler struct {
 	logger                   logger.Logger
 	errorWriter              errorwriter.ErrorWriter
 	EmptyPoolResponseCode503 bool
+	stripAnyPort             bool
+	listenerPorts            []string
+	misses                   *missLogSampler
+	trailingSlashMode        string
+}
+
+// missLogSampler limits the route lookup misses which are logged to a number
+// per second, so that scanning for routes does not flood the logs.
+type missLogSampler struct {
+	sync.Mutex
+	perSecond   int
+	windowStart time.Time
+	logged      int
+	suppressed  int
+}
+
+// sample reports whether a miss at now is logged, and how many misses were
+// suppressed since the last one logged.
+func (s *missLogSampler) sample(now time.Time) (bool, int) {
+	s.Lock()
+	defer s.Unlock()
+
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.logged = 0
+	}
+	if s.logged >= s.perSecond {
+		s.suppressed++
+		return false, 0
+	}
+
+	s.logged++
+	suppressed := s.suppressed
+	s.suppressed = 0
+	return true, suppressed
 }
 
 // NewLookup creates a handler responsible for looking up a route.
+//
+// When listenerPorts is nil, any port is stripped from the Host before the
+// lookup. Otherwise only ports matching one of listenerPorts are stripped, so
+// that an empty listenerPorts strips no port.
+//
+// Requests for unknown routes are logged, at most missesPerSecond of them
+// per second. Zero disables the logging.
+//
+// trailingSlashMode is one of the config.TRAILING_SLASH_* modes.
 func NewLookup(
 	registry registry.Registry,
 	rep metrics.ProxyReporter,
 	logger logger.Logger,
 	ew errorwriter.ErrorWriter,
 	emptyPoolResponseCode503 bool,
+	listenerPorts []uint16,
+	missesPerSecond int,
+	trailingSlashMode string,
 ) negroni.Handler {
+	ports := make([]string, 0, len(listenerPorts))
+	for _, port := range listenerPorts {
+		ports = append(ports, strconv.Itoa(int(port)))
+	}
+
+	var misses *missLogSampler
+	if missesPerSecond > 0 {
+		misses = &missLogSampler{perSecond: missesPerSecond}
+	}
+
 	return &lookupHandler{
 		registry:                 registry,
 		reporter:                 rep,
 		logger:                   logger,
 		errorWriter:              ew,
 		EmptyPoolResponseCode503: emptyPoolResponseCode503,
+		stripAnyPort:             listenerPorts == nil,
+		listenerPorts:            ports,
+		misses:                   misses,
+		trailingSlashMode:        trailingSlashMode,
 	}
 }
 
@@ -100,6 +167,28 @@ func (l *lookupHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 		return
 	}
 
+	if pool.HasTooFewHealthyEndpoints() {
+		l.handleInsufficientHealthyEndpoints(rw, r, logger, pool)
+		return
+	}
+
+	if location, ok := l.trailingSlashRedirect(r, pool); ok {
+		http.Redirect(rw, r, location, http.StatusPermanentRedirect)
+		return
+	}
+
+	if location, status, ok := hostRedirect(r, pool); ok {
+		http.Redirect(rw, r, location, status)
+		return
+	}
+
+	if location, ok := upgradeInsecureRequestRedirect(r, pool); ok {
+		// the response differs for clients that do not ask for the upgrade
+		rw.Header().Add("Vary", "Upgrade-Insecure-Requests")
+		http.Redirect(rw, r, location, http.StatusTemporaryRedirect)
+		return
+	}
+
 	requestInfo, err := ContextRequestInfo(r)
 	if err != nil {
 		logger.Panic("request-info-err", zap.Error(err))
@@ -109,6 +198,82 @@ func (l *lookupHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 	next(rw, r)
 }
 
+// trailingSlashRedirect returns the location to redirect the request to when
+// its path is the context path of the pool with a trailing slash, or without
+// one, depending on the trailing slash mode.
+func (l *lookupHandler) trailingSlashRedirect(r *http.Request, pool *route.EndpointPool) (string, bool) {
+	contextPath := pool.ContextPath()
+	if contextPath == "/" {
+		return "", false
+	}
+
+	path := r.URL.EscapedPath()
+	var location string
+	switch l.trailingSlashMode {
+	case config.TRAILING_SLASH_REDIRECT_ADD:
+		if !strings.EqualFold(path, contextPath) {
+			return "", false
+		}
+		location = path + "/"
+	case config.TRAILING_SLASH_REDIRECT_REMOVE:
+		if !strings.EqualFold(path, contextPath+"/") {
+			return "", false
+		}
+		location = strings.TrimSuffix(path, "/")
+	default:
+		return "", false
+	}
+
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	return location, true
+}
+
+// hostRedirect returns the location to redirect the request to when the pool
+// redirects requests to the apex domain or the www. subdomain, and the Host of
+// the request is not the one redirected to. The location keeps the port, path
+// and query of the request, and is relative to its scheme.
+func hostRedirect(r *http.Request, pool *route.EndpointPool) (string, int, bool) {
+	redirect, status := pool.HostRedirect()
+	if redirect == "" || isAsteriskForm(r) {
+		return "", 0, false
+	}
+
+	host := r.Host
+	hasWWW := len(host) > len("www.") && strings.EqualFold(host[:len("www.")], "www.")
+	switch {
+	case redirect == route.HOST_REDIRECT_APEX && hasWWW:
+		host = host[len("www."):]
+	case redirect == route.HOST_REDIRECT_WWW && !hasWWW:
+		host = "www." + host
+	default:
+		return "", 0, false
+	}
+
+	if status == 0 {
+		status = http.StatusPermanentRedirect
+	}
+	return "//" + host + r.URL.RequestURI(), status, true
+}
+
+// upgradeInsecureRequestRedirect returns the HTTPS location to redirect the
+// request to when the pool redirects requests asking to be upgraded and the
+// request arrived over plain HTTP, at the router and at any load balancer in
+// front of it.
+func upgradeInsecureRequestRedirect(r *http.Request, pool *route.EndpointPool) (string, bool) {
+	if pool.UpgradeInsecureRequests() != route.UPGRADE_INSECURE_REQUESTS_REDIRECT || isAsteriskForm(r) {
+		return "", false
+	}
+	if r.Header.Get("Upgrade-Insecure-Requests") != "1" ||
+		r.TLS != nil ||
+		strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		return "", false
+	}
+
+	return "https://" + hostWithoutPort(r.Host) + r.URL.RequestURI(), true
+}
+
 func (l *lookupHandler) handleInvalidInstanceHeader(rw http.ResponseWriter, r *http.Request, logger logger.Logger) {
 	l.reporter.CaptureBadRequest()
 
@@ -140,6 +305,16 @@ func (l *lookupHandler) handleMissingHost(rw http.ResponseWriter, r *http.Reques
 func (l *lookupHandler) handleMissingRoute(rw http.ResponseWriter, r *http.Request, logger logger.Logger) {
 	l.reporter.CaptureBadRequest()
 
+	if l.misses != nil {
+		if ok, suppressed := l.misses.sample(time.Now()); ok {
+			logger.Info("route-lookup-miss",
+				zap.String("host", r.Host),
+				zap.String("path", r.URL.EscapedPath()),
+				zap.Int("suppressed", suppressed),
+			)
+		}
+	}
+
 	AddRouterErrorHeader(rw, "unknown_route")
 	addNoCacheControlHeader(rw)
 
@@ -172,6 +347,24 @@ func (l *lookupHandler) handleUnavailableRoute(rw http.ResponseWriter, r *http.R
 	)
 }
 
+func (l *lookupHandler) handleInsufficientHealthyEndpoints(rw http.ResponseWriter, r *http.Request, logger logger.Logger, pool *route.EndpointPool) {
+	l.reporter.CaptureBackendInsufficientHealthy()
+	logger.Info("insufficient-healthy-endpoints", zap.Int("min-healthy-endpoints", pool.MinHealthyEndpoints()))
+
+	AddRouterErrorHeader(rw, "insufficient_healthy_endpoints")
+	addInvalidResponseCacheControlHeader(rw)
+	// failed endpoints become eligible again after the retry-after-failure duration
+	retryAfter := int(math.Ceil(pool.RetryAfterFailure().Seconds()))
+	rw.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+
+	l.errorWriter.WriteError(
+		rw,
+		http.StatusServiceUnavailable,
+		fmt.Sprintf("Requested route ('%s') has too few healthy endpoints.", r.Host),
+		logger,
+	)
+}
+
 func (l *lookupHandler) handleOverloadedRoute(rw http.ResponseWriter, r *http.Request, logger logger.Logger) {
 	l.reporter.CaptureBackendExhaustedConns()
 	l.logger.Info("connection-limit-reached")
@@ -188,8 +381,15 @@ func (l *lookupHandler) handleOverloadedRoute(rw http.ResponseWriter, r *http.Re
 
 func (l *lookupHandler) lookup(r *http.Request, logger logger.Logger) (*route.EndpointPool, error) {
 	requestPath := r.URL.EscapedPath()
+	// an OPTIONS * request is about the server as a whole, which is served by
+	// the route for the root path of the host
+	if isAsteriskForm(r) {
+		requestPath = "/"
+	}
 
-	uri := route.Uri(hostWithoutPort(r.Host) + requestPath)
+	// the remaining canonicalization of the host (case, trailing dot) is
+	// done by route.Uri.RouteKey, which is shared with route registration
+	uri := route.Uri(l.hostForLookup(r.Host) + requestPath)
 	appInstanceHeader := r.Header.Get(router_http.CfAppInstance)
 
 	if appInstanceHeader != "" {
@@ -206,6 +406,18 @@ func (l *lookupHandler) lookup(r *http.Request, logger logger.Logger) (*route.En
 	return l.registry.Lookup(uri), nil
 }
 
+func (l *lookupHandler) hostForLookup(reqHost string) string {
+	if l.stripAnyPort {
+		return hostWithoutPort(reqHost)
+	}
+
+	host, port, found := strings.Cut(reqHost, ":")
+	if found && slices.Contains(l.listenerPorts, port) {
+		return host
+	}
+	return reqHost
+}
+
 func validateInstanceHeader(appInstanceHeader string) error {
 	// Regex to match format of `APP_GUID:INSTANCE_ID`
 	r := regexp.MustCompile(`^[\da-f]{8}-([\da-f]{4}-){3}[\da-f]{12}:\d+$`)
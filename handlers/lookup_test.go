@@ -1,11 +1,14 @@
 package handlers_test
 
 import (
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"time"
 
+	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/errorwriter"
 	"github.com/mdimiceli/gorouter/handlers"
 	loggerfakes "github.com/mdimiceli/gorouter/logger/fakes"
@@ -17,6 +20,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
 )
 
 var _ = Describe("Lookup", func() {
@@ -52,7 +56,7 @@ var _ = Describe("Lookup", func() {
 		req = test_util.NewRequest("GET", "example.com", "/", nil)
 		resp = httptest.NewRecorder()
 		handler.Use(handlers.NewRequestInfo())
-		handler.Use(handlers.NewLookup(reg, rep, logger, ew, true))
+		handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, nil, 0, config.TRAILING_SLASH_IGNORE))
 		handler.UseHandler(nextHandler)
 	})
 
@@ -115,6 +119,94 @@ var _ = Describe("Lookup", func() {
 		})
 	})
 
+	Context("when the host needs canonicalization", func() {
+		BeforeEach(func() {
+			pool := route.NewPool(&route.PoolOpts{
+				Logger:            logger,
+				RetryAfterFailure: 2 * time.Minute,
+				Host:              "example.com",
+				ContextPath:       "/",
+			})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.3.5.6", Port: 5679}))
+			reg.LookupReturns(pool)
+		})
+
+		Context("when the host is uppercase and has a trailing dot", func() {
+			BeforeEach(func() {
+				req.Host = "EXAMPLE.com."
+			})
+
+			It("looks up a uri that canonicalizes to the registered route", func() {
+				Expect(reg.LookupCallCount()).To(Equal(1))
+				uri := reg.LookupArgsForCall(0)
+				Expect(uri.RouteKey()).To(Equal(route.Uri("example.com")))
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+
+		Context("when the host has a port", func() {
+			BeforeEach(func() {
+				req.Host = "example.com.:8443"
+			})
+
+			It("strips any port by default", func() {
+				uri := reg.LookupArgsForCall(0)
+				Expect(uri.RouteKey()).To(Equal(route.Uri("example.com")))
+			})
+
+			Context("when only listener ports are stripped", func() {
+				BeforeEach(func() {
+					handler = negroni.New()
+					handler.Use(handlers.NewRequestInfo())
+					handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, []uint16{80, 8443}, 0, config.TRAILING_SLASH_IGNORE))
+					handler.UseHandler(nextHandler)
+				})
+
+				It("strips a port matching a listener port", func() {
+					uri := reg.LookupArgsForCall(0)
+					Expect(uri.RouteKey()).To(Equal(route.Uri("example.com")))
+				})
+
+				Context("and the port does not match a listener port", func() {
+					BeforeEach(func() {
+						req.Host = "example.com:9000"
+					})
+
+					It("keeps the port", func() {
+						uri := reg.LookupArgsForCall(0)
+						Expect(uri.RouteKey()).To(Equal(route.Uri("example.com:9000")))
+					})
+				})
+			})
+
+			Context("when no port is stripped", func() {
+				BeforeEach(func() {
+					req.Host = "example.com:8443"
+					handler = negroni.New()
+					handler.Use(handlers.NewRequestInfo())
+					handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, []uint16{}, 0, config.TRAILING_SLASH_IGNORE))
+					handler.UseHandler(nextHandler)
+				})
+
+				It("keeps the port", func() {
+					uri := reg.LookupArgsForCall(0)
+					Expect(uri.RouteKey()).To(Equal(route.Uri("example.com:8443")))
+				})
+
+				Context("and the host has no port", func() {
+					BeforeEach(func() {
+						req.Host = "example.com"
+					})
+
+					It("looks up the host", func() {
+						uri := reg.LookupArgsForCall(0)
+						Expect(uri.RouteKey()).To(Equal(route.Uri("example.com")))
+					})
+				})
+			})
+		})
+	})
+
 	Context("when there is no pool that matches the request", func() {
 		Context("when the route does not exist", func() {
 			It("sends a bad request metric", func() {
@@ -167,6 +259,316 @@ var _ = Describe("Lookup", func() {
 		})
 	})
 
+	Context("when route lookup misses are logged", func() {
+		lookupMisses := func() []string {
+			var paths []string
+			for i := 0; i < logger.InfoCallCount(); i++ {
+				message, fields := logger.InfoArgsForCall(i)
+				if message != "route-lookup-miss" {
+					continue
+				}
+				for _, field := range fields {
+					if field.Key == "path" {
+						paths = append(paths, field.String)
+					}
+				}
+			}
+			return paths
+		}
+
+		BeforeEach(func() {
+			handler = negroni.New()
+			handler.Use(handlers.NewRequestInfo())
+			handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, nil, 2, config.TRAILING_SLASH_IGNORE))
+			handler.UseHandler(nextHandler)
+		})
+
+		It("logs misses up to the configured rate", func() {
+			for i := 0; i < 4; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), test_util.NewRequest("GET", "example.com", "/other", nil))
+			}
+
+			Expect(lookupMisses()).To(Equal([]string{"/", "/other"}))
+		})
+
+		It("logs misses again in the next second with the number suppressed", func() {
+			for i := 0; i < 4; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), test_util.NewRequest("GET", "example.com", "/other", nil))
+			}
+
+			time.Sleep(time.Second)
+			handler.ServeHTTP(httptest.NewRecorder(), test_util.NewRequest("GET", "example.com", "/later", nil))
+
+			Expect(lookupMisses()).To(Equal([]string{"/", "/other", "/later"}))
+			_, fields := logger.InfoArgsForCall(logger.InfoCallCount() - 1)
+			Expect(fields).To(ContainElement(zap.Int("suppressed", 3)))
+		})
+
+		Context("when the route exists", func() {
+			BeforeEach(func() {
+				pool := route.NewPool(&route.PoolOpts{
+					Logger:            logger,
+					RetryAfterFailure: 2 * time.Minute,
+					Host:              "example.com",
+					ContextPath:       "/",
+				})
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.3.5.6", Port: 5679}))
+				reg.LookupReturns(pool)
+			})
+
+			It("does not log the request", func() {
+				Expect(nextCalled).To(BeTrue())
+				Expect(lookupMisses()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the request path differs from the route by a trailing slash", func() {
+		var trailingSlashMode string
+
+		BeforeEach(func() {
+			pool := route.NewPool(&route.PoolOpts{
+				Logger:            logger,
+				RetryAfterFailure: 2 * time.Minute,
+				Host:              "example.com",
+				ContextPath:       "/foo",
+			})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.3.5.6", Port: 5679}))
+			reg.LookupReturns(pool)
+			trailingSlashMode = config.TRAILING_SLASH_IGNORE
+		})
+
+		JustBeforeEach(func() {
+			handler = negroni.New()
+			handler.Use(handlers.NewRequestInfo())
+			handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, nil, 0, trailingSlashMode))
+			handler.UseHandler(nextHandler)
+		})
+
+		serve := func(path string) *httptest.ResponseRecorder {
+			nextCalled = false
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, test_util.NewRequest("GET", "example.com", path, nil))
+			return resp
+		}
+
+		Context("when trailing slashes are ignored", func() {
+			It("routes the request with and without the trailing slash", func() {
+				serve("/foo")
+				Expect(nextCalled).To(BeTrue())
+
+				serve("/foo/")
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+
+		Context("when trailing slashes are added by a redirect", func() {
+			BeforeEach(func() {
+				trailingSlashMode = config.TRAILING_SLASH_REDIRECT_ADD
+			})
+
+			It("redirects the request without the trailing slash", func() {
+				resp := serve("/foo?bar=baz")
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusPermanentRedirect))
+				Expect(resp.Header().Get("Location")).To(Equal("/foo/?bar=baz"))
+			})
+
+			It("routes the request with the trailing slash", func() {
+				serve("/foo/")
+				Expect(nextCalled).To(BeTrue())
+			})
+
+			It("routes requests for paths below the route", func() {
+				serve("/foo/bar")
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+
+		Context("when trailing slashes are removed by a redirect", func() {
+			BeforeEach(func() {
+				trailingSlashMode = config.TRAILING_SLASH_REDIRECT_REMOVE
+			})
+
+			It("redirects the request with the trailing slash", func() {
+				resp := serve("/foo/?bar=baz")
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusPermanentRedirect))
+				Expect(resp.Header().Get("Location")).To(Equal("/foo?bar=baz"))
+			})
+
+			It("routes the request without the trailing slash", func() {
+				serve("/foo")
+				Expect(nextCalled).To(BeTrue())
+			})
+
+			It("routes requests for paths below the route", func() {
+				serve("/foo/bar/")
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+	})
+
+	Context("when the route redirects requests to another host", func() {
+		var (
+			redirect string
+			status   int
+		)
+
+		BeforeEach(func() {
+			status = 0
+		})
+
+		JustBeforeEach(func() {
+			pool := route.NewPool(&route.PoolOpts{
+				Logger:            logger,
+				RetryAfterFailure: 2 * time.Minute,
+				Host:              "example.com",
+				ContextPath:       "/",
+			})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:               "1.3.5.6",
+				Port:               5679,
+				HostRedirect:       redirect,
+				HostRedirectStatus: status,
+			}))
+			reg.LookupReturns(pool)
+
+			handler = negroni.New()
+			handler.Use(handlers.NewRequestInfo())
+			handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, nil, 0, config.TRAILING_SLASH_IGNORE))
+			handler.UseHandler(nextHandler)
+		})
+
+		serve := func(host string, path string) *httptest.ResponseRecorder {
+			nextCalled = false
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, test_util.NewRequest("GET", host, path, nil))
+			return resp
+		}
+
+		Context("when requests are redirected to the apex domain", func() {
+			BeforeEach(func() {
+				redirect = route.HOST_REDIRECT_APEX
+			})
+
+			It("redirects requests for the www. subdomain keeping the port, path and query", func() {
+				resp := serve("WWW.example.com:8080", "/foo/bar?baz=qux")
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusPermanentRedirect))
+				Expect(resp.Header().Get("Location")).To(Equal("//example.com:8080/foo/bar?baz=qux"))
+			})
+
+			It("routes requests for the apex domain", func() {
+				serve("example.com", "/foo")
+				Expect(nextCalled).To(BeTrue())
+			})
+
+			Context("when the redirect is a 301", func() {
+				BeforeEach(func() {
+					status = http.StatusMovedPermanently
+				})
+
+				It("redirects with the status of the route", func() {
+					resp := serve("www.example.com", "/")
+					Expect(resp.Code).To(Equal(http.StatusMovedPermanently))
+					Expect(resp.Header().Get("Location")).To(Equal("//example.com/"))
+				})
+			})
+		})
+
+		Context("when requests are redirected to the www. subdomain", func() {
+			BeforeEach(func() {
+				redirect = route.HOST_REDIRECT_WWW
+			})
+
+			It("redirects requests for the apex domain keeping the path and query", func() {
+				resp := serve("example.com", "/foo?bar=baz")
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusPermanentRedirect))
+				Expect(resp.Header().Get("Location")).To(Equal("//www.example.com/foo?bar=baz"))
+			})
+
+			It("routes requests for the www. subdomain", func() {
+				serve("www.example.com", "/foo")
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+	})
+
+	Context("when the route upgrades insecure requests", func() {
+		var mode string
+
+		JustBeforeEach(func() {
+			pool := route.NewPool(&route.PoolOpts{
+				Logger:            logger,
+				RetryAfterFailure: 2 * time.Minute,
+				Host:              "example.com",
+				ContextPath:       "/",
+			})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "1.3.5.6",
+				Port:                    5679,
+				UpgradeInsecureRequests: mode,
+			}))
+			reg.LookupReturns(pool)
+
+			handler = negroni.New()
+			handler.Use(handlers.NewRequestInfo())
+			handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, nil, 0, config.TRAILING_SLASH_IGNORE))
+			handler.UseHandler(nextHandler)
+		})
+
+		serve := func(header http.Header) *httptest.ResponseRecorder {
+			nextCalled = false
+			resp := httptest.NewRecorder()
+			req := test_util.NewRequest("GET", "example.com:8080", "/foo?bar=baz", nil)
+			for k, v := range header {
+				req.Header[k] = v
+			}
+			handler.ServeHTTP(resp, req)
+			return resp
+		}
+
+		Context("by redirecting them", func() {
+			BeforeEach(func() {
+				mode = route.UPGRADE_INSECURE_REQUESTS_REDIRECT
+			})
+
+			It("redirects plain HTTP requests asking for the upgrade to HTTPS", func() {
+				resp := serve(http.Header{"Upgrade-Insecure-Requests": {"1"}})
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusTemporaryRedirect))
+				Expect(resp.Header().Get("Location")).To(Equal("https://example.com/foo?bar=baz"))
+				Expect(resp.Header().Get("Vary")).To(Equal("Upgrade-Insecure-Requests"))
+			})
+
+			It("routes requests not asking for the upgrade", func() {
+				serve(http.Header{})
+				Expect(nextCalled).To(BeTrue())
+			})
+
+			It("routes requests forwarded over HTTPS", func() {
+				serve(http.Header{
+					"Upgrade-Insecure-Requests": {"1"},
+					"X-Forwarded-Proto":         {"https"},
+				})
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+
+		Context("by setting a content security policy", func() {
+			BeforeEach(func() {
+				mode = route.UPGRADE_INSECURE_REQUESTS_CSP
+			})
+
+			It("routes requests asking for the upgrade", func() {
+				serve(http.Header{"Upgrade-Insecure-Requests": {"1"}})
+				Expect(nextCalled).To(BeTrue())
+			})
+		})
+	})
+
 	Context("when there is a pool that matches the request, but it has no endpoints", func() {
 		var pool *route.EndpointPool
 		Context("when empty pool response code 503 is set to true", func() {
@@ -174,7 +576,7 @@ var _ = Describe("Lookup", func() {
 				emptyPoolResponseCode503 := true
 				handler = negroni.New()
 				handler.Use(handlers.NewRequestInfo())
-				handler.Use(handlers.NewLookup(reg, rep, logger, ew, emptyPoolResponseCode503))
+				handler.Use(handlers.NewLookup(reg, rep, logger, ew, emptyPoolResponseCode503, nil, 0, config.TRAILING_SLASH_IGNORE))
 				handler.UseHandler(nextHandler)
 
 				pool = route.NewPool(&route.PoolOpts{
@@ -214,7 +616,7 @@ var _ = Describe("Lookup", func() {
 				emptyPoolResponseCode503 := false
 				handler = negroni.New()
 				handler.Use(handlers.NewRequestInfo())
-				handler.Use(handlers.NewLookup(reg, rep, logger, ew, emptyPoolResponseCode503))
+				handler.Use(handlers.NewLookup(reg, rep, logger, ew, emptyPoolResponseCode503, nil, 0, config.TRAILING_SLASH_IGNORE))
 				handler.UseHandler(nextHandler)
 
 				pool = route.NewPool(&route.PoolOpts{
@@ -340,6 +742,50 @@ var _ = Describe("Lookup", func() {
 			})
 		})
 
+		Context("when the route requires a minimum of healthy endpoints", func() {
+			var (
+				pool           *route.EndpointPool
+				failedEndpoint *route.Endpoint
+			)
+
+			BeforeEach(func() {
+				pool = route.NewPool(&route.PoolOpts{
+					Logger:            test_util.NewTestZapLogger("pool"),
+					RetryAfterFailure: 90 * time.Second,
+					Host:              "example.com",
+					ContextPath:       "/",
+				})
+				failedEndpoint = route.NewEndpoint(&route.EndpointOpts{Host: "1.3.5.6", Port: 5679, MinHealthyEndpoints: 2})
+				pool.Put(failedEndpoint)
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.4.6.7", Port: 5679, MinHealthyEndpoints: 2}))
+				reg.LookupReturns(pool)
+			})
+
+			Context("and enough endpoints are healthy", func() {
+				It("calls next with the pool", func() {
+					Expect(nextCalled).To(BeTrue())
+					Expect(rep.CaptureBackendInsufficientHealthyCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("and an ejected endpoint brings the pool below the minimum", func() {
+				BeforeEach(func() {
+					pool.EndpointFailed(failedEndpoint, &net.OpError{Op: "read", Err: errors.New("read: connection reset by peer")})
+				})
+
+				It("returns a 503 with Retry-After", func() {
+					Expect(nextCalled).To(BeFalse())
+					Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(resp.Header().Get("Retry-After")).To(Equal("90"))
+					Expect(resp.Header().Get("X-Cf-RouterError")).To(Equal("insufficient_healthy_endpoints"))
+				})
+
+				It("increments the backend_insufficient_healthy metric", func() {
+					Expect(rep.CaptureBackendInsufficientHealthyCallCount()).To(Equal(1))
+				})
+			})
+		})
+
 		Context("when a specific instance is requested", func() {
 			BeforeEach(func() {
 				pool := route.NewPool(&route.PoolOpts{
@@ -472,7 +918,7 @@ var _ = Describe("Lookup", func() {
 		Context("when request info is not set on the request context", func() {
 			BeforeEach(func() {
 				handler = negroni.New()
-				handler.Use(handlers.NewLookup(reg, rep, logger, ew, true))
+				handler.Use(handlers.NewLookup(reg, rep, logger, ew, true, nil, 0, config.TRAILING_SLASH_IGNORE))
 				handler.UseHandler(nextHandler)
 
 				pool := route.NewPool(&route.PoolOpts{
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type maintenance struct {
+	page        config.MaintenancePageConfig
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewMaintenance creates a handler that answers requests to routes which are
+// down for maintenance with the configured maintenance page instead of
+// forwarding them.
+func NewMaintenance(page config.MaintenancePageConfig, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &maintenance{
+		page:        page,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (m *maintenance) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	logger := LoggerWithTraceInfo(m.logger, r)
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil {
+		logger.Panic("request-info-err", zap.Error(err))
+		return
+	}
+	if reqInfo.RoutePool == nil {
+		logger.Panic("request-info-err", zap.Error(errors.New("failed-to-access-RoutePool")))
+		return
+	}
+
+	if !reqInfo.RoutePool.Maintenance() {
+		next(rw, r)
+		return
+	}
+
+	AddRouterErrorHeader(rw, "maintenance")
+	addNoCacheControlHeader(rw)
+
+	m.errorWriter.WriteError(
+		rw,
+		m.page.StatusCode,
+		m.page.Message,
+		logger,
+	)
+}
@@ -0,0 +1,103 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("Maintenance", func() {
+	var (
+		handler     *negroni.Negroni
+		resp        *httptest.ResponseRecorder
+		routePool   *route.EndpointPool
+		page        config.MaintenancePageConfig
+		maintenance bool
+		nextCalled  bool
+	)
+
+	BeforeEach(func() {
+		page = config.MaintenancePageConfig{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "Back soon",
+		}
+		maintenance = false
+		resp = httptest.NewRecorder()
+		nextCalled = false
+	})
+
+	JustBeforeEach(func() {
+		logger := test_util.NewTestZapLogger("test")
+		routePool = route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 1 * time.Second,
+			Host:              "example.com",
+		})
+		routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+			Host:        "1.1.1.1",
+			Port:        8080,
+			Maintenance: maintenance,
+		}))
+
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.UseFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).ToNot(HaveOccurred())
+			reqInfo.RoutePool = routePool
+			next(rw, req)
+		})
+		handler.Use(handlers.NewMaintenance(page, logger, errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req, err := http.NewRequest("GET", "http://example.com/", nil)
+		Expect(err).ToNot(HaveOccurred())
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the route is not down for maintenance", func() {
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the route is down for maintenance", func() {
+		BeforeEach(func() {
+			maintenance = true
+		})
+
+		It("serves the maintenance page", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(resp.Body.String()).To(ContainSubstring("Back soon"))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("maintenance"))
+			Expect(resp.Header().Get("Cache-Control")).To(Equal("no-cache, no-store"))
+		})
+
+		Context("when the maintenance page has another status code", func() {
+			BeforeEach(func() {
+				page.StatusCode = http.StatusGone
+			})
+
+			It("responds with it", func() {
+				Expect(nextCalled).To(BeFalse())
+				Expect(resp.Code).To(Equal(http.StatusGone))
+			})
+		})
+	})
+})
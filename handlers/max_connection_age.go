@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/urfave/negroni/v3"
+)
+
+const ConnectionStartedAtCtxKey key = "ConnectionStartedAt"
+
+// ContextWithConnectionStartedAt attaches the time a client connection was
+// accepted to the context its requests are served with.
+func ContextWithConnectionStartedAt(ctx context.Context, startedAt time.Time) context.Context {
+	return context.WithValue(ctx, ConnectionStartedAtCtxKey, startedAt)
+}
+
+type maxConnectionAge struct {
+	maxAge time.Duration
+}
+
+// NewMaxConnectionAge creates a handler that closes client connections which
+// are older than maxAge after the response to their current request.
+// Connections whose start time is unknown are left open.
+func NewMaxConnectionAge(maxAge time.Duration) negroni.Handler {
+	return &maxConnectionAge{maxAge: maxAge}
+}
+
+func (m *maxConnectionAge) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	startedAt, ok := r.Context().Value(ConnectionStartedAtCtxKey).(time.Time)
+	if ok && time.Since(startedAt) > m.maxAge {
+		// the HTTP/1 server closes the connection after a response that
+		// carries Connection: close, the HTTP/2 server sends a GOAWAY
+		rw.Header().Set("Connection", "close")
+	}
+
+	next(rw, r)
+}
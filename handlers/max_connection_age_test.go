@@ -0,0 +1,60 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("MaxConnectionAge", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+
+		handler = negroni.New()
+		handler.Use(handlers.NewMaxConnectionAge(time.Minute))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	It("closes connections older than the maximum age", func() {
+		req = req.WithContext(handlers.ContextWithConnectionStartedAt(req.Context(), time.Now().Add(-2*time.Minute)))
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header().Get("Connection")).To(Equal("close"))
+	})
+
+	It("leaves younger connections open", func() {
+		req = req.WithContext(handlers.ContextWithConnectionStartedAt(req.Context(), time.Now().Add(-30*time.Second)))
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header()).NotTo(HaveKey("Connection"))
+	})
+
+	It("leaves connections with an unknown start time open", func() {
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header()).NotTo(HaveKey("Connection"))
+	})
+})
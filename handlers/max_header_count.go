@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type maxHeaderCount struct {
+	maxCount    int
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewMaxHeaderCount creates a handler that rejects requests carrying more than
+// maxCount header fields with a 431. Every value of a repeated header counts
+// as a field.
+func NewMaxHeaderCount(maxCount int, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &maxHeaderCount{
+		maxCount:    maxCount,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (m *maxHeaderCount) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if m.maxCount <= 0 {
+		next(rw, r)
+		return
+	}
+
+	count := headerCount(r.Header)
+	if count > m.maxCount {
+		logger := LoggerWithTraceInfo(m.logger, r)
+		logger.Info("max-header-count-exceeded", zap.Int("header-count", count), zap.Int("max-count", m.maxCount))
+
+		AddRouterErrorHeader(rw, "max-header-count-exceeded")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		m.errorWriter.WriteError(
+			rw,
+			http.StatusRequestHeaderFieldsTooLarge,
+			"Request has too many headers",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+func headerCount(h http.Header) int {
+	count := 0
+	for _, values := range h {
+		count += len(values)
+	}
+	return count
+}
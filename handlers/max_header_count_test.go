@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("MaxHeaderCount", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		maxCount   int
+		nextCalled bool
+	)
+
+	addHeaders := func(n int) {
+		for i := 0; i < n; i++ {
+			req.Header.Add(fmt.Sprintf("X-Header-%d", i), "value")
+		}
+	}
+
+	BeforeEach(func() {
+		maxCount = 10
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewMaxHeaderCount(maxCount, test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request has exactly as many headers as allowed", func() {
+		BeforeEach(func() {
+			addHeaders(10)
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request has more headers than allowed", func() {
+		BeforeEach(func() {
+			addHeaders(11)
+		})
+
+		It("responds with a 431", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusRequestHeaderFieldsTooLarge))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("max-header-count-exceeded"))
+			Expect(resp.Body.String()).To(ContainSubstring("Request has too many headers"))
+			Expect(req.Close).To(BeTrue())
+		})
+	})
+
+	Context("when a repeated header pushes the request over the limit", func() {
+		BeforeEach(func() {
+			addHeaders(9)
+			req.Header.Add("X-Repeated", "one")
+			req.Header.Add("X-Repeated", "two")
+		})
+
+		It("counts every value of the header", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusRequestHeaderFieldsTooLarge))
+		})
+	})
+
+	Context("when the limit is disabled", func() {
+		BeforeEach(func() {
+			maxCount = 0
+			addHeaders(1000)
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type maxRequestURILength struct {
+	maxLength   int
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewMaxRequestURILength creates a handler that rejects requests whose
+// request URI is longer than maxLength bytes with a 414.
+func NewMaxRequestURILength(maxLength int, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &maxRequestURILength{
+		maxLength:   maxLength,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (m *maxRequestURILength) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if m.maxLength <= 0 {
+		next(rw, r)
+		return
+	}
+
+	uriLength := requestURILength(r)
+	if uriLength > m.maxLength {
+		logger := LoggerWithTraceInfo(m.logger, r)
+		logger.Info("max-request-uri-length-exceeded", zap.Int("uri-length", uriLength), zap.Int("max-length", m.maxLength))
+
+		AddRouterErrorHeader(rw, "max-request-uri-length-exceeded")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		m.errorWriter.WriteError(
+			rw,
+			http.StatusRequestURITooLong,
+			"Request URI is too long",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+// requestURILength returns the length of the raw request URI. Request URIs
+// starting with "//" are forwarded as "//<host><path>", so the host counts
+// towards their length.
+func requestURILength(r *http.Request) int {
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	if strings.HasPrefix(uri, "//") {
+		return len(uri) + len(r.Host)
+	}
+	return len(uri)
+}
@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("MaxRequestURILength", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		maxLength  int
+		requestURI string
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		maxLength = 20
+		resp = httptest.NewRecorder()
+		nextCalled = false
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewMaxRequestURILength(maxLength, test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req = test_util.NewRequest("GET", "example.com", requestURI, nil)
+		req.RequestURI = requestURI
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request URI is exactly at the limit", func() {
+		BeforeEach(func() {
+			requestURI = "/" + strings.Repeat("a", 19)
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request URI is one byte over the limit", func() {
+		BeforeEach(func() {
+			requestURI = "/" + strings.Repeat("a", 20)
+		})
+
+		It("responds with a 414", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusRequestURITooLong))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("max-request-uri-length-exceeded"))
+			Expect(resp.Body.String()).To(ContainSubstring("Request URI is too long"))
+		})
+	})
+
+	Context("when the query string pushes the request URI over the limit", func() {
+		BeforeEach(func() {
+			requestURI = "/foo?" + strings.Repeat("q", 20)
+		})
+
+		It("responds with a 414", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusRequestURITooLong))
+		})
+	})
+
+	Context("when the request URI starts with a double slash", func() {
+		BeforeEach(func() {
+			// 11 bytes on its own, 22 once the host is prepended
+			requestURI = "//" + strings.Repeat("a", 9)
+		})
+
+		It("counts the host that is added when forwarding", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusRequestURITooLong))
+		})
+	})
+
+	Context("when the limit is disabled", func() {
+		BeforeEach(func() {
+			maxLength = 0
+			requestURI = "/" + strings.Repeat("a", 1000)
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
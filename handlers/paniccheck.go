@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
 
 	"github.com/mdimiceli/gorouter/common/health"
 
@@ -14,15 +16,20 @@ import (
 )
 
 type panicCheck struct {
-	health *health.Health
-	logger logger.Logger
+	health      *health.Health
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+	response    config.PanicResponseConfig
 }
 
 // NewPanicCheck creates a handler responsible for checking for panics and setting the Healthcheck to fail.
-func NewPanicCheck(health *health.Health, logger logger.Logger) negroni.Handler {
+// The response sent on a recovered panic is configured by response.
+func NewPanicCheck(health *health.Health, logger logger.Logger, errorWriter errorwriter.ErrorWriter, response config.PanicResponseConfig) negroni.Handler {
 	return &panicCheck{
-		health: health,
-		logger: logger,
+		health:      health,
+		logger:      logger,
+		errorWriter: errorWriter,
+		response:    response,
 	}
 }
 
@@ -44,11 +51,30 @@ func (p *panicCheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next htt
 				logger.Error("panic-check", zap.String("host", r.Host), zap.Nest("error", zap.Error(err), zap.Stack()))
 
 				rw.Header().Set(router_http.CfRouterError, "unknown_failure")
-				rw.WriteHeader(http.StatusBadGateway)
-				r.Close = true
+				if p.response.CloseConnection {
+					rw = &closingResponseWriter{ResponseWriter: rw}
+					r.Close = true
+				}
+				if p.response.UseErrorWriter {
+					p.errorWriter.WriteError(rw, p.response.StatusCode, "Internal error", logger)
+				} else {
+					rw.WriteHeader(p.response.StatusCode)
+				}
 			}
 		}
 	}()
 
 	next(rw, r)
 }
+
+// closingResponseWriter sets Connection: close right before the header is
+// written, as the error writer removes the Connection header of error
+// responses.
+type closingResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *closingResponseWriter) WriteHeader(code int) {
+	w.Header().Set("Connection", "close")
+	w.ResponseWriter.WriteHeader(code)
+}
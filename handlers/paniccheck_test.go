@@ -8,6 +8,8 @@ import (
 	router_http "github.com/mdimiceli/gorouter/common/http"
 
 	"github.com/mdimiceli/gorouter/common/health"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
 
 	"github.com/mdimiceli/gorouter/handlers"
 	"github.com/mdimiceli/gorouter/logger"
@@ -26,6 +28,7 @@ var _ = Describe("Paniccheck", func() {
 		panicHandler negroni.Handler
 		request      *http.Request
 		recorder     *httptest.ResponseRecorder
+		response     config.PanicResponseConfig
 	)
 
 	BeforeEach(func() {
@@ -36,7 +39,14 @@ var _ = Describe("Paniccheck", func() {
 		request = httptest.NewRequest("GET", "http://example.com/foo", nil)
 		request.Host = "somehost.com"
 		recorder = httptest.NewRecorder()
-		panicHandler = handlers.NewPanicCheck(healthStatus, testLogger)
+		response = config.PanicResponseConfig{
+			StatusCode:      http.StatusBadGateway,
+			CloseConnection: true,
+		}
+	})
+
+	JustBeforeEach(func() {
+		panicHandler = handlers.NewPanicCheck(healthStatus, testLogger, errorwriter.NewPlaintextErrorWriter(), response)
 	})
 
 	Context("when something panics", func() {
@@ -61,6 +71,52 @@ var _ = Describe("Paniccheck", func() {
 			Expect(testLogger).To(gbytes.Say("we expect this panic"))
 			Expect(testLogger).To(gbytes.Say("stacktrace"))
 		})
+
+		It("closes the connection", func() {
+			panicHandler.ServeHTTP(recorder, request, expectedPanic)
+			Expect(recorder.Header().Get("Connection")).To(Equal("close"))
+		})
+
+		Context("when a status code is configured", func() {
+			BeforeEach(func() {
+				response.StatusCode = http.StatusServiceUnavailable
+			})
+
+			It("responds with the configured status code", func() {
+				panicHandler.ServeHTTP(recorder, request, expectedPanic)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+
+		Context("when closing the connection is disabled", func() {
+			BeforeEach(func() {
+				response.CloseConnection = false
+			})
+
+			It("keeps the connection open", func() {
+				panicHandler.ServeHTTP(recorder, request, expectedPanic)
+				Expect(recorder.Header().Get("Connection")).To(BeEmpty())
+			})
+		})
+
+		Context("when the error writer is used", func() {
+			BeforeEach(func() {
+				response.StatusCode = http.StatusServiceUnavailable
+				response.UseErrorWriter = true
+			})
+
+			It("writes the error body with the configured status code", func() {
+				panicHandler.ServeHTTP(recorder, request, expectedPanic)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusServiceUnavailable))
+				Expect(recorder.Body.String()).To(Equal("503 Service Unavailable: Internal error\n"))
+				Expect(recorder.Header().Get(router_http.CfRouterError)).To(Equal("unknown_failure"))
+			})
+
+			It("still closes the connection", func() {
+				panicHandler.ServeHTTP(recorder, request, expectedPanic)
+				Expect(recorder.Header().Get("Connection")).To(Equal("close"))
+			})
+		})
 	})
 
 	Context("when there is no panic", func() {
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+var errPathTraversal = errors.New("path contains a '..' segment")
+
+type pathNormalization struct {
+	cfg         config.PathNormalizationConfig
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewPathNormalization creates a handler that normalizes the request path
+// before the route is looked up, so that equivalent paths are routed and
+// forwarded identically.
+func NewPathNormalization(cfg config.PathNormalizationConfig, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &pathNormalization{
+		cfg:         cfg,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (p *pathNormalization) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	// asterisk-form (OPTIONS *) and CONNECT requests do not carry a path
+	if r.RequestURI == "*" || r.URL.Path == "" {
+		next(rw, r)
+		return
+	}
+
+	logger := LoggerWithTraceInfo(p.logger, r)
+	escapedPath := r.URL.EscapedPath()
+
+	normalized, err := NormalizePath(escapedPath, p.cfg)
+	if err != nil {
+		logger.Info("invalid-request-path", zap.String("path", escapedPath), zap.Error(err))
+
+		AddRouterErrorHeader(rw, "invalid_path")
+		addInvalidResponseCacheControlHeader(rw)
+
+		p.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Invalid request path",
+			logger,
+		)
+		return
+	}
+
+	if normalized != escapedPath {
+		decoded, err := url.PathUnescape(normalized)
+		if err != nil {
+			logger.Panic("path-normalization-err", zap.Error(err))
+			return
+		}
+
+		r.URL.Path = decoded
+		r.URL.RawPath = normalized
+		r.RequestURI = r.URL.RequestURI()
+	}
+
+	next(rw, r)
+}
+
+// NormalizePath normalizes an escaped request path according to cfg. Paths
+// are split into segments on unescaped slashes only, so encoded slashes
+// (%2F) within a segment are preserved, matching how paths are escaped when
+// the request is forwarded.
+//
+// In strict mode an error is returned for any '..' segment, including
+// percent-encoded ones, instead of resolving it.
+func NormalizePath(escapedPath string, cfg config.PathNormalizationConfig) (string, error) {
+	if !strings.HasPrefix(escapedPath, "/") {
+		return escapedPath, nil
+	}
+
+	segments := strings.Split(escapedPath[1:], "/")
+	normalized := make([]string, 0, len(segments))
+	last := len(segments) - 1
+
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", err
+		}
+
+		if cfg.NormalizePercentEncoding {
+			segment = url.PathEscape(decoded)
+		}
+
+		switch {
+		case decoded == "..":
+			if cfg.Strict {
+				return "", errPathTraversal
+			}
+			if cfg.RemoveDotSegments {
+				if len(normalized) > 0 {
+					normalized = normalized[:len(normalized)-1]
+				}
+				if i == last {
+					normalized = append(normalized, "")
+				}
+				continue
+			}
+		case decoded == "." && cfg.RemoveDotSegments:
+			if i == last {
+				normalized = append(normalized, "")
+			}
+			continue
+		case segment == "" && cfg.MergeSlashes && i != last:
+			continue
+		}
+
+		normalized = append(normalized, segment)
+	}
+
+	return "/" + strings.Join(normalized, "/"), nil
+}
@@ -0,0 +1,194 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("PathNormalization", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		cfg        config.PathNormalizationConfig
+		requestURI string
+		nextReq    *http.Request
+	)
+
+	BeforeEach(func() {
+		cfg = config.PathNormalizationConfig{
+			MergeSlashes:      true,
+			RemoveDotSegments: true,
+		}
+		resp = httptest.NewRecorder()
+		nextReq = nil
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewPathNormalization(cfg, test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextReq = r
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		// parse the request URI the way the server does, rather than using
+		// test_util.NewRequest which only sets an opaque URL
+		var err error
+		req, err = http.NewRequest("GET", "http://example.com", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.URL, err = url.ParseRequestURI(requestURI)
+		Expect(err).ToNot(HaveOccurred())
+		req.RequestURI = requestURI
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the path contains double slashes", func() {
+		BeforeEach(func() {
+			requestURI = "/foo//bar///baz?q=1"
+		})
+
+		It("collapses them before calling the next handler", func() {
+			Expect(nextReq).ToNot(BeNil())
+			Expect(nextReq.URL.Path).To(Equal("/foo/bar/baz"))
+			Expect(nextReq.RequestURI).To(Equal("/foo/bar/baz?q=1"))
+		})
+
+		Context("and merging slashes is disabled", func() {
+			BeforeEach(func() {
+				cfg.MergeSlashes = false
+			})
+
+			It("leaves the path untouched", func() {
+				Expect(nextReq.URL.Path).To(Equal("/foo//bar///baz"))
+				Expect(nextReq.RequestURI).To(Equal("/foo//bar///baz?q=1"))
+			})
+		})
+	})
+
+	Context("when the request URI starts with a double slash and merging slashes is disabled", func() {
+		BeforeEach(func() {
+			cfg.MergeSlashes = false
+			requestURI = "//foo/./bar"
+		})
+
+		It("keeps the leading double slash so it is preserved when forwarding", func() {
+			Expect(nextReq.URL.Path).To(Equal("//foo/bar"))
+			Expect(nextReq.RequestURI).To(Equal("//foo/bar"))
+		})
+	})
+
+	Context("when the path contains dot segments", func() {
+		BeforeEach(func() {
+			requestURI = "/foo/./bar/../baz"
+		})
+
+		It("resolves them", func() {
+			Expect(nextReq.URL.Path).To(Equal("/foo/baz"))
+			Expect(nextReq.RequestURI).To(Equal("/foo/baz"))
+		})
+	})
+
+	Context("when the path tries to traverse above the root", func() {
+		BeforeEach(func() {
+			requestURI = "/../../etc/passwd"
+		})
+
+		It("clamps the path to the root", func() {
+			Expect(nextReq.URL.Path).To(Equal("/etc/passwd"))
+		})
+	})
+
+	Context("when the path ends in a dot segment", func() {
+		BeforeEach(func() {
+			requestURI = "/foo/bar/.."
+		})
+
+		It("keeps a trailing slash", func() {
+			Expect(nextReq.URL.Path).To(Equal("/foo/"))
+		})
+	})
+
+	Context("when the path contains percent-encoded dot segments", func() {
+		BeforeEach(func() {
+			requestURI = "/foo/%2e%2E/bar"
+		})
+
+		It("resolves them", func() {
+			Expect(nextReq.URL.Path).To(Equal("/bar"))
+		})
+	})
+
+	Context("when the path contains an encoded slash", func() {
+		BeforeEach(func() {
+			requestURI = "/foo/a%2Fb/../bar"
+		})
+
+		It("treats the encoded slash as part of the segment", func() {
+			Expect(nextReq.URL.Path).To(Equal("/foo/bar"))
+		})
+	})
+
+	Context("when percent-encoding normalization is enabled", func() {
+		BeforeEach(func() {
+			cfg.NormalizePercentEncoding = true
+			requestURI = "/%66%6f%6f/a%2fb"
+		})
+
+		It("decodes unnecessary escapes and re-encodes reserved characters", func() {
+			Expect(nextReq.URL.EscapedPath()).To(Equal("/foo/a%2Fb"))
+			Expect(nextReq.RequestURI).To(Equal("/foo/a%2Fb"))
+		})
+	})
+
+	Context("when strict mode is enabled", func() {
+		BeforeEach(func() {
+			cfg.Strict = true
+		})
+
+		Context("and the path contains a '..' segment", func() {
+			BeforeEach(func() {
+				requestURI = "/foo/../bar"
+			})
+
+			It("responds with a 400", func() {
+				Expect(nextReq).To(BeNil())
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("invalid_path"))
+			})
+		})
+
+		Context("and the path contains a percent-encoded '..' segment", func() {
+			BeforeEach(func() {
+				requestURI = "/foo/%2E%2e/bar"
+			})
+
+			It("responds with a 400", func() {
+				Expect(nextReq).To(BeNil())
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("and the path is clean", func() {
+			BeforeEach(func() {
+				requestURI = "/foo/bar..baz"
+			})
+
+			It("calls the next handler", func() {
+				Expect(resp.Code).To(Equal(http.StatusTeapot))
+				Expect(nextReq.URL.Path).To(Equal("/foo/bar..baz"))
+			})
+		})
+	})
+})
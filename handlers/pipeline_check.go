@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/metrics"
+	"github.com/urfave/negroni/v3"
+)
+
+const PipelineDetectorCtxKey key = "PipelineDetector"
+
+// PipelineDetector reports whether the request currently served on a client
+// connection was sent before the response to the previous request on it.
+type PipelineDetector interface {
+	Pipelined() bool
+}
+
+// ContextWithPipelineDetector attaches the PipelineDetector of a client
+// connection to the context its requests are served with.
+func ContextWithPipelineDetector(ctx context.Context, d PipelineDetector) context.Context {
+	return context.WithValue(ctx, PipelineDetectorCtxKey, d)
+}
+
+type pipelineCheck struct {
+	reporter    metrics.ProxyReporter
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewPipelineCheck creates a handler that rejects pipelined HTTP/1.1 requests
+// with a 400 and closes the connection. Requests on connections without a
+// PipelineDetector are passed on.
+func NewPipelineCheck(reporter metrics.ProxyReporter, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &pipelineCheck{
+		reporter:    reporter,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (p *pipelineCheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.ProtoMajor == 1 && isPipelined(r) {
+		logger := LoggerWithTraceInfo(p.logger, r)
+		logger.Info("pipelined-request-rejected")
+		p.reporter.CapturePipelinedRequestRejected()
+
+		AddRouterErrorHeader(rw, "pipelined-request")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		p.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Pipelined requests are not supported",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+func isPipelined(r *http.Request) bool {
+	d, ok := r.Context().Value(PipelineDetectorCtxKey).(PipelineDetector)
+	return ok && d.Pipelined()
+}
@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	metrics_fakes "github.com/mdimiceli/gorouter/metrics/fakes"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+type fakePipelineDetector bool
+
+func (d fakePipelineDetector) Pipelined() bool {
+	return bool(d)
+}
+
+var _ = Describe("PipelineCheck", func() {
+	var (
+		handler    *negroni.Negroni
+		reporter   *metrics_fakes.FakeProxyReporter
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		reporter = new(metrics_fakes.FakeProxyReporter)
+		resp = httptest.NewRecorder()
+		nextCalled = false
+
+		handler = negroni.New()
+		handler.Use(handlers.NewPipelineCheck(reporter, test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	withDetector := func(pipelined bool) {
+		req = req.WithContext(handlers.ContextWithPipelineDetector(req.Context(), fakePipelineDetector(pipelined)))
+	}
+
+	It("calls the next handler when the connection has no pipeline detector", func() {
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+	})
+
+	It("calls the next handler when the request was not pipelined", func() {
+		withDetector(false)
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+		Expect(reporter.CapturePipelinedRequestRejectedCallCount()).To(Equal(0))
+	})
+
+	Context("when the request was pipelined", func() {
+		BeforeEach(func() {
+			withDetector(true)
+		})
+
+		It("responds with a 400, closes the connection and reports it", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("pipelined-request"))
+			Expect(resp.Body.String()).To(ContainSubstring("Pipelined requests are not supported"))
+			Expect(req.Close).To(BeTrue())
+			Expect(reporter.CapturePipelinedRequestRejectedCallCount()).To(Equal(1))
+		})
+
+		It("calls the next handler for HTTP/2 requests", func() {
+			req.ProtoMajor = 2
+			handler.ServeHTTP(resp, req)
+
+			Expect(nextCalled).To(BeTrue())
+			Expect(reporter.CapturePipelinedRequestRejectedCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/mdimiceli/gorouter/common/health"
 	"github.com/urfave/negroni/v3"
@@ -10,16 +12,24 @@ import (
 type proxyHealthcheck struct {
 	userAgent string
 	health    *health.Health
+	cacheTTL  time.Duration
+
+	mu       sync.Mutex
+	cached   health.Status
+	cachedAt time.Time
 }
 
 // NewHealthcheck creates a handler that responds to healthcheck requests.
 // If userAgent is set to a non-empty string, it will use that user agent to
 // differentiate between healthcheck requests and non-healthcheck requests.
 // Otherwise, it will treat all requests as healthcheck requests.
-func NewProxyHealthcheck(userAgent string, health *health.Health) negroni.Handler {
+// If cacheTTL is positive, the health is evaluated at most once per cacheTTL
+// and healthcheck requests in between are answered from the last result.
+func NewProxyHealthcheck(userAgent string, health *health.Health, cacheTTL time.Duration) negroni.Handler {
 	return &proxyHealthcheck{
 		userAgent: userAgent,
 		health:    health,
+		cacheTTL:  cacheTTL,
 	}
 }
 
@@ -33,7 +43,7 @@ func (h *proxyHealthcheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, ne
 	rw.Header().Set("Cache-Control", "private, max-age=0")
 	rw.Header().Set("Expires", "0")
 
-	if h.health.Health() != health.Healthy {
+	if h.status() != health.Healthy {
 		rw.WriteHeader(http.StatusServiceUnavailable)
 		r.Close = true
 		return
@@ -43,3 +53,17 @@ func (h *proxyHealthcheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, ne
 	rw.Write([]byte("ok\n"))
 	r.Close = true
 }
+
+func (h *proxyHealthcheck) status() health.Status {
+	if h.cacheTTL <= 0 {
+		return h.health.Health()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if now := time.Now(); now.Sub(h.cachedAt) >= h.cacheTTL {
+		h.cached = h.health.Health()
+		h.cachedAt = now
+	}
+	return h.cached
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/mdimiceli/gorouter/common/health"
 
@@ -29,7 +30,7 @@ var _ = Describe("Proxy Healthcheck", func() {
 		healthStatus = &health.Health{}
 		healthStatus.SetHealth(health.Healthy)
 
-		handler = handlers.NewProxyHealthcheck("HTTP-Monitor/1.1", healthStatus)
+		handler = handlers.NewProxyHealthcheck("HTTP-Monitor/1.1", healthStatus, 0)
 		nextHandler = http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
 			nextCalled = true
 		})
@@ -89,6 +90,30 @@ var _ = Describe("Proxy Healthcheck", func() {
 		})
 	})
 
+	Context("when a cache TTL is configured", func() {
+		BeforeEach(func() {
+			req.Header.Set("User-Agent", "HTTP-Monitor/1.1")
+			handler = handlers.NewProxyHealthcheck("HTTP-Monitor/1.1", healthStatus, 500*time.Millisecond)
+		})
+
+		It("answers rapid healthchecks from the cache until it expires", func() {
+			handler.ServeHTTP(resp, req, nextHandler)
+			Expect(resp.Code).To(Equal(200))
+
+			healthStatus.SetHealth(health.Degraded)
+
+			resp = httptest.NewRecorder()
+			handler.ServeHTTP(resp, req, nextHandler)
+			Expect(resp.Code).To(Equal(200))
+
+			Eventually(func() int {
+				resp = httptest.NewRecorder()
+				handler.ServeHTTP(resp, req, nextHandler)
+				return resp.Code
+			}, "2s", "50ms").Should(Equal(503))
+		})
+	})
+
 	Context("when User-Agent is not set to the healthcheck User-Agent", func() {
 		BeforeEach(func() {
 			// req.Header.Set("User-Agent", "HTTP-Monitor/1.1")
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type queryParamAllowlist struct {
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewQueryParamAllowlist creates a handler that rejects requests carrying
+// query parameters which are not in the allowlist of the route with a 400.
+// Routes without an allowlist accept any query parameter.
+func NewQueryParamAllowlist(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &queryParamAllowlist{
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (q *queryParamAllowlist) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	logger := LoggerWithTraceInfo(q.logger, r)
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil {
+		logger.Panic("request-info-err", zap.Error(err))
+		return
+	}
+	if reqInfo.RoutePool == nil {
+		logger.Panic("request-info-err", zap.Error(errors.New("failed-to-access-RoutePool")))
+		return
+	}
+
+	allowed := reqInfo.RoutePool.AllowedQueryParams()
+	if len(allowed) == 0 {
+		next(rw, r)
+		return
+	}
+
+	if param, ok := disallowedQueryParam(r.URL.RawQuery, allowed); ok {
+		logger.Info("disallowed-query-param", zap.String("param", param))
+
+		AddRouterErrorHeader(rw, "disallowed_query_param")
+		addInvalidResponseCacheControlHeader(rw)
+
+		q.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Query parameter not allowed",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+// disallowedQueryParam returns the name of the first query parameter that is
+// not in allowed. Every occurrence of a repeated parameter is checked, and
+// parameters without a value are checked by name like any other.
+func disallowedQueryParam(rawQuery string, allowed []string) (string, bool) {
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !slices.Contains(allowed, key) {
+			return key, true
+		}
+	}
+	return "", false
+}
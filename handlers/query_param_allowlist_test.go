@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("QueryParamAllowlist", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		routePool  *route.EndpointPool
+		allowed    []string
+		requestURI string
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		allowed = []string{"page", "sort"}
+		resp = httptest.NewRecorder()
+		nextCalled = false
+	})
+
+	JustBeforeEach(func() {
+		logger := test_util.NewTestZapLogger("test")
+		routePool = route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 1 * time.Second,
+			Host:              "example.com",
+		})
+		routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+			Host:               "1.1.1.1",
+			Port:               8080,
+			AllowedQueryParams: allowed,
+		}))
+
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.UseFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).ToNot(HaveOccurred())
+			reqInfo.RoutePool = routePool
+			next(rw, req)
+		})
+		handler.Use(handlers.NewQueryParamAllowlist(logger, errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req, err := http.NewRequest("GET", "http://example.com"+requestURI, nil)
+		Expect(err).ToNot(HaveOccurred())
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request only carries allowed params", func() {
+		BeforeEach(func() {
+			requestURI = "/?page=1&sort=&page=2&sort"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request carries a disallowed param", func() {
+		BeforeEach(func() {
+			requestURI = "/?page=1&debug=true"
+		})
+
+		It("responds with a 400", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("disallowed_query_param"))
+		})
+	})
+
+	Context("when a disallowed param has no value", func() {
+		BeforeEach(func() {
+			requestURI = "/?page=1&debug"
+		})
+
+		It("responds with a 400", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when an allowed param name is percent-encoded", func() {
+		BeforeEach(func() {
+			requestURI = "/?%70age=1"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("when the request has no params", func() {
+		BeforeEach(func() {
+			requestURI = "/"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("when the route has no allowlist", func() {
+		BeforeEach(func() {
+			allowed = nil
+			requestURI = "/?anything=goes"
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+)
+
+type requestFraming struct {
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewRequestFraming creates a handler that rejects HTTP/1 requests whose body
+// length is ambiguous, as they can be used to smuggle requests, with a 400 and
+// closes the connection. Those are requests with more than one Content-Length,
+// or with both a Content-Length and a Transfer-Encoding. Requests without a
+// RawRequestHead are passed on.
+func NewRequestFraming(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &requestFraming{
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (f *requestFraming) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.ProtoMajor == 1 && hasConflictingFraming(r) {
+		logger := LoggerWithTraceInfo(f.logger, r)
+		logger.Info("conflicting-request-framing")
+
+		AddRouterErrorHeader(rw, "conflicting-request-framing")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		f.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Request has conflicting Content-Length and Transfer-Encoding headers",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+func hasConflictingFraming(r *http.Request) bool {
+	head, ok := GetRawRequestHead(r)
+	return ok && head.ConflictingFraming()
+}
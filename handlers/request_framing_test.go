@@ -0,0 +1,80 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("RequestFraming", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestFraming(test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		req = test_util.NewRequest("POST", "example.com", "/", nil)
+	})
+
+	withHead := func(head fakeRawRequestHead) {
+		req = req.WithContext(handlers.ContextWithRawRequestHead(req.Context(), head))
+	}
+
+	It("calls the next handler when the request has no raw head", func() {
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+	})
+
+	It("calls the next handler for well-framed requests", func() {
+		withHead(fakeRawRequestHead{obsFolded: true})
+		handler.ServeHTTP(resp, req)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+	})
+
+	Context("when the request has conflicting framing", func() {
+		BeforeEach(func() {
+			withHead(fakeRawRequestHead{conflictingFraming: true})
+		})
+
+		It("responds with a 400 and closes the connection", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("conflicting-request-framing"))
+			Expect(resp.Body.String()).To(ContainSubstring("Request has conflicting Content-Length and Transfer-Encoding headers"))
+			Expect(req.Close).To(BeTrue())
+		})
+
+		It("calls the next handler for HTTP/2 requests", func() {
+			req.ProtoMajor = 2
+			handler.ServeHTTP(resp, req)
+
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
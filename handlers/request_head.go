@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+const RawRequestHeadCtxKey key = "RawRequestHead"
+
+// RawRequestHead reports how the header lines of a request, as read from the
+// client connection, were malformed in ways the server repairs while parsing
+// the request.
+type RawRequestHead interface {
+	// ObsFolded reports whether a header line was folded (obs-fold), that is
+	// continued on the next line starting with a space or a tab.
+	ObsFolded() bool
+	// ConflictingFraming reports whether the request had more than one
+	// Content-Length header, or both a Content-Length and a
+	// Transfer-Encoding.
+	ConflictingFraming() bool
+}
+
+// ContextWithRawRequestHead attaches the RawRequestHead of a request to the
+// context it is served with.
+func ContextWithRawRequestHead(ctx context.Context, h RawRequestHead) context.Context {
+	return context.WithValue(ctx, RawRequestHeadCtxKey, h)
+}
+
+// GetRawRequestHead returns the RawRequestHead of the request, if it has one.
+func GetRawRequestHead(r *http.Request) (RawRequestHead, bool) {
+	head, ok := r.Context().Value(RawRequestHeadCtxKey).(RawRequestHead)
+	return head, ok
+}
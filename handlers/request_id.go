@@ -13,12 +13,17 @@ const (
 )
 
 type setVcapRequestIdHeader struct {
-	logger logger.Logger
+	logger         logger.Logger
+	responseHeader string
 }
 
-func NewVcapRequestIdHeader(logger logger.Logger) negroni.Handler {
+// NewVcapRequestIdHeader sets the X-Vcap-Request-Id header of the request.
+// When responseHeader is not empty, the request id is also sent to the client
+// in that header, including on responses generated by the router itself.
+func NewVcapRequestIdHeader(logger logger.Logger, responseHeader string) negroni.Handler {
 	return &setVcapRequestIdHeader{
-		logger: logger,
+		logger:         logger,
+		responseHeader: responseHeader,
 	}
 }
 
@@ -43,5 +48,11 @@ func (s *setVcapRequestIdHeader) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 	r.Header.Set(VcapRequestIdHeader, traceInfo.UUID)
 	logger.Debug("vcap-request-id-header-set", zap.String("VcapRequestIdHeader", traceInfo.UUID))
 
+	// set before any handler writes a response, so errors generated by the
+	// router carry the request id as well
+	if s.responseHeader != "" {
+		rw.Header().Set(s.responseHeader, traceInfo.UUID)
+	}
+
 	next(rw, r)
 }
@@ -41,7 +41,7 @@ var _ = Describe("Set Vcap Request Id header", func() {
 	BeforeEach(func() {
 		logger = test_util.NewTestZapLogger("setVcapRequestIdHeader")
 		nextCalled = false
-		handler = handlers.NewVcapRequestIdHeader(logger)
+		handler = handlers.NewVcapRequestIdHeader(logger, "")
 
 		previousReqInfo = new(handlers.RequestInfo)
 		req = test_util.NewRequest("GET", "example.com", "/", nil).
@@ -90,6 +90,21 @@ var _ = Describe("Set Vcap Request Id header", func() {
 		})
 	})
 
+	It("does not set a response header", func() {
+		Expect(resp.Header()).To(BeEmpty())
+	})
+
+	Context("when a response header is configured", func() {
+		BeforeEach(func() {
+			handler = handlers.NewVcapRequestIdHeader(logger, "X-Request-Id")
+		})
+
+		It("sets the response header to the request id", func() {
+			Expect(vcapIdHeader).To(MatchRegexp(UUIDRegex))
+			Expect(resp.Header().Get("X-Request-Id")).To(Equal(vcapIdHeader))
+		})
+	})
+
 	Context("when X-Vcap-Request-Id is set", func() {
 		BeforeEach(func() {
 			req.Header.Set(handlers.VcapRequestIdHeader, "BOGUS-HEADER")
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	metrics "code.cloudfoundry.org/go-metric-registry"
+	"github.com/urfave/negroni/v3"
+)
+
+type requestTimeBreakdownPrometheusHandler struct {
+	registry Registry
+}
+
+// NewRequestTimeBreakdownPrometheus creates a handler which records how the
+// time of requests to routes which ask for it was spent: in the router, in
+// the route service and in the backend. The time of the route service or the
+// backend runs from sending the request until the response body was copied
+// to the client, so that streaming the body is attributed to them rather than
+// to the router.
+func NewRequestTimeBreakdownPrometheus(r Registry) negroni.Handler {
+	return &requestTimeBreakdownPrometheusHandler{
+		registry: r,
+	}
+}
+
+func (h *requestTimeBreakdownPrometheusHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(rw, r)
+	finishedAt := time.Now()
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil || reqInfo.RoutePool == nil || !reqInfo.RoutePool.RequestTimeBreakdown() {
+		return
+	}
+	// without a response there is nothing to attribute to the backend or the
+	// route service
+	if !reqInfo.RoundTripSuccessful {
+		return
+	}
+
+	// A request to a route service comes back to the router, which then
+	// sends it to the backend. The time of the route service thus includes
+	// that second request, which is recorded on its own.
+	var backend, routeService time.Duration
+	if !reqInfo.BackendRequestStartedAt.IsZero() {
+		backend = finishedAt.Sub(reqInfo.BackendRequestStartedAt)
+	}
+	if !reqInfo.RouteServiceRequestStartedAt.IsZero() {
+		routeService = finishedAt.Sub(reqInfo.RouteServiceRequestStartedAt)
+	}
+	router := finishedAt.Sub(reqInfo.ReceivedAt) - backend - routeService
+
+	host := reqInfo.RoutePool.Host()
+	h.observe(host, "router", router)
+	h.observe(host, "route_service", routeService)
+	h.observe(host, "backend", backend)
+}
+
+func (h *requestTimeBreakdownPrometheusHandler) observe(host string, segment string, d time.Duration) {
+	histogram := h.registry.NewHistogram("request_time_breakdown_seconds", "the time of requests spent in the router, the route service and the backend",
+		[]float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.2, 0.4, 0.8, 1.6, 3.2, 6.4, 12.8, 25.6},
+		metrics.WithMetricLabels(map[string]string{"host": host, "segment": segment}))
+	histogram.Observe(d.Seconds())
+}
@@ -0,0 +1,138 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	fake_registry "code.cloudfoundry.org/go-metric-registry/testhelpers"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("RequestTimeBreakdownPrometheus", func() {
+	var (
+		handler      *negroni.Negroni
+		fakeRegistry *fake_registry.SpyMetricsRegistry
+
+		breakdown    bool
+		routeService bool
+		routerDelay  time.Duration
+		upstream     time.Duration
+		bodyDelay    time.Duration
+		succeeded    bool
+	)
+
+	segment := func(name string) map[string]string {
+		return map[string]string{"host": "example.com", "segment": name}
+	}
+
+	BeforeEach(func() {
+		fakeRegistry = fake_registry.NewMetricsRegistry()
+		breakdown = true
+		routeService = false
+		routerDelay = 20 * time.Millisecond
+		upstream = 50 * time.Millisecond
+		bodyDelay = 30 * time.Millisecond
+		succeeded = true
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.Use(handlers.NewRequestTimeBreakdownPrometheus(fakeRegistry))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			reqInfo.RoutePool = route.NewPool(&route.PoolOpts{Host: "example.com"})
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:                 "1.2.3.4",
+				Port:                 8080,
+				RequestTimeBreakdown: breakdown,
+			}))
+
+			time.Sleep(routerDelay)
+			startedAt := time.Now()
+			if routeService {
+				reqInfo.RouteServiceRequestStartedAt = startedAt
+			} else {
+				reqInfo.BackendRequestStartedAt = startedAt
+			}
+			time.Sleep(upstream)
+			reqInfo.RoundTripSuccessful = succeeded
+
+			rw.WriteHeader(http.StatusOK)
+			// the body is streamed after the response headers were received
+			time.Sleep(bodyDelay)
+		})
+	})
+
+	serve := func() time.Duration {
+		startedAt := time.Now()
+		handler.ServeHTTP(httptest.NewRecorder(), test_util.NewRequest("GET", "example.com", "/", nil))
+		return time.Since(startedAt)
+	}
+
+	It("attributes the time of the request to the router and the backend", func() {
+		total := serve()
+
+		routerTime := fakeRegistry.GetMetric("request_time_breakdown_seconds", segment("router")).Value()
+		routeServiceTime := fakeRegistry.GetMetric("request_time_breakdown_seconds", segment("route_service")).Value()
+		backendTime := fakeRegistry.GetMetric("request_time_breakdown_seconds", segment("backend")).Value()
+
+		Expect(routerTime).To(BeNumerically(">=", routerDelay.Seconds()))
+		Expect(routerTime).To(BeNumerically("<", (routerDelay + bodyDelay).Seconds()))
+		Expect(routeServiceTime).To(BeZero())
+		Expect(backendTime).To(BeNumerically(">=", (upstream + bodyDelay).Seconds()))
+		Expect(routerTime + routeServiceTime + backendTime).To(BeNumerically("~", total.Seconds(), 0.005))
+	})
+
+	Context("when the request was sent to a route service", func() {
+		BeforeEach(func() {
+			routeService = true
+		})
+
+		It("attributes the time of the request to the router and the route service", func() {
+			total := serve()
+
+			routerTime := fakeRegistry.GetMetric("request_time_breakdown_seconds", segment("router")).Value()
+			routeServiceTime := fakeRegistry.GetMetric("request_time_breakdown_seconds", segment("route_service")).Value()
+			backendTime := fakeRegistry.GetMetric("request_time_breakdown_seconds", segment("backend")).Value()
+
+			Expect(routerTime).To(BeNumerically(">=", routerDelay.Seconds()))
+			Expect(routeServiceTime).To(BeNumerically(">=", (upstream + bodyDelay).Seconds()))
+			Expect(backendTime).To(BeZero())
+			Expect(routerTime + routeServiceTime + backendTime).To(BeNumerically("~", total.Seconds(), 0.005))
+		})
+	})
+
+	Context("when the route does not ask for the breakdown", func() {
+		BeforeEach(func() {
+			breakdown = false
+		})
+
+		It("does not record it", func() {
+			serve()
+
+			Expect(fakeRegistry.HasMetric("request_time_breakdown_seconds", segment("router"))).To(BeFalse())
+		})
+	})
+
+	Context("when there was no response", func() {
+		BeforeEach(func() {
+			succeeded = false
+		})
+
+		It("does not record it", func() {
+			serve()
+
+			Expect(fakeRegistry.HasMetric("request_time_breakdown_seconds", segment("router"))).To(BeFalse())
+		})
+	})
+})
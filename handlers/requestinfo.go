@@ -54,6 +54,19 @@ type RequestInfo struct {
 	TlsHandshakeStartedAt  time.Time
 	TlsHandshakeFinishedAt time.Time
 
+	// BackendRequestStartedAt and BackendResponseReceivedAt record the
+	// boundaries of the successful attempt to a backend, from sending the
+	// request until receiving the response headers. They are not set for
+	// requests to route services.
+	BackendRequestStartedAt   time.Time
+	BackendResponseReceivedAt time.Time
+
+	// RouteServiceRequestStartedAt and RouteServiceResponseReceivedAt record
+	// the boundaries of the successful attempt to a route service, from
+	// sending the request until receiving the response headers.
+	RouteServiceRequestStartedAt   time.Time
+	RouteServiceResponseReceivedAt time.Time
+
 	// AppRequestFinishedAt records the time at which either a response was
 	// received or the last performed attempt failed and no further attempts
 	// could be made.
@@ -142,11 +155,22 @@ func generateTraceAndSpanIDFromGUID(guid string) (string, string, error) {
 	return traceID.String(), spanID.String(), nil
 }
 
+// LoggerWithTraceInfo returns a logger for the request, which adds its trace
+// and span ids to every log line and logs at the level of its route, if the
+// route overrides the global level.
 func LoggerWithTraceInfo(l logger.Logger, r *http.Request) logger.Logger {
 	reqInfo, err := ContextRequestInfo(r)
 	if err != nil {
 		return l
 	}
+	if reqInfo.RoutePool != nil {
+		if logLevel := reqInfo.RoutePool.LogLevel(); logLevel != "" {
+			var level zap.Level
+			if err := level.UnmarshalText([]byte(logLevel)); err == nil {
+				l = l.WithLevel(level)
+			}
+		}
+	}
 	if reqInfo.TraceInfo.TraceID == "" {
 		return l
 	}
@@ -209,6 +209,7 @@ var _ = Describe("RequestInfo", func() {
 			testLogger = logger.NewLogger(
 				"request-info",
 				"unix-epoch",
+				"json",
 				zap.DebugLevel,
 				zap.Output(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))),
 				zap.ErrorOutput(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))))
@@ -246,5 +247,61 @@ var _ = Describe("RequestInfo", func() {
 				Expect(testSink.Lines()[0]).NotTo(MatchRegexp(`trace-id`))
 			})
 		})
+
+		Context("when the route of the request overrides the log level", func() {
+			var req *http.Request
+
+			newRequest := func(logLevel string) *http.Request {
+				req, err := http.NewRequest("GET", "http://example.com", nil)
+				Expect(err).NotTo(HaveOccurred())
+				ri := new(handlers.RequestInfo)
+				ri.TraceInfo.TraceID = "abc"
+				ri.TraceInfo.SpanID = "def"
+				ri.RoutePool = route.NewPool(&route.PoolOpts{})
+				ri.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{
+					Host:     "1.2.3.4",
+					Port:     8080,
+					LogLevel: logLevel,
+				}))
+				return req.WithContext(context.WithValue(req.Context(), handlers.RequestInfoCtxKey, ri))
+			}
+
+			BeforeEach(func() {
+				testLogger = logger.NewLogger(
+					"request-info",
+					"unix-epoch",
+					"json",
+					zap.InfoLevel,
+					zap.Output(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))),
+					zap.ErrorOutput(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))))
+			})
+
+			It("logs at the level of the route", func() {
+				req = newRequest("debug")
+				routeLogger := handlers.LoggerWithTraceInfo(testLogger.Session("proxy"), req)
+				routeLogger.Debug("some-detail")
+
+				Expect(testSink.Lines()).To(HaveLen(1))
+				Expect(testSink.Lines()[0]).To(MatchRegexp(`{"log_level":0,.*"message":"some-detail","source":"request-info.proxy","data":{"trace-id":"abc","span-id":"def"}}`))
+			})
+
+			It("logs at the global level for other routes", func() {
+				req = newRequest("")
+				routeLogger := handlers.LoggerWithTraceInfo(testLogger, req)
+				routeLogger.Debug("some-detail")
+				routeLogger.Info("some-action")
+
+				Expect(testSink.Lines()).To(HaveLen(1))
+				Expect(testSink.Lines()[0]).To(MatchRegexp(`"message":"some-action"`))
+			})
+
+			It("does not change the level of the logger it was given", func() {
+				req = newRequest("debug")
+				handlers.LoggerWithTraceInfo(testLogger, req)
+				testLogger.Debug("some-detail")
+
+				Expect(testSink.Lines()).To(BeEmpty())
+			})
+		})
 	})
 })
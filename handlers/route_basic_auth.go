@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type routeBasicAuth struct {
+	authHandlers map[string]AuthHandler
+	skipAuth     func(req *http.Request) bool
+	logger       logger.Logger
+	errorWriter  errorwriter.ErrorWriter
+}
+
+// NewRouteBasicAuth creates a handler that requires requests to routes which
+// opted in to basic authentication to present credentials from the
+// configured set the route names. Requests without valid credentials are
+// rejected with a 401, as are requests to routes naming a set that is not
+// configured. Requests for which skipAuth returns true, such as requests
+// coming back from a route service, are passed on without credentials.
+func NewRouteBasicAuth(credentials map[string]config.BasicAuthConfig, skipAuth func(req *http.Request) bool, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	authHandlers := make(map[string]AuthHandler, len(credentials))
+	for name, basicAuth := range credentials {
+		authHandlers[name] = NewBasicAuthHandler(basicAuth.Realm, basicAuth.Users)
+	}
+	return &routeBasicAuth{
+		authHandlers: authHandlers,
+		skipAuth:     skipAuth,
+		logger:       logger,
+		errorWriter:  errorWriter,
+	}
+}
+
+func (b *routeBasicAuth) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	logger := LoggerWithTraceInfo(b.logger, r)
+
+	reqInfo, err := ContextRequestInfo(r)
+	if err != nil {
+		logger.Panic("request-info-err", zap.Error(err))
+		return
+	}
+	if reqInfo.RoutePool == nil {
+		logger.Panic("request-info-err", zap.Error(errors.New("failed-to-access-RoutePool")))
+		return
+	}
+
+	name := reqInfo.RoutePool.BasicAuth()
+	if name == "" {
+		next(rw, r)
+		return
+	}
+
+	// the credentials were checked, and removed, before the request was sent
+	// to the route service
+	if b.skipAuth(r) {
+		next(rw, r)
+		return
+	}
+
+	authHandler, ok := b.authHandlers[name]
+	if !ok {
+		logger.Error("basic-auth-credentials-not-configured", zap.String("credentials", name))
+		r.Header.Del("Authorization")
+		writeAuthError(rw, r, errors.New("basic auth credentials not configured"), b.logger, b.errorWriter)
+		return
+	}
+
+	if err := authHandler.Authenticate(r); err != nil {
+		writeAuthError(rw, r, err, b.logger, b.errorWriter)
+		return
+	}
+
+	next(rw, r)
+}
@@ -0,0 +1,174 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("RouteBasicAuth", func() {
+	var (
+		handler     *negroni.Negroni
+		logger      *test_util.TestZapLogger
+		resp        *httptest.ResponseRecorder
+		req         *http.Request
+		basicAuth   string
+		credentials map[string]config.BasicAuthConfig
+		nextCalled  bool
+		nextAuth    string
+		skipAuth    bool
+	)
+
+	BeforeEach(func() {
+		basicAuth = "operators"
+		credentials = map[string]config.BasicAuthConfig{
+			"operators": {
+				Realm: "operators",
+				Users: map[string]string{"alice": "secret"},
+			},
+		}
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		nextAuth = ""
+		skipAuth = false
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	JustBeforeEach(func() {
+		logger = test_util.NewTestZapLogger("test")
+		routePool := route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 1 * time.Second,
+			Host:              "example.com",
+		})
+		routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+			Host:      "1.1.1.1",
+			Port:      8080,
+			BasicAuth: basicAuth,
+		}))
+
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.UseFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			reqInfo, err := handlers.ContextRequestInfo(req)
+			Expect(err).ToNot(HaveOccurred())
+			reqInfo.RoutePool = routePool
+			next(rw, req)
+		})
+		handler.Use(handlers.NewRouteBasicAuth(credentials, func(*http.Request) bool { return skipAuth }, logger, errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			nextAuth = r.Header.Get("Authorization")
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		handler.ServeHTTP(resp, req)
+	})
+
+	expectUnauthorized := func() {
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header().Get("WWW-Authenticate")).To(Equal(`Basic realm="operators"`))
+		Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("unauthenticated"))
+	}
+
+	Context("when the route is not protected", func() {
+		BeforeEach(func() {
+			basicAuth = ""
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request has valid credentials", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("alice", "secret")
+		})
+
+		It("calls the next handler without forwarding the credentials", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+			Expect(nextAuth).To(BeEmpty())
+		})
+	})
+
+	Context("when the password has the right length but is wrong", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("alice", "secreT")
+		})
+
+		It("responds with a 401 and a challenge", func() {
+			expectUnauthorized()
+		})
+
+		It("does not log the credentials", func() {
+			Expect(logger.Lines(zap.InfoLevel)).ToNot(BeEmpty())
+			Expect(string(logger.Contents())).ToNot(ContainSubstring("secreT"))
+		})
+	})
+
+	Context("when the password has a different length", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("alice", "wrong-password")
+		})
+
+		It("responds with a 401 and a challenge", func() {
+			expectUnauthorized()
+		})
+	})
+
+	Context("when the user is unknown", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("bob", "secret")
+		})
+
+		It("responds with a 401 and a challenge", func() {
+			expectUnauthorized()
+		})
+	})
+
+	Context("when the request has no credentials", func() {
+		It("responds with a 401 and a challenge", func() {
+			expectUnauthorized()
+		})
+	})
+
+	Context("when the authentication is skipped for the request", func() {
+		BeforeEach(func() {
+			skipAuth = true
+		})
+
+		It("calls the next handler without credentials", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the route names credentials that are not configured", func() {
+		BeforeEach(func() {
+			basicAuth = "unknown"
+			req.SetBasicAuth("alice", "secret")
+		})
+
+		It("responds with a 401", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(logger.Lines(zap.ErrorLevel)).To(ContainElement(ContainSubstring("basic-auth-credentials-not-configured")))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+const StageTimingsCtxKey key = "StageTimings"
+
+// StageTiming is the time a handler spent on a request, excluding the time
+// spent in the handlers after it.
+type StageTiming struct {
+	Stage    string
+	Duration time.Duration
+}
+
+// StageTimings collects the StageTiming of every handler a request passed.
+type StageTimings struct {
+	lock    sync.Mutex
+	timings []StageTiming
+}
+
+func (s *StageTimings) add(stage string, d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timings = append(s.timings, StageTiming{Stage: stage, Duration: d})
+}
+
+// Timings returns the timings recorded so far, in the order in which the
+// handlers finished.
+func (s *StageTimings) Timings() []StageTiming {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]StageTiming(nil), s.timings...)
+}
+
+// ContextStageTimings returns the StageTimings of the request, if stage
+// timing is enabled.
+func ContextStageTimings(r *http.Request) (*StageTimings, bool) {
+	timings, ok := r.Context().Value(StageTimingsCtxKey).(*StageTimings)
+	return timings, ok
+}
+
+// WithStageTiming wraps each of handlers to record the time it spends on a
+// request, and prepends a handler logging the breakdown of requests taking at
+// least slowRequestThreshold.
+func WithStageTiming(handlers []negroni.Handler, slowRequestThreshold time.Duration, logger logger.Logger) []negroni.Handler {
+	timed := make([]negroni.Handler, 0, len(handlers)+1)
+	timed = append(timed, &stageTimingCollector{
+		slowRequestThreshold: slowRequestThreshold,
+		logger:               logger,
+	})
+	for _, h := range handlers {
+		timed = append(timed, &timedHandler{
+			stage:   strings.TrimPrefix(fmt.Sprintf("%T", h), "*"),
+			handler: h,
+		})
+	}
+	return timed
+}
+
+type stageTimingCollector struct {
+	slowRequestThreshold time.Duration
+	logger               logger.Logger
+}
+
+func (c *stageTimingCollector) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	timings := &StageTimings{}
+	r = r.WithContext(context.WithValue(r.Context(), StageTimingsCtxKey, timings))
+
+	start := time.Now()
+	next(rw, r)
+	total := time.Since(start)
+
+	if total < c.slowRequestThreshold {
+		return
+	}
+
+	fields := []zap.Field{zap.Duration("total", total), zap.Namespace("stages")}
+	for _, t := range timings.Timings() {
+		fields = append(fields, zap.Duration(t.Stage, t.Duration))
+	}
+	LoggerWithTraceInfo(c.logger, r).Info("slow-request-stages", fields...)
+}
+
+type timedHandler struct {
+	stage   string
+	handler negroni.Handler
+}
+
+func (t *timedHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	timings, ok := ContextStageTimings(r)
+	if !ok {
+		t.handler.ServeHTTP(rw, r, next)
+		return
+	}
+
+	var inNext time.Duration
+	start := time.Now()
+	t.handler.ServeHTTP(rw, r, func(rw http.ResponseWriter, r *http.Request) {
+		nextStart := time.Now()
+		next(rw, r)
+		inNext += time.Since(nextStart)
+	})
+	timings.add(t.stage, time.Since(start)-inNext)
+}
@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type sleepingHandler struct {
+	before time.Duration
+}
+
+func (s *sleepingHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	time.Sleep(s.before)
+	next(rw, r)
+}
+
+var _ = Describe("StageTiming", func() {
+	var (
+		logger    *test_util.TestZapLogger
+		threshold time.Duration
+		timings   []handlers.StageTiming
+		resp      *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		logger = test_util.NewTestZapLogger("test")
+		threshold = time.Hour
+		timings = nil
+		resp = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		var stageTimings *handlers.StageTimings
+		chain := []negroni.Handler{
+			&sleepingHandler{before: 20 * time.Millisecond},
+			negroni.Wrap(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				var ok bool
+				stageTimings, ok = handlers.ContextStageTimings(r)
+				Expect(ok).To(BeTrue())
+				time.Sleep(50 * time.Millisecond)
+				rw.WriteHeader(http.StatusTeapot)
+			})),
+		}
+
+		handler := negroni.New(handlers.WithStageTiming(chain, threshold, logger)...)
+		handler.ServeHTTP(resp, test_util.NewRequest("GET", "example.com", "/", nil))
+		timings = stageTimings.Timings()
+	})
+
+	It("records the time each handler spent on the request, excluding the handlers after it", func() {
+		Expect(resp.Code).To(Equal(http.StatusTeapot))
+		Expect(timings).To(HaveLen(2))
+
+		Expect(timings[0].Stage).To(Equal("negroni.HandlerFunc"))
+		Expect(timings[0].Duration).To(BeNumerically(">=", 50*time.Millisecond))
+
+		Expect(timings[1].Stage).To(Equal("handlers_test.sleepingHandler"))
+		Expect(timings[1].Duration).To(BeNumerically(">=", 20*time.Millisecond))
+		Expect(timings[1].Duration).To(BeNumerically("<", 50*time.Millisecond))
+	})
+
+	It("does not log requests faster than the threshold", func() {
+		Expect(logger.Lines(zap.InfoLevel)).To(BeEmpty())
+	})
+
+	Context("when the request is slower than the threshold", func() {
+		BeforeEach(func() {
+			threshold = 10 * time.Millisecond
+		})
+
+		It("logs the breakdown of the stages", func() {
+			lines := logger.Lines(zap.InfoLevel)
+			Expect(lines).To(HaveLen(1))
+			Expect(lines[0]).To(ContainSubstring("slow-request-stages"))
+			Expect(lines[0]).To(ContainSubstring(`"stages":{`))
+			Expect(lines[0]).To(ContainSubstring(`"handlers_test.sleepingHandler":`))
+			Expect(lines[0]).To(ContainSubstring(`"negroni.HandlerFunc":`))
+		})
+	})
+})
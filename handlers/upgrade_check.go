@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+)
+
+type upgradeCheck struct {
+	allowed     []string
+	logger      logger.Logger
+	errorWriter errorwriter.ErrorWriter
+}
+
+// NewUpgradeCheck creates a handler that rejects requests to upgrade the
+// connection to a protocol which is not in allowed with a 400. Protocols are
+// matched case-insensitively by name, ignoring their version.
+func NewUpgradeCheck(allowed []string, logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+	return &upgradeCheck{
+		allowed:     allowed,
+		logger:      logger,
+		errorWriter: errorWriter,
+	}
+}
+
+func (u *upgradeCheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if upgradeHeader(r) == "" {
+		next(rw, r)
+		return
+	}
+
+	if protocol, ok := u.disallowedProtocol(r.Header.Values("Upgrade")); ok {
+		logger := LoggerWithTraceInfo(u.logger, r)
+		logger.Info("upgrade-protocol-not-allowed", zap.String("protocol", protocol))
+
+		AddRouterErrorHeader(rw, "upgrade_protocol_not_allowed")
+		addInvalidResponseCacheControlHeader(rw)
+		r.Close = true
+
+		u.errorWriter.WriteError(
+			rw,
+			http.StatusBadRequest,
+			"Upgrade protocol not allowed",
+			logger,
+		)
+		return
+	}
+
+	next(rw, r)
+}
+
+// disallowedProtocol returns the first protocol offered in the Upgrade header
+// values that is not allowed.
+func (u *upgradeCheck) disallowedProtocol(values []string) (string, bool) {
+	for _, value := range values {
+		for _, protocol := range strings.Split(value, ",") {
+			protocol = strings.TrimSpace(protocol)
+			if protocol == "" {
+				continue
+			}
+			name, _, _ := strings.Cut(protocol, "/")
+			if !u.isAllowed(name) {
+				return protocol, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (u *upgradeCheck) isAllowed(name string) bool {
+	for _, allowed := range u.allowed {
+		if strings.EqualFold(name, allowed) {
+			return true
+		}
+	}
+	return false
+}
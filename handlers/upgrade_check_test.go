@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
+)
+
+var _ = Describe("UpgradeCheck", func() {
+	var (
+		handler    *negroni.Negroni
+		resp       *httptest.ResponseRecorder
+		req        *http.Request
+		nextCalled bool
+	)
+
+	BeforeEach(func() {
+		resp = httptest.NewRecorder()
+		nextCalled = false
+		req = test_util.NewRequest("GET", "example.com", "/", nil)
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewUpgradeCheck([]string{"websocket"}, test_util.NewTestZapLogger("test"), errorwriter.NewPlaintextErrorWriter()))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextCalled = true
+			rw.WriteHeader(http.StatusTeapot)
+		})
+
+		handler.ServeHTTP(resp, req)
+	})
+
+	Context("when the request does not upgrade the connection", func() {
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request upgrades to websocket", func() {
+		BeforeEach(func() {
+			req.Header.Set("Connection", "keep-alive, Upgrade")
+			req.Header.Set("Upgrade", "WebSocket")
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+			Expect(resp.Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	Context("when the request upgrades to a protocol that is not allowed", func() {
+		BeforeEach(func() {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "custom-protocol/1.0")
+		})
+
+		It("responds with a 400 without calling the next handler", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header().Get(router_http.CfRouterError)).To(Equal("upgrade_protocol_not_allowed"))
+			Expect(resp.Body.String()).To(ContainSubstring("Upgrade protocol not allowed"))
+		})
+	})
+
+	Context("when the request offers an allowed and a disallowed protocol", func() {
+		BeforeEach(func() {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket, h2c")
+		})
+
+		It("responds with a 400", func() {
+			Expect(nextCalled).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when the Upgrade header is sent without Connection: Upgrade", func() {
+		BeforeEach(func() {
+			req.Header.Set("Upgrade", "custom-protocol")
+		})
+
+		It("calls the next handler", func() {
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
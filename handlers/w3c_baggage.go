@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/urfave/negroni/v3"
+	"go.uber.org/zap"
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/logger"
+)
+
+const (
+	W3CBaggageHeader = "baggage"
+
+	// limits of the W3C Baggage specification
+	w3cBaggageMaxEntries = 64
+	w3cBaggageMaxBytes   = 8192
+)
+
+// W3CBaggage is a handler that passes W3C baggage headers on to backends,
+// dropping them if they are malformed or exceed the configured limits.
+type W3CBaggage struct {
+	enabled    bool
+	maxEntries int
+	maxBytes   int
+	log        bool
+	logger     logger.Logger
+}
+
+var _ negroni.Handler = new(W3CBaggage)
+
+// NewW3CBaggage creates a new handler that validates W3C baggage headers on
+// requests. Limits that are zero default to those of the W3C specification.
+func NewW3CBaggage(cfg config.BaggageConfig, logger logger.Logger) *W3CBaggage {
+	b := &W3CBaggage{
+		enabled:    cfg.Enabled,
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxBytes,
+		log:        cfg.Log,
+		logger:     logger,
+	}
+	if b.maxEntries == 0 {
+		b.maxEntries = w3cBaggageMaxEntries
+	}
+	if b.maxBytes == 0 {
+		b.maxBytes = w3cBaggageMaxBytes
+	}
+	return b
+}
+
+func (b *W3CBaggage) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if b.enabled {
+		if values := r.Header.Values(W3CBaggageHeader); len(values) > 0 {
+			if reason, ok := b.invalidBaggage(strings.Join(values, ",")); ok {
+				logger := LoggerWithTraceInfo(b.logger, r)
+				logger.Info("dropped-w3c-baggage", zap.String("reason", reason))
+				r.Header.Del(W3CBaggageHeader)
+			}
+		}
+	}
+
+	next(rw, r)
+}
+
+// invalidBaggage returns why the baggage must be dropped, if it must.
+func (b *W3CBaggage) invalidBaggage(baggage string) (string, bool) {
+	if len(baggage) > b.maxBytes {
+		return "too-large", true
+	}
+
+	members := strings.Split(baggage, ",")
+	if len(members) > b.maxEntries {
+		return "too-many-entries", true
+	}
+
+	for _, member := range members {
+		if !validW3CBaggageMember(member) {
+			return "malformed", true
+		}
+	}
+	return "", false
+}
+
+// validW3CBaggageMember reports whether member is a key=value pair followed
+// by optional ;key or ;key=value properties, with whitespace allowed around
+// each of them.
+func validW3CBaggageMember(member string) bool {
+	pair, properties, _ := strings.Cut(member, ";")
+
+	key, value, found := strings.Cut(pair, "=")
+	if !found || !validW3CBaggageKey(key) || !validW3CBaggageValue(value) {
+		return false
+	}
+
+	if properties == "" {
+		return true
+	}
+	for _, property := range strings.Split(properties, ";") {
+		key, value, found := strings.Cut(property, "=")
+		if !validW3CBaggageKey(key) || (found && !validW3CBaggageValue(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+func validW3CBaggageKey(key string) bool {
+	return httpguts.ValidHeaderFieldName(strings.Trim(key, " \t"))
+}
+
+func validW3CBaggageValue(value string) bool {
+	for _, c := range []byte(strings.Trim(value, " \t")) {
+		// baggage-octet excludes controls, whitespace, DQUOTE, comma,
+		// semicolon and backslash
+		if c < 0x21 || c > 0x7e || c == '"' || c == ',' || c == ';' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// HeadersToLog specifies the headers which should be logged if logging of W3C
+// baggage headers is enabled
+func (b *W3CBaggage) HeadersToLog() []string {
+	if !b.enabled || !b.log {
+		return []string{}
+	}
+
+	return []string{
+		W3CBaggageHeader,
+	}
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("W3CBaggage", func() {
+	var (
+		handler    *handlers.W3CBaggage
+		logger     *test_util.TestZapLogger
+		cfg        config.BaggageConfig
+		resp       http.ResponseWriter
+		req        *http.Request
+		nextCalled bool
+	)
+
+	nextHandler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+	})
+
+	BeforeEach(func() {
+		logger = test_util.NewTestZapLogger("w3c-baggage")
+		cfg = config.BaggageConfig{Enabled: true}
+		ri := new(handlers.RequestInfo)
+		req = test_util.NewRequest("GET", "example.com", "/", nil).
+			WithContext(context.WithValue(context.Background(), handlers.RequestInfoCtxKey, ri))
+		resp = httptest.NewRecorder()
+		nextCalled = false
+	})
+
+	JustBeforeEach(func() {
+		handler = handlers.NewW3CBaggage(cfg, logger)
+		handler.ServeHTTP(resp, req, nextHandler)
+	})
+
+	Context("with valid baggage", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.W3CBaggageHeader, "userId=alice, serverNode=DF%2028;prop, isProduction=false;ttl=60")
+		})
+
+		It("passes it on unchanged", func() {
+			Expect(req.Header.Get(handlers.W3CBaggageHeader)).To(Equal("userId=alice, serverNode=DF%2028;prop, isProduction=false;ttl=60"))
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("with valid baggage in multiple headers", func() {
+		BeforeEach(func() {
+			req.Header.Add(handlers.W3CBaggageHeader, "userId=alice")
+			req.Header.Add(handlers.W3CBaggageHeader, "isProduction=false")
+		})
+
+		It("passes them on unchanged", func() {
+			Expect(req.Header.Values(handlers.W3CBaggageHeader)).To(Equal([]string{"userId=alice", "isProduction=false"}))
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("with malformed baggage", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.W3CBaggageHeader, `userId=alice, "quoted"=value`)
+		})
+
+		It("drops it", func() {
+			Expect(req.Header).NotTo(HaveKey("Baggage"))
+			Expect(logger).To(gbytes.Say("dropped-w3c-baggage"))
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Context("with a member without a value", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.W3CBaggageHeader, "userId")
+		})
+
+		It("drops it", func() {
+			Expect(req.Header).NotTo(HaveKey("Baggage"))
+		})
+	})
+
+	Context("with more entries than allowed", func() {
+		BeforeEach(func() {
+			cfg.MaxEntries = 2
+			req.Header.Set(handlers.W3CBaggageHeader, "a=1,b=2,c=3")
+		})
+
+		It("drops it", func() {
+			Expect(req.Header).NotTo(HaveKey("Baggage"))
+			Expect(logger).To(gbytes.Say("too-many-entries"))
+		})
+	})
+
+	Context("with more bytes than allowed", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.W3CBaggageHeader, "a="+strings.Repeat("x", 8192))
+		})
+
+		It("drops it", func() {
+			Expect(req.Header).NotTo(HaveKey("Baggage"))
+			Expect(logger).To(gbytes.Say("too-large"))
+		})
+	})
+
+	Context("when disabled", func() {
+		BeforeEach(func() {
+			cfg.Enabled = false
+			req.Header.Set(handlers.W3CBaggageHeader, `"quoted"=value`)
+		})
+
+		It("leaves the baggage alone", func() {
+			Expect(req.Header.Get(handlers.W3CBaggageHeader)).To(Equal(`"quoted"=value`))
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+
+	Describe("HeadersToLog", func() {
+		It("does not log the baggage header by default", func() {
+			Expect(handler.HeadersToLog()).To(BeEmpty())
+		})
+
+		Context("when logging of the baggage header is enabled", func() {
+			BeforeEach(func() {
+				cfg.Log = true
+			})
+
+			It("logs it", func() {
+				Expect(handler.HeadersToLog()).To(ConsistOf(handlers.W3CBaggageHeader))
+			})
+		})
+	})
+})
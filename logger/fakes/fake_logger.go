@@ -102,6 +102,17 @@ type FakeLogger struct {
 	withReturnsOnCall map[int]struct {
 		result1 logger.Logger
 	}
+	WithLevelStub        func(zap.Level) logger.Logger
+	withLevelMutex       sync.RWMutex
+	withLevelArgsForCall []struct {
+		arg1 zap.Level
+	}
+	withLevelReturns struct {
+		result1 logger.Logger
+	}
+	withLevelReturnsOnCall map[int]struct {
+		result1 logger.Logger
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -608,6 +619,67 @@ func (fake *FakeLogger) WithReturnsOnCall(i int, result1 logger.Logger) {
 	}{result1}
 }
 
+func (fake *FakeLogger) WithLevel(arg1 zap.Level) logger.Logger {
+	fake.withLevelMutex.Lock()
+	ret, specificReturn := fake.withLevelReturnsOnCall[len(fake.withLevelArgsForCall)]
+	fake.withLevelArgsForCall = append(fake.withLevelArgsForCall, struct {
+		arg1 zap.Level
+	}{arg1})
+	stub := fake.WithLevelStub
+	fakeReturns := fake.withLevelReturns
+	fake.recordInvocation("WithLevel", []interface{}{arg1})
+	fake.withLevelMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLogger) WithLevelCallCount() int {
+	fake.withLevelMutex.RLock()
+	defer fake.withLevelMutex.RUnlock()
+	return len(fake.withLevelArgsForCall)
+}
+
+func (fake *FakeLogger) WithLevelCalls(stub func(zap.Level) logger.Logger) {
+	fake.withLevelMutex.Lock()
+	defer fake.withLevelMutex.Unlock()
+	fake.WithLevelStub = stub
+}
+
+func (fake *FakeLogger) WithLevelArgsForCall(i int) zap.Level {
+	fake.withLevelMutex.RLock()
+	defer fake.withLevelMutex.RUnlock()
+	argsForCall := fake.withLevelArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLogger) WithLevelReturns(result1 logger.Logger) {
+	fake.withLevelMutex.Lock()
+	defer fake.withLevelMutex.Unlock()
+	fake.WithLevelStub = nil
+	fake.withLevelReturns = struct {
+		result1 logger.Logger
+	}{result1}
+}
+
+func (fake *FakeLogger) WithLevelReturnsOnCall(i int, result1 logger.Logger) {
+	fake.withLevelMutex.Lock()
+	defer fake.withLevelMutex.Unlock()
+	fake.WithLevelStub = nil
+	if fake.withLevelReturnsOnCall == nil {
+		fake.withLevelReturnsOnCall = make(map[int]struct {
+			result1 logger.Logger
+		})
+	}
+	fake.withLevelReturnsOnCall[i] = struct {
+		result1 logger.Logger
+	}{result1}
+}
+
 func (fake *FakeLogger) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -635,6 +707,8 @@ func (fake *FakeLogger) Invocations() map[string][][]interface{} {
 	defer fake.warnMutex.RUnlock()
 	fake.withMutex.RLock()
 	defer fake.withMutex.RUnlock()
+	fake.withLevelMutex.RLock()
+	defer fake.withLevelMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
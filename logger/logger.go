@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -22,6 +23,7 @@ type Logger interface {
 	Fatal(string, ...zap.Field)
 	Session(string) Logger
 	SessionName() string
+	WithLevel(zap.Level) Logger
 }
 
 type logger struct {
@@ -29,6 +31,25 @@ type logger struct {
 	origLogger zap.Logger
 	context    []zap.Field
 	zap.Logger
+
+	// the encoder and options are kept to create loggers with another level
+	encoder zap.Encoder
+	options []zap.Option
+	// levels caches the loggers created with another level, which are shared
+	// by all loggers derived from the same logger
+	levels *sync.Map
+}
+
+// levelKey identifies a logger created with another level.
+type levelKey struct {
+	level  zap.Level
+	source string
+}
+
+// levelLogger is a logger created with another level.
+type levelLogger struct {
+	origLogger zap.Logger
+	logger     zap.Logger
 }
 
 func RFC3339Formatter(key string) zap.TimeFormatter {
@@ -42,25 +63,35 @@ func UnixEpochFormatter(key string) zap.TimeFormatter {
 }
 
 // NewLogger returns a new zap logger that implements the Logger interface.
-func NewLogger(component string, timestampFormat string, options ...zap.Option) Logger {
-	formatter := UnixEpochFormatter("timestamp")
-
-	if timestampFormat == "rfc3339" {
-		formatter = RFC3339Formatter("timestamp")
+// It writes JSON unless encoding is "console", in which case it writes the
+// human readable console format with RFC3339 timestamps.
+func NewLogger(component string, timestampFormat string, encoding string, options ...zap.Option) Logger {
+	var enc zap.Encoder
+	if encoding == "console" {
+		enc = zap.NewTextEncoder(zap.TextTimeFormat(time.RFC3339Nano))
+	} else {
+		formatter := UnixEpochFormatter("timestamp")
+
+		if timestampFormat == "rfc3339" {
+			formatter = RFC3339Formatter("timestamp")
+		}
+
+		enc = zap.NewJSONEncoder(
+			zap.LevelString("log_level"),
+			zap.MessageKey("message"),
+			formatter,
+			numberLevelFormatter(),
+		)
 	}
-
-	enc := zap.NewJSONEncoder(
-		zap.LevelString("log_level"),
-		zap.MessageKey("message"),
-		formatter,
-		numberLevelFormatter(),
-	)
 	origLogger := zap.New(enc, options...)
 
 	return &logger{
 		source:     component,
 		origLogger: origLogger,
 		Logger:     origLogger.With(zap.String("source", component)),
+		encoder:    enc,
+		options:    options,
+		levels:     &sync.Map{},
 	}
 }
 
@@ -71,6 +102,9 @@ func (l *logger) Session(component string) Logger {
 		origLogger: l.origLogger,
 		Logger:     l.origLogger.With(zap.String("source", newSource)),
 		context:    l.context,
+		encoder:    l.encoder,
+		options:    l.options,
+		levels:     l.levels,
 	}
 	return lggr
 }
@@ -90,6 +124,37 @@ func (l *logger) With(fields ...zap.Field) Logger {
 		origLogger: l.origLogger,
 		Logger:     l.Logger,
 		context:    append(l.context, fields...),
+		encoder:    l.encoder,
+		options:    l.options,
+		levels:     l.levels,
+	}
+}
+
+// WithLevel returns a logger with the same source and context which logs
+// messages of the given level and above, regardless of the level the logger
+// was created with. The underlying zap logger is created once per level and
+// source, as this is called for every request of routes with a log level.
+func (l *logger) WithLevel(level zap.Level) Logger {
+	key := levelKey{level: level, source: l.source}
+	cached, ok := l.levels.Load(key)
+	if !ok {
+		// the last level option wins
+		options := append(l.options[:len(l.options):len(l.options)], level)
+		origLogger := zap.New(l.encoder, options...)
+		cached, _ = l.levels.LoadOrStore(key, levelLogger{
+			origLogger: origLogger,
+			logger:     origLogger.With(zap.String("source", l.source)),
+		})
+	}
+	ll := cached.(levelLogger)
+	return &logger{
+		source:     l.source,
+		origLogger: ll.origLogger,
+		Logger:     ll.logger,
+		context:    l.context,
+		encoder:    l.encoder,
+		options:    l.options,
+		levels:     l.levels,
 	}
 }
 
@@ -26,6 +26,7 @@ var _ = Describe("Logger", func() {
 		logger = NewLogger(
 			component,
 			"unix-epoch",
+			"json",
 			zap.DebugLevel,
 			zap.Output(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))),
 			zap.ErrorOutput(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))))
@@ -72,6 +73,7 @@ var _ = Describe("Logger", func() {
 				logger = NewLogger(
 					component,
 					"rfc3339",
+					"json",
 					zap.DebugLevel,
 					zap.Output(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))),
 					zap.ErrorOutput(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))))
@@ -109,6 +111,31 @@ var _ = Describe("Logger", func() {
 				})
 			})
 		})
+
+		Context("when configured to use the console encoding", func() {
+			BeforeEach(func() {
+				testSink = &test_util.TestZapSink{Buffer: gbytes.NewBuffer()}
+				logger = NewLogger(
+					component,
+					"unix-epoch",
+					"console",
+					zap.DebugLevel,
+					zap.Output(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))),
+					zap.ErrorOutput(zap.MultiWriteSyncer(testSink, zap.AddSync(GinkgoWriter))))
+				logger = logger.Session("my-subcomponent")
+				logger.Info(action)
+			})
+
+			It("outputs a message in the console format instead of JSON", func() {
+				Expect(testSink.Lines()).To(HaveLen(1))
+
+				Expect(testSink.Lines()[0]).To(MatchRegexp(
+					`^\[I\] \d{4}-\d{2}-\d{2}T\S+ %s source=my-component.my-subcomponent`,
+					action,
+				))
+				Expect(testSink.Lines()[0]).NotTo(HavePrefix("{"))
+			})
+		})
 	})
 
 	Describe("SessionName", func() {
@@ -155,6 +182,38 @@ var _ = Describe("Logger", func() {
 		})
 	})
 
+	Describe("WithLevel", func() {
+		BeforeEach(func() {
+			logger = logger.Session("session-id").With(testField)
+		})
+
+		It("returns a logger that logs at the given level with the same source and context", func() {
+			logger.WithLevel(zap.WarnLevel).Info(action)
+			logger.WithLevel(zap.WarnLevel).Warn(action)
+
+			Expect(testSink.Lines()).To(HaveLen(1))
+			Expect(testSink.Lines()[0]).To(MatchRegexp(`{.*"log_level":2.*}`))
+			Expect(testSink.Lines()[0]).To(MatchRegexp(`{.*"source":"my-component.session-id".*}`))
+			Expect(testSink.Lines()[0]).To(MatchRegexp(`{.*"data":{"new-key":"new-value"}}`))
+		})
+
+		It("keeps the source of each session at the same level", func() {
+			logger.Session("first").WithLevel(zap.WarnLevel).Warn(action)
+			logger.Session("second").WithLevel(zap.WarnLevel).Warn(action)
+
+			Expect(testSink.Lines()).To(HaveLen(2))
+			Expect(testSink.Lines()[0]).To(MatchRegexp(`{.*"source":"my-component.session-id.first".*}`))
+			Expect(testSink.Lines()[1]).To(MatchRegexp(`{.*"source":"my-component.session-id.second".*}`))
+		})
+
+		It("does not change the level of the original logger", func() {
+			logger.WithLevel(zap.WarnLevel)
+			logger.Debug(action)
+
+			Expect(testSink.Lines()).To(HaveLen(1))
+		})
+	})
+
 	Describe("Log", func() {
 		It("formats the log line correctly", func() {
 			logger.Log(zap.InfoLevel, action, testField)
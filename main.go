@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"runtime"
@@ -20,6 +22,8 @@ import (
 	"github.com/mdimiceli/gorouter/common/secure"
 	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/errorwriter"
+	"github.com/mdimiceli/gorouter/geoip"
+	"github.com/mdimiceli/gorouter/handlers"
 	goRouterLogger "github.com/mdimiceli/gorouter/logger"
 	"github.com/mdimiceli/gorouter/mbus"
 	"github.com/mdimiceli/gorouter/metrics"
@@ -54,7 +58,7 @@ func main() {
 	flag.Parse()
 
 	prefix := "gorouter.stdout"
-	tmpLogger, _ := createLogger(prefix, "INFO", "unix-epoch")
+	tmpLogger, _ := createLogger(prefix, "INFO", "unix-epoch", "json")
 
 	c, err := config.DefaultConfig()
 	if err != nil {
@@ -73,7 +77,7 @@ func main() {
 	if c.Logging.Syslog != "" {
 		prefix = c.Logging.Syslog
 	}
-	logger, minLagerLogLevel := createLogger(prefix, c.Logging.Level, c.Logging.Format.Timestamp)
+	logger, minLagerLogLevel := createLogger(prefix, c.Logging.Level, c.Logging.Format.Timestamp, c.Logging.Format.Encoding)
 	logger.Info("starting")
 	logger.Debug("local-az-set", zap.String("AvailabilityZone", c.Zone))
 
@@ -86,6 +90,9 @@ func main() {
 	} else {
 		ew = errorwriter.NewPlaintextErrorWriter()
 	}
+	if c.ErrorCacheControl != "" {
+		ew = errorwriter.NewCacheControlErrorWriter(ew, c.ErrorCacheControl)
+	}
 
 	err = dropsonde.Initialize(c.Logging.MetronAddress, c.Logging.JobName)
 	if err != nil {
@@ -131,13 +138,22 @@ func main() {
 	if c.SuspendPruningIfNatsUnavailable {
 		registry.SuspendPruning(func() bool { return !(natsClient.Status() == nats.CONNECTED) })
 	}
+	if c.RegistrySnapshot.Path != "" {
+		loaded, err := registry.LoadSnapshot(c.RegistrySnapshot.Path, c.RegistrySnapshot.TTL)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Error("registry-snapshot-load-failed", zap.String("path", c.RegistrySnapshot.Path), zap.Int("endpoints", loaded), zap.Error(err))
+		} else {
+			logger.Info("registry-snapshot-loaded", zap.String("path", c.RegistrySnapshot.Path), zap.Int("endpoints", loaded))
+		}
+	}
 
 	varz := rvarz.NewVarz(registry)
 	compositeReporter := &metrics.CompositeReporter{VarzReporter: varz, ProxyReporter: metricsReporter}
 
+	logSender := accesslog.NewLogSender(c, dropsonde.AutowiredEmitter(), compositeReporter, logger)
 	accessLogger, err := accesslog.CreateRunningAccessLogger(
 		logger.Session("access-log"),
-		accesslog.NewLogSender(c, dropsonde.AutowiredEmitter(), logger),
+		logSender,
 		c,
 	)
 	if err != nil {
@@ -190,9 +206,27 @@ func main() {
 	if c.Prometheus.Port != 0 {
 		metricsRegistry = mr.NewRegistry(log.Default(),
 			mr.WithTLSServer(int(c.Prometheus.Port), c.Prometheus.CertPath, c.Prometheus.KeyPath, c.Prometheus.CAPath))
+		if c.ConcurrentConnectionsMetricReporting {
+			compositeReporter.ConcurrentConnections = metricsRegistry.NewGauge("concurrent_connections", "the number of client connections currently open")
+		}
 	}
 
-	h = &health.Health{}
+	var debugTap *handlers.DebugTap
+	if c.DebugTap.Enabled {
+		debugTap = handlers.NewDebugTap(c.DebugTap.MaxSubscribers, c.DebugTap.BufferSize)
+	}
+
+	var geoLookup handlers.GeoLookup
+	var geoReloader *geoip.Reloader
+	if c.GeoIP.DatabasePath != "" {
+		geoReloader, err = geoip.NewReloader(c.GeoIP.DatabasePath, c.GeoIP.ReloadInterval, logger.Session("geoip"))
+		if err != nil {
+			logger.Fatal("geoip-database-load-failed", zap.Error(err))
+		}
+		geoLookup = geoReloader
+	}
+
+	h = &health.Health{Logger: logger.Session("health"), Reporter: metricsReporter}
 	proxy := proxy.NewProxy(
 		logger,
 		accessLogger,
@@ -206,6 +240,8 @@ func main() {
 		routeServiceTLSConfig,
 		h,
 		rss.GetRoundTripper(),
+		debugTap,
+		geoLookup,
 	)
 
 	var errorChannel chan error = nil
@@ -217,10 +253,12 @@ func main() {
 		natsClient,
 		registry,
 		varz,
+		compositeReporter,
 		h,
 		logCounter,
 		errorChannel,
 		rss,
+		debugTap,
 	)
 
 	h.OnDegrade = goRouter.DrainAndStop
@@ -239,9 +277,16 @@ func main() {
 	subscriber := mbus.NewSubscriber(natsClient, registry, c, natsReconnected, logger.Session("subscriber"))
 	natsMonitor := initializeNATSMonitor(subscriber, sender, logger)
 
+	if c.RegistrySnapshot.Path != "" {
+		snapshotWriter := rregistry.NewSnapshotWriter(registry, c.RegistrySnapshot.Path, c.RegistrySnapshot.Interval, logger.Session("registry-snapshot"))
+		members = append(members, grouper.Member{Name: "registry-snapshot", Runner: snapshotWriter})
+	}
 	members = append(members, grouper.Member{Name: "fdMonitor", Runner: fdMonitor})
 	members = append(members, grouper.Member{Name: "subscriber", Runner: subscriber})
 	members = append(members, grouper.Member{Name: "natsMonitor", Runner: natsMonitor})
+	if geoReloader != nil {
+		members = append(members, grouper.Member{Name: "geoip", Runner: geoReloader})
+	}
 	members = append(members, grouper.Member{Name: "router", Runner: goRouter})
 
 	group := grouper.NewOrdered(os.Interrupt, members)
@@ -257,6 +302,9 @@ func main() {
 	h.SetHealth(health.Healthy)
 
 	err = <-monitor.Wait()
+	// the access logger hands its queued records to the log sender
+	accessLogger.Stop()
+	logSender.Stop()
 	if err != nil {
 		logger.Error("gorouter.exited-with-failure", zap.Error(err))
 		os.Exit(1)
@@ -304,7 +352,7 @@ func initializeMetrics(sender *metric_sender.MetricSender, c *config.Config) *me
 		"websocket_upgrades",
 	)
 
-	return &metrics.MetricsReporter{Sender: sender, Batcher: batcher, PerRequestMetricsReporting: c.PerRequestMetricsReporting}
+	return &metrics.MetricsReporter{Sender: sender, Batcher: batcher, PerRequestMetricsReporting: c.PerRequestMetricsReporting, RegistryChurnMetricsReporting: c.RegistryChurnMetricsReporting}
 }
 
 func createCrypto(logger goRouterLogger.Logger, secret string) *secure.AesGCM {
@@ -403,7 +451,7 @@ func setupRouteFetcher(logger goRouterLogger.Logger, c *config.Config, registry
 	return routeFetcher
 }
 
-func createLogger(component string, level string, timestampFormat string) (goRouterLogger.Logger, lager.LogLevel) {
+func createLogger(component string, level string, timestampFormat string, encoding string) (goRouterLogger.Logger, lager.LogLevel) {
 	var logLevel zap.Level
 	logLevel.UnmarshalText([]byte(level))
 
@@ -421,6 +469,6 @@ func createLogger(component string, level string, timestampFormat string) (goRou
 		panic(fmt.Errorf("unknown log level: %s", level))
 	}
 
-	lggr := goRouterLogger.NewLogger(component, timestampFormat, logLevel, zap.Output(os.Stdout))
+	lggr := goRouterLogger.NewLogger(component, timestampFormat, encoding, logLevel, zap.Output(os.Stdout))
 	return lggr, minLagerLogLevel
 }
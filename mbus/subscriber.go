@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
+	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -22,24 +25,58 @@ import (
 )
 
 type RegistryMessage struct {
-	App                     string            `json:"app"`
-	AvailabilityZone        string            `json:"availability_zone"`
-	EndpointUpdatedAtNs     int64             `json:"endpoint_updated_at_ns"`
-	Host                    string            `json:"host"`
-	IsolationSegment        string            `json:"isolation_segment"`
-	Port                    uint16            `json:"port"`
-	PrivateInstanceID       string            `json:"private_instance_id"`
-	PrivateInstanceIndex    string            `json:"private_instance_index"`
-	Protocol                string            `json:"protocol"`
-	RouteServiceURL         string            `json:"route_service_url"`
-	ServerCertDomainSAN     string            `json:"server_cert_domain_san"`
-	StaleThresholdInSeconds int               `json:"stale_threshold_in_seconds"`
-	TLSPort                 uint16            `json:"tls_port"`
-	Tags                    map[string]string `json:"tags"`
-	Uris                    []route.Uri       `json:"uris"`
+	App                     string              `json:"app"`
+	AvailabilityZone        string              `json:"availability_zone"`
+	EndpointUpdatedAtNs     int64               `json:"endpoint_updated_at_ns"`
+	Host                    string              `json:"host"`
+	IsolationSegment        string              `json:"isolation_segment"`
+	Port                    uint16              `json:"port"`
+	PrivateInstanceID       string              `json:"private_instance_id"`
+	PrivateInstanceIndex    string              `json:"private_instance_index"`
+	Protocol                string              `json:"protocol"`
+	RouteServiceURL         string              `json:"route_service_url"`
+	ServerCertDomainSAN     string              `json:"server_cert_domain_san"`
+	StaleThresholdInSeconds int                 `json:"stale_threshold_in_seconds"`
+	TLS                     bool                `json:"tls"`
+	TLSPort                 uint16              `json:"tls_port"`
+	Tags                    map[string]string   `json:"tags"`
+	Uris                    []route.Uri         `json:"uris"`
+	Options                 RegistryMessageOpts `json:"options"`
 }
 
-func (rm *RegistryMessage) makeEndpoint(http2Enabled bool) (*route.Endpoint, error) {
+// RegistryMessageOpts holds optional, per-route settings of a registration.
+type RegistryMessageOpts struct {
+	GzipRequestBody          bool        `json:"gzip_request_body"`
+	StripQueryParams         []string    `json:"strip_query_params"`
+	AllowedQueryParams       []string    `json:"allowed_query_params"`
+	AllowedContentTypes      []string    `json:"allowed_content_types"`
+	InsecureSkipTLSVerify    bool        `json:"insecure_skip_tls_verify"`
+	HTTP1ConcurrentReadWrite bool        `json:"http1_concurrent_read_write"`
+	MinHealthyEndpoints      int         `json:"min_healthy_endpoints"`
+	MaxResponseBodyBytes     int64       `json:"max_response_body_bytes"`
+	BasicAuth                string      `json:"basic_auth"`
+	ConnectTunnel            bool        `json:"connect_tunnel"`
+	StatusRemap              map[int]int `json:"status_remap"`
+	StatusRemapReplaceBody   bool        `json:"status_remap_replace_body"`
+	Deprecation              string      `json:"deprecation"`
+	Sunset                   string      `json:"sunset"`
+	MaxConnections           int64       `json:"max_connections"`
+	DecompressResponse       bool        `json:"decompress_response"`
+	PreserveHeaderCase       bool        `json:"preserve_header_case"`
+	HeadAsGet                bool        `json:"head_as_get"`
+	LogLevel                 string      `json:"log_level"`
+	RequestTimeBreakdown     bool        `json:"request_time_breakdown"`
+	HostRedirect             string      `json:"host_redirect"`
+	HostRedirectStatus       int         `json:"host_redirect_status"`
+	StickySessionTTLSeconds  int64       `json:"sticky_session_ttl_seconds"`
+	Maintenance              bool        `json:"maintenance"`
+	ResponseDigest           bool        `json:"response_digest"`
+	UpgradeInsecureRequests  string      `json:"upgrade_insecure_requests"`
+	AccessLogOnlyErrors      bool        `json:"access_log_only_errors"`
+	TLSPassthrough           bool        `json:"tls_passthrough"`
+}
+
+func (rm *RegistryMessage) makeEndpoint(http2Enabled, captureHeaderCase bool) (*route.Endpoint, error) {
 	port, useTLS, err := rm.port()
 	if err != nil {
 		return nil, err
@@ -54,22 +91,95 @@ func (rm *RegistryMessage) makeEndpoint(http2Enabled bool) (*route.Endpoint, err
 		protocol = "http1"
 	}
 
+	for from, to := range rm.Options.StatusRemap {
+		if from < 100 || from > 999 || to < 100 || to > 999 {
+			return nil, fmt.Errorf("invalid status remap %d to %d", from, to)
+		}
+	}
+
+	if rm.Options.MaxConnections < 0 {
+		return nil, fmt.Errorf("invalid max connections %d", rm.Options.MaxConnections)
+	}
+
+	if rm.Options.HostRedirect != "" && !slices.Contains(route.AllowedHostRedirects, rm.Options.HostRedirect) {
+		return nil, fmt.Errorf("invalid host redirect %q", rm.Options.HostRedirect)
+	}
+
+	switch rm.Options.HostRedirectStatus {
+	case 0, http.StatusMovedPermanently, http.StatusPermanentRedirect:
+	default:
+		return nil, fmt.Errorf("invalid host redirect status %d", rm.Options.HostRedirectStatus)
+	}
+
+	if rm.Options.UpgradeInsecureRequests != "" && !slices.Contains(route.AllowedUpgradeInsecureRequests, rm.Options.UpgradeInsecureRequests) {
+		return nil, fmt.Errorf("invalid upgrade insecure requests %q", rm.Options.UpgradeInsecureRequests)
+	}
+
+	for _, contentType := range rm.Options.AllowedContentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return nil, fmt.Errorf("invalid allowed content type %q", contentType)
+		}
+	}
+
+	if rm.Options.StickySessionTTLSeconds < 0 {
+		return nil, fmt.Errorf("invalid sticky session ttl %d", rm.Options.StickySessionTTLSeconds)
+	}
+
+	if rm.Options.PreserveHeaderCase && !captureHeaderCase {
+		return nil, errors.New("preserve_header_case requires capture_request_header_case")
+	}
+
+	if rm.Options.LogLevel != "" {
+		var level zap.Level
+		if err := level.UnmarshalText([]byte(rm.Options.LogLevel)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", rm.Options.LogLevel)
+		}
+	}
+
 	return route.NewEndpoint(&route.EndpointOpts{
-		AppId:                   rm.App,
-		AvailabilityZone:        rm.AvailabilityZone,
-		Host:                    rm.Host,
-		Port:                    port,
-		Protocol:                protocol,
-		ServerCertDomainSAN:     rm.ServerCertDomainSAN,
-		PrivateInstanceId:       rm.PrivateInstanceID,
-		PrivateInstanceIndex:    rm.PrivateInstanceIndex,
-		Tags:                    rm.Tags,
-		StaleThresholdInSeconds: rm.StaleThresholdInSeconds,
-		RouteServiceUrl:         rm.RouteServiceURL,
-		ModificationTag:         models.ModificationTag{},
-		IsolationSegment:        rm.IsolationSegment,
-		UseTLS:                  useTLS,
-		UpdatedAt:               updatedAt,
+		AppId:                    rm.App,
+		AvailabilityZone:         rm.AvailabilityZone,
+		Host:                     rm.Host,
+		Port:                     port,
+		Protocol:                 protocol,
+		ServerCertDomainSAN:      rm.ServerCertDomainSAN,
+		PrivateInstanceId:        rm.PrivateInstanceID,
+		PrivateInstanceIndex:     rm.PrivateInstanceIndex,
+		Tags:                     rm.Tags,
+		StaleThresholdInSeconds:  rm.StaleThresholdInSeconds,
+		RouteServiceUrl:          rm.RouteServiceURL,
+		ModificationTag:          models.ModificationTag{},
+		IsolationSegment:         rm.IsolationSegment,
+		UseTLS:                   useTLS,
+		UpdatedAt:                updatedAt,
+		GzipRequestBody:          rm.Options.GzipRequestBody,
+		StripQueryParams:         rm.Options.StripQueryParams,
+		AllowedQueryParams:       rm.Options.AllowedQueryParams,
+		AllowedContentTypes:      rm.Options.AllowedContentTypes,
+		InsecureSkipTLSVerify:    rm.Options.InsecureSkipTLSVerify,
+		HTTP1ConcurrentReadWrite: rm.Options.HTTP1ConcurrentReadWrite,
+		MinHealthyEndpoints:      rm.Options.MinHealthyEndpoints,
+		MaxResponseBodyBytes:     rm.Options.MaxResponseBodyBytes,
+		BasicAuth:                rm.Options.BasicAuth,
+		ConnectTunnel:            rm.Options.ConnectTunnel,
+		StatusRemap:              rm.Options.StatusRemap,
+		StatusRemapReplaceBody:   rm.Options.StatusRemapReplaceBody,
+		Deprecation:              rm.Options.Deprecation,
+		Sunset:                   rm.Options.Sunset,
+		MaxConnections:           rm.Options.MaxConnections,
+		DecompressResponse:       rm.Options.DecompressResponse,
+		PreserveHeaderCase:       rm.Options.PreserveHeaderCase,
+		HeadAsGet:                rm.Options.HeadAsGet,
+		LogLevel:                 rm.Options.LogLevel,
+		RequestTimeBreakdown:     rm.Options.RequestTimeBreakdown,
+		HostRedirect:             rm.Options.HostRedirect,
+		HostRedirectStatus:       rm.Options.HostRedirectStatus,
+		StickySessionTTL:         time.Duration(rm.Options.StickySessionTTLSeconds) * time.Second,
+		Maintenance:              rm.Options.Maintenance,
+		ResponseDigest:           rm.Options.ResponseDigest,
+		UpgradeInsecureRequests:  rm.Options.UpgradeInsecureRequests,
+		AccessLogOnlyErrors:      rm.Options.AccessLogOnlyErrors,
+		TLSPassthrough:           rm.Options.TLSPassthrough,
 	}), nil
 }
 
@@ -78,22 +188,24 @@ func (rm *RegistryMessage) ValidateMessage() bool {
 	return rm.RouteServiceURL == "" || strings.HasPrefix(rm.RouteServiceURL, "https")
 }
 
-// Prefer TLS Port instead of HTTP Port in Registrty Message
+// Prefer TLS Port instead of HTTP Port in Registrty Message. The HTTP Port
+// is dialed over TLS when the message explicitly sets tls.
 func (rm *RegistryMessage) port() (uint16, bool, error) {
 	if rm.TLSPort != 0 {
 		return rm.TLSPort, true, nil
 	}
-	return rm.Port, false, nil
+	return rm.Port, rm.TLS, nil
 }
 
 // Subscriber subscribes to NATS for all router.* messages and handles them
 type Subscriber struct {
-	mbusClient       Client
-	routeRegistry    registry.Registry
-	subscription     *nats.Subscription
-	reconnected      <-chan Signal
-	natsPendingLimit int
-	http2Enabled     bool
+	mbusClient        Client
+	routeRegistry     registry.Registry
+	subscription      *nats.Subscription
+	reconnected       <-chan Signal
+	natsPendingLimit  int
+	http2Enabled      bool
+	captureHeaderCase bool
 
 	params startMessageParams
 
@@ -127,10 +239,11 @@ func NewSubscriber(
 			minimumRegisterIntervalInSeconds: int(c.StartResponseDelayInterval.Seconds()),
 			pruneThresholdInSeconds:          int(c.DropletStaleThreshold.Seconds()),
 		},
-		reconnected:      reconnected,
-		natsPendingLimit: c.NatsClientMessageBufferSize,
-		logger:           l,
-		http2Enabled:     c.EnableHTTP2,
+		reconnected:       reconnected,
+		natsPendingLimit:  c.NatsClientMessageBufferSize,
+		logger:            l,
+		http2Enabled:      c.EnableHTTP2,
+		captureHeaderCase: c.CaptureRequestHeaderCase,
 	}
 }
 
@@ -233,7 +346,7 @@ func (s *Subscriber) subscribeRoutes() (*nats.Subscription, error) {
 }
 
 func (s *Subscriber) registerEndpoint(msg *RegistryMessage) {
-	endpoint, err := msg.makeEndpoint(s.http2Enabled)
+	endpoint, err := msg.makeEndpoint(s.http2Enabled, s.captureHeaderCase)
 	if err != nil {
 		s.logger.Error("Unable to register route",
 			zap.Error(err),
@@ -248,7 +361,7 @@ func (s *Subscriber) registerEndpoint(msg *RegistryMessage) {
 }
 
 func (s *Subscriber) unregisterEndpoint(msg *RegistryMessage) {
-	endpoint, err := msg.makeEndpoint(s.http2Enabled)
+	endpoint, err := msg.makeEndpoint(s.http2Enabled, s.captureHeaderCase)
 	if err != nil {
 		s.logger.Error("Unable to unregister route",
 			zap.Error(err),
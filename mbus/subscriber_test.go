@@ -383,6 +383,560 @@ var _ = Describe("Subscriber", func() {
 		})
 	})
 
+	Context("when the message contains options", func() {
+		BeforeEach(func() {
+			cfg.CaptureRequestHeaderCase = true
+			sub = mbus.NewSubscriber(natsClient, registry, cfg, reconnected, l)
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with gzip_request_body", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"gzip_request_body":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:            "host",
+				AppId:           "app",
+				Protocol:        "http1",
+				GzipRequestBody: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with strip_query_params", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"strip_query_params":["utm_source","fbclid"]}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:             "host",
+				AppId:            "app",
+				Protocol:         "http1",
+				StripQueryParams: []string{"utm_source", "fbclid"},
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with allowed_query_params", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"allowed_query_params":["page"]}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:               "host",
+				AppId:              "app",
+				Protocol:           "http1",
+				AllowedQueryParams: []string{"page"},
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with http1_concurrent_read_write", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"http1_concurrent_read_write":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                     "host",
+				AppId:                    "app",
+				Protocol:                 "http1",
+				HTTP1ConcurrentReadWrite: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with min_healthy_endpoints", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"min_healthy_endpoints":3}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                "host",
+				AppId:               "app",
+				Protocol:            "http1",
+				MinHealthyEndpoints: 3,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with max_response_body_bytes", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"max_response_body_bytes":1048576}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                 "host",
+				AppId:                "app",
+				Protocol:             "http1",
+				MaxResponseBodyBytes: 1048576,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with basic_auth", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"basic_auth":"operators"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:      "host",
+				AppId:     "app",
+				Protocol:  "http1",
+				BasicAuth: "operators",
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with connect_tunnel", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"connect_tunnel":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:          "host",
+				AppId:         "app",
+				Protocol:      "http1",
+				ConnectTunnel: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with status_remap", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"status_remap":{"418":429},"status_remap_replace_body":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                   "host",
+				AppId:                  "app",
+				Protocol:               "http1",
+				StatusRemap:            map[int]int{418: 429},
+				StatusRemapReplaceBody: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("does not register an endpoint with an invalid status_remap", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"status_remap":{"418":42}}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("endpoint is constructed with deprecation and sunset", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"deprecation":"true","sunset":"Wed, 11 Nov 2026 23:59:59 GMT"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:        "host",
+				AppId:       "app",
+				Protocol:    "http1",
+				Deprecation: "true",
+				Sunset:      "Wed, 11 Nov 2026 23:59:59 GMT",
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with max_connections", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"max_connections":20}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:           "host",
+				AppId:          "app",
+				Protocol:       "http1",
+				MaxConnections: 20,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with decompress_response", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"decompress_response":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:               "host",
+				AppId:              "app",
+				Protocol:           "http1",
+				DecompressResponse: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with preserve_header_case", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"preserve_header_case":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:               "host",
+				AppId:              "app",
+				Protocol:           "http1",
+				PreserveHeaderCase: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with head_as_get", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"head_as_get":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:      "host",
+				AppId:     "app",
+				Protocol:  "http1",
+				HeadAsGet: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with log_level", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"log_level":"debug"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:     "host",
+				AppId:    "app",
+				Protocol: "http1",
+				LogLevel: "debug",
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with request_time_breakdown", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"request_time_breakdown":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                 "host",
+				AppId:                "app",
+				Protocol:             "http1",
+				RequestTimeBreakdown: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with host_redirect", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["www.example.com"],"options":{"host_redirect":"apex","host_redirect_status":301}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:               "host",
+				AppId:              "app",
+				Protocol:           "http1",
+				HostRedirect:       "apex",
+				HostRedirectStatus: 301,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("does not register an endpoint with an unknown host_redirect", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["www.example.com"],"options":{"host_redirect":"naked"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("does not register an endpoint with a host_redirect_status which is not a permanent redirect", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["www.example.com"],"options":{"host_redirect":"apex","host_redirect_status":302}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("endpoint is constructed with sticky_session_ttl_seconds", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"sticky_session_ttl_seconds":3600}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:             "host",
+				AppId:            "app",
+				Protocol:         "http1",
+				StickySessionTTL: time.Hour,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("does not register an endpoint with a negative sticky_session_ttl_seconds", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"sticky_session_ttl_seconds":-1}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("endpoint is constructed with maintenance", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"maintenance":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:        "host",
+				AppId:       "app",
+				Protocol:    "http1",
+				Maintenance: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with response_digest", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"response_digest":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:           "host",
+				AppId:          "app",
+				Protocol:       "http1",
+				ResponseDigest: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with upgrade_insecure_requests", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"upgrade_insecure_requests":"redirect"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "host",
+				AppId:                   "app",
+				Protocol:                "http1",
+				UpgradeInsecureRequests: route.UPGRADE_INSECURE_REQUESTS_REDIRECT,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("does not register an endpoint with an unknown upgrade_insecure_requests", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"upgrade_insecure_requests":"always"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("endpoint is constructed with allowed_content_types", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"allowed_content_types":["application/json"]}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                "host",
+				AppId:               "app",
+				Protocol:            "http1",
+				AllowedContentTypes: []string{"application/json"},
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("does not register an endpoint with a malformed allowed content type", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"allowed_content_types":["application/"]}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("endpoint is constructed with access_log_only_errors", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"access_log_only_errors":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                "host",
+				AppId:               "app",
+				Protocol:            "http1",
+				AccessLogOnlyErrors: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("endpoint is constructed with tls_passthrough", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"tls_passthrough":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:           "host",
+				AppId:          "app",
+				Protocol:       "http1",
+				TLSPassthrough: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+
+		It("does not register an endpoint with an unknown log_level", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"log_level":"verbose"}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("does not register an endpoint with negative max_connections", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"max_connections":-1}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+
+		It("endpoint is constructed with insecure_skip_tls_verify", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"tls_port":1999,"options":{"insecure_skip_tls_verify":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:                  "host",
+				AppId:                 "app",
+				Protocol:              "http1",
+				Port:                  1999,
+				UseTLS:                true,
+				InsecureSkipTLSVerify: true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+		})
+	})
+
+	Context("when the spelling of request header names is not captured", func() {
+		BeforeEach(func() {
+			cfg.CaptureRequestHeaderCase = false
+			sub = mbus.NewSubscriber(natsClient, registry, cfg, reconnected, l)
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("does not register an endpoint with preserve_header_case", func() {
+			data := []byte(`{"host":"host","app":"app","uris":["test.example.com"],"options":{"preserve_header_case":true}}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(registry.RegisterCallCount).Should(BeZero())
+		})
+	})
+
 	Context("when the message does not contain an availability_zone", func() {
 		BeforeEach(func() {
 			sub = mbus.NewSubscriber(natsClient, registry, cfg, reconnected, l)
@@ -552,6 +1106,46 @@ var _ = Describe("Subscriber", func() {
 		})
 	})
 
+	Context("when the message sets tls for a regular port", func() {
+		BeforeEach(func() {
+			sub = mbus.NewSubscriber(natsClient, registry, cfg, reconnected, l)
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the regular port and useTls set to true", func() {
+			data := []byte(`{"host":"host","app":"app","port":8443,"tls":true,"uris":["test.example.com"]}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			expectedEndpoint := route.NewEndpoint(&route.EndpointOpts{
+				Host:     "host",
+				AppId:    "app",
+				Port:     8443,
+				Protocol: "http1",
+				UseTLS:   true,
+			})
+
+			Expect(originalEndpoint).To(Equal(expectedEndpoint))
+			Expect(originalEndpoint.IsTLS()).To(BeTrue())
+		})
+
+		It("prefers the tls port when both are set", func() {
+			data := []byte(`{"host":"host","app":"app","port":8080,"tls":true,"tls_port":1999,"uris":["test.example.com"]}`)
+
+			err := natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.CanonicalAddr()).To(Equal("host:1999"))
+			Expect(originalEndpoint.IsTLS()).To(BeTrue())
+		})
+	})
+
 	It("converts endpoint_updated_at_ns", func() {
 		process = ifrit.Invoke(sub)
 		Eventually(process.Ready()).Should(BeClosed())
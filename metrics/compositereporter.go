@@ -24,9 +24,21 @@ type ProxyReporter interface {
 	CaptureBackendInvalidID()
 	CaptureBackendInvalidTLSCert()
 	CaptureBackendTLSHandshakeFailed()
+	CaptureBackendTLSValidationBypassed()
+	CaptureBackendInsufficientHealthy()
+	CaptureBackendUnexpectedSwitchingProtocols()
 	CaptureBadRequest()
 	CaptureBadGateway()
+	CaptureClientRequestTimeout()
+	CaptureConcurrentConnections(count int64)
 	CaptureMissingContentLengthHeader()
+	CapturePipelinedRequestRejected()
+	CaptureTLSHandshakeOverLimit()
+	CaptureAccessLogDropped()
+	CaptureResponseBodyTooLarge()
+	CaptureBackendFirstByteTimeout()
+	CaptureRouteConnectionLimitReached()
+	CaptureSlowClientAborted()
 	CaptureRoutingRequest(b *route.Endpoint)
 	CaptureRoutingResponse(statusCode int)
 	CaptureRoutingResponseLatency(b *route.Endpoint, statusCode int, t time.Time, d time.Duration)
@@ -43,6 +55,10 @@ type ComponentTagged interface {
 type RouteRegistryReporter interface {
 	CaptureRouteStats(totalRoutes int, msSinceLastUpdate int64)
 	CaptureRoutesPruned(prunedRoutes uint64)
+	CaptureTotalEndpoints(totalEndpoints int)
+	CaptureEndpointRegistered()
+	CaptureEndpointUnregistered()
+	CaptureRouteConflict()
 	CaptureLookupTime(t time.Duration)
 	CaptureRegistryMessage(msg ComponentTagged)
 	CaptureRouteRegistrationLatency(t time.Duration)
@@ -50,9 +66,17 @@ type RouteRegistryReporter interface {
 	CaptureUnregistryMessage(msg ComponentTagged)
 }
 
+// Gauge is a Prometheus metric set to the current value of a quantity.
+type Gauge interface {
+	Set(float64)
+}
+
 type CompositeReporter struct {
 	VarzReporter
 	ProxyReporter
+	// ConcurrentConnections is the Prometheus gauge of the open client
+	// connections. They are not reported to Prometheus if it is nil.
+	ConcurrentConnections Gauge
 }
 
 func (c *CompositeReporter) CaptureBadRequest() {
@@ -65,6 +89,13 @@ func (c *CompositeReporter) CaptureBadGateway() {
 	c.ProxyReporter.CaptureBadGateway()
 }
 
+func (c *CompositeReporter) CaptureConcurrentConnections(count int64) {
+	c.ProxyReporter.CaptureConcurrentConnections(count)
+	if c.ConcurrentConnections != nil {
+		c.ConcurrentConnections.Set(float64(count))
+	}
+}
+
 func (c *CompositeReporter) CaptureMissingContentLengthHeader() {
 	c.ProxyReporter.CaptureMissingContentLengthHeader()
 }
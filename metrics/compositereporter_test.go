@@ -109,6 +109,21 @@ var _ = Describe("CompositeReporter", func() {
 		Expect(callResponseCode).To(Equal(response.StatusCode))
 	})
 
+	It("forwards CaptureConcurrentConnections to proxy reporter", func() {
+		composite.CaptureConcurrentConnections(3)
+
+		Expect(fakeProxyReporter.CaptureConcurrentConnectionsCallCount()).To(Equal(1))
+		Expect(fakeProxyReporter.CaptureConcurrentConnectionsArgsForCall(0)).To(BeEquivalentTo(3))
+	})
+
+	It("sets the concurrent connections gauge if there is one", func() {
+		gauge := &fakeGauge{}
+		composite = &metrics.CompositeReporter{VarzReporter: fakeVarzReporter, ProxyReporter: fakeProxyReporter, ConcurrentConnections: gauge}
+		composite.CaptureConcurrentConnections(3)
+
+		Expect(gauge.value).To(BeEquivalentTo(3))
+	})
+
 	It("forwards CaptureWebSocketUpdate to proxy reporter", func() {
 		composite.CaptureWebSocketUpdate()
 
@@ -121,3 +136,11 @@ var _ = Describe("CompositeReporter", func() {
 		Expect(fakeProxyReporter.CaptureWebSocketFailureCallCount()).To(Equal(1))
 	})
 })
+
+type fakeGauge struct {
+	value float64
+}
+
+func (g *fakeGauge) Set(value float64) {
+	g.value = value
+}
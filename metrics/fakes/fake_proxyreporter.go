@@ -27,10 +27,59 @@ type FakeProxyReporter struct {
 	captureBackendTLSHandshakeFailedMutex       sync.RWMutex
 	captureBackendTLSHandshakeFailedArgsForCall []struct {
 	}
+	CaptureBackendTLSValidationBypassedStub        func()
+	captureBackendTLSValidationBypassedMutex       sync.RWMutex
+	captureBackendTLSValidationBypassedArgsForCall []struct {
+	}
+	CaptureBackendInsufficientHealthyStub        func()
+	captureBackendInsufficientHealthyMutex       sync.RWMutex
+	captureBackendInsufficientHealthyArgsForCall []struct {
+	}
+	CaptureBackendUnexpectedSwitchingProtocolsStub        func()
+	captureBackendUnexpectedSwitchingProtocolsMutex       sync.RWMutex
+	captureBackendUnexpectedSwitchingProtocolsArgsForCall []struct {
+	}
 	CaptureBadGatewayStub        func()
 	captureBadGatewayMutex       sync.RWMutex
 	captureBadGatewayArgsForCall []struct {
 	}
+	CaptureClientRequestTimeoutStub        func()
+	captureClientRequestTimeoutMutex       sync.RWMutex
+	captureClientRequestTimeoutArgsForCall []struct {
+	}
+	CaptureConcurrentConnectionsStub        func(int64)
+	captureConcurrentConnectionsMutex       sync.RWMutex
+	captureConcurrentConnectionsArgsForCall []struct {
+		arg1 int64
+	}
+	CaptureSlowClientAbortedStub        func()
+	captureSlowClientAbortedMutex       sync.RWMutex
+	captureSlowClientAbortedArgsForCall []struct {
+	}
+	CaptureRouteConnectionLimitReachedStub        func()
+	captureRouteConnectionLimitReachedMutex       sync.RWMutex
+	captureRouteConnectionLimitReachedArgsForCall []struct {
+	}
+	CaptureBackendFirstByteTimeoutStub        func()
+	captureBackendFirstByteTimeoutMutex       sync.RWMutex
+	captureBackendFirstByteTimeoutArgsForCall []struct {
+	}
+	CaptureTLSHandshakeOverLimitStub        func()
+	captureTLSHandshakeOverLimitMutex       sync.RWMutex
+	captureTLSHandshakeOverLimitArgsForCall []struct {
+	}
+	CaptureAccessLogDroppedStub        func()
+	captureAccessLogDroppedMutex       sync.RWMutex
+	captureAccessLogDroppedArgsForCall []struct {
+	}
+	CapturePipelinedRequestRejectedStub        func()
+	capturePipelinedRequestRejectedMutex       sync.RWMutex
+	capturePipelinedRequestRejectedArgsForCall []struct {
+	}
+	CaptureResponseBodyTooLargeStub        func()
+	captureResponseBodyTooLargeMutex       sync.RWMutex
+	captureResponseBodyTooLargeArgsForCall []struct {
+	}
 	CaptureBadRequestStub        func()
 	captureBadRequestMutex       sync.RWMutex
 	captureBadRequestArgsForCall []struct {
@@ -170,6 +219,78 @@ func (fake *FakeProxyReporter) CaptureBackendTLSHandshakeFailedCalls(stub func()
 	fake.CaptureBackendTLSHandshakeFailedStub = stub
 }
 
+func (fake *FakeProxyReporter) CaptureBackendTLSValidationBypassed() {
+	fake.captureBackendTLSValidationBypassedMutex.Lock()
+	fake.captureBackendTLSValidationBypassedArgsForCall = append(fake.captureBackendTLSValidationBypassedArgsForCall, struct {
+	}{})
+	stub := fake.CaptureBackendTLSValidationBypassedStub
+	fake.recordInvocation("CaptureBackendTLSValidationBypassed", []interface{}{})
+	fake.captureBackendTLSValidationBypassedMutex.Unlock()
+	if stub != nil {
+		fake.CaptureBackendTLSValidationBypassedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureBackendTLSValidationBypassedCallCount() int {
+	fake.captureBackendTLSValidationBypassedMutex.RLock()
+	defer fake.captureBackendTLSValidationBypassedMutex.RUnlock()
+	return len(fake.captureBackendTLSValidationBypassedArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureBackendTLSValidationBypassedCalls(stub func()) {
+	fake.captureBackendTLSValidationBypassedMutex.Lock()
+	defer fake.captureBackendTLSValidationBypassedMutex.Unlock()
+	fake.CaptureBackendTLSValidationBypassedStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureBackendInsufficientHealthy() {
+	fake.captureBackendInsufficientHealthyMutex.Lock()
+	fake.captureBackendInsufficientHealthyArgsForCall = append(fake.captureBackendInsufficientHealthyArgsForCall, struct {
+	}{})
+	stub := fake.CaptureBackendInsufficientHealthyStub
+	fake.recordInvocation("CaptureBackendInsufficientHealthy", []interface{}{})
+	fake.captureBackendInsufficientHealthyMutex.Unlock()
+	if stub != nil {
+		fake.CaptureBackendInsufficientHealthyStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureBackendInsufficientHealthyCallCount() int {
+	fake.captureBackendInsufficientHealthyMutex.RLock()
+	defer fake.captureBackendInsufficientHealthyMutex.RUnlock()
+	return len(fake.captureBackendInsufficientHealthyArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureBackendInsufficientHealthyCalls(stub func()) {
+	fake.captureBackendInsufficientHealthyMutex.Lock()
+	defer fake.captureBackendInsufficientHealthyMutex.Unlock()
+	fake.CaptureBackendInsufficientHealthyStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureBackendUnexpectedSwitchingProtocols() {
+	fake.captureBackendUnexpectedSwitchingProtocolsMutex.Lock()
+	fake.captureBackendUnexpectedSwitchingProtocolsArgsForCall = append(fake.captureBackendUnexpectedSwitchingProtocolsArgsForCall, struct {
+	}{})
+	stub := fake.CaptureBackendUnexpectedSwitchingProtocolsStub
+	fake.recordInvocation("CaptureBackendUnexpectedSwitchingProtocols", []interface{}{})
+	fake.captureBackendUnexpectedSwitchingProtocolsMutex.Unlock()
+	if stub != nil {
+		fake.CaptureBackendUnexpectedSwitchingProtocolsStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureBackendUnexpectedSwitchingProtocolsCallCount() int {
+	fake.captureBackendUnexpectedSwitchingProtocolsMutex.RLock()
+	defer fake.captureBackendUnexpectedSwitchingProtocolsMutex.RUnlock()
+	return len(fake.captureBackendUnexpectedSwitchingProtocolsArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureBackendUnexpectedSwitchingProtocolsCalls(stub func()) {
+	fake.captureBackendUnexpectedSwitchingProtocolsMutex.Lock()
+	defer fake.captureBackendUnexpectedSwitchingProtocolsMutex.Unlock()
+	fake.CaptureBackendUnexpectedSwitchingProtocolsStub = stub
+}
+
 func (fake *FakeProxyReporter) CaptureBadGateway() {
 	fake.captureBadGatewayMutex.Lock()
 	fake.captureBadGatewayArgsForCall = append(fake.captureBadGatewayArgsForCall, struct {
@@ -194,6 +315,230 @@ func (fake *FakeProxyReporter) CaptureBadGatewayCalls(stub func()) {
 	fake.CaptureBadGatewayStub = stub
 }
 
+func (fake *FakeProxyReporter) CaptureClientRequestTimeout() {
+	fake.captureClientRequestTimeoutMutex.Lock()
+	fake.captureClientRequestTimeoutArgsForCall = append(fake.captureClientRequestTimeoutArgsForCall, struct {
+	}{})
+	stub := fake.CaptureClientRequestTimeoutStub
+	fake.recordInvocation("CaptureClientRequestTimeout", []interface{}{})
+	fake.captureClientRequestTimeoutMutex.Unlock()
+	if stub != nil {
+		fake.CaptureClientRequestTimeoutStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureClientRequestTimeoutCallCount() int {
+	fake.captureClientRequestTimeoutMutex.RLock()
+	defer fake.captureClientRequestTimeoutMutex.RUnlock()
+	return len(fake.captureClientRequestTimeoutArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureClientRequestTimeoutCalls(stub func()) {
+	fake.captureClientRequestTimeoutMutex.Lock()
+	defer fake.captureClientRequestTimeoutMutex.Unlock()
+	fake.CaptureClientRequestTimeoutStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureConcurrentConnections(arg1 int64) {
+	fake.captureConcurrentConnectionsMutex.Lock()
+	fake.captureConcurrentConnectionsArgsForCall = append(fake.captureConcurrentConnectionsArgsForCall, struct {
+		arg1 int64
+	}{arg1})
+	stub := fake.CaptureConcurrentConnectionsStub
+	fake.recordInvocation("CaptureConcurrentConnections", []interface{}{arg1})
+	fake.captureConcurrentConnectionsMutex.Unlock()
+	if stub != nil {
+		fake.CaptureConcurrentConnectionsStub(arg1)
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureConcurrentConnectionsCallCount() int {
+	fake.captureConcurrentConnectionsMutex.RLock()
+	defer fake.captureConcurrentConnectionsMutex.RUnlock()
+	return len(fake.captureConcurrentConnectionsArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureConcurrentConnectionsCalls(stub func(int64)) {
+	fake.captureConcurrentConnectionsMutex.Lock()
+	defer fake.captureConcurrentConnectionsMutex.Unlock()
+	fake.CaptureConcurrentConnectionsStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureConcurrentConnectionsArgsForCall(i int) int64 {
+	fake.captureConcurrentConnectionsMutex.RLock()
+	defer fake.captureConcurrentConnectionsMutex.RUnlock()
+	argsForCall := fake.captureConcurrentConnectionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeProxyReporter) CaptureSlowClientAborted() {
+	fake.captureSlowClientAbortedMutex.Lock()
+	fake.captureSlowClientAbortedArgsForCall = append(fake.captureSlowClientAbortedArgsForCall, struct {
+	}{})
+	stub := fake.CaptureSlowClientAbortedStub
+	fake.recordInvocation("CaptureSlowClientAborted", []interface{}{})
+	fake.captureSlowClientAbortedMutex.Unlock()
+	if stub != nil {
+		fake.CaptureSlowClientAbortedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureSlowClientAbortedCallCount() int {
+	fake.captureSlowClientAbortedMutex.RLock()
+	defer fake.captureSlowClientAbortedMutex.RUnlock()
+	return len(fake.captureSlowClientAbortedArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureSlowClientAbortedCalls(stub func()) {
+	fake.captureSlowClientAbortedMutex.Lock()
+	defer fake.captureSlowClientAbortedMutex.Unlock()
+	fake.CaptureSlowClientAbortedStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureRouteConnectionLimitReached() {
+	fake.captureRouteConnectionLimitReachedMutex.Lock()
+	fake.captureRouteConnectionLimitReachedArgsForCall = append(fake.captureRouteConnectionLimitReachedArgsForCall, struct {
+	}{})
+	stub := fake.CaptureRouteConnectionLimitReachedStub
+	fake.recordInvocation("CaptureRouteConnectionLimitReached", []interface{}{})
+	fake.captureRouteConnectionLimitReachedMutex.Unlock()
+	if stub != nil {
+		fake.CaptureRouteConnectionLimitReachedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureRouteConnectionLimitReachedCallCount() int {
+	fake.captureRouteConnectionLimitReachedMutex.RLock()
+	defer fake.captureRouteConnectionLimitReachedMutex.RUnlock()
+	return len(fake.captureRouteConnectionLimitReachedArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureRouteConnectionLimitReachedCalls(stub func()) {
+	fake.captureRouteConnectionLimitReachedMutex.Lock()
+	defer fake.captureRouteConnectionLimitReachedMutex.Unlock()
+	fake.CaptureRouteConnectionLimitReachedStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureBackendFirstByteTimeout() {
+	fake.captureBackendFirstByteTimeoutMutex.Lock()
+	fake.captureBackendFirstByteTimeoutArgsForCall = append(fake.captureBackendFirstByteTimeoutArgsForCall, struct {
+	}{})
+	stub := fake.CaptureBackendFirstByteTimeoutStub
+	fake.recordInvocation("CaptureBackendFirstByteTimeout", []interface{}{})
+	fake.captureBackendFirstByteTimeoutMutex.Unlock()
+	if stub != nil {
+		fake.CaptureBackendFirstByteTimeoutStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureBackendFirstByteTimeoutCallCount() int {
+	fake.captureBackendFirstByteTimeoutMutex.RLock()
+	defer fake.captureBackendFirstByteTimeoutMutex.RUnlock()
+	return len(fake.captureBackendFirstByteTimeoutArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureBackendFirstByteTimeoutCalls(stub func()) {
+	fake.captureBackendFirstByteTimeoutMutex.Lock()
+	defer fake.captureBackendFirstByteTimeoutMutex.Unlock()
+	fake.CaptureBackendFirstByteTimeoutStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureTLSHandshakeOverLimit() {
+	fake.captureTLSHandshakeOverLimitMutex.Lock()
+	fake.captureTLSHandshakeOverLimitArgsForCall = append(fake.captureTLSHandshakeOverLimitArgsForCall, struct {
+	}{})
+	stub := fake.CaptureTLSHandshakeOverLimitStub
+	fake.recordInvocation("CaptureTLSHandshakeOverLimit", []interface{}{})
+	fake.captureTLSHandshakeOverLimitMutex.Unlock()
+	if stub != nil {
+		fake.CaptureTLSHandshakeOverLimitStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureTLSHandshakeOverLimitCallCount() int {
+	fake.captureTLSHandshakeOverLimitMutex.RLock()
+	defer fake.captureTLSHandshakeOverLimitMutex.RUnlock()
+	return len(fake.captureTLSHandshakeOverLimitArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureTLSHandshakeOverLimitCalls(stub func()) {
+	fake.captureTLSHandshakeOverLimitMutex.Lock()
+	defer fake.captureTLSHandshakeOverLimitMutex.Unlock()
+	fake.CaptureTLSHandshakeOverLimitStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureAccessLogDropped() {
+	fake.captureAccessLogDroppedMutex.Lock()
+	fake.captureAccessLogDroppedArgsForCall = append(fake.captureAccessLogDroppedArgsForCall, struct {
+	}{})
+	stub := fake.CaptureAccessLogDroppedStub
+	fake.recordInvocation("CaptureAccessLogDropped", []interface{}{})
+	fake.captureAccessLogDroppedMutex.Unlock()
+	if stub != nil {
+		fake.CaptureAccessLogDroppedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureAccessLogDroppedCallCount() int {
+	fake.captureAccessLogDroppedMutex.RLock()
+	defer fake.captureAccessLogDroppedMutex.RUnlock()
+	return len(fake.captureAccessLogDroppedArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureAccessLogDroppedCalls(stub func()) {
+	fake.captureAccessLogDroppedMutex.Lock()
+	defer fake.captureAccessLogDroppedMutex.Unlock()
+	fake.CaptureAccessLogDroppedStub = stub
+}
+
+func (fake *FakeProxyReporter) CapturePipelinedRequestRejected() {
+	fake.capturePipelinedRequestRejectedMutex.Lock()
+	fake.capturePipelinedRequestRejectedArgsForCall = append(fake.capturePipelinedRequestRejectedArgsForCall, struct {
+	}{})
+	stub := fake.CapturePipelinedRequestRejectedStub
+	fake.recordInvocation("CapturePipelinedRequestRejected", []interface{}{})
+	fake.capturePipelinedRequestRejectedMutex.Unlock()
+	if stub != nil {
+		fake.CapturePipelinedRequestRejectedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CapturePipelinedRequestRejectedCallCount() int {
+	fake.capturePipelinedRequestRejectedMutex.RLock()
+	defer fake.capturePipelinedRequestRejectedMutex.RUnlock()
+	return len(fake.capturePipelinedRequestRejectedArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CapturePipelinedRequestRejectedCalls(stub func()) {
+	fake.capturePipelinedRequestRejectedMutex.Lock()
+	defer fake.capturePipelinedRequestRejectedMutex.Unlock()
+	fake.CapturePipelinedRequestRejectedStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureResponseBodyTooLarge() {
+	fake.captureResponseBodyTooLargeMutex.Lock()
+	fake.captureResponseBodyTooLargeArgsForCall = append(fake.captureResponseBodyTooLargeArgsForCall, struct {
+	}{})
+	stub := fake.CaptureResponseBodyTooLargeStub
+	fake.recordInvocation("CaptureResponseBodyTooLarge", []interface{}{})
+	fake.captureResponseBodyTooLargeMutex.Unlock()
+	if stub != nil {
+		fake.CaptureResponseBodyTooLargeStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureResponseBodyTooLargeCallCount() int {
+	fake.captureResponseBodyTooLargeMutex.RLock()
+	defer fake.captureResponseBodyTooLargeMutex.RUnlock()
+	return len(fake.captureResponseBodyTooLargeArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureResponseBodyTooLargeCalls(stub func()) {
+	fake.captureResponseBodyTooLargeMutex.Lock()
+	defer fake.captureResponseBodyTooLargeMutex.Unlock()
+	fake.CaptureResponseBodyTooLargeStub = stub
+}
+
 func (fake *FakeProxyReporter) CaptureBadRequest() {
 	fake.captureBadRequestMutex.Lock()
 	fake.captureBadRequestArgsForCall = append(fake.captureBadRequestArgsForCall, struct {
@@ -432,8 +777,32 @@ func (fake *FakeProxyReporter) Invocations() map[string][][]interface{} {
 	defer fake.captureBackendInvalidTLSCertMutex.RUnlock()
 	fake.captureBackendTLSHandshakeFailedMutex.RLock()
 	defer fake.captureBackendTLSHandshakeFailedMutex.RUnlock()
+	fake.captureBackendTLSValidationBypassedMutex.RLock()
+	defer fake.captureBackendTLSValidationBypassedMutex.RUnlock()
+	fake.captureBackendInsufficientHealthyMutex.RLock()
+	defer fake.captureBackendInsufficientHealthyMutex.RUnlock()
+	fake.captureBackendUnexpectedSwitchingProtocolsMutex.RLock()
+	defer fake.captureBackendUnexpectedSwitchingProtocolsMutex.RUnlock()
 	fake.captureBadGatewayMutex.RLock()
 	defer fake.captureBadGatewayMutex.RUnlock()
+	fake.captureClientRequestTimeoutMutex.RLock()
+	defer fake.captureClientRequestTimeoutMutex.RUnlock()
+	fake.captureConcurrentConnectionsMutex.RLock()
+	defer fake.captureConcurrentConnectionsMutex.RUnlock()
+	fake.captureSlowClientAbortedMutex.RLock()
+	defer fake.captureSlowClientAbortedMutex.RUnlock()
+	fake.captureRouteConnectionLimitReachedMutex.RLock()
+	defer fake.captureRouteConnectionLimitReachedMutex.RUnlock()
+	fake.captureBackendFirstByteTimeoutMutex.RLock()
+	defer fake.captureBackendFirstByteTimeoutMutex.RUnlock()
+	fake.captureTLSHandshakeOverLimitMutex.RLock()
+	defer fake.captureTLSHandshakeOverLimitMutex.RUnlock()
+	fake.captureAccessLogDroppedMutex.RLock()
+	defer fake.captureAccessLogDroppedMutex.RUnlock()
+	fake.capturePipelinedRequestRejectedMutex.RLock()
+	defer fake.capturePipelinedRequestRejectedMutex.RUnlock()
+	fake.captureResponseBodyTooLargeMutex.RLock()
+	defer fake.captureResponseBodyTooLargeMutex.RUnlock()
 	fake.captureBadRequestMutex.RLock()
 	defer fake.captureBadRequestMutex.RUnlock()
 	fake.captureMissingContentLengthHeaderMutex.RLock()
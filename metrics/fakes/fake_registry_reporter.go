@@ -35,6 +35,11 @@ type FakeRouteRegistryReporter struct {
 	captureRoutesPrunedArgsForCall []struct {
 		arg1 uint64
 	}
+	CaptureTotalEndpointsStub        func(int)
+	captureTotalEndpointsMutex       sync.RWMutex
+	captureTotalEndpointsArgsForCall []struct {
+		arg1 int
+	}
 	CaptureUnregistryMessageStub        func(metrics.ComponentTagged)
 	captureUnregistryMessageMutex       sync.RWMutex
 	captureUnregistryMessageArgsForCall []struct {
@@ -44,6 +49,18 @@ type FakeRouteRegistryReporter struct {
 	unmuzzleRouteRegistrationLatencyMutex       sync.RWMutex
 	unmuzzleRouteRegistrationLatencyArgsForCall []struct {
 	}
+	CaptureRouteConflictStub        func()
+	captureRouteConflictMutex       sync.RWMutex
+	captureRouteConflictArgsForCall []struct {
+	}
+	CaptureEndpointUnregisteredStub        func()
+	captureEndpointUnregisteredMutex       sync.RWMutex
+	captureEndpointUnregisteredArgsForCall []struct {
+	}
+	CaptureEndpointRegisteredStub        func()
+	captureEndpointRegisteredMutex       sync.RWMutex
+	captureEndpointRegisteredArgsForCall []struct {
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -209,6 +226,38 @@ func (fake *FakeRouteRegistryReporter) CaptureRoutesPrunedArgsForCall(i int) uin
 	return argsForCall.arg1
 }
 
+func (fake *FakeRouteRegistryReporter) CaptureTotalEndpoints(arg1 int) {
+	fake.captureTotalEndpointsMutex.Lock()
+	fake.captureTotalEndpointsArgsForCall = append(fake.captureTotalEndpointsArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.CaptureTotalEndpointsStub
+	fake.recordInvocation("CaptureTotalEndpoints", []interface{}{arg1})
+	fake.captureTotalEndpointsMutex.Unlock()
+	if stub != nil {
+		fake.CaptureTotalEndpointsStub(arg1)
+	}
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureTotalEndpointsCallCount() int {
+	fake.captureTotalEndpointsMutex.RLock()
+	defer fake.captureTotalEndpointsMutex.RUnlock()
+	return len(fake.captureTotalEndpointsArgsForCall)
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureTotalEndpointsCalls(stub func(int)) {
+	fake.captureTotalEndpointsMutex.Lock()
+	defer fake.captureTotalEndpointsMutex.Unlock()
+	fake.CaptureTotalEndpointsStub = stub
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureTotalEndpointsArgsForCall(i int) int {
+	fake.captureTotalEndpointsMutex.RLock()
+	defer fake.captureTotalEndpointsMutex.RUnlock()
+	argsForCall := fake.captureTotalEndpointsArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeRouteRegistryReporter) CaptureUnregistryMessage(arg1 metrics.ComponentTagged) {
 	fake.captureUnregistryMessageMutex.Lock()
 	fake.captureUnregistryMessageArgsForCall = append(fake.captureUnregistryMessageArgsForCall, struct {
@@ -265,6 +314,78 @@ func (fake *FakeRouteRegistryReporter) UnmuzzleRouteRegistrationLatencyCalls(stu
 	fake.UnmuzzleRouteRegistrationLatencyStub = stub
 }
 
+func (fake *FakeRouteRegistryReporter) CaptureRouteConflict() {
+	fake.captureRouteConflictMutex.Lock()
+	fake.captureRouteConflictArgsForCall = append(fake.captureRouteConflictArgsForCall, struct {
+	}{})
+	stub := fake.CaptureRouteConflictStub
+	fake.recordInvocation("CaptureRouteConflict", []interface{}{})
+	fake.captureRouteConflictMutex.Unlock()
+	if stub != nil {
+		fake.CaptureRouteConflictStub()
+	}
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureRouteConflictCallCount() int {
+	fake.captureRouteConflictMutex.RLock()
+	defer fake.captureRouteConflictMutex.RUnlock()
+	return len(fake.captureRouteConflictArgsForCall)
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureRouteConflictCalls(stub func()) {
+	fake.captureRouteConflictMutex.Lock()
+	defer fake.captureRouteConflictMutex.Unlock()
+	fake.CaptureRouteConflictStub = stub
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureEndpointUnregistered() {
+	fake.captureEndpointUnregisteredMutex.Lock()
+	fake.captureEndpointUnregisteredArgsForCall = append(fake.captureEndpointUnregisteredArgsForCall, struct {
+	}{})
+	stub := fake.CaptureEndpointUnregisteredStub
+	fake.recordInvocation("CaptureEndpointUnregistered", []interface{}{})
+	fake.captureEndpointUnregisteredMutex.Unlock()
+	if stub != nil {
+		fake.CaptureEndpointUnregisteredStub()
+	}
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureEndpointUnregisteredCallCount() int {
+	fake.captureEndpointUnregisteredMutex.RLock()
+	defer fake.captureEndpointUnregisteredMutex.RUnlock()
+	return len(fake.captureEndpointUnregisteredArgsForCall)
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureEndpointUnregisteredCalls(stub func()) {
+	fake.captureEndpointUnregisteredMutex.Lock()
+	defer fake.captureEndpointUnregisteredMutex.Unlock()
+	fake.CaptureEndpointUnregisteredStub = stub
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureEndpointRegistered() {
+	fake.captureEndpointRegisteredMutex.Lock()
+	fake.captureEndpointRegisteredArgsForCall = append(fake.captureEndpointRegisteredArgsForCall, struct {
+	}{})
+	stub := fake.CaptureEndpointRegisteredStub
+	fake.recordInvocation("CaptureEndpointRegistered", []interface{}{})
+	fake.captureEndpointRegisteredMutex.Unlock()
+	if stub != nil {
+		fake.CaptureEndpointRegisteredStub()
+	}
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureEndpointRegisteredCallCount() int {
+	fake.captureEndpointRegisteredMutex.RLock()
+	defer fake.captureEndpointRegisteredMutex.RUnlock()
+	return len(fake.captureEndpointRegisteredArgsForCall)
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureEndpointRegisteredCalls(stub func()) {
+	fake.captureEndpointRegisteredMutex.Lock()
+	defer fake.captureEndpointRegisteredMutex.Unlock()
+	fake.CaptureEndpointRegisteredStub = stub
+}
+
 func (fake *FakeRouteRegistryReporter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -278,10 +399,18 @@ func (fake *FakeRouteRegistryReporter) Invocations() map[string][][]interface{}
 	defer fake.captureRouteStatsMutex.RUnlock()
 	fake.captureRoutesPrunedMutex.RLock()
 	defer fake.captureRoutesPrunedMutex.RUnlock()
+	fake.captureTotalEndpointsMutex.RLock()
+	defer fake.captureTotalEndpointsMutex.RUnlock()
 	fake.captureUnregistryMessageMutex.RLock()
 	defer fake.captureUnregistryMessageMutex.RUnlock()
 	fake.unmuzzleRouteRegistrationLatencyMutex.RLock()
 	defer fake.unmuzzleRouteRegistrationLatencyMutex.RUnlock()
+	fake.captureRouteConflictMutex.RLock()
+	defer fake.captureRouteConflictMutex.RUnlock()
+	fake.captureEndpointUnregisteredMutex.RLock()
+	defer fake.captureEndpointUnregisteredMutex.RUnlock()
+	fake.captureEndpointRegisteredMutex.RLock()
+	defer fake.captureEndpointRegisteredMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
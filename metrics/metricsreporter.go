@@ -7,16 +7,18 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/mdimiceli/gorouter/common/health"
 	"github.com/mdimiceli/gorouter/route"
 
 	"github.com/cloudfoundry/dropsonde/metrics"
 )
 
 type MetricsReporter struct {
-	Sender                     metrics.MetricSender
-	Batcher                    metrics.MetricBatcher
-	PerRequestMetricsReporting bool
-	unmuzzled                  uint64
+	Sender                        metrics.MetricSender
+	Batcher                       metrics.MetricBatcher
+	PerRequestMetricsReporting    bool
+	RegistryChurnMetricsReporting bool
+	unmuzzled                     uint64
 }
 
 func (m *MetricsReporter) CaptureBackendExhaustedConns() {
@@ -35,6 +37,54 @@ func (m *MetricsReporter) CaptureBackendInvalidTLSCert() {
 	m.Batcher.BatchIncrementCounter("backend_invalid_tls_cert")
 }
 
+func (m *MetricsReporter) CaptureBackendTLSValidationBypassed() {
+	m.Batcher.BatchIncrementCounter("backend_tls_validation_bypassed")
+}
+
+func (m *MetricsReporter) CaptureBackendInsufficientHealthy() {
+	m.Batcher.BatchIncrementCounter("backend_insufficient_healthy")
+}
+
+func (m *MetricsReporter) CaptureBackendUnexpectedSwitchingProtocols() {
+	m.Batcher.BatchIncrementCounter("backend_unexpected_switching_protocols")
+}
+
+func (m *MetricsReporter) CaptureClientRequestTimeout() {
+	m.Batcher.BatchIncrementCounter("client_request_timeout")
+}
+
+func (m *MetricsReporter) CaptureConcurrentConnections(count int64) {
+	m.Sender.SendValue("concurrent_connections", float64(count), "")
+}
+
+func (m *MetricsReporter) CaptureResponseBodyTooLarge() {
+	m.Batcher.BatchIncrementCounter("response_body_too_large")
+}
+
+func (m *MetricsReporter) CapturePipelinedRequestRejected() {
+	m.Batcher.BatchIncrementCounter("pipelined_requests_rejected")
+}
+
+func (m *MetricsReporter) CaptureTLSHandshakeOverLimit() {
+	m.Batcher.BatchIncrementCounter("tls_handshakes_over_limit")
+}
+
+func (m *MetricsReporter) CaptureAccessLogDropped() {
+	m.Batcher.BatchIncrementCounter("access_logs_dropped")
+}
+
+func (m *MetricsReporter) CaptureBackendFirstByteTimeout() {
+	m.Batcher.BatchIncrementCounter("backend_first_byte_timeout")
+}
+
+func (m *MetricsReporter) CaptureRouteConnectionLimitReached() {
+	m.Batcher.BatchIncrementCounter("route_connection_limit_reached")
+}
+
+func (m *MetricsReporter) CaptureSlowClientAborted() {
+	m.Batcher.BatchIncrementCounter("slow_client_aborted")
+}
+
 func (m *MetricsReporter) CaptureBadRequest() {
 	m.Batcher.BatchIncrementCounter("rejected_requests")
 }
@@ -113,6 +163,28 @@ func (m *MetricsReporter) CaptureRoutesPruned(routesPruned uint64) {
 	m.Batcher.BatchAddCounter("routes_pruned", routesPruned)
 }
 
+func (m *MetricsReporter) CaptureTotalEndpoints(totalEndpoints int) {
+	if m.RegistryChurnMetricsReporting {
+		m.Sender.SendValue("total_endpoints", float64(totalEndpoints), "")
+	}
+}
+
+func (m *MetricsReporter) CaptureEndpointRegistered() {
+	if m.RegistryChurnMetricsReporting {
+		m.Batcher.BatchIncrementCounter("endpoints_registered")
+	}
+}
+
+func (m *MetricsReporter) CaptureEndpointUnregistered() {
+	if m.RegistryChurnMetricsReporting {
+		m.Batcher.BatchIncrementCounter("endpoints_unregistered")
+	}
+}
+
+func (m *MetricsReporter) CaptureRouteConflict() {
+	m.Batcher.BatchIncrementCounter("route_conflicts")
+}
+
 func (m *MetricsReporter) CaptureRegistryMessage(msg ComponentTagged) {
 	var componentName string
 	if msg.Component() == "" {
@@ -133,6 +205,11 @@ func (m *MetricsReporter) CaptureUnregistryMessage(msg ComponentTagged) {
 	m.Sender.IncrementCounter(componentName)
 }
 
+func (m *MetricsReporter) CaptureHealthTransition(_, to health.Status) {
+	m.Sender.SendValue("health_status", float64(to), "")
+	m.Batcher.BatchIncrementCounter("health_transitions")
+}
+
 func (m *MetricsReporter) CaptureWebSocketUpdate() {
 	m.Batcher.BatchIncrementCounter("websocket_upgrades")
 }
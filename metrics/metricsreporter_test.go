@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/mdimiceli/gorouter/common/health"
 	"github.com/mdimiceli/gorouter/config"
 
 	"github.com/mdimiceli/gorouter/metrics"
@@ -96,6 +97,93 @@ var _ = Describe("MetricsReporter", func() {
 		Expect(batcher.BatchIncrementCounterArgsForCall(1)).To(Equal("backend_invalid_tls_cert"))
 	})
 
+	It("increments the backend_tls_validation_bypassed metric", func() {
+		metricReporter.CaptureBackendTLSValidationBypassed()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("backend_tls_validation_bypassed"))
+	})
+
+	It("increments the backend_insufficient_healthy metric", func() {
+		metricReporter.CaptureBackendInsufficientHealthy()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("backend_insufficient_healthy"))
+	})
+
+	It("increments the backend_unexpected_switching_protocols metric", func() {
+		metricReporter.CaptureBackendUnexpectedSwitchingProtocols()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("backend_unexpected_switching_protocols"))
+	})
+
+	It("increments the client_request_timeout metric", func() {
+		metricReporter.CaptureClientRequestTimeout()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("client_request_timeout"))
+	})
+
+	It("increments the response_body_too_large metric", func() {
+		metricReporter.CaptureResponseBodyTooLarge()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("response_body_too_large"))
+	})
+
+	It("increments the pipelined_requests_rejected metric", func() {
+		metricReporter.CapturePipelinedRequestRejected()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("pipelined_requests_rejected"))
+	})
+
+	It("increments the tls_handshakes_over_limit metric", func() {
+		metricReporter.CaptureTLSHandshakeOverLimit()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("tls_handshakes_over_limit"))
+	})
+
+	It("increments the access_logs_dropped metric", func() {
+		metricReporter.CaptureAccessLogDropped()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("access_logs_dropped"))
+	})
+
+	It("increments the backend_first_byte_timeout metric", func() {
+		metricReporter.CaptureBackendFirstByteTimeout()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("backend_first_byte_timeout"))
+	})
+
+	It("increments the route_connection_limit_reached metric", func() {
+		metricReporter.CaptureRouteConnectionLimitReached()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("route_connection_limit_reached"))
+	})
+
+	It("increments the slow_client_aborted metric", func() {
+		metricReporter.CaptureSlowClientAborted()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("slow_client_aborted"))
+	})
+
+	It("sends the concurrent_connections metric", func() {
+		metricReporter.CaptureConcurrentConnections(3)
+
+		Expect(sender.SendValueCallCount()).To(Equal(1))
+		name, value, unit := sender.SendValueArgsForCall(0)
+		Expect(name).To(Equal("concurrent_connections"))
+		Expect(value).To(BeEquivalentTo(3))
+		Expect(unit).To(Equal(""))
+	})
+
 	Context("increments the request metrics", func() {
 		It("increments the total requests metric", func() {
 			metricReporter.CaptureRoutingRequest(&route.Endpoint{})
@@ -449,6 +537,54 @@ var _ = Describe("MetricsReporter", func() {
 		Expect(count).To(Equal(uint64(5)))
 	})
 
+	Context("when registry churn metrics reporting is enabled", func() {
+		BeforeEach(func() {
+			metricReporter.RegistryChurnMetricsReporting = true
+		})
+
+		It("sends the total endpoints", func() {
+			metricReporter.CaptureTotalEndpoints(42)
+
+			Expect(sender.SendValueCallCount()).To(Equal(1))
+			name, value, unit := sender.SendValueArgsForCall(0)
+			Expect(name).To(Equal("total_endpoints"))
+			Expect(value).To(BeEquivalentTo(42))
+			Expect(unit).To(Equal(""))
+		})
+
+		It("increments the endpoints_registered metric", func() {
+			metricReporter.CaptureEndpointRegistered()
+
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("endpoints_registered"))
+		})
+
+		It("increments the endpoints_unregistered metric", func() {
+			metricReporter.CaptureEndpointUnregistered()
+
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("endpoints_unregistered"))
+		})
+	})
+
+	Context("when registry churn metrics reporting is disabled", func() {
+		It("does not send registry churn metrics", func() {
+			metricReporter.CaptureTotalEndpoints(42)
+			metricReporter.CaptureEndpointRegistered()
+			metricReporter.CaptureEndpointUnregistered()
+
+			Expect(sender.SendValueCallCount()).To(BeZero())
+			Expect(batcher.BatchIncrementCounterCallCount()).To(BeZero())
+		})
+	})
+
+	It("increments the route_conflicts metric", func() {
+		metricReporter.CaptureRouteConflict()
+
+		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+		Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("route_conflicts"))
+	})
+
 	It("increments the backend_tls_handshake_failed metric", func() {
 		metricReporter.CaptureBackendTLSHandshakeFailed()
 		Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
@@ -505,6 +641,21 @@ var _ = Describe("MetricsReporter", func() {
 		})
 	})
 
+	Context("health metrics", func() {
+		It("sends the new health status and counts the transition", func() {
+			metricReporter.CaptureHealthTransition(health.Healthy, health.Degraded)
+
+			Expect(sender.SendValueCallCount()).To(Equal(1))
+			name, value, unit := sender.SendValueArgsForCall(0)
+			Expect(name).To(Equal("health_status"))
+			Expect(value).To(BeEquivalentTo(health.Degraded))
+			Expect(unit).To(Equal(""))
+
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("health_transitions"))
+		})
+	})
+
 	Describe("CaptureRouteRegistrationLatency", func() {
 		It("is muzzled by default", func() {
 			metricReporter.CaptureRouteRegistrationLatency(2 * time.Second)
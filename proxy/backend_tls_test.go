@@ -89,6 +89,45 @@ var _ = Describe("Backend TLS", func() {
 			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 		})
 	})
+	Context("when the pool mixes tls and plain backends", func() {
+		It("dials each backend according to its own scheme", func() {
+			backendHandler := func(name string) func(conn *test_util.HttpConn) {
+				return func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					if err != nil {
+						conn.WriteResponse(test_util.NewResponse(http.StatusInternalServerError))
+						return
+					}
+					resp := test_util.NewResponse(http.StatusOK)
+					resp.Header.Set("X-Backend", name)
+					conn.WriteResponse(resp)
+				}
+			}
+
+			tlsLn := test_util.RegisterConnHandler(r, "test", backendHandler("tls"), registerConfig)
+			defer tlsLn.Close()
+			plainLn := test_util.RegisterConnHandler(r, "test", backendHandler("plain"), test_util.RegisterConfig{
+				InstanceId: "instance-2",
+				AppId:      "app-1",
+			})
+			defer plainLn.Close()
+
+			backends := map[string]int{}
+			for i := 0; i < 2; i++ {
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"GET / HTTP/1.1",
+					"Host: test",
+				})
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				backends[resp.Header.Get("X-Backend")]++
+			}
+
+			Expect(backends).To(Equal(map[string]int{"tls": 1, "plain": 1}))
+		})
+	})
 	Context("when the backend requires a client certificate", func() {
 		BeforeEach(func() {
 			registerConfig.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
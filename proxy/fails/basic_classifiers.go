@@ -13,6 +13,16 @@ var IdempotentRequestEOFError = errors.New("EOF (via idempotent request)")
 
 var IncompleteRequestError = errors.New("incomplete request")
 
+var RequestHeadersTooLargeError = errors.New("request headers too large for backend")
+
+var ClientRequestTimeoutError = errors.New("timed out reading request from client")
+
+var BackendTimeToFirstByteError = errors.New("backend did not start responding in time")
+
+var RouteConnectionLimitError = errors.New("route connection limit reached")
+
+var UnexpectedSwitchingProtocolsError = errors.New("backend switched protocols without an upgrade request")
+
 var AttemptedTLSWithNonTLSBackend = ClassifierFunc(func(err error) bool {
 	return errors.As(err, &tls.RecordHeaderError{})
 })
@@ -92,3 +102,23 @@ var IdempotentRequestEOF = ClassifierFunc(func(err error) bool {
 var IncompleteRequest = ClassifierFunc(func(err error) bool {
 	return errors.Is(err, IncompleteRequestError)
 })
+
+var RequestHeadersTooLarge = ClassifierFunc(func(err error) bool {
+	return errors.Is(err, RequestHeadersTooLargeError)
+})
+
+var ClientRequestTimeout = ClassifierFunc(func(err error) bool {
+	return errors.Is(err, ClientRequestTimeoutError)
+})
+
+var BackendTimeToFirstByte = ClassifierFunc(func(err error) bool {
+	return errors.Is(err, BackendTimeToFirstByteError)
+})
+
+var RouteConnectionLimit = ClassifierFunc(func(err error) bool {
+	return errors.Is(err, RouteConnectionLimitError)
+})
+
+var UnexpectedSwitchingProtocols = ClassifierFunc(func(err error) bool {
+	return errors.Is(err, UnexpectedSwitchingProtocolsError)
+})
@@ -23,6 +23,19 @@ var RetriableClassifiers = ClassifierGroup{
 	IncompleteRequest,
 }
 
+// ConnectionFailureClassifiers include only backend errors that occur while
+// establishing the connection, before the backend could have seen the request.
+var ConnectionFailureClassifiers = ClassifierGroup{
+	Dial,
+	AttemptedTLSWithNonTLSBackend,
+	HostnameMismatch,
+	RemoteFailedCertCheck,
+	RemoteHandshakeFailure,
+	RemoteHandshakeTimeout,
+	UntrustedCert,
+	ExpiredOrNotYetValidCertFailure,
+}
+
 var FailableClassifiers = ClassifierGroup{
 	Dial,
 	AttemptedTLSWithNonTLSBackend,
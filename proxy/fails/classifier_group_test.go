@@ -55,6 +55,20 @@ var _ = Describe("ClassifierGroup", func() {
 		})
 	})
 
+	Describe("connection failure", func() {
+		It("matches only errors establishing the connection", func() {
+			cc := fails.ConnectionFailureClassifiers
+
+			Expect(cc.Classify(&net.OpError{Op: "dial"})).To(BeTrue())
+			Expect(cc.Classify(fmt.Errorf("%w (%w)", fails.IncompleteRequestError, &net.OpError{Op: "dial"}))).To(BeTrue())
+			Expect(cc.Classify(&net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")})).To(BeTrue())
+			Expect(cc.Classify(x509.HostnameError{})).To(BeTrue())
+			Expect(cc.Classify(&net.OpError{Op: "read", Err: errors.New("read: connection reset by peer")})).To(BeFalse())
+			Expect(cc.Classify(fails.IdempotentRequestEOFError)).To(BeFalse())
+			Expect(cc.Classify(fails.IncompleteRequestError)).To(BeFalse())
+		})
+	})
+
 	Describe("prunable", func() {
 		It("matches prunable errors", func() {
 			pc := fails.PrunableClassifiers
@@ -1,13 +1,28 @@
 package proxy
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
 
 	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
 )
 
+var errResponseBodyTooLarge = errors.New("response body exceeds the maximum size of the route")
+
 func (p *proxy) modifyResponse(res *http.Response) error {
 	req := res.Request
 	if req == nil {
@@ -31,11 +46,425 @@ func (p *proxy) modifyResponse(res *http.Response) error {
 		return errors.New("reqInfo.RoutePool is empty on a successful response")
 	}
 
+	// headers are canonicalized when the response is read, so deleting by
+	// name removes every value regardless of the case sent by the backend
+	for _, header := range p.config.StripResponseHeaders {
+		res.Header.Del(header)
+	}
+
+	if p.config.DuplicateSetCookiePolicy == config.DUPLICATE_SET_COOKIE_KEEP_LAST {
+		dedupeSetCookies(res.Header)
+	}
+
+	p.remapStatus(res, routePool)
+	setDeprecationHeaders(res.Header, routePool)
+
+	if routePool.UpgradeInsecureRequests() == route.UPGRADE_INSECURE_REQUESTS_CSP {
+		addUpgradeInsecureRequestsPolicy(res.Header)
+	}
+
 	if p.config.TraceKey != "" && req.Header.Get(router_http.VcapTraceHeader) == p.config.TraceKey {
 		res.Header.Set(router_http.VcapRouterHeader, p.config.Ip)
 		res.Header.Set(router_http.VcapBackendHeader, endpoint.CanonicalAddr())
 		res.Header.Set(router_http.CfRouteEndpointHeader, endpoint.CanonicalAddr())
 	}
 
+	// the endpoint is set per attempt, so it is the one that served the response
+	if p.config.EmitBackendInstanceHeader && endpoint.PrivateInstanceId != "" {
+		res.Header.Set(router_http.CfInstanceHeader, endpoint.PrivateInstanceId)
+	}
+
+	// the time spent by the backend, excluding the router and failed attempts
+	if p.config.EmitUpstreamResponseTimeHeader && !reqInfo.BackendRequestStartedAt.IsZero() {
+		upstreamTime := reqInfo.BackendResponseReceivedAt.Sub(reqInfo.BackendRequestStartedAt)
+		res.Header.Set(router_http.UpstreamResponseTimeHeader, strconv.FormatFloat(upstreamTime.Seconds(), 'f', 3, 64))
+	}
+
+	// the request id is already set on the client response by
+	// handlers.NewVcapRequestIdHeader; a value sent by the backend would be
+	// added next to it
+	if p.config.ResponseRequestIDHeader != "" {
+		res.Header.Del(p.config.ResponseRequestIDHeader)
+	}
+
+	// the reverse proxy removes the Connection header of the backend from all
+	// responses but upgrades, so this mostly applies to those
+	if p.config.NormalizeResponseConnectionHeader {
+		normalizeConnectionHeader(res)
+	}
+
+	// without a Date header in the response the server sets one with its own clock
+	if p.config.OverrideBackendDateHeader {
+		res.Header.Del("Date")
+	}
+
+	if routePool.DecompressResponse() {
+		p.decompressResponse(res)
+	}
+
+	if routePool.ResponseDigest() {
+		if err := p.digestResponse(res); err != nil {
+			return err
+		}
+	}
+
+	if maxBytes := routePool.MaxResponseBodyBytes(); maxBytes > 0 && !isStreamingResponse(res) {
+		logger := handlers.LoggerWithTraceInfo(p.logger, req)
+		res.Body = &limitedResponseBody{
+			ReadCloser: res.Body,
+			remaining:  maxBytes,
+			onExceeded: func() {
+				logger.Info("max-response-body-size-exceeded", zap.Int64("max-response-body-bytes", maxBytes))
+				p.reporter.CaptureResponseBodyTooLarge()
+			},
+		}
+	}
+
 	return nil
 }
+
+// remapStatus sends the response with the status code the route maps the
+// backend's status code to, replacing the body with an error page for the new
+// status code if the route asks for it.
+func (p *proxy) remapStatus(res *http.Response, routePool *route.EndpointPool) {
+	code, ok := routePool.StatusRemap()[res.StatusCode]
+	if !ok {
+		return
+	}
+
+	logger := handlers.LoggerWithTraceInfo(p.logger, res.Request)
+	logger.Info("response-status-remapped",
+		zap.Int("backend-status-code", res.StatusCode),
+		zap.Int("status-code", code),
+	)
+	res.StatusCode = code
+	res.Status = fmt.Sprintf("%d %s", code, http.StatusText(code))
+
+	if !routePool.StatusRemapReplaceBody() {
+		return
+	}
+
+	page := &responseBuffer{header: http.Header{}}
+	p.errorWriter.WriteError(page, code, "Response status remapped by the route", logger)
+
+	res.Body.Close()
+	res.Body = io.NopCloser(&page.body)
+	res.ContentLength = int64(page.body.Len())
+	res.TransferEncoding = nil
+	res.Header.Del("Content-Encoding")
+	for name, values := range page.header {
+		res.Header[name] = values
+	}
+	res.Header.Set("Content-Length", strconv.Itoa(page.body.Len()))
+}
+
+// decompressResponse decompresses gzip responses for clients which did not
+// advertise gzip in their Accept-Encoding. The body is decompressed while it
+// is streamed to the client, up to the configured maximum size. Responses
+// with any other encoding are passed through as they are, and so is partial
+// content, whose ranges are ranges of the gzip body.
+//
+// Gzip responses vary by Accept-Encoding, whether they are decompressed or
+// not. A strong ETag is weakened when the body is decompressed, as it no
+// longer matches the bytes the backend tagged.
+func (p *proxy) decompressResponse(res *http.Response) {
+	if res.Request.Method == http.MethodHead ||
+		res.StatusCode == http.StatusNoContent ||
+		res.StatusCode == http.StatusPartialContent ||
+		res.StatusCode == http.StatusNotModified ||
+		isStreamingResponse(res) {
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(res.Header.Get("Content-Encoding")), "gzip") {
+		return
+	}
+	addVary(res.Header, "Accept-Encoding")
+	if acceptsEncoding(res.Request.Header, "gzip") {
+		return
+	}
+
+	res.Body = &gunzipResponseBody{ReadCloser: res.Body}
+	if maxBytes := p.config.Backends.DecompressResponseMaxBytes; maxBytes > 0 {
+		logger := handlers.LoggerWithTraceInfo(p.logger, res.Request)
+		res.Body = &limitedResponseBody{
+			ReadCloser: res.Body,
+			remaining:  maxBytes,
+			onExceeded: func() {
+				logger.Info("max-decompressed-response-size-exceeded", zap.Int64("max-decompressed-bytes", maxBytes))
+			},
+		}
+	}
+	res.ContentLength = -1
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		res.Header.Set("ETag", "W/"+etag)
+	}
+}
+
+// addVary adds the header name to the Vary header, unless it is listed there
+// already or the response varies by everything.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, token := range strings.Split(value, ",") {
+			token = strings.TrimSpace(token)
+			if token == "*" || strings.EqualFold(token, name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// digestResponse adds Digest and ETag headers with the SHA-256 of the body to
+// successful responses which have neither and whose Content-Length fits in the
+// configured maximum size. The body is buffered to compute them. Bodies of
+// unknown length are passed on as they are, as they may be streamed for long.
+// Partial content is skipped, as the digest would not be the one of the
+// resource.
+func (p *proxy) digestResponse(res *http.Response) error {
+	if res.Request.Method == http.MethodHead ||
+		res.StatusCode < 200 || res.StatusCode > 299 ||
+		res.StatusCode == http.StatusNoContent ||
+		res.StatusCode == http.StatusPartialContent ||
+		isStreamingResponse(res) {
+		return nil
+	}
+	if res.Header.Get("Digest") != "" && res.Header.Get("ETag") != "" {
+		return nil
+	}
+	if res.ContentLength < 0 || res.ContentLength > p.config.Backends.ResponseDigestMaxBytes {
+		return nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	sum := sha256.Sum256(body)
+	if res.Header.Get("Digest") == "" {
+		res.Header.Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	if res.Header.Get("ETag") == "" {
+		res.Header.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.TransferEncoding = nil
+	res.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// normalizeConnectionHeader keeps only the tokens of the Connection header
+// which are valid for the protocol the client speaks and the response status.
+// Both close and keep-alive are reduced to close.
+func normalizeConnectionHeader(res *http.Response) {
+	values := res.Header.Values("Connection")
+	if len(values) == 0 {
+		return
+	}
+	res.Header.Del("Connection")
+	if res.Request.ProtoMajor >= 2 {
+		// connection-specific headers are not allowed in HTTP/2
+		return
+	}
+
+	var upgrade, keepAlive, closeConn bool
+	for _, value := range values {
+		for _, token := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(token)) {
+			case "upgrade":
+				upgrade = true
+			case "keep-alive":
+				keepAlive = true
+			case "close":
+				closeConn = true
+			}
+		}
+	}
+
+	switch {
+	case res.StatusCode == http.StatusSwitchingProtocols:
+		if upgrade {
+			res.Header.Set("Connection", "Upgrade")
+		}
+	case closeConn:
+		res.Header.Set("Connection", "close")
+	case keepAlive && res.Request.ProtoMajor == 1 && res.Request.ProtoMinor == 0:
+		res.Header.Set("Connection", "keep-alive")
+	}
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header of a request
+// lists the encoding with a non-zero quality. The "*" wildcard only applies
+// when the encoding is not listed itself.
+func acceptsEncoding(header http.Header, encoding string) bool {
+	var listed, wildcard bool
+	for _, value := range header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.TrimSpace(name)
+			switch {
+			case strings.EqualFold(name, encoding):
+				if acceptableQuality(params) {
+					return true
+				}
+				listed = true
+			case name == "*":
+				wildcard = wildcard || acceptableQuality(params)
+			}
+		}
+	}
+	return !listed && wildcard
+}
+
+// acceptableQuality reports whether the parameters of a coding do not set a
+// zero quality.
+func acceptableQuality(params string) bool {
+	q, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+	if !found {
+		return true
+	}
+	quality, err := strconv.ParseFloat(q, 64)
+	return err != nil || quality > 0
+}
+
+// gunzipResponseBody decompresses a gzip body. The gzip header is only read
+// on the first read, so that creating it does not block on the backend.
+type gunzipResponseBody struct {
+	io.ReadCloser
+	reader *gzip.Reader
+}
+
+func (b *gunzipResponseBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		reader, err := gzip.NewReader(b.ReadCloser)
+		if err != nil {
+			return 0, err
+		}
+		b.reader = reader
+	}
+	return b.reader.Read(p)
+}
+
+// setDeprecationHeaders adds the Deprecation and Sunset headers of deprecated
+// routes to their responses, leaving the values a backend set untouched.
+func setDeprecationHeaders(header http.Header, routePool *route.EndpointPool) {
+	if deprecation := routePool.Deprecation(); deprecation != "" && header.Get("Deprecation") == "" {
+		header.Set("Deprecation", deprecation)
+	}
+	if sunset := routePool.Sunset(); sunset != "" && header.Get("Sunset") == "" {
+		header.Set("Sunset", sunset)
+	}
+}
+
+// addUpgradeInsecureRequestsPolicy adds a Content-Security-Policy with the
+// upgrade-insecure-requests directive to the response, unless a policy of the
+// backend has the directive already. Additional policies are enforced along
+// with the ones of the backend.
+func addUpgradeInsecureRequestsPolicy(header http.Header) {
+	for _, policy := range header.Values("Content-Security-Policy") {
+		for _, directive := range strings.Split(policy, ";") {
+			if strings.EqualFold(strings.TrimSpace(directive), "upgrade-insecure-requests") {
+				return
+			}
+		}
+	}
+	header.Add("Content-Security-Policy", "upgrade-insecure-requests")
+}
+
+// responseBuffer is a http.ResponseWriter keeping the response written to it
+// in memory, so that an error page can be sent in place of a backend's body.
+type responseBuffer struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *responseBuffer) WriteHeader(int) {}
+
+// dedupeSetCookies keeps only the last Set-Cookie header for each cookie
+// name, in the order of those last headers. Values a cookie name cannot be
+// parsed from are kept as they are.
+func dedupeSetCookies(header http.Header) {
+	values := header.Values("Set-Cookie")
+	if len(values) < 2 {
+		return
+	}
+
+	last := make(map[string]int, len(values))
+	for i, value := range values {
+		if name, ok := setCookieName(value); ok {
+			last[name] = i
+		}
+	}
+	if len(last) == len(values) {
+		return
+	}
+
+	deduped := make([]string, 0, len(last))
+	for i, value := range values {
+		if name, ok := setCookieName(value); ok && last[name] != i {
+			continue
+		}
+		deduped = append(deduped, value)
+	}
+	header["Set-Cookie"] = deduped
+}
+
+// setCookieName returns the name of the cookie set by a Set-Cookie header
+// value, i.e. everything before the first '=' of its name-value pair.
+func setCookieName(value string) (string, bool) {
+	pair, _, _ := strings.Cut(value, ";")
+	name, _, found := strings.Cut(pair, "=")
+	name = strings.TrimSpace(name)
+	return name, found && name != ""
+}
+
+// isStreamingResponse reports whether the response is an upgraded connection
+// or an event stream, neither of which has a bounded body.
+func isStreamingResponse(res *http.Response) bool {
+	return res.StatusCode == http.StatusSwitchingProtocols ||
+		strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// limitedResponseBody fails the read that takes the body past its remaining
+// size. The reverse proxy then aborts the response, closing the client
+// connection instead of passing on a body that looks complete.
+type limitedResponseBody struct {
+	io.ReadCloser
+	remaining  int64
+	exceeded   bool
+	onExceeded func()
+}
+
+func (b *limitedResponseBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errResponseBodyTooLarge
+	}
+	// read one byte past the limit to tell a body of exactly the maximum size
+	// from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.exceeded = true
+		b.onExceeded()
+		return n, errResponseBodyTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
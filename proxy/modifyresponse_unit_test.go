@@ -1,18 +1,28 @@
 package proxy
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mdimiceli/gorouter/config"
 
 	router_http "github.com/mdimiceli/gorouter/common/http"
+	"github.com/mdimiceli/gorouter/errorwriter"
 	"github.com/mdimiceli/gorouter/handlers"
 	"github.com/mdimiceli/gorouter/logger/fakes"
+	metric_fakes "github.com/mdimiceli/gorouter/metrics/fakes"
 	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("modifyResponse", func() {
@@ -37,7 +47,7 @@ var _ = Describe("modifyResponse", func() {
 		})
 		reqInfo, err = handlers.ContextRequestInfo(modifiedReq)
 		Expect(err).ToNot(HaveOccurred())
-		reqInfo.RouteEndpoint = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678})
+		reqInfo.RouteEndpoint = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, PrivateInstanceId: "instance-id"})
 		reqInfo.RoutePool = route.NewPool(&route.PoolOpts{
 			Logger:             new(fakes.FakeLogger),
 			RetryAfterFailure:  0,
@@ -138,4 +148,603 @@ var _ = Describe("modifyResponse", func() {
 			})
 		})
 	})
+	Describe("X-Cf-Instance header", func() {
+		It("does not add the header by default", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get(router_http.CfInstanceHeader)).To(BeEmpty())
+		})
+
+		Context("when emitting the backend instance header is enabled", func() {
+			BeforeEach(func() {
+				p.config.EmitBackendInstanceHeader = true
+			})
+			It("adds the instance id of the endpoint", func() {
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get(router_http.CfInstanceHeader)).To(Equal("instance-id"))
+			})
+			It("does not add the header when the endpoint has no instance id", func() {
+				reqInfo.RouteEndpoint = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678})
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get(router_http.CfInstanceHeader)).To(BeEmpty())
+			})
+		})
+	})
+	Describe("X-Upstream-Response-Time header", func() {
+		BeforeEach(func() {
+			reqInfo.BackendRequestStartedAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			reqInfo.BackendResponseReceivedAt = reqInfo.BackendRequestStartedAt.Add(1234 * time.Millisecond)
+		})
+
+		It("does not add the header by default", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header).NotTo(HaveKey(router_http.UpstreamResponseTimeHeader))
+		})
+
+		Context("when emitting the upstream response time header is enabled", func() {
+			BeforeEach(func() {
+				p.config.EmitUpstreamResponseTimeHeader = true
+			})
+
+			It("adds the duration of the backend request in seconds", func() {
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get(router_http.UpstreamResponseTimeHeader)).To(Equal("1.234"))
+			})
+
+			It("does not add the header when the request did not go to a backend", func() {
+				reqInfo.BackendRequestStartedAt = time.Time{}
+				reqInfo.BackendResponseReceivedAt = time.Time{}
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header).NotTo(HaveKey(router_http.UpstreamResponseTimeHeader))
+			})
+		})
+	})
+	Describe("Connection header", func() {
+		BeforeEach(func() {
+			resp.Header.Set("Connection", "keep-alive, X-Foo")
+		})
+
+		It("passes the header through by default", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Connection")).To(Equal("keep-alive, X-Foo"))
+		})
+
+		Context("when normalization is enabled", func() {
+			BeforeEach(func() {
+				p.config.NormalizeResponseConnectionHeader = true
+			})
+
+			DescribeTable("normalizes the header per protocol",
+				func(protoMinor int, statusCode int, values []string, expected []string) {
+					resp.Request.ProtoMinor = protoMinor
+					resp.StatusCode = statusCode
+					resp.Header["Connection"] = values
+					err := p.modifyResponse(resp)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.Header.Values("Connection")).To(Equal(expected))
+				},
+				Entry("removes keep-alive for HTTP/1.1 clients", 1, http.StatusOK, []string{"keep-alive"}, nil),
+				Entry("keeps keep-alive for HTTP/1.0 clients", 0, http.StatusOK, []string{"Keep-Alive, X-Foo"}, []string{"keep-alive"}),
+				Entry("keeps close for HTTP/1.1 clients", 1, http.StatusOK, []string{"X-Foo", "Close"}, []string{"close"}),
+				Entry("prefers close over keep-alive", 0, http.StatusOK, []string{"keep-alive, close"}, []string{"close"}),
+				Entry("keeps only upgrade for upgrades", 1, http.StatusSwitchingProtocols, []string{"keep-alive, Upgrade"}, []string{"Upgrade"}),
+				Entry("removes invalid tokens of upgrades", 1, http.StatusSwitchingProtocols, []string{"keep-alive"}, nil),
+			)
+
+			It("removes the header for HTTP/2 clients", func() {
+				resp.Request.ProtoMajor = 2
+				resp.Request.ProtoMinor = 0
+				resp.Header.Set("Connection", "close")
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Values("Connection")).To(BeEmpty())
+			})
+		})
+	})
+	Describe("Date header", func() {
+		BeforeEach(func() {
+			resp.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+		})
+
+		It("preserves the Date header of the backend by default", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Date")).To(Equal("Mon, 02 Jan 2006 15:04:05 GMT"))
+		})
+
+		Context("when overriding the Date header of the backend is enabled", func() {
+			BeforeEach(func() {
+				p.config.OverrideBackendDateHeader = true
+			})
+			It("removes the Date header so that the server sets its own", func() {
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header).NotTo(HaveKey("Date"))
+			})
+		})
+	})
+	Describe("stripped response headers", func() {
+		BeforeEach(func() {
+			resp.Header.Add("X-Powered-By", "PHP/8.3")
+			resp.Header.Add("X-Debug-Info", "query=1")
+			resp.Header.Add("X-Debug-Info", "query=2")
+			resp.Header.Set("X-App-Header", "keep-me")
+		})
+
+		It("passes all headers through by default", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header).To(HaveKey("X-Powered-By"))
+			Expect(resp.Header["X-Debug-Info"]).To(HaveLen(2))
+		})
+
+		Context("when headers to strip are configured", func() {
+			BeforeEach(func() {
+				p.config.StripResponseHeaders = []string{"x-powered-by", "X-DEBUG-INFO"}
+			})
+
+			It("removes every value of the listed headers regardless of case", func() {
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header).NotTo(HaveKey("X-Powered-By"))
+				Expect(resp.Header).NotTo(HaveKey("X-Debug-Info"))
+			})
+
+			It("passes other headers through", func() {
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get("X-App-Header")).To(Equal("keep-me"))
+				Expect(resp.Header.Get(handlers.VcapRequestIdHeader)).To(Equal("foo-uuid"))
+			})
+		})
+	})
+	Describe("duplicate Set-Cookie headers", func() {
+		BeforeEach(func() {
+			resp.Header.Add("Set-Cookie", "session=route-service; Path=/")
+			resp.Header.Add("Set-Cookie", "theme=dark")
+			resp.Header.Add("Set-Cookie", "session=backend; Path=/; HttpOnly")
+			resp.Header.Add("Set-Cookie", "invalid")
+		})
+
+		It("preserves all of them by default", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Set-Cookie")).To(Equal([]string{
+				"session=route-service; Path=/",
+				"theme=dark",
+				"session=backend; Path=/; HttpOnly",
+				"invalid",
+			}))
+		})
+
+		Context("when the policy is to keep the last one", func() {
+			BeforeEach(func() {
+				p.config.DuplicateSetCookiePolicy = config.DUPLICATE_SET_COOKIE_KEEP_LAST
+			})
+
+			It("keeps only the last header for each cookie name", func() {
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Values("Set-Cookie")).To(Equal([]string{
+					"theme=dark",
+					"session=backend; Path=/; HttpOnly",
+					"invalid",
+				}))
+			})
+
+			It("does not treat cookies whose names share a prefix as duplicates", func() {
+				resp.Header.Del("Set-Cookie")
+				resp.Header.Add("Set-Cookie", "session=1")
+				resp.Header.Add("Set-Cookie", "session_id=2")
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Values("Set-Cookie")).To(Equal([]string{"session=1", "session_id=2"}))
+			})
+		})
+	})
+	Describe("status remapping", func() {
+		var logger *test_util.TestZapLogger
+
+		BeforeEach(func() {
+			logger = test_util.NewTestZapLogger("test")
+			p.logger = logger
+			p.errorWriter = errorwriter.NewPlaintextErrorWriter()
+			resp.StatusCode = http.StatusTeapot
+			resp.Header.Set("Content-Type", "application/json")
+			resp.Body = io.NopCloser(strings.NewReader(`{"error":"slow down"}`))
+		})
+
+		setRemap := func(replaceBody bool) {
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:                   "1.2.3.4",
+				Port:                   5678,
+				StatusRemap:            map[int]int{http.StatusTeapot: http.StatusTooManyRequests},
+				StatusRemapReplaceBody: replaceBody,
+			}))
+		}
+
+		It("passes the status code through when the route has no remapping", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusTeapot))
+			Expect(logger.Buffer()).NotTo(gbytes.Say("response-status-remapped"))
+		})
+
+		It("passes through status codes the route does not remap", func() {
+			setRemap(false)
+			resp.StatusCode = http.StatusServiceUnavailable
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("remaps the status code and preserves the body", func() {
+			setRemap(false)
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal(`{"error":"slow down"}`))
+			Expect(logger.Buffer()).To(gbytes.Say(`response-status-remapped.*"backend-status-code":418,"status-code":429`))
+		})
+
+		It("replaces the body with an error page when the route asks for it", func() {
+			setRemap(true)
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/plain; charset=utf-8"))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(HavePrefix("429 Too Many Requests"))
+			Expect(resp.Header.Get("Content-Length")).To(Equal(strconv.Itoa(len(body))))
+		})
+	})
+	Describe("Deprecation and Sunset headers", func() {
+		deprecate := func() {
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:        "1.2.3.4",
+				Port:        5678,
+				Deprecation: "@1688169599",
+				Sunset:      "Wed, 11 Nov 2026 23:59:59 GMT",
+			}))
+		}
+
+		It("does not add the headers to routes that are not deprecated", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header).NotTo(HaveKey("Deprecation"))
+			Expect(resp.Header).NotTo(HaveKey("Sunset"))
+		})
+
+		It("adds the headers to deprecated routes", func() {
+			deprecate()
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Deprecation")).To(Equal("@1688169599"))
+			Expect(resp.Header.Get("Sunset")).To(Equal("Wed, 11 Nov 2026 23:59:59 GMT"))
+		})
+
+		It("does not override the values set by the backend", func() {
+			deprecate()
+			resp.Header.Set("Deprecation", "@1700000000")
+			resp.Header.Set("Sunset", "Thu, 31 Dec 2026 23:59:59 GMT")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Deprecation")).To(Equal([]string{"@1700000000"}))
+			Expect(resp.Header.Values("Sunset")).To(Equal([]string{"Thu, 31 Dec 2026 23:59:59 GMT"}))
+		})
+	})
+	Describe("upgrade-insecure-requests content security policy", func() {
+		upgrade := func() {
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "1.2.3.4",
+				Port:                    5678,
+				UpgradeInsecureRequests: route.UPGRADE_INSECURE_REQUESTS_CSP,
+			}))
+		}
+
+		It("does not add a policy to routes that do not upgrade insecure requests", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header).NotTo(HaveKey("Content-Security-Policy"))
+		})
+
+		It("adds the policy to routes that upgrade insecure requests", func() {
+			upgrade()
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Content-Security-Policy")).To(Equal([]string{"upgrade-insecure-requests"}))
+		})
+
+		It("adds the policy alongside other policies of the backend", func() {
+			upgrade()
+			resp.Header.Set("Content-Security-Policy", "default-src 'self'")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Content-Security-Policy")).To(Equal([]string{"default-src 'self'", "upgrade-insecure-requests"}))
+		})
+
+		It("does not add the policy when the backend has the directive already", func() {
+			upgrade()
+			resp.Header.Set("Content-Security-Policy", "default-src 'self'; Upgrade-Insecure-Requests")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Content-Security-Policy")).To(Equal([]string{"default-src 'self'; Upgrade-Insecure-Requests"}))
+		})
+	})
+	Describe("maximum response body size", func() {
+		var reporter *metric_fakes.FakeProxyReporter
+
+		BeforeEach(func() {
+			reporter = new(metric_fakes.FakeProxyReporter)
+			p.logger = test_util.NewTestZapLogger("test")
+			p.reporter = reporter
+			resp.Body = io.NopCloser(strings.NewReader("0123456789"))
+		})
+
+		It("passes the body through when the route has no maximum", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("0123456789"))
+		})
+
+		Context("when the route has a maximum", func() {
+			setMaximum := func(max int64) {
+				reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, MaxResponseBodyBytes: max}))
+			}
+
+			It("passes through a body of exactly the maximum size", func() {
+				setMaximum(10)
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("0123456789"))
+				Expect(reporter.CaptureResponseBodyTooLargeCallCount()).To(Equal(0))
+			})
+
+			It("fails reading a body larger than the maximum and reports it once", func() {
+				setMaximum(4)
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).To(MatchError(errResponseBodyTooLarge))
+				Expect(string(body)).To(Equal("0123"))
+
+				_, err = resp.Body.Read(make([]byte, 8))
+				Expect(err).To(MatchError(errResponseBodyTooLarge))
+				Expect(reporter.CaptureResponseBodyTooLargeCallCount()).To(Equal(1))
+			})
+
+			It("does not limit event streams", func() {
+				setMaximum(4)
+				resp.Header.Set("Content-Type", "text/event-stream; charset=utf-8")
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("0123456789"))
+			})
+
+			It("does not limit upgraded connections", func() {
+				setMaximum(4)
+				resp.StatusCode = http.StatusSwitchingProtocols
+				err := p.modifyResponse(resp)
+				Expect(err).ToNot(HaveOccurred())
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("0123456789"))
+			})
+		})
+	})
+	Describe("response decompression", func() {
+		gzipped := func(body string) io.ReadCloser {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			_, err := w.Write([]byte(body))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w.Close()).To(Succeed())
+			return io.NopCloser(&buf)
+		}
+
+		BeforeEach(func() {
+			p.logger = test_util.NewTestZapLogger("test")
+			p.config.Backends.DecompressResponseMaxBytes = 1024
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, DecompressResponse: true}))
+			resp.Header.Set("Content-Encoding", "gzip")
+			resp.Header.Set("Content-Length", "42")
+			resp.ContentLength = 42
+			resp.Body = gzipped("0123456789")
+		})
+
+		It("decompresses gzip responses for clients that do not accept gzip", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(BeEmpty())
+			Expect(resp.Header.Get("Content-Length")).To(BeEmpty())
+			Expect(resp.ContentLength).To(Equal(int64(-1)))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("0123456789"))
+		})
+
+		It("decompresses gzip responses for clients that refuse gzip", func() {
+			resp.Request.Header.Set("Accept-Encoding", "gzip;q=0, identity")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(BeEmpty())
+		})
+
+		It("passes gzip responses through for clients that accept gzip", func() {
+			resp.Request.Header.Set("Accept-Encoding", "deflate, GZIP;q=0.5")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+			Expect(resp.ContentLength).To(Equal(int64(42)))
+		})
+
+		It("decompresses gzip responses for clients that refuse gzip but accept any encoding", func() {
+			resp.Request.Header.Set("Accept-Encoding", "*, gzip;q=0")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(BeEmpty())
+		})
+
+		It("passes gzip responses through for clients that accept any encoding", func() {
+			resp.Request.Header.Set("Accept-Encoding", "*")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+		})
+
+		It("varies gzip responses by Accept-Encoding", func() {
+			resp.Header.Set("Vary", "Origin")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Vary")).To(Equal([]string{"Origin", "Accept-Encoding"}))
+		})
+
+		It("does not repeat Accept-Encoding in the Vary header", func() {
+			resp.Request.Header.Set("Accept-Encoding", "gzip")
+			resp.Header.Set("Vary", "accept-encoding")
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Values("Vary")).To(Equal([]string{"accept-encoding"}))
+		})
+
+		It("weakens the ETag of decompressed responses", func() {
+			resp.Header.Set("ETag", `"abc"`)
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("ETag")).To(Equal(`W/"abc"`))
+		})
+
+		It("passes partial content through", func() {
+			resp.StatusCode = http.StatusPartialContent
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+			Expect(resp.ContentLength).To(Equal(int64(42)))
+		})
+
+		It("passes responses with unknown encodings through", func() {
+			resp.Header.Set("Content-Encoding", "br")
+			resp.Body = io.NopCloser(strings.NewReader("brotli"))
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(Equal("br"))
+			Expect(resp.Header.Get("Content-Length")).To(Equal("42"))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("brotli"))
+		})
+
+		It("does not decompress responses of routes that did not opt in", func() {
+			reqInfo.RoutePool = route.NewPool(&route.PoolOpts{Logger: new(fakes.FakeLogger), Host: "foo.com"})
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678}))
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+		})
+
+		It("fails reading a body that decompresses past the maximum size", func() {
+			p.config.Backends.DecompressResponseMaxBytes = 4
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).To(MatchError(errResponseBodyTooLarge))
+			Expect(string(body)).To(Equal("0123"))
+		})
+	})
+
+	Describe("response digest", func() {
+		BeforeEach(func() {
+			p.config.Backends.ResponseDigestMaxBytes = 16
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, ResponseDigest: true}))
+			resp.Body = io.NopCloser(strings.NewReader("hello world"))
+			resp.ContentLength = 11
+		})
+
+		It("adds the Digest and ETag headers to small responses", func() {
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			// sha256 of "hello world"
+			Expect(resp.Header.Get("Digest")).To(Equal("sha-256=uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="))
+			Expect(resp.Header.Get("ETag")).To(Equal(`"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`))
+			Expect(resp.Header.Get("Content-Length")).To(Equal("11"))
+			Expect(resp.ContentLength).To(Equal(int64(11)))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("hello world"))
+		})
+
+		It("keeps the headers the backend set", func() {
+			resp.Header.Set("ETag", `"backend"`)
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("ETag")).To(Equal(`"backend"`))
+			Expect(resp.Header.Get("Digest")).To(HavePrefix("sha-256="))
+		})
+
+		It("skips responses of unknown length without reading them", func() {
+			bodyReader, bodyWriter := io.Pipe()
+			defer bodyWriter.Close()
+			resp.Body = bodyReader
+			resp.ContentLength = -1
+			resp.TransferEncoding = []string{"chunked"}
+
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Digest")).To(BeEmpty())
+			Expect(resp.Header.Get("ETag")).To(BeEmpty())
+			Expect(resp.Body).To(BeIdenticalTo(bodyReader))
+		})
+
+		It("skips responses with a Content-Length larger than the maximum size", func() {
+			resp.ContentLength = 17
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Digest")).To(BeEmpty())
+		})
+
+		It("skips partial content", func() {
+			resp.StatusCode = http.StatusPartialContent
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Digest")).To(BeEmpty())
+			Expect(resp.Header.Get("ETag")).To(BeEmpty())
+		})
+
+		It("skips unsuccessful responses", func() {
+			resp.StatusCode = http.StatusNotFound
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Digest")).To(BeEmpty())
+			Expect(resp.Header.Get("ETag")).To(BeEmpty())
+		})
+
+		It("does not add the headers for routes that did not opt in", func() {
+			reqInfo.RoutePool = route.NewPool(&route.PoolOpts{Logger: new(fakes.FakeLogger), Host: "foo.com"})
+			reqInfo.RoutePool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678}))
+			err := p.modifyResponse(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Header.Get("Digest")).To(BeEmpty())
+			Expect(resp.Header.Get("ETag")).To(BeEmpty())
+		})
+	})
 })
@@ -1,14 +1,17 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mdimiceli/gorouter/common/health"
@@ -47,6 +50,8 @@ type proxy struct {
 	backendTLSConfig      *tls.Config
 	routeServiceTLSConfig *tls.Config
 	config                *config.Config
+
+	halfDuplexFallbackOnce sync.Once
 }
 
 func NewProxy(
@@ -62,6 +67,8 @@ func NewProxy(
 	routeServiceTLSConfig *tls.Config,
 	health *health.Health,
 	routeServicesTransport http.RoundTripper,
+	debugTap *handlers.DebugTap,
+	geoLookup handlers.GeoLookup,
 ) http.Handler {
 
 	p := &proxy{
@@ -106,16 +113,24 @@ func NewProxy(
 			ExpectContinueTimeout: 1 * time.Second,
 		},
 		IsInstrumented: cfg.SendHttpStartStopClientEvent,
+		Logger:         logger,
+		Reporter:       reporter,
+	}
+
+	retriableClassifiers := fails.RetriableClassifiers
+	if cfg.Backends.RetryOnlyOnConnectionFailure {
+		retriableClassifiers = fails.ConnectionFailureClassifiers
 	}
 
 	prt := round_tripper.NewProxyRoundTripper(
 		roundTripperFactory,
-		fails.RetriableClassifiers,
+		retriableClassifiers,
 		logger,
 		reporter,
 		&round_tripper.ErrorHandler{
 			MetricReporter: reporter,
 			ErrorSpecs:     round_tripper.DefaultErrorSpecs,
+			CacheControl:   cfg.ErrorCacheControl,
 		},
 		routeServicesTransport,
 		cfg,
@@ -133,20 +148,23 @@ func NewProxy(
 
 	zipkinHandler := handlers.NewZipkin(cfg.Tracing.EnableZipkin, logger)
 	w3cHandler := handlers.NewW3C(cfg.Tracing.EnableW3C, cfg.Tracing.W3CTenantID, logger)
+	w3cBaggageHandler := handlers.NewW3CBaggage(cfg.Tracing.Baggage, logger)
 
 	headersToLog := utils.CollectHeadersToLog(
 		cfg.ExtraHeadersToLog,
 		zipkinHandler.HeadersToLog(),
 		w3cHandler.HeadersToLog(),
+		w3cBaggageHandler.HeadersToLog(),
 	)
 
 	n := negroni.New()
-	n.Use(handlers.NewPanicCheck(p.health, logger))
+	n.Use(handlers.NewPanicCheck(p.health, logger, errorWriter, cfg.PanicResponse))
 	n.Use(handlers.NewRequestInfo())
 	n.Use(handlers.NewProxyWriter(logger))
 	n.Use(zipkinHandler)
 	n.Use(w3cHandler)
-	n.Use(handlers.NewVcapRequestIdHeader(logger))
+	n.Use(w3cBaggageHandler)
+	n.Use(handlers.NewVcapRequestIdHeader(logger, cfg.ResponseRequestIDHeader))
 	if cfg.SendHttpStartStopServerEvent {
 		n.Use(handlers.NewHTTPStartStop(dropsonde.DefaultEmitter, logger))
 	}
@@ -154,14 +172,82 @@ func NewProxy(
 		if cfg.PerAppPrometheusHttpMetricsReporting {
 			n.Use(handlers.NewHTTPLatencyPrometheus(p.promRegistry))
 		}
+		n.Use(handlers.NewRequestTimeBreakdownPrometheus(p.promRegistry))
 	}
-	n.Use(handlers.NewAccessLog(accessLogger, headersToLog, cfg.Logging.EnableAttemptsDetails, logger))
+	n.Use(handlers.NewAccessLog(accessLogger, headersToLog, cfg.Logging.EnableAttemptsDetails, cfg.AccessLog.LogRequestStart, cfg.AccessLog.OnlyErrors, logger))
 	n.Use(handlers.NewQueryParam(logger))
 	n.Use(handlers.NewReporter(reporter, logger))
+	if debugTap != nil {
+		n.Use(handlers.NewDebugTapHandler(debugTap))
+	}
 	n.Use(handlers.NewHTTPRewriteHandler(cfg.HTTPRewrite, headersToAlwaysRemove))
-	n.Use(handlers.NewProxyHealthcheck(cfg.HealthCheckUserAgent, p.health))
+	n.Use(handlers.NewProxyHealthcheck(cfg.HealthCheckUserAgent, p.health, cfg.HealthCheckCacheTTL))
+	if !cfg.KeepAliveHTTP10Clients {
+		n.Use(handlers.NewHTTP10ConnectionClose())
+	}
+	if cfg.MaxClientConnectionAge > 0 {
+		n.Use(handlers.NewMaxConnectionAge(cfg.MaxClientConnectionAge))
+	}
 	n.Use(handlers.NewProtocolCheck(logger, errorWriter, cfg.EnableHTTP2))
-	n.Use(handlers.NewLookup(registry, reporter, logger, errorWriter, cfg.EmptyPoolResponseCode503))
+	if cfg.AsteriskOptionsRequests == config.ASTERISK_OPTIONS_RESPOND {
+		n.Use(handlers.NewAsteriskOptions(cfg.AsteriskOptionsAllow))
+	}
+	if cfg.ConnectRequests != config.CONNECT_REQUESTS_TUNNEL {
+		n.Use(handlers.NewConnectCheck(logger, errorWriter))
+	}
+	if cfg.PipelinedRequests == config.PIPELINED_REQUESTS_REJECT {
+		n.Use(handlers.NewPipelineCheck(reporter, logger, errorWriter))
+	}
+	if cfg.AbsoluteFormRequests == config.ABSOLUTE_FORM_REQUESTS_REJECT {
+		n.Use(handlers.NewAbsoluteFormCheck(logger, errorWriter))
+	}
+	if cfg.StrictRequestFraming {
+		n.Use(handlers.NewRequestFraming(logger, errorWriter))
+	}
+	if cfg.StrictHeaderValidation {
+		n.Use(handlers.NewHeaderValidation(logger, errorWriter))
+	}
+	if cfg.MaxRequestURILength > 0 {
+		n.Use(handlers.NewMaxRequestURILength(cfg.MaxRequestURILength, logger, errorWriter))
+	}
+	if cfg.MaxHeaderCount > 0 {
+		n.Use(handlers.NewMaxHeaderCount(cfg.MaxHeaderCount, logger, errorWriter))
+	}
+	if len(cfg.AllowedUpgradeProtocols) > 0 {
+		n.Use(handlers.NewUpgradeCheck(cfg.AllowedUpgradeProtocols, logger, errorWriter))
+	}
+	if len(cfg.AllowedHosts) > 0 {
+		n.Use(handlers.NewHostAllowlist(cfg.AllowedHosts, logger, errorWriter))
+	}
+	if cfg.PathNormalization.Enabled() {
+		n.Use(handlers.NewPathNormalization(cfg.PathNormalization, logger, errorWriter))
+	}
+	if geoLookup != nil {
+		n.Use(handlers.NewGeoIP(geoLookup, cfg.GeoIP, cfg.ClientIPTrustedProxyDepth))
+	}
+	var listenerPorts []uint16
+	switch cfg.HostPortStripMode {
+	case config.HOST_PORT_STRIP_LISTENER:
+		listenerPorts = []uint16{cfg.Port}
+		if cfg.EnableSSL {
+			listenerPorts = append(listenerPorts, cfg.SSLPort)
+		}
+	case config.HOST_PORT_STRIP_NONE:
+		listenerPorts = []uint16{}
+	}
+	n.Use(handlers.NewLookup(registry, reporter, logger, errorWriter, cfg.EmptyPoolResponseCode503, listenerPorts, cfg.Logging.RouteLookupMissesPerSecond, cfg.TrailingSlashMode))
+	// requests coming back from a route service are recognized by the route
+	// service of their route, so they are authenticated once it is looked up
+	if authHandler := handlers.NewAuthHandler(cfg.Auth); authHandler != nil {
+		n.Use(handlers.NewAuth(authHandler, SkipRouteAuth(routeServiceHandler.(*handlers.RouteService), logger), logger, errorWriter))
+	}
+	n.Use(handlers.NewMaintenance(cfg.MaintenancePage, logger, errorWriter))
+	n.Use(handlers.NewQueryParamAllowlist(logger, errorWriter))
+	n.Use(handlers.NewContentTypeAllowlist(logger, errorWriter))
+	n.Use(handlers.NewRouteBasicAuth(cfg.RouteBasicAuth, SkipRouteAuth(routeServiceHandler.(*handlers.RouteService), logger), logger, errorWriter))
+	if cfg.ConnectRequests == config.CONNECT_REQUESTS_TUNNEL {
+		n.Use(handlers.NewConnectTunnel(cfg, logger, errorWriter))
+	}
 	n.Use(handlers.NewMaxRequestSize(cfg, logger))
 	n.Use(handlers.NewClientCert(
 		SkipSanitize(routeServiceHandler.(*handlers.RouteService)),
@@ -180,6 +266,10 @@ func NewProxy(
 	n.Use(p)
 	n.UseHandler(rproxy)
 
+	if cfg.StageTiming.Enabled {
+		n = negroni.New(handlers.WithStageTiming(n.Handlers(), cfg.StageTiming.SlowRequestThreshold, logger)...)
+	}
+
 	return n
 }
 
@@ -200,6 +290,15 @@ func SkipSanitize(routeServiceValidator RouteServiceValidator) func(*http.Reques
 	}
 }
 
+// SkipRouteAuth skips the authentication of requests that come back from the
+// route service of their route, as it was done before they were sent there.
+func SkipRouteAuth(routeServiceValidator RouteServiceValidator, logger logger.Logger) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		valid, err := routeServiceValidator.ArrivedViaRouteService(req, logger)
+		return err == nil && valid
+	}
+}
+
 func ForceDeleteXFCCHeader(routeServiceValidator RouteServiceValidator, forwardedClientCert string, logger logger.Logger) func(*http.Request) (bool, error) {
 	return func(req *http.Request) (bool, error) {
 		valid, err := routeServiceValidator.ArrivedViaRouteService(req, logger)
@@ -214,15 +313,6 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 	logger := handlers.LoggerWithTraceInfo(p.logger, request)
 	proxyWriter := responseWriter.(utils.ProxyResponseWriter)
 
-	if p.config.EnableHTTP1ConcurrentReadWrite && request.ProtoMajor == 1 {
-		rc := http.NewResponseController(proxyWriter)
-
-		err := rc.EnableFullDuplex()
-		if err != nil {
-			logger.Panic("enable-full-duplex-err", zap.Error(err))
-		}
-	}
-
 	reqInfo, err := handlers.ContextRequestInfo(request)
 	if err != nil {
 		logger.Panic("request-info-err", zap.Error(err))
@@ -232,6 +322,50 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 		logger.Panic("request-info-err", zap.Error(errors.New("failed-to-access-RoutePool")))
 	}
 
+	fullDuplex := p.config.EnableHTTP1ConcurrentReadWrite || reqInfo.RoutePool.HTTP1ConcurrentReadWrite()
+	if fullDuplex && request.ProtoMajor == 1 {
+		rc := http.NewResponseController(proxyWriter)
+
+		err := rc.EnableFullDuplex()
+		if err != nil {
+			if p.config.FullDuplexFailureMode != config.FULL_DUPLEX_FAILURE_DEGRADE {
+				logger.Panic("enable-full-duplex-err", zap.Error(err))
+			}
+			p.halfDuplexFallbackOnce.Do(func() {
+				logger.Warn("enable-full-duplex-failed-serving-half-duplex", zap.Error(err))
+			})
+		} else {
+			// The server cancels the request context when it reads EOF from
+			// the client, which is also what a client that merely closed its
+			// write side looks like. Once the response was started, keep
+			// streaming it in that case; a client that is really gone is
+			// noticed when writing the response fails. Until then the request
+			// is cancelled along with the client.
+			startedWriter := &responseStartedWriter{ProxyResponseWriter: proxyWriter}
+			proxyWriter = startedWriter
+			responseWriter = startedWriter
+
+			ctx, cancel := context.WithCancel(context.WithoutCancel(request.Context()))
+			defer cancel()
+			stop := context.AfterFunc(request.Context(), func() {
+				if !startedWriter.started.Load() {
+					cancel()
+				}
+			})
+			defer stop()
+			request = request.WithContext(ctx)
+		}
+	}
+
+	if p.config.FrontendWriteStallTimeout > 0 {
+		stallWriter := newWriteStallResponseWriter(proxyWriter, p.config.FrontendWriteStallTimeout, func() {
+			logger.Info("slow-client-aborted", zap.Duration("write-stall-timeout", p.config.FrontendWriteStallTimeout))
+			p.reporter.CaptureSlowClientAborted()
+		})
+		defer stallWriter.clearDeadline()
+		responseWriter = stallWriter
+	}
+
 	reqInfo.AppRequestStartedAt = time.Now()
 	next(responseWriter, request)
 	reqInfo.AppRequestFinishedAt = time.Now()
@@ -245,6 +379,10 @@ func (p *proxy) setupProxyRequest(target *http.Request) {
 	}
 	reqInfo.BackendReqHeaders = target.Header
 
+	if reqInfo.RoutePool != nil {
+		stripQueryParams(target, reqInfo.RoutePool.StripQueryParams())
+	}
+
 	target.URL.Scheme = "http"
 	target.URL.Host = target.Host
 	target.URL.ForceQuery = false
@@ -260,16 +398,93 @@ func (p *proxy) setupProxyRequest(target *http.Request) {
 	}
 	target.URL.RawQuery = ""
 
-	setRequestXRequestStart(target)
+	setRequestXRequestStart(target, p.config.XRequestStartPolicy == config.X_REQUEST_START_OVERWRITE)
+	if p.config.RequestReceivedHeader != "" {
+		setRequestReceived(target, p.config.RequestReceivedHeader, p.config.RequestReceivedFormat, reqInfo.ReceivedAt)
+	}
+	if p.config.ClientIPHeader != "" {
+		setRequestClientIP(target, p.config.ClientIPHeader, p.config.ClientIPTrustedProxyDepth)
+	}
+	setRequestXForwardedFor(target, p.config.ForwardedForMode)
 	target.Header.Del(router_http.CfAppInstance)
 }
 
-func setRequestXRequestStart(request *http.Request) {
-	if _, ok := request.Header[http.CanonicalHeaderKey("X-Request-Start")]; !ok {
+func setRequestXRequestStart(request *http.Request, overwrite bool) {
+	if _, ok := request.Header[http.CanonicalHeaderKey("X-Request-Start")]; !ok || overwrite {
 		request.Header.Set("X-Request-Start", strconv.FormatInt(time.Now().UnixNano()/1e6, 10))
 	}
 }
 
+// setRequestReceived sets header to the time at which the request was
+// received, replacing any value sent by the client.
+func setRequestReceived(request *http.Request, header string, format string, receivedAt time.Time) {
+	switch format {
+	case config.REQUEST_RECEIVED_FORMAT_RFC3339:
+		request.Header.Set(header, receivedAt.UTC().Format(time.RFC3339Nano))
+	default:
+		request.Header.Set(header, strconv.FormatInt(receivedAt.UnixMilli(), 10))
+	}
+}
+
+// setRequestClientIP sets header to the IP of the client, replacing any value
+// sent by the client. See handlers.ClientIP for how the IP is derived.
+func setRequestClientIP(request *http.Request, header string, trustedProxyDepth int) {
+	clientIP, ok := handlers.ClientIP(request, trustedProxyDepth)
+	if !ok {
+		request.Header.Del(header)
+		return
+	}
+
+	request.Header.Set(header, clientIP)
+}
+
+// setRequestXForwardedFor prepares the X-Forwarded-For header for the reverse
+// proxy, which appends the client IP to the header after the director ran,
+// unless the header is set to nil.
+func setRequestXForwardedFor(request *http.Request, mode string) {
+	switch mode {
+	case config.FORWARDED_FOR_REPLACE:
+		request.Header.Del("X-Forwarded-For")
+	case config.FORWARDED_FOR_REMOVE:
+		request.Header["X-Forwarded-For"] = nil
+	}
+}
+
+// stripQueryParams removes the named parameters from the query of both the
+// request URI and the URL. The remaining parameters are kept verbatim, in
+// their original order and encoding.
+func stripQueryParams(request *http.Request, names []string) {
+	if len(names) == 0 || request.URL.RawQuery == "" {
+		return
+	}
+
+	request.URL.RawQuery = stripRawQueryParams(request.URL.RawQuery, names)
+
+	if path, query, found := strings.Cut(request.RequestURI, "?"); found {
+		query = stripRawQueryParams(query, names)
+		if query == "" {
+			request.RequestURI = path
+		} else {
+			request.RequestURI = path + "?" + query
+		}
+	}
+}
+
+func stripRawQueryParams(rawQuery string, names []string) string {
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !slices.Contains(names, key) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
 func escapePathAndPreserveSlashes(unescaped string) string {
 	parts := strings.Split(unescaped, "/")
 	escapedPath := ""
@@ -138,15 +138,15 @@ var _ = JustBeforeEach(func() {
 
 	fakeRouteServicesClient = &sharedfakes.RoundTripper{}
 
-	p = proxy.NewProxy(testLogger, al, fakeRegistry, ew, conf, r, fakeReporter, routeServiceConfig, tlsConfig, tlsConfig, healthStatus, fakeRouteServicesClient)
+	p = proxy.NewProxy(testLogger, al, fakeRegistry, ew, conf, r, fakeReporter, routeServiceConfig, tlsConfig, tlsConfig, healthStatus, fakeRouteServicesClient, nil, nil)
 
 	if conf.EnableHTTP2 {
-		server := http.Server{Handler: p}
+		server := http.Server{Handler: p, ReadTimeout: conf.FrontendReadTimeout, DisableGeneralOptionsHandler: true}
 		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
 		tlsListener := tls.NewListener(proxyServer, tlsConfig)
 		go server.Serve(tlsListener)
 	} else {
-		server := http.Server{Handler: p}
+		server := http.Server{Handler: p, ReadTimeout: conf.FrontendReadTimeout, DisableGeneralOptionsHandler: true}
 		go server.Serve(proxyServer)
 	}
 })
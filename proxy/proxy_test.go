@@ -10,6 +10,8 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/textproto"
 	"net/url"
 	"os"
 	"regexp"
@@ -32,6 +34,7 @@ import (
 
 	router_http "github.com/mdimiceli/gorouter/common/http"
 	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/errorwriter"
 	"github.com/mdimiceli/gorouter/handlers"
 	"github.com/mdimiceli/gorouter/route"
 	"github.com/mdimiceli/gorouter/test_util"
@@ -80,6 +83,119 @@ var _ = Describe("Proxy", func() {
 			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 		})
 
+		Describe("HTTP/1.0 clients", func() {
+			var ln net.Listener
+
+			AfterEach(func() {
+				ln.Close()
+			})
+
+			registerBackend := func(chunked bool) {
+				ln = test_util.RegisterConnHandler(r, "http10", func(conn *test_util.HttpConn) {
+					defer conn.Close()
+					for {
+						_, err := http.ReadRequest(conn.Reader)
+						if err != nil {
+							return
+						}
+						if chunked {
+							conn.WriteLines([]string{
+								"HTTP/1.1 200 OK",
+								"Transfer-Encoding: chunked",
+								"",
+								"5",
+								"hello",
+								"0",
+							})
+						} else {
+							resp := test_util.NewResponse(http.StatusOK)
+							resp.ContentLength = 5
+							resp.Body = io.NopCloser(strings.NewReader("hello"))
+							conn.WriteResponse(resp)
+						}
+					}
+				})
+			}
+
+			expectClosed := func(conn *test_util.HttpConn) {
+				_, err := conn.Reader.ReadByte()
+				Expect(err).To(MatchError(io.EOF))
+			}
+
+			It("does not chunk a body of unknown length, closing the connection after it instead", func() {
+				registerBackend(true)
+
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"GET / HTTP/1.0",
+					"Host: http10",
+					"Connection: keep-alive",
+				})
+
+				resp, body := conn.ReadResponse()
+				Expect(resp.Proto).To(Equal("HTTP/1.0"))
+				Expect(resp.TransferEncoding).To(BeEmpty())
+				Expect(resp.ContentLength).To(Equal(int64(-1)))
+				Expect(body).To(Equal("hello"))
+				expectClosed(conn)
+			})
+
+			It("closes the connection after a response when the client did not ask to keep it alive", func() {
+				registerBackend(false)
+
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"GET / HTTP/1.0",
+					"Host: http10",
+				})
+
+				resp, body := conn.ReadResponse()
+				Expect(resp.ContentLength).To(Equal(int64(5)))
+				Expect(body).To(Equal("hello"))
+				expectClosed(conn)
+			})
+
+			It("keeps the connection alive when the client asks for it and the length of the response is known", func() {
+				registerBackend(false)
+
+				conn := dialProxy(proxyServer)
+				for i := 0; i < 2; i++ {
+					conn.WriteLines([]string{
+						"GET / HTTP/1.0",
+						"Host: http10",
+						"Connection: keep-alive",
+					})
+
+					resp, body := conn.ReadResponse()
+					Expect(resp.Header.Get("Connection")).To(Equal("keep-alive"))
+					Expect(resp.ContentLength).To(Equal(int64(5)))
+					Expect(body).To(Equal("hello"))
+				}
+			})
+
+			Context("when keeping the connections of HTTP/1.0 clients alive is disabled", func() {
+				BeforeEach(func() {
+					conf.KeepAliveHTTP10Clients = false
+				})
+
+				It("closes the connection after every response", func() {
+					registerBackend(false)
+
+					conn := dialProxy(proxyServer)
+					conn.WriteLines([]string{
+						"GET / HTTP/1.0",
+						"Host: http10",
+						"Connection: keep-alive",
+					})
+
+					resp, body := conn.ReadResponse()
+					Expect(resp.Header.Get("Connection")).To(Equal("close"))
+					Expect(body).To(Equal("hello"))
+					expectClosed(conn)
+				})
+			})
+		})
+
 		Describe("proxying HTTP2", func() {
 			var (
 				registerConfig    test_util.RegisterConfig
@@ -319,6 +435,136 @@ var _ = Describe("Proxy", func() {
 			conn.CheckLine("HTTP/1.1 200 OK")
 		})
 
+		It("routes absolute-form requests by the host of the request target", func() {
+			ln := test_util.RegisterConnHandler(r, "test.io", func(conn *test_util.HttpConn) {
+				conn.CheckLine("GET http://test.io/path HTTP/1.1")
+
+				conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			conn.WriteLines([]string{
+				"GET http://test.io/path HTTP/1.1",
+				"Host: other.io",
+			})
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		Context("when absolute-form requests are rejected", func() {
+			BeforeEach(func() {
+				conf.AbsoluteFormRequests = config.ABSOLUTE_FORM_REQUESTS_REJECT
+			})
+
+			It("responds to absolute-form requests with a 400", func() {
+				ln := test_util.RegisterConnHandler(r, "test.io", func(conn *test_util.HttpConn) {
+					defer GinkgoRecover()
+					Fail("the request should not reach the backend")
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+
+				conn.WriteLines([]string{
+					"GET http://test.io/ HTTP/1.1",
+					"Host: test.io",
+				})
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+
+			It("routes origin-form requests", func() {
+				ln := test_util.RegisterConnHandler(r, "test.io", func(conn *test_util.HttpConn) {
+					conn.CheckLine("GET / HTTP/1.1")
+
+					conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+
+				conn.WriteLines([]string{
+					"GET / HTTP/1.1",
+					"Host: test.io",
+				})
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			})
+		})
+
+		Describe("OPTIONS * requests", func() {
+			It("responds to them with a 200", func() {
+				ln := test_util.RegisterConnHandler(r, "test.io", func(conn *test_util.HttpConn) {
+					defer GinkgoRecover()
+					Fail("the request should not reach the backend")
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+
+				conn.WriteLines([]string{
+					"OPTIONS * HTTP/1.1",
+					"Host: test.io",
+				})
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Allow")).To(BeEmpty())
+			})
+
+			Context("when an Allow header is configured", func() {
+				BeforeEach(func() {
+					conf.AsteriskOptionsAllow = "GET, HEAD, POST, OPTIONS"
+				})
+
+				It("sends it in the response", func() {
+					conn := dialProxy(proxyServer)
+
+					conn.WriteLines([]string{
+						"OPTIONS * HTTP/1.1",
+						"Host: test.io",
+					})
+
+					resp, _ := conn.ReadResponse()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(resp.Header.Get("Allow")).To(Equal("GET, HEAD, POST, OPTIONS"))
+				})
+			})
+
+			Context("when they are forwarded", func() {
+				BeforeEach(func() {
+					conf.AsteriskOptionsRequests = config.ASTERISK_OPTIONS_FORWARD
+				})
+
+				It("forwards them with an unchanged target to the route of the root path", func() {
+					ln := test_util.RegisterConnHandler(r, "test.io", func(conn *test_util.HttpConn) {
+						conn.CheckLine("OPTIONS * HTTP/1.1")
+
+						resp := test_util.NewResponse(http.StatusNoContent)
+						resp.Header.Set("Allow", "GET, OPTIONS")
+						conn.WriteResponse(resp)
+					})
+					defer ln.Close()
+
+					conn := dialProxy(proxyServer)
+
+					conn.WriteLines([]string{
+						"OPTIONS * HTTP/1.1",
+						"Host: test.io",
+					})
+
+					resp, _ := conn.ReadResponse()
+					Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+					Expect(resp.Header.Get("Allow")).To(Equal("GET, OPTIONS"))
+				})
+			})
+		})
+
 		It("maintains percent-encoded values in URLs", func() {
 			shouldEcho("/abc%2b%2f%25%20%22%3F%5Edef", "/abc%2b%2f%25%20%22%3F%5Edef") // +, /, %, <space>, ", £, ^
 		})
@@ -485,6 +731,40 @@ var _ = Describe("Proxy", func() {
 			return headers
 		}
 
+		Describe("TE", func() {
+			It("forwards TE: trailers", func() {
+				req.Header.Set("Connection", "TE")
+				req.Header.Set("TE", "trailers")
+				Expect(getProxiedHeaders(req).Get("TE")).To(Equal("trailers"))
+			})
+
+			It("does not forward other transfer codings", func() {
+				req.Header.Set("TE", "gzip")
+				Expect(getProxiedHeaders(req)).NotTo(HaveKey("Te"))
+			})
+
+			Context("when TE: trailers is forced", func() {
+				BeforeEach(func() {
+					conf.ForceTETrailers = true
+				})
+
+				It("sends TE: trailers when the client did not", func() {
+					Expect(getProxiedHeaders(req).Get("TE")).To(Equal("trailers"))
+				})
+
+				Context("when TE is filtered from the Connection header", func() {
+					BeforeEach(func() {
+						conf.HopByHopHeadersToFilter = []string{"TE"}
+					})
+
+					It("keeps sending TE: trailers", func() {
+						req.Header.Set("Connection", "TE")
+						Expect(getProxiedHeaders(req).Get("TE")).To(Equal("trailers"))
+					})
+				})
+			})
+		})
+
 		Describe("X-Forwarded-For", func() {
 			It("sets X-Forwarded-For", func() {
 				Expect(getProxiedHeaders(req).Get("X-Forwarded-For")).To(Equal("127.0.0.1"))
@@ -495,6 +775,37 @@ var _ = Describe("Proxy", func() {
 					Expect(getProxiedHeaders(req).Get("X-Forwarded-For")).To(Equal("1.2.3.4, 127.0.0.1"))
 				})
 			})
+
+			Context("when the mode is replace", func() {
+				BeforeEach(func() {
+					conf.ForwardedForMode = config.FORWARDED_FOR_REPLACE
+				})
+
+				It("sets X-Forwarded-For", func() {
+					Expect(getProxiedHeaders(req).Get("X-Forwarded-For")).To(Equal("127.0.0.1"))
+				})
+
+				It("replaces an existing chain with the client IP", func() {
+					req.Header.Add("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
+					req.Header.Add("X-Forwarded-For", "9.10.11.12")
+					Expect(getProxiedHeaders(req)["X-Forwarded-For"]).To(Equal([]string{"127.0.0.1"}))
+				})
+			})
+
+			Context("when the mode is remove", func() {
+				BeforeEach(func() {
+					conf.ForwardedForMode = config.FORWARDED_FOR_REMOVE
+				})
+
+				It("does not set X-Forwarded-For", func() {
+					Expect(getProxiedHeaders(req)).NotTo(HaveKey("X-Forwarded-For"))
+				})
+
+				It("removes an existing chain", func() {
+					req.Header.Add("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
+					Expect(getProxiedHeaders(req)).NotTo(HaveKey("X-Forwarded-For"))
+				})
+			})
 		})
 
 		Describe("X-Request-Start", func() {
@@ -509,6 +820,125 @@ var _ = Describe("Proxy", func() {
 					Expect(getProxiedHeaders(req)["X-Request-Start"]).To(Equal([]string{"", "user-set2"}))
 				})
 			})
+
+			Context("when the policy is to overwrite the header", func() {
+				BeforeEach(func() {
+					conf.XRequestStartPolicy = config.X_REQUEST_START_OVERWRITE
+				})
+
+				It("appends X-Request-Start", func() {
+					Expect(getProxiedHeaders(req).Get("X-Request-Start")).To(MatchRegexp("^\\d{10}\\d{3}$"))
+				})
+
+				It("replaces a header set by the client", func() {
+					req.Header.Add("X-Request-Start", "")
+					req.Header.Add("X-Request-Start", "user-set2")
+					values := getProxiedHeaders(req)["X-Request-Start"]
+					Expect(values).To(HaveLen(1))
+					Expect(values[0]).To(MatchRegexp("^\\d{10}\\d{3}$"))
+				})
+			})
+		})
+
+		Describe("request received header", func() {
+			It("is not set by default", func() {
+				Expect(getProxiedHeaders(req)).NotTo(HaveKey("X-Received-At"))
+			})
+
+			Context("when a header is configured", func() {
+				BeforeEach(func() {
+					conf.RequestReceivedHeader = "X-Received-At"
+				})
+
+				It("sets it to the time the request was received in epoch milliseconds", func() {
+					before := time.Now().UnixMilli()
+					value := getProxiedHeaders(req).Get("X-Received-At")
+					after := time.Now().UnixMilli()
+
+					Expect(value).To(MatchRegexp("^\\d{13}$"))
+					receivedAt, err := strconv.ParseInt(value, 10, 64)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(receivedAt).To(BeNumerically(">=", before))
+					Expect(receivedAt).To(BeNumerically("<=", after))
+				})
+
+				It("replaces a header set by the client", func() {
+					req.Header.Set("X-Received-At", "user-set")
+					values := getProxiedHeaders(req)["X-Received-At"]
+					Expect(values).To(HaveLen(1))
+					Expect(values[0]).NotTo(Equal("user-set"))
+				})
+
+				Context("when the format is RFC 3339", func() {
+					BeforeEach(func() {
+						conf.RequestReceivedFormat = config.REQUEST_RECEIVED_FORMAT_RFC3339
+					})
+
+					It("sets it to the time the request was received in RFC 3339", func() {
+						before := time.Now()
+						value := getProxiedHeaders(req).Get("X-Received-At")
+						after := time.Now()
+
+						receivedAt, err := time.Parse(time.RFC3339Nano, value)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(value).To(HaveSuffix("Z"))
+						Expect(receivedAt).To(BeTemporally(">=", before))
+						Expect(receivedAt).To(BeTemporally("<=", after))
+					})
+				})
+			})
+		})
+
+		Describe("client IP header", func() {
+			It("is not set by default", func() {
+				Expect(getProxiedHeaders(req)).NotTo(HaveKey("True-Client-Ip"))
+			})
+
+			Context("when a header is configured", func() {
+				BeforeEach(func() {
+					conf.ClientIPHeader = "True-Client-IP"
+				})
+
+				It("sets it to the IP of the peer", func() {
+					req.Header.Add("X-Forwarded-For", "1.2.3.4")
+					Expect(getProxiedHeaders(req).Get("True-Client-IP")).To(Equal("127.0.0.1"))
+				})
+
+				It("replaces a header set by the client", func() {
+					req.Header.Set("True-Client-IP", "1.2.3.4")
+					Expect(getProxiedHeaders(req)["True-Client-Ip"]).To(Equal([]string{"127.0.0.1"}))
+				})
+
+				Context("when there are trusted proxies in front of gorouter", func() {
+					BeforeEach(func() {
+						conf.ClientIPTrustedProxyDepth = 2
+					})
+
+					It("skips them in the X-Forwarded-For chain", func() {
+						req.Header.Add("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
+						req.Header.Add("X-Forwarded-For", "9.10.11.12")
+						Expect(getProxiedHeaders(req).Get("True-Client-IP")).To(Equal("5.6.7.8"))
+					})
+
+					It("uses the first IP of a shorter chain", func() {
+						req.Header.Add("X-Forwarded-For", "1.2.3.4")
+						Expect(getProxiedHeaders(req).Get("True-Client-IP")).To(Equal("1.2.3.4"))
+					})
+
+					Context("when X-Forwarded-For is removed", func() {
+						BeforeEach(func() {
+							conf.ForwardedForMode = config.FORWARDED_FOR_REMOVE
+						})
+
+						It("still derives the client IP from it", func() {
+							req.Header.Add("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
+							headers := getProxiedHeaders(req)
+							Expect(headers.Get("True-Client-IP")).To(Equal("1.2.3.4"))
+							Expect(headers).NotTo(HaveKey("X-Forwarded-For"))
+						})
+					})
+				})
+			})
 		})
 
 		Describe("X-CF-InstanceID", func() {
@@ -613,22 +1043,413 @@ var _ = Describe("Proxy", func() {
 			Expect(resp.Header.Get(router_http.VcapRouterHeader)).To(Equal(conf.Ip))
 		})
 
-		It("trace headers not added on incorrect TraceKey", func() {
-			ln := test_util.RegisterConnHandler(r, "trace-test", func(conn *test_util.HttpConn) {
-				_, err := http.ReadRequest(conn.Reader)
-				Expect(err).NotTo(HaveOccurred())
-
-				resp := test_util.NewResponse(http.StatusOK)
-				conn.WriteResponse(resp)
-				conn.Close()
+		Context("when emitting the backend instance header is enabled", func() {
+			BeforeEach(func() {
+				conf.EmitBackendInstanceHeader = true
 			})
-			defer ln.Close()
 
-			conn := dialProxy(proxyServer)
+			It("sets the header to the instance that served the request after retries", func() {
+				ln := test_util.RegisterConnHandler(r, "instance-test", func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
 
-			req := test_util.NewRequest("GET", "trace-test", "/", nil)
-			req.Header.Set(router_http.VcapTraceHeader, "a_bad_trace_key")
-			conn.WriteRequest(req)
+					resp := test_util.NewResponse(http.StatusOK)
+					conn.WriteResponse(resp)
+					conn.Close()
+				}, test_util.RegisterConfig{InstanceId: "good-instance"})
+				defer ln.Close()
+
+				test_util.RegisterAddr(r, "instance-test", "localhost:81", test_util.RegisterConfig{
+					InstanceId: "bad-instance",
+				})
+
+				for i := 0; i < 5; i++ {
+					conn := dialProxy(proxyServer)
+
+					req := test_util.NewRequest("GET", "instance-test", "/", nil)
+					conn.WriteRequest(req)
+
+					resp, _ := conn.ReadResponse()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(resp.Header.Get(router_http.CfInstanceHeader)).To(Equal("good-instance"))
+				}
+			})
+		})
+
+		Context("when emitting the upstream response time header is enabled", func() {
+			BeforeEach(func() {
+				conf.EmitUpstreamResponseTimeHeader = true
+			})
+
+			It("sets the header to the time the backend took to respond", func() {
+				ln := test_util.RegisterConnHandler(r, "upstream-time-test", func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					time.Sleep(200 * time.Millisecond)
+					resp := test_util.NewResponse(http.StatusOK)
+					conn.WriteResponse(resp)
+					conn.Close()
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+
+				req := test_util.NewRequest("GET", "upstream-time-test", "/", nil)
+				started := time.Now()
+				conn.WriteRequest(req)
+
+				resp, _ := conn.ReadResponse()
+				elapsed := time.Since(started)
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				upstreamTime, err := strconv.ParseFloat(resp.Header.Get(router_http.UpstreamResponseTimeHeader), 64)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(upstreamTime).To(BeNumerically(">=", 0.2))
+				Expect(upstreamTime).To(BeNumerically("<=", elapsed.Seconds()))
+			})
+		})
+
+		Context("when a response request id header is configured", func() {
+			BeforeEach(func() {
+				conf.ResponseRequestIDHeader = "X-Request-Id"
+			})
+
+			It("sets the header to the request id sent to the backend", func() {
+				var backendRequestID string
+				ln := test_util.RegisterConnHandler(r, "request-id-test", func(conn *test_util.HttpConn) {
+					req, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+					backendRequestID = req.Header.Get(handlers.VcapRequestIdHeader)
+
+					resp := test_util.NewResponse(http.StatusOK)
+					resp.Header.Set("X-Request-Id", "backend-request-id")
+					conn.WriteResponse(resp)
+					conn.Close()
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "request-id-test", "/", nil))
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(backendRequestID).NotTo(BeEmpty())
+				Expect(resp.Header.Values("X-Request-Id")).To(Equal([]string{backendRequestID}))
+			})
+
+			It("sets the header on errors generated by gorouter", func() {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+				addr := ln.Addr().String()
+				ln.Close()
+				test_util.RegisterAddr(r, "unreachable-request-id-test", addr, test_util.RegisterConfig{})
+
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "unreachable-request-id-test", "/", nil))
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+				Expect(resp.Header.Get("X-Request-Id")).NotTo(BeEmpty())
+			})
+		})
+
+		Describe("the Date header", func() {
+			var ln net.Listener
+
+			JustBeforeEach(func() {
+				ln = test_util.RegisterConnHandler(r, "date-test", func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					resp := test_util.NewResponse(http.StatusOK)
+					resp.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+					conn.WriteResponse(resp)
+					conn.Close()
+				})
+			})
+
+			AfterEach(func() {
+				ln.Close()
+			})
+
+			getDate := func() string {
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "date-test", "/", nil))
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				return resp.Header.Get("Date")
+			}
+
+			It("preserves the Date header of the backend", func() {
+				Expect(getDate()).To(Equal("Mon, 02 Jan 2006 15:04:05 GMT"))
+			})
+
+			Context("when overriding the Date header of the backend is enabled", func() {
+				BeforeEach(func() {
+					conf.OverrideBackendDateHeader = true
+				})
+
+				It("responds with the Date of the router", func() {
+					date, err := http.ParseTime(getDate())
+					Expect(err).NotTo(HaveOccurred())
+					Expect(date).To(BeTemporally("~", time.Now(), time.Minute))
+				})
+			})
+		})
+
+		Describe("a draining endpoint", func() {
+			It("finishes its requests in flight but receives no new ones", func() {
+				received := make(chan struct{})
+				release := make(chan struct{})
+				drainingLn := test_util.RegisterConnHandler(r, "drain-test", func(conn *test_util.HttpConn) {
+					defer conn.Close()
+					for {
+						_, err := http.ReadRequest(conn.Reader)
+						if err != nil {
+							return
+						}
+						received <- struct{}{}
+						<-release
+
+						resp := test_util.NewResponse(http.StatusOK)
+						resp.Header.Set("X-Backend", "draining")
+						conn.WriteResponse(resp)
+					}
+				})
+				defer drainingLn.Close()
+
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "drain-test", "/", nil))
+				Eventually(received).Should(Receive())
+
+				otherLn := test_util.RegisterConnHandler(r, "drain-test", func(conn *test_util.HttpConn) {
+					defer conn.Close()
+					for {
+						_, err := http.ReadRequest(conn.Reader)
+						if err != nil {
+							return
+						}
+						resp := test_util.NewResponse(http.StatusOK)
+						resp.Header.Set("X-Backend", "other")
+						conn.WriteResponse(resp)
+					}
+				})
+				defer otherLn.Close()
+
+				Expect(r.DrainEndpoint(drainingLn.Addr().String())).To(Equal(1))
+				close(release)
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("X-Backend")).To(Equal("draining"))
+
+				for i := 0; i < 3; i++ {
+					conn.WriteRequest(test_util.NewRequest("GET", "drain-test", "/", nil))
+					resp, _ = conn.ReadResponse()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(resp.Header.Get("X-Backend")).To(Equal("other"))
+				}
+			})
+		})
+
+		Describe("the maximum response body size of a route", func() {
+			var ln net.Listener
+
+			JustBeforeEach(func() {
+				ln = test_util.RegisterConnHandler(r, "max-body-test", func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					resp := test_util.NewResponse(http.StatusOK)
+					resp.ContentLength = 1024
+					resp.Body = io.NopCloser(strings.NewReader(strings.Repeat("a", 1024)))
+					conn.WriteResponse(resp)
+					conn.Close()
+				}, test_util.RegisterConfig{MaxResponseBodyBytes: 100})
+			})
+
+			AfterEach(func() {
+				ln.Close()
+			})
+
+			It("aborts the response once the body exceeds the maximum", func() {
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "max-body-test", "/", nil))
+
+				resp, err := http.ReadResponse(conn.Reader, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+				Expect(len(body)).To(BeNumerically("<=", 100))
+
+				Expect(fakeReporter.CaptureResponseBodyTooLargeCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when a frontend write stall timeout is set", func() {
+			const bodySize = 256 * 1024 * 1024
+
+			var (
+				ln          net.Listener
+				written     int64
+				backendDone chan struct{}
+			)
+
+			BeforeEach(func() {
+				conf.FrontendWriteStallTimeout = 200 * time.Millisecond
+				atomic.StoreInt64(&written, 0)
+				backendDone = make(chan struct{})
+			})
+
+			JustBeforeEach(func() {
+				ln = test_util.RegisterConnHandler(r, "fast-app", func(conn *test_util.HttpConn) {
+					defer GinkgoRecover()
+					defer close(backendDone)
+
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					conn.WriteLines([]string{
+						"HTTP/1.1 200 OK",
+						fmt.Sprintf("Content-Length: %d", bodySize),
+					})
+
+					chunk := bytes.Repeat([]byte("a"), 32*1024)
+					for atomic.LoadInt64(&written) < bodySize {
+						n, err := conn.Conn.Write(chunk)
+						atomic.AddInt64(&written, int64(n))
+						if err != nil {
+							return
+						}
+					}
+				})
+			})
+
+			AfterEach(func() {
+				ln.Close()
+			})
+
+			It("aborts the response to a client which stops reading without buffering the backend response", func() {
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "fast-app", "/", nil))
+
+				resp, err := http.ReadResponse(conn.Reader, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				// the client does not read the body
+				Eventually(fakeReporter.CaptureSlowClientAbortedCallCount, 5*time.Second).Should(Equal(1))
+				Eventually(backendDone, 5*time.Second).Should(BeClosed())
+
+				// only what fits into the socket buffers and the copy buffer
+				// of the reverse proxy was read from the backend
+				Expect(atomic.LoadInt64(&written)).To(BeNumerically("<", bodySize/4))
+			})
+
+			It("does not abort the response to a client which keeps reading", func() {
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "fast-app", "/", nil))
+
+				resp, err := http.ReadResponse(conn.Reader, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				n, err := io.Copy(io.Discard, resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(n).To(Equal(int64(bodySize)))
+				Expect(fakeReporter.CaptureSlowClientAbortedCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("a route which sends HEAD requests as GET requests", func() {
+			var (
+				ln             net.Listener
+				registerConfig test_util.RegisterConfig
+				encoding       string
+			)
+
+			BeforeEach(func() {
+				registerConfig = test_util.RegisterConfig{HeadAsGet: true}
+				encoding = ""
+			})
+
+			JustBeforeEach(func() {
+				ln = test_util.RegisterConnHandler(r, "head-as-get-test", func(conn *test_util.HttpConn) {
+					req, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					resp := test_util.NewResponse(http.StatusMethodNotAllowed)
+					if req.Method == http.MethodGet {
+						resp = test_util.NewResponse(http.StatusOK)
+						resp.Header.Set("Content-Type", "text/plain")
+						if encoding != "" {
+							resp.Header.Set("Content-Encoding", encoding)
+						}
+						resp.ContentLength = 5
+						resp.Body = io.NopCloser(strings.NewReader("hello"))
+					}
+					conn.WriteResponse(resp)
+					conn.Close()
+				}, registerConfig)
+			})
+
+			AfterEach(func() {
+				ln.Close()
+			})
+
+			It("responds to HEAD requests with the headers of the GET response and no body", func() {
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("HEAD", "head-as-get-test", "/", nil))
+
+				resp, err := http.ReadResponse(conn.Reader, &http.Request{Method: http.MethodHead})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("text/plain"))
+				Expect(resp.ContentLength).To(Equal(int64(5)))
+
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(body).To(BeEmpty())
+			})
+
+			Context("when the route also decompresses responses", func() {
+				BeforeEach(func() {
+					registerConfig.DecompressResponse = true
+					encoding = "gzip"
+				})
+
+				It("responds to HEAD requests with the headers of the compressed GET response", func() {
+					conn := dialProxy(proxyServer)
+					conn.WriteRequest(test_util.NewRequest("HEAD", "head-as-get-test", "/", nil))
+
+					resp, err := http.ReadResponse(conn.Reader, &http.Request{Method: http.MethodHead})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+					Expect(resp.ContentLength).To(Equal(int64(5)))
+				})
+			})
+		})
+
+		It("trace headers not added on incorrect TraceKey", func() {
+			ln := test_util.RegisterConnHandler(r, "trace-test", func(conn *test_util.HttpConn) {
+				_, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				resp := test_util.NewResponse(http.StatusOK)
+				conn.WriteResponse(resp)
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "trace-test", "/", nil)
+			req.Header.Set(router_http.VcapTraceHeader, "a_bad_trace_key")
+			conn.WriteRequest(req)
 
 			resp, _ := conn.ReadResponse()
 			Expect(resp.Header.Get(router_http.VcapBackendHeader)).To(Equal(""))
@@ -689,56 +1510,194 @@ var _ = Describe("Proxy", func() {
 				conn.WriteResponse(resp)
 				conn.Close()
 			})
-			defer ln.Close()
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "not-modified", "/", nil)
+
+			req.Header.Set("Connection", "close")
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+			Expect(resp.TransferEncoding).To(BeNil())
+		})
+
+		It("transfers chunked encodings", func() {
+			ln := test_util.RegisterHTTPHandler(r, "chunk", func(w http.ResponseWriter, r *http.Request) {
+				flusher, ok := w.(http.Flusher)
+				Expect(ok).To(BeTrue())
+
+				for i := 0; i < 3; i++ {
+					_, err := w.Write([]byte("hello"))
+					Expect(err).NotTo(HaveOccurred())
+					flusher.Flush()
+				}
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "chunk", "/", nil)
+
+			err := req.Write(conn)
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := http.ReadResponse(conn.Reader, &http.Request{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.TransferEncoding).To(Equal([]string{"chunked"}))
+
+			// Expect 3 individual reads to complete
+			b := make([]byte, 5)
+			for i := 0; i < 3; i++ {
+				n, err := resp.Body.Read(b[0:])
+				if err != nil {
+					Expect(err).To(Equal(io.EOF))
+				}
+				Expect(n).To(Equal(5))
+				Expect(string(b[0:n])).To(Equal("hello"))
+			}
+		})
+
+		// The HTTP/1.1 server drops the Content-Length of chunked requests
+		// while parsing them, as RFC 9112 requires of intermediaries, so such
+		// requests are forwarded chunked unless strict request framing
+		// rejects them, see the router tests.
+		Describe("request framing", func() {
+			var backendHeaders chan textproto.MIMEHeader
+
+			BeforeEach(func() {
+				backendHeaders = make(chan textproto.MIMEHeader, 1)
+			})
+
+			readRawChunkedRequest := func(conn *test_util.HttpConn) {
+				// read the head without http.ReadRequest, which drops conflicting headers itself
+				tp := textproto.NewReader(conn.Reader)
+				_, err := tp.ReadLine()
+				Expect(err).NotTo(HaveOccurred())
+				header, err := tp.ReadMIMEHeader()
+				Expect(err).NotTo(HaveOccurred())
+				backendHeaders <- header
+
+				body, err := io.ReadAll(httputil.NewChunkedReader(conn.Reader))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(body)).To(Equal("body"))
+
+				conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+				conn.Close()
+			}
+
+			It("forwards well-formed chunked requests", func() {
+				ln := test_util.RegisterConnHandler(r, "framing", readRawChunkedRequest)
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"POST / HTTP/1.1",
+					"Host: framing",
+					"Transfer-Encoding: chunked",
+				})
+				conn.Writer.WriteString("4\r\nbody\r\n0\r\n\r\n")
+				conn.Writer.Flush()
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var header textproto.MIMEHeader
+				Eventually(backendHeaders).Should(Receive(&header))
+				Expect(header.Get("Transfer-Encoding")).To(Equal("chunked"))
+				Expect(header).NotTo(HaveKey("Content-Length"))
+			})
 
-			conn := dialProxy(proxyServer)
+			It("never forwards a Content-Length together with a chunked body", func() {
+				ln := test_util.RegisterConnHandler(r, "framing", readRawChunkedRequest)
+				defer ln.Close()
 
-			req := test_util.NewRequest("GET", "not-modified", "/", nil)
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"POST / HTTP/1.1",
+					"Host: framing",
+					"Content-Length: 100",
+					"Transfer-Encoding: chunked",
+				})
+				conn.Writer.WriteString("4\r\nbody\r\n0\r\n\r\n")
+				conn.Writer.Flush()
 
-			req.Header.Set("Connection", "close")
-			conn.WriteRequest(req)
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
-			resp, _ := conn.ReadResponse()
-			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
-			Expect(resp.TransferEncoding).To(BeNil())
+				var header textproto.MIMEHeader
+				Eventually(backendHeaders).Should(Receive(&header))
+				Expect(header.Get("Transfer-Encoding")).To(Equal("chunked"))
+				Expect(header).NotTo(HaveKey("Content-Length"))
+			})
 		})
 
-		It("transfers chunked encodings", func() {
-			ln := test_util.RegisterHTTPHandler(r, "chunk", func(w http.ResponseWriter, r *http.Request) {
-				flusher, ok := w.(http.Flusher)
-				Expect(ok).To(BeTrue())
+		// Malformed request headers are rejected by the server with a 400
+		// before any handler runs: header names must be tokens and values
+		// must not contain control characters. Folded header lines
+		// (obs-fold) are joined into a single line, unless strict header
+		// validation rejects them, see the router tests.
+		Describe("malformed request headers", func() {
+			It("rejects headers with a bare carriage return", func() {
+				ln := test_util.RegisterConnHandler(r, "malformed", func(conn *test_util.HttpConn) {
+					defer GinkgoRecover()
+					Fail("the request should not reach the backend")
+				})
+				defer ln.Close()
 
-				for i := 0; i < 3; i++ {
-					_, err := w.Write([]byte("hello"))
-					Expect(err).NotTo(HaveOccurred())
-					flusher.Flush()
-				}
-			})
-			defer ln.Close()
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"GET / HTTP/1.1",
+					"Host: malformed",
+					"X-Injected: foo\rbar",
+				})
 
-			conn := dialProxy(proxyServer)
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
 
-			req := test_util.NewRequest("GET", "chunk", "/", nil)
+			It("forwards folded headers as a single line", func() {
+				backendLines := make(chan []string, 1)
+				ln := test_util.RegisterConnHandler(r, "folded", func(conn *test_util.HttpConn) {
+					tp := textproto.NewReader(conn.Reader)
+					_, err := tp.ReadLine()
+					Expect(err).NotTo(HaveOccurred())
+					var lines []string
+					for {
+						line, err := tp.ReadLine()
+						Expect(err).NotTo(HaveOccurred())
+						if line == "" {
+							break
+						}
+						Expect(line).NotTo(HavePrefix(" "), "the backend must not receive folded lines")
+						lines = append(lines, line)
+					}
+					backendLines <- lines
 
-			err := req.Write(conn)
-			Expect(err).NotTo(HaveOccurred())
+					conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+					conn.Close()
+				})
+				defer ln.Close()
 
-			resp, err := http.ReadResponse(conn.Reader, &http.Request{})
-			Expect(err).NotTo(HaveOccurred())
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"GET / HTTP/1.1",
+					"Host: folded",
+					"X-Folded: foo",
+					" bar",
+				})
 
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
-			Expect(resp.TransferEncoding).To(Equal([]string{"chunked"}))
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
-			// Expect 3 individual reads to complete
-			b := make([]byte, 5)
-			for i := 0; i < 3; i++ {
-				n, err := resp.Body.Read(b[0:])
-				if err != nil {
-					Expect(err).To(Equal(io.EOF))
-				}
-				Expect(n).To(Equal(5))
-				Expect(string(b[0:n])).To(Equal("hello"))
-			}
+				var lines []string
+				Eventually(backendLines).Should(Receive(&lines))
+				Expect(lines).To(ContainElement("X-Folded: foo bar"))
+			})
 		})
 
 		It("disables compression", func() {
@@ -877,6 +1836,91 @@ var _ = Describe("Proxy", func() {
 					Expect(line).To(Equal(message))
 				}
 			})
+
+			It("keeps streaming the response after the client closes its write side", func() {
+				clientHalfClosed := make(chan struct{})
+				ln := test_util.RegisterConnHandler(r, "half-close", func(conn *test_util.HttpConn) {
+					defer conn.Close()
+
+					req, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+					_, err = io.ReadAll(req.Body)
+					Expect(err).NotTo(HaveOccurred())
+
+					conn.Writer.WriteString("HTTP/1.1 200 OK\r\n" +
+						"Content-Type: text/plain\r\n" +
+						"Content-Length: 10\r\n" +
+						"\r\n" +
+						"first\n")
+					conn.Writer.Flush()
+
+					<-clientHalfClosed
+					// give the proxy time to notice the EOF from the client
+					time.Sleep(100 * time.Millisecond)
+					conn.Writer.WriteString("end\n")
+					conn.Writer.Flush()
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				defer conn.Close()
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+				conn.Writer.WriteString("POST / HTTP/1.1\r\n" +
+					"Host: half-close\r\n" +
+					"Content-Length: 4\r\n" +
+					"\r\n" +
+					"body")
+				conn.Writer.Flush()
+
+				resp, err := http.ReadResponse(conn.Reader, &http.Request{})
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				reader := bufio.NewReader(resp.Body)
+
+				line, err := reader.ReadString('\n')
+				Expect(err).NotTo(HaveOccurred())
+				Expect(line).To(Equal("first\n"))
+
+				Expect(conn.Conn.(*net.TCPConn).CloseWrite()).To(Succeed())
+				close(clientHalfClosed)
+
+				rest, err := io.ReadAll(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(rest)).To(Equal("end\n"))
+			})
+
+			It("cancels the request when the client closes its write side before the response started", func() {
+				backendClosed := make(chan struct{})
+				ln := test_util.RegisterConnHandler(r, "half-close-early", func(conn *test_util.HttpConn) {
+					defer conn.Close()
+
+					req, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+					_, err = io.ReadAll(req.Body)
+					Expect(err).NotTo(HaveOccurred())
+
+					// the proxy closes the connection once the request is cancelled
+					_, err = conn.Reader.ReadByte()
+					if err != nil {
+						close(backendClosed)
+					}
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				defer conn.Close()
+
+				conn.Writer.WriteString("POST / HTTP/1.1\r\n" +
+					"Host: half-close-early\r\n" +
+					"Content-Length: 4\r\n" +
+					"\r\n" +
+					"body")
+				conn.Writer.Flush()
+				Expect(conn.Conn.(*net.TCPConn).CloseWrite()).To(Succeed())
+
+				Eventually(backendClosed, 5*time.Second).Should(BeClosed())
+			})
 		})
 
 		It("retries on POST requests if nothing was written", func() {
@@ -1069,6 +2113,63 @@ var _ = Describe("Proxy", func() {
 			})
 		})
 
+		Context("when a time to first byte from backends is set", func() {
+			BeforeEach(func() {
+				conf.BackendTimeToFirstByte = 100 * time.Millisecond
+			})
+
+			It("responds with a 504 when the backend stalls before responding", func() {
+				ln := test_util.RegisterConnHandler(r, "stalling-app", func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					time.Sleep(500 * time.Millisecond)
+					resp := test_util.NewResponse(http.StatusOK)
+					conn.WriteResponse(resp)
+					conn.Close()
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+
+				req := test_util.NewRequest("GET", "stalling-app", "/", nil)
+
+				started := time.Now()
+				conn.WriteRequest(req)
+
+				resp, err := http.ReadResponse(conn.Reader, &http.Request{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+				Expect(time.Since(started)).To(BeNumerically("<", 500*time.Millisecond))
+				Expect(fakeReporter.CaptureBackendFirstByteTimeoutCallCount()).To(Equal(1))
+				Expect(fakeReporter.CaptureBadGatewayCallCount()).To(Equal(0))
+			})
+
+			It("does not limit a response body streamed after the first byte", func() {
+				ln := test_util.RegisterConnHandler(r, "streaming-app", func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+
+					conn.WriteLines([]string{
+						"HTTP/1.1 200 OK",
+						"Content-Length: 5",
+					})
+					time.Sleep(300 * time.Millisecond)
+					conn.WriteLine("hello")
+					conn.Close()
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "streaming-app", "/", nil))
+
+				resp, body := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(body).To(Equal("hello"))
+			})
+		})
+
 		It("request terminates with slow response", func() {
 			ln := test_util.RegisterConnHandler(r, "slow-app", func(conn *test_util.HttpConn) {
 				_, err := http.ReadRequest(conn.Reader)
@@ -1430,6 +2531,47 @@ var _ = Describe("Proxy", func() {
 			Expect(testLogger.(*test_util.TestZapLogger).Lines(zap.WarnLevel)).To(ContainElement(ContainSubstring("deprecated-semicolon-params")))
 
 		})
+
+		Context("when the route strips query params", func() {
+			var requestURI chan string
+
+			BeforeEach(func() {
+				requestURI = make(chan string, 1)
+			})
+
+			sendRequest := func(uri string) {
+				ln := test_util.RegisterConnHandler(r, "strip-query-test", func(conn *test_util.HttpConn) {
+					req, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+					requestURI <- req.RequestURI
+
+					conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+					conn.Close()
+				}, test_util.RegisterConfig{StripQueryParams: []string{"utm_source", "fbclid"}})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				conn.WriteRequest(test_util.NewRequest("GET", "strip-query-test", uri, nil))
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			}
+
+			It("removes the named params and forwards the rest intact", func() {
+				sendRequest("/path?a=1&utm_source=mail&b=%20x&a=2&fbclid=abc&fbclid=def&c")
+				Eventually(requestURI).Should(Receive(Equal("/path?a=1&b=%20x&a=2&c")))
+			})
+
+			It("drops the query string when only stripped params were present", func() {
+				sendRequest("/path?utm_source=mail")
+				Eventually(requestURI).Should(Receive(Equal("/path")))
+			})
+
+			It("leaves requests without the named params untouched", func() {
+				sendRequest("/path?utm_sourcex=1&b=2")
+				Eventually(requestURI).Should(Receive(Equal("/path?utm_sourcex=1&b=2")))
+			})
+		})
 	})
 	Describe("Access Logging", func() {
 		It("logs a request", func() {
@@ -1481,6 +2623,44 @@ var _ = Describe("Proxy", func() {
 			Expect(b[len(b)-1]).To(Equal(byte('\n')))
 		})
 
+		Context("when the client is too slow sending the request body", func() {
+			BeforeEach(func() {
+				conf.FrontendReadTimeout = 200 * time.Millisecond
+			})
+
+			It("responds with a 408 and logs and reports the timeout", func() {
+				ln := test_util.RegisterConnHandler(r, "slow-client", func(conn *test_util.HttpConn) {
+					_, _ = io.Copy(io.Discard, conn.Reader)
+					conn.Close()
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+				conn.WriteLines([]string{
+					"POST / HTTP/1.1",
+					"Host: slow-client",
+					"Content-Length: 10",
+				})
+				conn.Writer.WriteString("ABCD")
+				conn.Writer.Flush()
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusRequestTimeout))
+				Expect(resp.Header.Get(router_http.CfRouterError)).To(HavePrefix("client_request_timeout"))
+				Expect(fakeReporter.CaptureClientRequestTimeoutCallCount()).To(Equal(1))
+				Expect(fakeReporter.CaptureBadGatewayCallCount()).To(Equal(0))
+
+				Eventually(func() (string, error) {
+					b, err := os.ReadFile(f.Name())
+					return string(b), err
+				}).Should(ContainSubstring(`"POST / HTTP/1.1" 408`))
+
+				b, err := os.ReadFile(f.Name())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring(`x_cf_routererror:"client_request_timeout`))
+			})
+		})
+
 		It("logs a websocket request", func() {
 			ln := test_util.RegisterWSHandler(r, "ws-test", func(conn *websocket.Conn) {
 				msgBuf := make([]byte, 100)
@@ -2274,6 +3454,55 @@ var _ = Describe("Proxy", func() {
 		})
 	})
 
+	Describe("Cache-Control of errors", func() {
+		var originalErrorWriter errorwriter.ErrorWriter
+
+		BeforeEach(func() {
+			originalErrorWriter = ew
+			ew = errorwriter.NewCacheControlErrorWriter(ew, "no-store")
+			conf.ErrorCacheControl = "no-store"
+		})
+
+		AfterEach(func() {
+			ew = originalErrorWriter
+		})
+
+		It("sets no-store on errors generated by gorouter", func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			addr := ln.Addr().String()
+			ln.Close()
+			test_util.RegisterAddr(r, "unreachable-app", addr, test_util.RegisterConfig{})
+
+			conn := dialProxy(proxyServer)
+			conn.WriteRequest(test_util.NewRequest("GET", "unreachable-app", "/", nil))
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(resp.Header.Get("Cache-Control")).To(Equal("no-store"))
+		})
+
+		It("leaves the caching headers of backend errors untouched", func() {
+			ln := test_util.RegisterConnHandler(r, "failing-app", func(conn *test_util.HttpConn) {
+				_, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				resp := test_util.NewResponse(http.StatusInternalServerError)
+				resp.Header.Set("Cache-Control", "max-age=60")
+				conn.WriteResponse(resp)
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+			conn.WriteRequest(test_util.NewRequest("GET", "failing-app", "/", nil))
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(resp.Header.Get("Cache-Control")).To(Equal("max-age=60"))
+		})
+	})
+
 	Describe("User-Agent Healthcheck", func() {
 		It("responds to load balancer check", func() {
 			conn := dialProxy(proxyServer)
@@ -2584,6 +3813,29 @@ var _ = Describe("Proxy", func() {
 			conn.Close()
 		})
 
+		It("responds with 502 when the backend switches protocols without an upgrade request", func() {
+			ln := test_util.RegisterConnHandler(r, "ws", func(conn *test_util.HttpConn) {
+				_, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				resp := test_util.NewResponse(http.StatusSwitchingProtocols)
+				resp.Header.Set("Upgrade", "Websocket")
+				resp.Header.Set("Connection", "Upgrade")
+				conn.WriteResponse(resp)
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "ws", "/chat", nil)
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+
+			conn.Close()
+		})
+
 		It("upgrades for a WebSocket request with comma-separated Connection header", func() {
 			done := make(chan bool)
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/mdimiceli/gorouter/common/health"
+	"github.com/mdimiceli/gorouter/config"
 
 	fakelogger "github.com/mdimiceli/gorouter/accesslog/fakes"
 	"github.com/mdimiceli/gorouter/errorwriter"
@@ -75,7 +76,7 @@ var _ = Describe("Proxy Unit tests", func() {
 
 			skipSanitization = func(req *http.Request) bool { return false }
 			proxyObj = proxy.NewProxy(fakeLogger, fakeAccessLogger, fakeRegistry, ew, conf, r, combinedReporter,
-				routeServiceConfig, tlsConfig, tlsConfig, &health.Health{}, rt)
+				routeServiceConfig, tlsConfig, tlsConfig, &health.Health{}, rt, nil, nil)
 
 			r.Register(route.Uri("some-app"), &route.Endpoint{Stats: route.NewStats()})
 
@@ -117,6 +118,28 @@ var _ = Describe("Proxy Unit tests", func() {
 							Eventually(fakeLogger).Should(Say("enable-full-duplex-err"))
 						})
 					})
+
+					Context("when enabling duplex fails and the failure mode is degrade", func() {
+						BeforeEach(func() {
+							conf.FullDuplexFailureMode = config.FULL_DUPLEX_FAILURE_DEGRADE
+						})
+
+						It("serves requests in half-duplex mode and warns once", func() {
+							responseRecorder.EnableFullDuplexErr = errors.New("unsupported")
+							for i := 0; i < 2; i++ {
+								req := test_util.NewRequest("GET", "some-app", "/", bytes.NewReader([]byte("some-body")))
+								proxyObj.ServeHTTP(resp, req)
+							}
+
+							// a recovered panic would be answered with a 503 instead of
+							// proxying to the (unreachable) backend
+							Expect(responseRecorder.Code).To(Equal(http.StatusBadGateway))
+							Expect(responseRecorder.EnableFullDuplexCallCount).To(Equal(2))
+							Expect(fakeLogger).To(Say("enable-full-duplex-failed-serving-half-duplex"))
+							Expect(fakeLogger).NotTo(Say("enable-full-duplex-failed-serving-half-duplex"))
+							Expect(fakeLogger).NotTo(Say("enable-full-duplex-err"))
+						})
+					})
 				})
 
 				Context("when concurrent read write is not enabled", func() {
@@ -129,6 +152,27 @@ var _ = Describe("Proxy Unit tests", func() {
 						proxyObj.ServeHTTP(resp, req)
 						Expect(responseRecorder.EnableFullDuplexCallCount).To(Equal(0))
 					})
+
+					Context("when the route opts in to concurrent read write", func() {
+						BeforeEach(func() {
+							r.Register(route.Uri("duplex-app"), &route.Endpoint{
+								Stats:                    route.NewStats(),
+								HTTP1ConcurrentReadWrite: true,
+							})
+						})
+
+						It("enables full duplex for requests to that route", func() {
+							req := test_util.NewRequest("GET", "duplex-app", "/", bytes.NewReader([]byte("some-body")))
+							proxyObj.ServeHTTP(resp, req)
+							Expect(responseRecorder.EnableFullDuplexCallCount).To(Equal(1))
+						})
+
+						It("does not enable full duplex for other routes", func() {
+							req := test_util.NewRequest("GET", "some-app", "/", bytes.NewReader([]byte("some-body")))
+							proxyObj.ServeHTTP(resp, req)
+							Expect(responseRecorder.EnableFullDuplexCallCount).To(Equal(0))
+						})
+					})
 				})
 			})
 
@@ -207,6 +251,24 @@ var _ = Describe("Proxy Unit tests", func() {
 		)
 	})
 
+	Describe("SkipRouteAuth", func() {
+		BeforeEach(func() {
+			fakeLogger = test_util.NewTestZapLogger("test")
+		})
+		DescribeTable("the returned function",
+			func(arrivedViaRouteService proxy.RouteServiceValidator, expectedValue bool) {
+				skipAuth := proxy.SkipRouteAuth(arrivedViaRouteService, fakeLogger)
+				Expect(skipAuth(&http.Request{})).To(Equal(expectedValue))
+			},
+			Entry("arrivedViaRouteService returns (false, nil)",
+				notArrivedViaRouteService, false),
+			Entry("arrivedViaRouteService returns (true, nil)",
+				arrivedViaRouteService, true),
+			Entry("arrivedViaRouteService returns (false, error)",
+				errorViaRouteService, false),
+		)
+	})
+
 	Describe("ForceDeleteXFCCHeader", func() {
 		BeforeEach(func() {
 			fakeLogger = test_util.NewTestZapLogger("test")
@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/mdimiceli/gorouter/proxy/utils"
+)
+
+// responseStartedWriter records whether the final response was started, from
+// any goroutine. Informational responses do not count.
+type responseStartedWriter struct {
+	utils.ProxyResponseWriter
+	started atomic.Bool
+}
+
+func (w *responseStartedWriter) WriteHeader(status int) {
+	if status >= http.StatusOK {
+		w.started.Store(true)
+	}
+	w.ProxyResponseWriter.WriteHeader(status)
+}
+
+func (w *responseStartedWriter) Write(b []byte) (int, error) {
+	w.started.Store(true)
+	return w.ProxyResponseWriter.Write(b)
+}
+
+// Satisfy http.ResponseController support (Go 1.20+)
+func (w *responseStartedWriter) Unwrap() http.ResponseWriter {
+	return w.ProxyResponseWriter
+}
@@ -0,0 +1,42 @@
+package round_tripper
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+)
+
+// timeoutTrackingBody records whether reading the client request body failed
+// because the read timeout of the server was exceeded.
+type timeoutTrackingBody struct {
+	io.ReadCloser
+	timedOut atomic.Bool
+}
+
+func (b *timeoutTrackingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var netErr net.Error
+	if err != nil && errors.As(err, &netErr) && netErr.Timeout() {
+		b.timedOut.Store(true)
+	}
+	return n, err
+}
+
+// TimedOut returns true once a read of the body has timed out. It is safe to
+// call on a nil body.
+func (b *timeoutTrackingBody) TimedOut() bool {
+	return b != nil && b.timedOut.Load()
+}
+
+// bufferRequestBody reads body into memory if it is at most maxBytes long, so
+// that it can be sent again when the request is retried. Otherwise, or if
+// reading fails, it returns false and a body continuing with what was read.
+func bufferRequestBody(body io.Reader, maxBytes int64) ([]byte, io.ReadCloser, bool) {
+	data, err := io.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil || int64(len(data)) > maxBytes {
+		return nil, io.NopCloser(io.MultiReader(bytes.NewReader(data), body)), false
+	}
+	return data, io.NopCloser(bytes.NewReader(data)), true
+}
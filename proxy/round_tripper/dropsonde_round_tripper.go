@@ -1,10 +1,16 @@
 package round_tripper
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"net/http"
 
 	"github.com/cloudfoundry/dropsonde"
+	"go.uber.org/zap"
 
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/metrics"
 	"github.com/mdimiceli/gorouter/proxy/utils"
 )
 
@@ -32,9 +38,11 @@ type FactoryImpl struct {
 	BackendTemplate      *http.Transport
 	RouteServiceTemplate *http.Transport
 	IsInstrumented       bool
+	Logger               logger.Logger
+	Reporter             metrics.ProxyReporter
 }
 
-func (t *FactoryImpl) New(expectedServerName string, isRouteService bool, isHttp2 bool) ProxyRoundTripper {
+func (t *FactoryImpl) New(expectedServerName string, isRouteService, isHttp2, insecureSkipVerify bool) ProxyRoundTripper {
 	var template *http.Transport
 	if isRouteService {
 		template = t.RouteServiceTemplate
@@ -43,6 +51,19 @@ func (t *FactoryImpl) New(expectedServerName string, isRouteService bool, isHttp
 	}
 
 	customTLSConfig := utils.TLSConfigWithServerName(expectedServerName, template.TLSClientConfig, isRouteService)
+	// Advertise h2 via ALPN only when the transport is able to speak it, the
+	// backend picks the protocol and HTTP/1.1 remains the fallback.
+	if isHttp2 {
+		customTLSConfig.NextProtos = []string{"h2", "http/1.1"}
+	} else {
+		customTLSConfig.NextProtos = []string{"http/1.1"}
+	}
+	// Routes explicitly registered as insecure still have their backend
+	// certificate validated, but a failure is only logged and counted.
+	if insecureSkipVerify && !isRouteService {
+		customTLSConfig.InsecureSkipVerify = true
+		customTLSConfig.VerifyConnection = t.logUnverifiedConnection(customTLSConfig.RootCAs)
+	}
 
 	newTransport := &http.Transport{
 		DialContext:           template.DialContext,
@@ -63,3 +84,40 @@ func (t *FactoryImpl) New(expectedServerName string, isRouteService bool, isHttp
 	}
 
 }
+
+func (t *FactoryImpl) logUnverifiedConnection(rootCAs *x509.CertPool) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		err := verifyPeerCertificates(cs, rootCAs)
+		if err == nil {
+			return nil
+		}
+
+		if t.Logger != nil {
+			t.Logger.Warn("backend-tls-validation-failed-proxying-anyway",
+				zap.String("server-name", cs.ServerName),
+				zap.Error(err),
+			)
+		}
+		if t.Reporter != nil {
+			t.Reporter.CaptureBackendTLSValidationBypassed()
+		}
+		return nil
+	}
+}
+
+func verifyPeerCertificates(cs tls.ConnectionState, rootCAs *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no peer certificates")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         rootCAs,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
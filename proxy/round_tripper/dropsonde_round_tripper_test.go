@@ -0,0 +1,124 @@
+package round_tripper_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/mdimiceli/gorouter/metrics/fakes"
+	"github.com/mdimiceli/gorouter/proxy/round_tripper"
+	"github.com/mdimiceli/gorouter/test_util"
+)
+
+var _ = Describe("FactoryImpl", func() {
+	var (
+		backend *httptest.Server
+		factory *round_tripper.FactoryImpl
+	)
+
+	startBackend := func(enableHTTP2 bool) {
+		backend = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Negotiated-Protocol", r.TLS.NegotiatedProtocol)
+			w.WriteHeader(http.StatusOK)
+		}))
+		backend.EnableHTTP2 = enableHTTP2
+		backend.StartTLS()
+
+		factory = &round_tripper.FactoryImpl{
+			BackendTemplate: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs: backend.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+				},
+			},
+		}
+	}
+
+	AfterEach(func() {
+		backend.Close()
+	})
+
+	roundTrip := func(isHttp2 bool) *http.Response {
+		req, err := http.NewRequest("GET", backend.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := factory.New("example.com", false, isHttp2, false).RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		return resp
+	}
+
+	Context("when the backend supports h2", func() {
+		BeforeEach(func() {
+			startBackend(true)
+		})
+
+		It("negotiates h2 for http2 routes", func() {
+			resp := roundTrip(true)
+			Expect(resp.ProtoMajor).To(Equal(2))
+			Expect(resp.Header.Get("X-Negotiated-Protocol")).To(Equal("h2"))
+		})
+
+		It("negotiates http/1.1 for http1 routes", func() {
+			resp := roundTrip(false)
+			Expect(resp.ProtoMajor).To(Equal(1))
+			Expect(resp.Header.Get("X-Negotiated-Protocol")).To(Equal("http/1.1"))
+		})
+	})
+
+	Context("when the backend only supports http/1.1", func() {
+		BeforeEach(func() {
+			startBackend(false)
+		})
+
+		It("falls back to http/1.1 for http2 routes", func() {
+			resp := roundTrip(true)
+			Expect(resp.ProtoMajor).To(Equal(1))
+		})
+	})
+
+	Context("when the backend certificate is not trusted", func() {
+		var (
+			logger   *test_util.TestZapLogger
+			reporter *fakes.FakeProxyReporter
+		)
+
+		BeforeEach(func() {
+			startBackend(false)
+			logger = test_util.NewTestZapLogger("test")
+			reporter = &fakes.FakeProxyReporter{}
+			factory.BackendTemplate.TLSClientConfig.RootCAs = nil
+			factory.Logger = logger
+			factory.Reporter = reporter
+		})
+
+		newRequest := func() *http.Request {
+			req, err := http.NewRequest("GET", backend.URL, nil)
+			Expect(err).ToNot(HaveOccurred())
+			return req
+		}
+
+		It("fails the request for strict routes", func() {
+			_, err := factory.New("example.com", false, false, false).RoundTrip(newRequest())
+			Expect(err).To(HaveOccurred())
+			Expect(reporter.CaptureBackendTLSValidationBypassedCallCount()).To(Equal(0))
+		})
+
+		It("logs, counts and proxies anyway for insecure routes", func() {
+			resp, err := factory.New("example.com", false, false, true).RoundTrip(newRequest())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(reporter.CaptureBackendTLSValidationBypassedCallCount()).To(Equal(1))
+			Expect(logger.Buffer()).To(gbytes.Say("backend-tls-validation-failed-proxying-anyway"))
+		})
+
+		It("still validates route service certificates", func() {
+			factory.RouteServiceTemplate = factory.BackendTemplate
+			_, err := factory.New("example.com", true, false, true).RoundTrip(newRequest())
+			Expect(err).To(HaveOccurred())
+			Expect(reporter.CaptureBackendTLSValidationBypassedCallCount()).To(Equal(0))
+		})
+	})
+})
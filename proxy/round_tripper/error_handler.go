@@ -30,6 +30,23 @@ func handleUntrustedCert(reporter metrics.ProxyReporter) {
 	reporter.CaptureBackendInvalidTLSCert()
 }
 
+func handleClientRequestTimeout(reporter metrics.ProxyReporter) {
+	reporter.CaptureClientRequestTimeout()
+}
+
+func handleBackendTimeToFirstByte(reporter metrics.ProxyReporter) {
+	reporter.CaptureBackendFirstByteTimeout()
+}
+
+func handleRouteConnectionLimit(reporter metrics.ProxyReporter) {
+	reporter.CaptureRouteConnectionLimitReached()
+}
+
+func handleUnexpectedSwitchingProtocols(reporter metrics.ProxyReporter) {
+	reporter.CaptureBackendUnexpectedSwitchingProtocols()
+	reporter.CaptureBadGateway()
+}
+
 var DefaultErrorSpecs = []ErrorSpec{
 	{fails.AttemptedTLSWithNonTLSBackend, SSLHandshakeMessage, 525, handleSSLHandshake},
 	{fails.HostnameMismatch, HostnameErrorMessage, http.StatusServiceUnavailable, handleHostnameMismatch},
@@ -37,15 +54,26 @@ var DefaultErrorSpecs = []ErrorSpec{
 	{fails.RemoteFailedCertCheck, SSLCertRequiredMessage, 496, nil},
 	{fails.ContextCancelled, ContextCancelledMessage, 499, nil},
 	{fails.RemoteHandshakeFailure, SSLHandshakeMessage, 525, handleSSLHandshake},
+	{fails.RequestHeadersTooLarge, RequestHeadersTooLargeMessage, http.StatusRequestHeaderFieldsTooLarge, nil},
+	{fails.ClientRequestTimeout, ClientRequestTimeoutMessage, http.StatusRequestTimeout, handleClientRequestTimeout},
+	{fails.BackendTimeToFirstByte, GatewayTimeoutMessage, http.StatusGatewayTimeout, handleBackendTimeToFirstByte},
+	{fails.RouteConnectionLimit, RouteConnectionLimitMessage, http.StatusServiceUnavailable, handleRouteConnectionLimit},
+	{fails.UnexpectedSwitchingProtocols, BadGatewayMessage, http.StatusBadGateway, handleUnexpectedSwitchingProtocols},
 }
 
 type ErrorHandler struct {
 	MetricReporter metrics.ProxyReporter
 	ErrorSpecs     []ErrorSpec
+	// CacheControl is set as the Cache-Control header of the 5xx responses
+	// written, like the error writer does for other errors of the router.
+	CacheControl string
 }
 
 func (eh *ErrorHandler) HandleError(responseWriter utils.ProxyResponseWriter, err error) {
 	msg := "endpoint_failure"
+	if fails.ClientRequestTimeout.Classify(err) {
+		msg = "client_request_timeout"
+	}
 	if err != nil {
 		msg = fmt.Sprintf("%s (%s)", msg, err)
 	}
@@ -63,12 +91,20 @@ func (eh *ErrorHandler) writeErrorCode(err error, responseWriter http.ResponseWr
 			if spec.HandleError != nil {
 				spec.HandleError(eh.MetricReporter)
 			}
+			eh.setCacheControl(responseWriter, spec.Code)
 			http.Error(responseWriter, spec.Message, spec.Code)
 			return
 		}
 	}
 
 	// default case
+	eh.setCacheControl(responseWriter, http.StatusBadGateway)
 	http.Error(responseWriter, BadGatewayMessage, http.StatusBadGateway)
 	eh.MetricReporter.CaptureBadGateway()
 }
+
+func (eh *ErrorHandler) setCacheControl(responseWriter http.ResponseWriter, code int) {
+	if eh.CacheControl != "" && code >= http.StatusInternalServerError {
+		responseWriter.Header().Set("Cache-Control", eh.CacheControl)
+	}
+}
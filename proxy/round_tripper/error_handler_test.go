@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"net/http/httptest"
+	"os"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -99,6 +101,29 @@ var _ = Describe("HandleError", func() {
 		})
 	})
 
+	It("does not set a Cache-Control header", func() {
+		errorHandler.HandleError(responseWriter, errors.New("potato"))
+		Expect(responseWriter.Header().Get("Cache-Control")).To(BeEmpty())
+	})
+
+	Context("when a Cache-Control header is configured", func() {
+		BeforeEach(func() {
+			errorHandler.CacheControl = "no-store"
+		})
+
+		It("sets it on 5xx responses", func() {
+			errorHandler.HandleError(responseWriter, errors.New("potato"))
+			Expect(responseWriter.Status()).To(Equal(502))
+			Expect(responseWriter.Header().Get("Cache-Control")).To(Equal("no-store"))
+		})
+
+		It("does not set it on other responses", func() {
+			errorHandler.HandleError(responseWriter, errors.New("i'm a teapot"))
+			Expect(responseWriter.Status()).To(Equal(418))
+			Expect(responseWriter.Header().Get("Cache-Control")).To(BeEmpty())
+		})
+	})
+
 	It("removes any headers named 'Connection'", func() {
 		responseWriter.Header().Add("Connection", "foo")
 		errorHandler.HandleError(responseWriter, errors.New("potato"))
@@ -246,6 +271,69 @@ var _ = Describe("HandleError", func() {
 			})
 		})
 
+		Context("Request headers too large for the backend", func() {
+			BeforeEach(func() {
+				err = fmt.Errorf("%w (300 bytes, limit 200 bytes)", fails.RequestHeadersTooLargeError)
+				errorHandler.HandleError(responseWriter, err)
+			})
+
+			It("has a 431 Status Code", func() {
+				Expect(responseWriter.Status()).To(Equal(http.StatusRequestHeaderFieldsTooLarge))
+			})
+		})
+
+		Context("Client request timeout", func() {
+			BeforeEach(func() {
+				err = fmt.Errorf("%w (%w)", fails.ClientRequestTimeoutError, os.ErrDeadlineExceeded)
+				errorHandler.HandleError(responseWriter, err)
+			})
+
+			It("has a 408 Status Code", func() {
+				Expect(responseWriter.Status()).To(Equal(http.StatusRequestTimeout))
+			})
+
+			It("emits a client_request_timeout metric", func() {
+				Expect(metricReporter.CaptureClientRequestTimeoutCallCount()).To(Equal(1))
+				Expect(metricReporter.CaptureBadGatewayCallCount()).To(Equal(0))
+			})
+
+			It("describes the client timeout instead of an endpoint failure", func() {
+				Expect(responseWriter.Header().Get(router_http.CfRouterError)).To(HavePrefix("client_request_timeout ("))
+			})
+		})
+
+		Context("Route connection limit reached", func() {
+			BeforeEach(func() {
+				err = fails.RouteConnectionLimitError
+				errorHandler.HandleError(responseWriter, err)
+			})
+
+			It("has a 503 Status Code", func() {
+				Expect(responseWriter.Status()).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			It("emits a route_connection_limit_reached metric", func() {
+				Expect(metricReporter.CaptureRouteConnectionLimitReachedCallCount()).To(Equal(1))
+				Expect(metricReporter.CaptureBadGatewayCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("Backend switched protocols without an upgrade request", func() {
+			BeforeEach(func() {
+				err = fails.UnexpectedSwitchingProtocolsError
+				errorHandler.HandleError(responseWriter, err)
+			})
+
+			It("has a 502 Status Code", func() {
+				Expect(responseWriter.Status()).To(Equal(http.StatusBadGateway))
+			})
+
+			It("emits a backend_unexpected_switching_protocols metric", func() {
+				Expect(metricReporter.CaptureBackendUnexpectedSwitchingProtocolsCallCount()).To(Equal(1))
+				Expect(metricReporter.CaptureBadGatewayCallCount()).To(Equal(1))
+			})
+		})
+
 		Context("Context Cancelled Error", func() {
 			BeforeEach(func() {
 				err = context.Canceled
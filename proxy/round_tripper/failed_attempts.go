@@ -0,0 +1,45 @@
+package round_tripper
+
+import (
+	"github.com/mdimiceli/gorouter/proxy/fails"
+)
+
+// failedAttempt describes a failed backend attempt in the summary logged once
+// all attempts of a request have failed.
+type failedAttempt struct {
+	Attempt    int    `json:"attempt"`
+	Addr       string `json:"addr"`
+	ErrorClass string `json:"error-class"`
+	Error      string `json:"error"`
+}
+
+var errorClasses = []struct {
+	name       string
+	classifier fails.Classifier
+}{
+	{"dial", fails.Dial},
+	{"tls-with-non-tls-backend", fails.AttemptedTLSWithNonTLSBackend},
+	{"hostname-mismatch", fails.HostnameMismatch},
+	{"remote-failed-cert-check", fails.RemoteFailedCertCheck},
+	{"remote-handshake-timeout", fails.RemoteHandshakeTimeout},
+	{"remote-handshake-failure", fails.RemoteHandshakeFailure},
+	{"untrusted-cert", fails.UntrustedCert},
+	{"expired-or-not-yet-valid-cert", fails.ExpiredOrNotYetValidCertFailure},
+	{"idempotent-request-eof", fails.IdempotentRequestEOF},
+	{"incomplete-request", fails.IncompleteRequest},
+	{"connection-reset-on-read", fails.ConnectionResetOnRead},
+	{"backend-time-to-first-byte", fails.BackendTimeToFirstByte},
+	{"client-request-timeout", fails.ClientRequestTimeout},
+	{"context-cancelled", fails.ContextCancelled},
+}
+
+// errorClass names the class of a backend error for logging, or returns
+// "other" if it matches none of the known classes.
+func errorClass(err error) string {
+	for _, class := range errorClasses {
+		if class.classifier.Classify(err) {
+			return class.name
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,116 @@
+package round_tripper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
+)
+
+const gzipReadChunkSize = 32 * 1024
+
+// requestBodyGzipper remembers the original body of a request so that every
+// attempt can send it either compressed or as-is, depending on whether the
+// endpoint selected for that attempt registered with gzip_request_body.
+//
+// The original body is only ever wrapped and never read ahead of the
+// transport. An attempt that fails before the body was written therefore
+// leaves it intact for the next attempt, just like an uncompressed request.
+type requestBodyGzipper struct {
+	body          io.ReadCloser
+	contentLength int64
+	minSize       int64
+	eligible      bool
+}
+
+func newRequestBodyGzipper(originalRequest, request *http.Request, minSize int64) *requestBodyGzipper {
+	return &requestBodyGzipper{
+		body:          request.Body,
+		contentLength: request.ContentLength,
+		minSize:       minSize,
+		eligible: originalRequest.Body != nil &&
+			originalRequest.Body != http.NoBody &&
+			request.Header.Get("Content-Encoding") == "" &&
+			!handlers.IsWebSocketUpgrade(request),
+	}
+}
+
+// SetBody replaces the body of request, for a buffered body being sent again
+// on a retry.
+func (g *requestBodyGzipper) SetBody(request *http.Request, body io.ReadCloser) {
+	request.Body = body
+	g.body = body
+}
+
+// Prepare sets the body, Content-Length and Content-Encoding of request for an
+// attempt against endpoint. Bodies of unknown length (chunked) are always
+// compressed for flagged endpoints since their size cannot be checked upfront.
+func (g *requestBodyGzipper) Prepare(request *http.Request, endpoint *route.Endpoint) {
+	if !g.eligible {
+		return
+	}
+
+	if !endpoint.GzipRequestBody || (g.contentLength >= 0 && g.contentLength < g.minSize) {
+		request.Body = g.body
+		request.ContentLength = g.contentLength
+		request.Header.Del("Content-Encoding")
+		return
+	}
+
+	request.Body = newGzipReader(g.body)
+	request.ContentLength = -1
+	request.Header.Set("Content-Encoding", "gzip")
+}
+
+// gzipReader compresses src as it is being read.
+type gzipReader struct {
+	src   io.Reader
+	gz    *gzip.Writer
+	buf   bytes.Buffer
+	chunk []byte
+	done  bool
+}
+
+func newGzipReader(src io.Reader) *gzipReader {
+	r := &gzipReader{src: src}
+	r.gz = gzip.NewWriter(&r.buf)
+	return r
+}
+
+func (r *gzipReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if r.chunk == nil {
+			r.chunk = make([]byte, gzipReadChunkSize)
+		}
+
+		n, err := r.src.Read(r.chunk)
+		if n > 0 {
+			if _, werr := r.gz.Write(r.chunk[:n]); werr != nil {
+				return 0, werr
+			}
+		}
+
+		if err == io.EOF {
+			if cerr := r.gz.Close(); cerr != nil {
+				return 0, cerr
+			}
+			r.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	return r.buf.Read(p)
+}
+
+// Close does not close src, which is owned by the original request.
+func (r *gzipReader) Close() error {
+	return nil
+}
@@ -0,0 +1,216 @@
+package round_tripper_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/metrics/fakes"
+	"github.com/mdimiceli/gorouter/proxy/round_tripper"
+	"github.com/mdimiceli/gorouter/proxy/utils"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	sharedfakes "github.com/mdimiceli/gorouter/fakes"
+	errorClassifierFakes "github.com/mdimiceli/gorouter/proxy/fails/fakes"
+	roundtripperfakes "github.com/mdimiceli/gorouter/proxy/round_tripper/fakes"
+)
+
+var _ = Describe("Gzip request bodies", func() {
+	var (
+		proxyRoundTripper   round_tripper.ProxyRoundTripper
+		routePool           *route.EndpointPool
+		transport           *roundtripperfakes.FakeProxyRoundTripper
+		retriableClassifier *errorClassifierFakes.Classifier
+		cfg                 *config.Config
+		req                 *http.Request
+		payload             string
+		gzipRequestBody     bool
+		numEndpoints        int
+		failFirstAttempt    bool
+
+		receivedBodies    []string
+		receivedEncodings []string
+		receivedLengths   []int64
+	)
+
+	BeforeEach(func() {
+		logger := test_util.NewTestZapLogger("test")
+		routePool = route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 1 * time.Second,
+			Host:              "myapp.com",
+		})
+
+		var err error
+		cfg, err = config.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		cfg.EndpointTimeout = 0
+		cfg.Backends.MaxAttempts = 3
+		cfg.Backends.GzipRequestBodyMinSize = 10
+
+		payload = strings.Repeat("a", 100)
+		gzipRequestBody = true
+		numEndpoints = 1
+		failFirstAttempt = false
+
+		receivedBodies = nil
+		receivedEncodings = nil
+		receivedLengths = nil
+
+		transport = new(roundtripperfakes.FakeProxyRoundTripper)
+		transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+			if failFirstAttempt && transport.RoundTripCallCount() == 1 {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("error")}
+			}
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			receivedBodies = append(receivedBodies, string(body))
+			receivedEncodings = append(receivedEncodings, r.Header.Get("Content-Encoding"))
+			receivedLengths = append(receivedLengths, r.ContentLength)
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}
+
+		retriableClassifier = &errorClassifierFakes.Classifier{}
+		retriableClassifier.ClassifyReturns(false)
+
+		proxyRoundTripper = round_tripper.NewProxyRoundTripper(
+			&FakeRoundTripperFactory{ReturnValue: transport},
+			retriableClassifier,
+			logger,
+			new(fakes.FakeProxyReporter),
+			&roundtripperfakes.ErrorHandler{},
+			&sharedfakes.RoundTripper{},
+			cfg,
+		)
+	})
+
+	JustBeforeEach(func() {
+		for i := 1; i <= numEndpoints; i++ {
+			endpoint := route.NewEndpoint(&route.EndpointOpts{
+				AppId:           "appID",
+				Host:            fmt.Sprintf("%d.%d.%d.%d", i, i, i, i),
+				Port:            9090,
+				GzipRequestBody: gzipRequestBody,
+			})
+			Expect(routePool.Put(endpoint)).To(Equal(route.ADDED))
+		}
+
+		req = test_util.NewRequest("POST", "myapp.com", "/", strings.NewReader(payload))
+		handlers.NewRequestInfo().ServeHTTP(nil, req, func(_ http.ResponseWriter, transformedReq *http.Request) {
+			req = transformedReq
+		})
+
+		reqInfo, err := handlers.ContextRequestInfo(req)
+		Expect(err).ToNot(HaveOccurred())
+		reqInfo.RoutePool = routePool
+		reqInfo.ProxyResponseWriter = utils.NewProxyResponseWriter(httptest.NewRecorder())
+	})
+
+	gunzip := func(s string) string {
+		gz, err := gzip.NewReader(bytes.NewBufferString(s))
+		Expect(err).ToNot(HaveOccurred())
+		b, err := io.ReadAll(gz)
+		Expect(err).ToNot(HaveOccurred())
+		return string(b)
+	}
+
+	It("compresses bodies above the threshold for flagged routes", func() {
+		_, err := proxyRoundTripper.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(receivedEncodings).To(Equal([]string{"gzip"}))
+		Expect(receivedLengths).To(Equal([]int64{-1}))
+		Expect(gunzip(receivedBodies[0])).To(Equal(payload))
+	})
+
+	Context("when the body is below the threshold", func() {
+		BeforeEach(func() {
+			payload = "tiny"
+		})
+
+		It("sends the body as-is", func() {
+			_, err := proxyRoundTripper.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedEncodings).To(Equal([]string{""}))
+			Expect(receivedLengths).To(Equal([]int64{4}))
+			Expect(receivedBodies).To(Equal([]string{"tiny"}))
+		})
+	})
+
+	Context("when the route is not flagged", func() {
+		BeforeEach(func() {
+			gzipRequestBody = false
+		})
+
+		It("sends the body as-is", func() {
+			_, err := proxyRoundTripper.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedEncodings).To(Equal([]string{""}))
+			Expect(receivedBodies).To(Equal([]string{payload}))
+		})
+	})
+
+	Context("when the request body is already encoded", func() {
+		JustBeforeEach(func() {
+			req.Header.Set("Content-Encoding", "br")
+		})
+
+		It("sends the body as-is", func() {
+			_, err := proxyRoundTripper.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedEncodings).To(Equal([]string{"br"}))
+			Expect(receivedBodies).To(Equal([]string{payload}))
+		})
+	})
+
+	Context("when the request body is chunked", func() {
+		BeforeEach(func() {
+			payload = "tiny"
+		})
+
+		JustBeforeEach(func() {
+			req.ContentLength = -1
+		})
+
+		It("compresses the body regardless of the threshold", func() {
+			_, err := proxyRoundTripper.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedEncodings).To(Equal([]string{"gzip"}))
+			Expect(gunzip(receivedBodies[0])).To(Equal(payload))
+		})
+	})
+
+	Context("when the first attempt fails before the body is sent", func() {
+		BeforeEach(func() {
+			numEndpoints = 2
+			failFirstAttempt = true
+			retriableClassifier.ClassifyReturns(true)
+		})
+
+		It("retries with a freshly compressed body", func() {
+			_, err := proxyRoundTripper.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(transport.RoundTripCallCount()).To(Equal(2))
+			Expect(receivedEncodings).To(Equal([]string{"gzip"}))
+			Expect(gunzip(receivedBodies[0])).To(Equal(payload))
+		})
+	})
+})
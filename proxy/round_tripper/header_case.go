@@ -0,0 +1,46 @@
+package round_tripper
+
+import (
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/handlers"
+)
+
+// transportHeaders are looked up by their canonical name by the transport,
+// which adds its own values for those it does not find. They are therefore
+// always sent with the canonical spelling.
+var transportHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Range":             true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"User-Agent":        true,
+}
+
+// withHeaderCase returns a shallow copy of the request whose header names are
+// spelled the way the client spelled them, as far as it was recorded for the
+// request. The transport writes header names as they are stored. The request
+// is returned as it is if it has no recorder.
+func withHeaderCase(request *http.Request) *http.Request {
+	recorder, ok := handlers.GetHeaderCaseRecorder(request)
+	if !ok {
+		return request
+	}
+
+	header := make(http.Header, len(request.Header))
+	for name, values := range request.Header {
+		if raw := recorder.HeaderCase(name); raw != "" && !transportHeaders[name] {
+			name = raw
+		}
+		header[name] = values
+	}
+
+	cased := request.WithContext(request.Context())
+	cased.Header = header
+	return cased
+}
@@ -2,6 +2,7 @@ package round_tripper
 
 import (
 	router_http "github.com/mdimiceli/gorouter/common/http"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/net/http/httpguts"
 
 	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/handlers"
@@ -36,6 +38,10 @@ const (
 	SSLHandshakeMessage                      = "525 SSL Handshake Failed"
 	SSLCertRequiredMessage                   = "496 SSL Certificate Required"
 	ContextCancelledMessage                  = "499 Request Cancelled"
+	RequestHeadersTooLargeMessage            = "431 Request Header Fields Too Large"
+	ClientRequestTimeoutMessage              = "408 Request Timeout"
+	GatewayTimeoutMessage                    = "504 Gateway Timeout"
+	RouteConnectionLimitMessage              = "503 Service Unavailable: Route connection limit reached"
 	HTTP2Protocol                            = "http2"
 	AuthNegotiateHeaderCookieMaxAgeInSeconds = 60
 )
@@ -49,14 +55,14 @@ type ProxyRoundTripper interface {
 }
 
 type RoundTripperFactory interface {
-	New(expectedServerName string, isRouteService, isHttp2 bool) ProxyRoundTripper
+	New(expectedServerName string, isRouteService, isHttp2, insecureSkipVerify bool) ProxyRoundTripper
 }
 
 func GetRoundTripper(endpoint *route.Endpoint, roundTripperFactory RoundTripperFactory, isRouteService, http2Enabled bool) ProxyRoundTripper {
 	endpoint.RoundTripperInit.Do(func() {
 		endpoint.SetRoundTripperIfNil(func() route.ProxyRoundTripper {
 			isHttp2 := (endpoint.Protocol == HTTP2Protocol) && http2Enabled
-			return roundTripperFactory.New(endpoint.ServerCertDomainSAN, isRouteService, isHttp2)
+			return roundTripperFactory.New(endpoint.ServerCertDomainSAN, isRouteService, isHttp2, endpoint.InsecureSkipTLSVerify)
 		})
 	})
 
@@ -132,14 +138,34 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 		return originalGot1xxResponse(code, header)
 	}
 
+	var clientBody *timeoutTrackingBody
 	if request.Body != nil {
+		clientBody = &timeoutTrackingBody{ReadCloser: request.Body}
+
 		// Temporarily disable closing of the body while in the RoundTrip function, since
 		// the underlying Transport will close the client request body.
 		// https://github.com/golang/go/blob/ab5d9f5831cd267e0d8e8954cfe9987b737aec9c/src/net/http/request.go#L179-L182
 
-		request.Body = io.NopCloser(request.Body)
+		request.Body = io.NopCloser(clientBody)
 	}
 
+	// buffering the body of requests with an idempotency key makes them
+	// replayable, and thereby retriable, see isIdempotent
+	replayBody := false
+	if maxBytes := rt.config.Backends.RetryBodyBufferBytes; maxBytes > 0 && clientBody != nil &&
+		originalRequest.Body != http.NoBody && hasIdempotencyKey(request) {
+		var buffered []byte
+		buffered, request.Body, replayBody = bufferRequestBody(clientBody, maxBytes)
+		if replayBody {
+			request.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(buffered)), nil
+			}
+		} else {
+			rt.logger.Debug("request-body-too-large-to-retry", zap.Int64("max-bytes", maxBytes))
+		}
+	}
+	bodyGzipper := newRequestBodyGzipper(originalRequest, request, rt.config.Backends.GzipRequestBodyMinSize)
+
 	reqInfo, err := handlers.ContextRequestInfo(request)
 	if err != nil {
 		return nil, err
@@ -152,16 +178,50 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 		return nil, errors.New("ProxyResponseWriter not set on context")
 	}
 
+	// all header mutations of the router have been applied at this point
+	if maxBytes := rt.config.Backends.MaxRequestHeaderBytes; maxBytes > 0 {
+		if size := requestHeaderSize(request); size > maxBytes {
+			err = fmt.Errorf("%w (%d bytes, limit %d bytes)", fails.RequestHeadersTooLargeError, size, maxBytes)
+			rt.logger.Error("backend-request-headers-too-large", zap.Int("size", size), zap.Int("limit", maxBytes))
+			rt.errorHandler.HandleError(reqInfo.ProxyResponseWriter, err)
+			return nil, err
+		}
+	}
+
 	stickyEndpointID, mustBeSticky := handlers.GetStickySession(request, rt.config.StickySessionCookieNames, rt.config.StickySessionsForAuthNegotiate)
 	numberOfEndpoints := reqInfo.RoutePool.NumEndpoints()
 	iter := reqInfo.RoutePool.Endpoints(rt.logger, rt.config.LoadBalance, stickyEndpointID, mustBeSticky, rt.config.LoadBalanceAZPreference, rt.config.Zone)
+	if rt.config.Backends.ExcludeAttemptedEndpoints {
+		iter = route.NewExcludeAttempted(iter)
+	}
 
 	// The selectEndpointErr needs to be tracked separately. If we get an error
 	// while selecting an endpoint we might just have run out of routes. In
 	// such cases the last error that was returned by the round trip should be
 	// used to produce a 502 instead of the error returned from selecting the
 	// endpoint which would result in a 404 Not Found.
+	holdsConn := false
+	if reqInfo.RouteServiceURL == nil && reqInfo.RoutePool.MaxConnections() > 0 {
+		if !reqInfo.RoutePool.AcquireConnection(request.Context(), rt.config.Backends.RouteMaxConnsQueueTimeout) {
+			err = fails.RouteConnectionLimitError
+			rt.logger.Error("route-connection-limit-reached",
+				zap.String("host", reqInfo.RoutePool.Host()),
+				zap.Int64("max-connections", reqInfo.RoutePool.MaxConnections()),
+			)
+			rt.errorHandler.HandleError(reqInfo.ProxyResponseWriter, err)
+			return nil, err
+		}
+		holdsConn = true
+		defer func() {
+			if holdsConn {
+				reqInfo.RoutePool.ReleaseConnection()
+			}
+		}()
+	}
+
 	var selectEndpointErr error
+	var failedAttempts []failedAttempt
+	var attemptStartedAt, attemptFinishedAt time.Time
 	var maxAttempts int
 	if reqInfo.RouteServiceURL == nil {
 		maxAttempts = max(min(rt.config.Backends.MaxAttempts, reqInfo.RoutePool.NumEndpoints()), 1)
@@ -175,6 +235,11 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 		// Reset the trace to prepare for new times and prevent old data from polluting our results.
 		trace.Reset()
 
+		if replayBody && attempt > 1 {
+			body, _ := request.GetBody()
+			bodyGzipper.SetBody(request, body)
+		}
+
 		if reqInfo.RouteServiceURL == nil {
 			// Because this for-loop is 1-indexed, we substract one from the attempt value passed to selectEndpoint,
 			// which expects a 0-indexed value
@@ -185,21 +250,48 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 			}
 			logger = logger.With(zap.Nest("route-endpoint", endpoint.ToLogData()...))
 			reqInfo.RouteEndpoint = endpoint
+			errLogger := logger.With(rt.backendErrorFields(reqInfo)...)
 
 			logger.Debug("backend", zap.Int("attempt", attempt))
 			if endpoint.IsTLS() {
 				request.URL.Scheme = "https"
+				if endpoint.InsecureSkipTLSVerify {
+					logger.Warn("backend-tls-verification-disabled-for-route")
+				}
 			} else {
 				request.URL.Scheme = "http"
 			}
-			res, err = rt.backendRoundTrip(request, endpoint, iter, logger)
+			bodyGzipper.Prepare(request, endpoint)
+			attemptStartedAt = time.Now()
+			res, err = rt.backendRoundTrip(request, endpoint, iter, errLogger)
+			attemptFinishedAt = time.Now()
+
+			// the connection cannot be used for either protocol anymore, so
+			// it is closed along with the body
+			if err == nil && rt.config.Backends.RejectUnexpectedSwitchingProtocols && unexpectedSwitchingProtocols(request, res) {
+				res.Body.Close()
+				res = nil
+				err = fails.UnexpectedSwitchingProtocolsError
+			}
 
 			if err != nil {
 				reqInfo.FailedAttempts++
 				reqInfo.LastFailedAttemptFinishedAt = time.Now()
-				retriable, err := rt.isRetriable(request, err, trace)
-
-				logger.Error("backend-endpoint-failed",
+				retriable, err := rt.isRetriable(request, err, trace, clientBody)
+
+				// with the summary enabled, individual attempts are only of
+				// interest when debugging
+				level := zap.ErrorLevel
+				if rt.config.Logging.FailedAttemptsSummary {
+					level = zap.DebugLevel
+					failedAttempts = append(failedAttempts, failedAttempt{
+						Attempt:    attempt,
+						Addr:       endpoint.CanonicalAddr(),
+						ErrorClass: errorClass(err),
+						Error:      err.Error(),
+					})
+				}
+				errLogger.Log(level, "backend-endpoint-failed",
 					zap.Error(err),
 					zap.Int("attempt", attempt),
 					zap.String("vcap_request_id", request.Header.Get(handlers.VcapRequestIdHeader)),
@@ -242,11 +334,13 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 				roundTripper = rt.routeServicesTransport
 			}
 
+			attemptStartedAt = time.Now()
 			res, err = rt.timedRoundTrip(roundTripper, request, logger)
+			attemptFinishedAt = time.Now()
 			if err != nil {
 				reqInfo.FailedAttempts++
 				reqInfo.LastFailedAttemptFinishedAt = time.Now()
-				retriable, err := rt.isRetriable(request, err, trace)
+				retriable, err := rt.isRetriable(request, err, trace, clientBody)
 
 				logger.Error(
 					"route-service-connection-failed",
@@ -283,7 +377,7 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 
 	// if the client disconnects before response is sent then return context.Canceled (499) instead of the gateway error
 	if err != nil && errors.Is(originalRequest.Context().Err(), context.Canceled) && !errors.Is(err, context.Canceled) {
-		rt.logger.Error("gateway-error-and-original-request-context-cancelled", zap.Error(err))
+		rt.logger.With(rt.backendErrorFields(reqInfo)...).Error("gateway-error-and-original-request-context-cancelled", zap.Error(err))
 		err = originalRequest.Context().Err()
 		if originalRequest.Body != nil {
 			_ = originalRequest.Body.Close()
@@ -297,6 +391,16 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 		err = selectEndpointErr
 	}
 
+	if err != nil && len(failedAttempts) > 0 {
+		rt.logger.With(rt.backendErrorFields(reqInfo)...).Error("backend-attempts-failed",
+			zap.String("host", reqInfo.RoutePool.Host()),
+			zap.String("vcap_request_id", request.Header.Get(handlers.VcapRequestIdHeader)),
+			zap.Int("num-endpoints", numberOfEndpoints),
+			zap.Object("attempts", failedAttempts),
+			zap.Error(err),
+		)
+	}
+
 	if err != nil {
 		// When roundtrip returns an error, transport readLoop might still be running.
 		// Protect access to response headers map which can be handled in Got1xxResponse hook in readLoop
@@ -330,15 +434,31 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 	reqInfo.DialFinishedAt = trace.DialDone()
 	reqInfo.TlsHandshakeStartedAt = trace.TlsStart()
 	reqInfo.TlsHandshakeFinishedAt = trace.TlsDone()
+	if reqInfo.RouteServiceURL == nil {
+		reqInfo.BackendRequestStartedAt = attemptStartedAt
+		reqInfo.BackendResponseReceivedAt = attemptFinishedAt
+	} else {
+		reqInfo.RouteServiceRequestStartedAt = attemptStartedAt
+		reqInfo.RouteServiceResponseReceivedAt = attemptFinishedAt
+	}
 
 	if res != nil && endpoint.PrivateInstanceId != "" && !requestSentToRouteService(request) {
 		setupStickySession(
 			res, endpoint, stickyEndpointID, rt.config.SecureCookies,
 			reqInfo.RoutePool.ContextPath(), rt.config.StickySessionCookieNames,
 			rt.config.StickySessionsForAuthNegotiate,
+			hasStickySessionCookie(request, rt.config.StickySessionCookieNames),
+			reqInfo.RoutePool.StickySessionTTL(),
 		)
 	}
 
+	// the backend connection is in use until the body is read, or, after a
+	// protocol switch, until the upgraded connection ends
+	if holdsConn && res != nil && res.Body != nil {
+		res.Body = releaseOnClose(res.Body, reqInfo.RoutePool.ReleaseConnection)
+		holdsConn = false
+	}
+
 	return res, nil
 }
 
@@ -352,18 +472,63 @@ func (rt *roundTripper) CancelRequest(request *http.Request) {
 	tr.CancelRequest(request)
 }
 
+// headAsGetMaxDrainBytes is how much of the body of a GET response sent for a
+// HEAD request is read before it is discarded, so that the backend connection
+// can be reused. Connections with larger bodies are closed instead.
+const headAsGetMaxDrainBytes = 256 << 10
+
 func (rt *roundTripper) backendRoundTrip(request *http.Request, endpoint *route.Endpoint, iter route.EndpointIterator, logger logger.Logger) (*http.Response, error) {
 	request.URL.Host = endpoint.CanonicalAddr()
 	request.Header.Set("X-CF-ApplicationID", endpoint.ApplicationId)
 	request.Header.Set("X-CF-InstanceIndex", endpoint.PrivateInstanceIndex)
 	setRequestXCfInstanceId(request, endpoint)
+	// HTTP/2 lowercases all header names
+	if endpoint.PreserveHeaderCase && endpoint.Protocol != "http2" {
+		request = withHeaderCase(request)
+	}
+
+	headAsGet := endpoint.HeadAsGet && request.Method == http.MethodHead
+	if headAsGet {
+		request = request.WithContext(request.Context())
+		request.Method = http.MethodGet
+	}
 
 	// increment connection stats
 	iter.PreRequest(endpoint)
 
 	rt.combinedReporter.CaptureRoutingRequest(endpoint)
 	tr := GetRoundTripper(endpoint, rt.roundTripperFactory, false, rt.config.EnableHTTP2)
+
+	var (
+		firstByteTimedOut func() bool
+		releaseFirstByte  func()
+	)
+	if rt.config.BackendTimeToFirstByte > 0 {
+		request, firstByteTimedOut, releaseFirstByte = withTimeToFirstByte(request, rt.config.BackendTimeToFirstByte)
+	}
 	res, err := rt.timedRoundTrip(tr, request, logger)
+	if err != nil && firstByteTimedOut != nil && firstByteTimedOut() {
+		logger.Error("backend-time-to-first-byte-exceeded", zap.Duration("timeout", rt.config.BackendTimeToFirstByte), zap.Error(err))
+		err = fails.BackendTimeToFirstByteError
+	}
+	// the response body is read with the context of the request
+	if releaseFirstByte != nil {
+		if err == nil && res.Body != nil {
+			res.Body = releaseOnClose(res.Body, releaseFirstByte)
+		} else {
+			releaseFirstByte()
+		}
+	}
+
+	// the client asked for the headers only, which keep the Content-Length of
+	// the body. The response is handled as the one of the client's HEAD request.
+	if headAsGet && err == nil {
+		io.Copy(io.Discard, io.LimitReader(res.Body, headAsGetMaxDrainBytes))
+		res.Body.Close()
+		res.Body = http.NoBody
+		res.Request = request.WithContext(request.Context())
+		res.Request.Method = http.MethodHead
+	}
 
 	// decrement connection stats
 	iter.PostRequest(endpoint)
@@ -407,6 +572,19 @@ func (rt *roundTripper) selectEndpoint(iter route.EndpointIterator, request *htt
 	return endpoint, nil
 }
 
+// backendErrorFields returns the address and app id of the endpoint the
+// request was last sent to, if enabled, so that errors can be attributed to
+// it directly.
+func (rt *roundTripper) backendErrorFields(reqInfo *handlers.RequestInfo) []zap.Field {
+	if !rt.config.Logging.BackendErrorFields || reqInfo.RouteEndpoint == nil || reqInfo.RouteEndpoint.CanonicalAddr() == "" {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("backend-addr", reqInfo.RouteEndpoint.CanonicalAddr()),
+		zap.String("backend-app-id", reqInfo.RouteEndpoint.ApplicationId),
+	}
+}
+
 func setRequestXCfInstanceId(request *http.Request, endpoint *route.Endpoint) {
 	value := endpoint.PrivateInstanceId
 	if value == "" {
@@ -424,6 +602,8 @@ func setupStickySession(
 	path string,
 	stickySessionCookieNames config.StringSet,
 	authNegotiateSticky bool,
+	requestContainsSessionCookie bool,
+	ttl time.Duration,
 ) {
 
 	requestContainsStickySessionCookies := originalEndpointId != ""
@@ -461,6 +641,20 @@ func setupStickySession(
 		}
 	}
 
+	if ttl > 0 {
+		// the affinity is only established, never renewed, so that it expires
+		// with the VCAP_ID cookie and the session is balanced anew afterwards
+		if !requestNotSentToRequestedApp {
+			shouldSetVCAPID = false
+		} else if requestContainsSessionCookie && !requestContainsStickySessionCookies {
+			shouldSetVCAPID = true
+		}
+		if maxAge >= 0 {
+			maxAge = int(ttl / time.Second)
+			expiry = time.Time{}
+		}
+	}
+
 	for _, v := range response.Cookies() {
 		if v.Name == VcapCookieId {
 			shouldSetVCAPID = false
@@ -495,6 +689,15 @@ func setupStickySession(
 	}
 }
 
+func hasStickySessionCookie(request *http.Request, stickySessionCookieNames config.StringSet) bool {
+	for name := range stickySessionCookieNames {
+		if _, err := request.Cookie(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func requestSentToRouteService(request *http.Request) bool {
 	sigHeader := request.Header.Get(routeservice.HeaderKeySignature)
 	rsUrl := request.Header.Get(routeservice.HeaderKeyForwardedURL)
@@ -509,22 +712,59 @@ func isIdempotent(request *http.Request) bool {
 		case "GET", "HEAD", "OPTIONS", "TRACE", "":
 			return true
 		}
-		// The Idempotency-Key, while non-standard, is widely used to
-		// mean a POST or other request is idempotent. See
-		// https://golang.org/issue/19943#issuecomment-421092421
-		if request.Header.Get("Idempotency-Key") != "" || request.Header.Get("X-Idempotency-Key") != "" {
+		if hasIdempotencyKey(request) {
 			return true
 		}
 	}
 	return false
 }
 
-func (rt *roundTripper) isRetriable(request *http.Request, err error, trace *requestTracer) (bool, error) {
+// hasIdempotencyKey reports whether the request carries an Idempotency-Key,
+// which, while non-standard, is widely used to mean a POST or other request
+// is idempotent. See https://golang.org/issue/19943#issuecomment-421092421
+func hasIdempotencyKey(request *http.Request) bool {
+	return request.Header.Get("Idempotency-Key") != "" || request.Header.Get("X-Idempotency-Key") != ""
+}
+
+// requestHeaderSize returns the size of the request line and headers as they
+// are written to the backend.
+func requestHeaderSize(request *http.Request) int {
+	size := len(request.Method) + len(request.URL.RequestURI()) + len(request.Proto) + 4 // two spaces and \r\n
+	size += len("Host") + len(request.Host) + 4                                          // ": " and \r\n
+	for name, values := range request.Header {
+		for _, value := range values {
+			size += len(name) + len(value) + 4 // ": " and \r\n
+		}
+	}
+	return size
+}
+
+// unexpectedSwitchingProtocols reports whether the backend switched protocols
+// although the request did not ask it to.
+func unexpectedSwitchingProtocols(request *http.Request, res *http.Response) bool {
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return false
+	}
+	return request.Header.Get("Upgrade") == "" ||
+		!httpguts.HeaderValuesContainsToken(request.Header["Connection"], "Upgrade")
+}
+
+func (rt *roundTripper) isRetriable(request *http.Request, err error, trace *requestTracer, clientBody *timeoutTrackingBody) (bool, error) {
 	// if the context has been cancelled we do not perform further retries
 	if request.Context().Err() != nil {
 		return false, fmt.Errorf("%w (%w)", request.Context().Err(), err)
 	}
 
+	// the client did not send the request body in time, the backend is not at fault
+	if clientBody.TimedOut() {
+		return false, fmt.Errorf("%w (%w)", fails.ClientRequestTimeoutError, err)
+	}
+
+	// in strict mode the backend must not have seen any part of the request
+	if rt.config.Backends.RetryOnlyOnConnectionFailure && trace.WroteRequestBytes() {
+		return false, err
+	}
+
 	// io.EOF errors are considered safe to retry for certain requests
 	// Replace the error here to track this state when classifying later.
 	if err == io.EOF && isIdempotent(request) {
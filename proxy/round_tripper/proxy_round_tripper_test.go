@@ -2,6 +2,7 @@ package round_tripper_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,8 +12,10 @@ import (
 	"net/http/httptrace"
 	"net/textproto"
 	"net/url"
+	"os"
 	"strings"
 	"sync"
+	"testing/iotest"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -52,8 +55,15 @@ func (t *testBody) Close() error {
 }
 
 type RequestedRoundTripperType struct {
-	IsRouteService bool
-	IsHttp2        bool
+	IsRouteService     bool
+	IsHttp2            bool
+	InsecureSkipVerify bool
+}
+
+type headerCaseRecorder map[string]string
+
+func (r headerCaseRecorder) HeaderCase(canonicalName string) string {
+	return r[canonicalName]
 }
 
 type FakeRoundTripperFactory struct {
@@ -61,10 +71,11 @@ type FakeRoundTripperFactory struct {
 	RequestedRoundTripperTypes []RequestedRoundTripperType
 }
 
-func (f *FakeRoundTripperFactory) New(expectedServerName string, isRouteService bool, isHttp2 bool) round_tripper.ProxyRoundTripper {
+func (f *FakeRoundTripperFactory) New(expectedServerName string, isRouteService, isHttp2, insecureSkipVerify bool) round_tripper.ProxyRoundTripper {
 	f.RequestedRoundTripperTypes = append(f.RequestedRoundTripperTypes, RequestedRoundTripperType{
-		IsRouteService: isRouteService,
-		IsHttp2:        isHttp2,
+		IsRouteService:     isRouteService,
+		IsHttp2:            isHttp2,
+		InsecureSkipVerify: insecureSkipVerify,
 	})
 	return f.ReturnValue
 }
@@ -216,6 +227,153 @@ var _ = Describe("ProxyRoundTripper", func() {
 				})
 			})
 
+			Context("when the client spelled header names in another case", func() {
+				var preserveHeaderCase bool
+
+				BeforeEach(func() {
+					preserveHeaderCase = true
+					transport.RoundTripReturns(resp.Result(), nil)
+					req.Header.Set("X-Api-Key", "secret")
+					req.Header.Set("User-Agent", "curl")
+					req = req.WithContext(handlers.ContextWithHeaderCaseRecorder(req.Context(), headerCaseRecorder{
+						"X-Api-Key":  "x-api-KEY",
+						"User-Agent": "user-agent",
+					}))
+				})
+
+				JustBeforeEach(func() {
+					var oldEndpoints []*route.Endpoint
+					routePool.Each(func(endpoint *route.Endpoint) {
+						oldEndpoints = append(oldEndpoints, endpoint)
+					})
+					for _, ep := range oldEndpoints {
+						routePool.Remove(ep)
+					}
+
+					endpoint = route.NewEndpoint(&route.EndpointOpts{
+						Host:               "1.1.1.1",
+						Port:               9090,
+						PreserveHeaderCase: preserveHeaderCase,
+					})
+					Expect(routePool.Put(endpoint)).To(Equal(route.ADDED))
+				})
+
+				It("forwards the headers with the client's spelling to routes which preserve it", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+					outreq := transport.RoundTripArgsForCall(0)
+					Expect(outreq.Header).To(HaveKeyWithValue("x-api-KEY", []string{"secret"}))
+					Expect(outreq.Header).ToNot(HaveKey("X-Api-Key"))
+				})
+
+				It("keeps the canonical spelling of headers the transport looks up", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					outreq := transport.RoundTripArgsForCall(0)
+					Expect(outreq.Header).To(HaveKeyWithValue("User-Agent", []string{"curl"}))
+				})
+
+				Context("when the route does not preserve it", func() {
+					BeforeEach(func() {
+						preserveHeaderCase = false
+					})
+
+					It("forwards the headers with the canonical spelling", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+						outreq := transport.RoundTripArgsForCall(0)
+						Expect(outreq.Header).To(HaveKeyWithValue("X-Api-Key", []string{"secret"}))
+						Expect(outreq.Header).ToNot(HaveKey("x-api-KEY"))
+					})
+				})
+			})
+
+			Context("when the route sends HEAD requests as GET requests", func() {
+				var (
+					headAsGet bool
+					getBody   *strings.Reader
+				)
+
+				BeforeEach(func() {
+					headAsGet = true
+					req.Method = http.MethodHead
+					getBody = strings.NewReader("hello")
+					transport.RoundTripReturns(&http.Response{
+						StatusCode:    http.StatusOK,
+						Header:        http.Header{"Content-Length": []string{"5"}, "Content-Type": []string{"text/plain"}},
+						ContentLength: 5,
+						Body:          io.NopCloser(getBody),
+					}, nil)
+				})
+
+				JustBeforeEach(func() {
+					var oldEndpoints []*route.Endpoint
+					routePool.Each(func(endpoint *route.Endpoint) {
+						oldEndpoints = append(oldEndpoints, endpoint)
+					})
+					for _, ep := range oldEndpoints {
+						routePool.Remove(ep)
+					}
+
+					endpoint = route.NewEndpoint(&route.EndpointOpts{
+						Host:      "1.1.1.1",
+						Port:      9090,
+						HeadAsGet: headAsGet,
+					})
+					Expect(routePool.Put(endpoint)).To(Equal(route.ADDED))
+				})
+
+				It("sends a GET request and discards the body of the response", func() {
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(transport.RoundTripArgsForCall(0).Method).To(Equal(http.MethodGet))
+
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+					Expect(res.Header.Get("Content-Length")).To(Equal("5"))
+					Expect(res.Header.Get("Content-Type")).To(Equal("text/plain"))
+					body, err := io.ReadAll(res.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(body).To(BeEmpty())
+				})
+
+				It("reads the body of the GET response so that the connection can be reused", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(getBody.Len()).To(BeZero())
+				})
+
+				It("returns the response as the one of the HEAD request", func() {
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Request.Method).To(Equal(http.MethodHead))
+					Expect(req.Method).To(Equal(http.MethodHead))
+				})
+
+				It("sends other methods as they are", func() {
+					req.Method = http.MethodGet
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(transport.RoundTripArgsForCall(0).Method).To(Equal(http.MethodGet))
+
+					body, err := io.ReadAll(res.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal("hello"))
+				})
+
+				Context("when the route does not", func() {
+					BeforeEach(func() {
+						headAsGet = false
+					})
+
+					It("sends HEAD requests as they are", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(transport.RoundTripArgsForCall(0).Method).To(Equal(http.MethodHead))
+					})
+				})
+			})
+
 			Context("when some backends fail", func() {
 				BeforeEach(func() {
 					numEndpoints = 3
@@ -312,6 +470,33 @@ var _ = Describe("ProxyRoundTripper", func() {
 				})
 			})
 
+			Context("with 2 backends, the first failing without being marked as failed", func() {
+				var hosts []string
+
+				BeforeEach(func() {
+					numEndpoints = 2
+					hosts = nil
+					cfg.LoadBalance = config.LOAD_BALANCE_LC
+					transport.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+						hosts = append(hosts, req.URL.Host)
+						if transport.RoundTripCallCount() == 1 {
+							return nil, errors.New("connection reset by peer")
+						}
+						return &http.Response{StatusCode: http.StatusTeapot}, nil
+					}
+
+					retriableClassifier.ClassifyReturns(true)
+				})
+
+				It("retries on the other backend", func() {
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+					Expect(hosts).To(HaveLen(2))
+					Expect(hosts[1]).NotTo(Equal(hosts[0]))
+				})
+			})
+
 			Context("with 5 backends, 4 of them failing", func() {
 				BeforeEach(func() {
 					numEndpoints = 5
@@ -430,6 +615,218 @@ var _ = Describe("ProxyRoundTripper", func() {
 						Expect(reqInfo.RoundTripSuccessful).To(BeFalse())
 					})
 				})
+
+				Context("when the failed attempts summary is enabled", func() {
+					BeforeEach(func() {
+						cfg.Backends.MaxAttempts = 10
+						cfg.Logging.FailedAttemptsSummary = true
+					})
+
+					It("logs a single summary listing all attempts", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).To(HaveOccurred())
+
+						var summaries []string
+						for _, line := range logger.Lines(zap.ErrorLevel) {
+							Expect(line).ToNot(ContainSubstring("backend-endpoint-failed"))
+							if strings.Contains(line, "backend-attempts-failed") {
+								summaries = append(summaries, line)
+							}
+						}
+						Expect(summaries).To(HaveLen(1))
+						for i := 1; i <= numEndpoints; i++ {
+							Expect(summaries[0]).To(ContainSubstring(fmt.Sprintf(`"addr":"%d.%d.%d.%d:9090"`, i, i, i, i)))
+						}
+						Expect(strings.Count(summaries[0], `"error-class":"dial"`)).To(Equal(numEndpoints))
+					})
+
+					It("logs the individual attempts at debug level", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).To(HaveOccurred())
+
+						debugLogs := logger.Lines(zap.DebugLevel)
+						failedLogs := 0
+						for _, line := range debugLogs {
+							if strings.Contains(line, "backend-endpoint-failed") {
+								failedLogs++
+							}
+						}
+						Expect(failedLogs).To(Equal(numEndpoints))
+					})
+				})
+
+				Context("when backend error fields are enabled", func() {
+					BeforeEach(func() {
+						cfg.Backends.MaxAttempts = 10
+						cfg.Logging.BackendErrorFields = true
+					})
+
+					It("logs the address and app id of the failed endpoint", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).To(HaveOccurred())
+
+						failedLogs := 0
+						for _, line := range logger.Lines(zap.ErrorLevel) {
+							if !strings.Contains(line, "backend-endpoint-failed") {
+								continue
+							}
+							failedLogs++
+							Expect(line).To(MatchRegexp(`"backend-addr":"\d\.\d\.\d\.\d:9090","backend-app-id":"appID\d"`))
+						}
+						Expect(failedLogs).To(Equal(numEndpoints))
+					})
+				})
+
+				Context("when backend error fields are disabled", func() {
+					BeforeEach(func() {
+						cfg.Backends.MaxAttempts = 10
+					})
+
+					It("does not log them", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).To(HaveOccurred())
+
+						for _, line := range logger.Lines(zap.ErrorLevel) {
+							Expect(line).ToNot(ContainSubstring("backend-addr"))
+						}
+					})
+				})
+			})
+
+			Context("when the route limits its connections", func() {
+				var unblock chan struct{}
+
+				BeforeEach(func() {
+					numEndpoints = 2
+					cfg.Backends.RouteMaxConnsQueueTimeout = 20 * time.Millisecond
+					unblock = make(chan struct{})
+					transport.RoundTripStub = func(*http.Request) (*http.Response, error) {
+						<-unblock
+						return &http.Response{StatusCode: http.StatusTeapot}, nil
+					}
+				})
+
+				JustBeforeEach(func() {
+					routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+						Host:           "1.1.1.1",
+						Port:           9090,
+						MaxConnections: 1,
+					}))
+				})
+
+				AfterEach(func() {
+					close(unblock)
+				})
+
+				It("bounds the number of connections to the route's backends", func() {
+					go proxyRoundTripper.RoundTrip(req)
+					Eventually(transport.RoundTripCallCount).Should(Equal(1))
+					Expect(routePool.ActiveConnections()).To(Equal(int64(1)))
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(MatchError(fails.RouteConnectionLimitError))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+
+					Expect(errorHandler.HandleErrorCallCount()).To(Equal(1))
+					_, handledErr := errorHandler.HandleErrorArgsForCall(0)
+					Expect(handledErr).To(MatchError(fails.RouteConnectionLimitError))
+					Expect(logger.Buffer()).To(gbytes.Say(`route-connection-limit-reached`))
+				})
+
+				Context("when a connection is released while waiting", func() {
+					BeforeEach(func() {
+						cfg.Backends.RouteMaxConnsQueueTimeout = 5 * time.Second
+					})
+
+					It("sends the queued request", func() {
+						done := make(chan struct{})
+						go func() {
+							defer close(done)
+							proxyRoundTripper.RoundTrip(req)
+						}()
+						Eventually(transport.RoundTripCallCount).Should(Equal(1))
+
+						result := make(chan error)
+						go func() {
+							_, err := proxyRoundTripper.RoundTrip(req)
+							result <- err
+						}()
+						Consistently(transport.RoundTripCallCount, 100*time.Millisecond).Should(Equal(1))
+
+						unblock <- struct{}{}
+						Eventually(done).Should(BeClosed())
+						Eventually(transport.RoundTripCallCount).Should(Equal(2))
+						unblock <- struct{}{}
+						Eventually(result).Should(Receive(BeNil()))
+						Expect(routePool.ActiveConnections()).To(BeZero())
+					})
+				})
+
+				Context("when the response body is streamed slowly", func() {
+					var bodyWriter *io.PipeWriter
+
+					BeforeEach(func() {
+						var bodyReader *io.PipeReader
+						bodyReader, bodyWriter = io.Pipe()
+						transport.RoundTripStub = func(*http.Request) (*http.Response, error) {
+							return &http.Response{StatusCode: http.StatusOK, Body: bodyReader}, nil
+						}
+					})
+
+					It("holds the connection until the body is closed", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(routePool.ActiveConnections()).To(Equal(int64(1)))
+
+						go bodyWriter.Write([]byte("first chunk"))
+						chunk := make([]byte, len("first chunk"))
+						_, err = io.ReadFull(res.Body, chunk)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(routePool.ActiveConnections()).To(Equal(int64(1)))
+
+						_, err = proxyRoundTripper.RoundTrip(req)
+						Expect(err).To(MatchError(fails.RouteConnectionLimitError))
+
+						Expect(res.Body.Close()).To(Succeed())
+						Expect(routePool.ActiveConnections()).To(BeZero())
+
+						Expect(res.Body.Close()).To(Succeed())
+						Expect(routePool.ActiveConnections()).To(BeZero())
+					})
+				})
+
+				Context("when the backend switches protocols", func() {
+					var backendConn net.Conn
+
+					BeforeEach(func() {
+						var conn net.Conn
+						conn, backendConn = net.Pipe()
+						transport.RoundTripStub = func(*http.Request) (*http.Response, error) {
+							return &http.Response{StatusCode: http.StatusSwitchingProtocols, Body: conn}, nil
+						}
+					})
+
+					AfterEach(func() {
+						backendConn.Close()
+					})
+
+					It("holds the connection until the upgraded connection is closed", func() {
+						req.Header.Set("Connection", "Upgrade")
+						req.Header.Set("Upgrade", "websocket")
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(routePool.ActiveConnections()).To(Equal(int64(1)))
+
+						upgraded, ok := res.Body.(io.ReadWriteCloser)
+						Expect(ok).To(BeTrue())
+						go backendConn.Read(make([]byte, 4))
+						_, err = upgraded.Write([]byte("ping"))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(upgraded.Close()).To(Succeed())
+						Expect(routePool.ActiveConnections()).To(BeZero())
+					})
+				})
 			})
 
 			Context("when backend is unavailable due to non-retriable error", func() {
@@ -561,6 +958,237 @@ var _ = Describe("ProxyRoundTripper", func() {
 				})
 			})
 
+			Context("when the backend switches protocols without an upgrade request", func() {
+				var body *testBody
+
+				BeforeEach(func() {
+					body = &testBody{}
+					transport.RoundTripReturns(&http.Response{
+						StatusCode: http.StatusSwitchingProtocols,
+						Header:     http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}},
+						Body:       body,
+					}, nil)
+				})
+
+				It("fails the request with a protocol error", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(MatchError(fails.UnexpectedSwitchingProtocolsError))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+					Expect(body.closeCount).To(Equal(1))
+
+					Expect(errorHandler.HandleErrorCallCount()).To(Equal(1))
+					_, err = errorHandler.HandleErrorArgsForCall(0)
+					Expect(err).To(MatchError(fails.UnexpectedSwitchingProtocolsError))
+					Expect(reqInfo.RoundTripSuccessful).To(BeFalse())
+				})
+
+				Context("when the client requested an upgrade", func() {
+					BeforeEach(func() {
+						req.Header.Set("Connection", "Upgrade")
+						req.Header.Set("Upgrade", "websocket")
+					})
+
+					It("passes the response on", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+						Expect(body.closeCount).To(BeZero())
+					})
+				})
+
+				Context("when rejecting unexpected protocol switches is disabled", func() {
+					BeforeEach(func() {
+						cfg.Backends.RejectUnexpectedSwitchingProtocols = false
+					})
+
+					It("passes the response on", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+					})
+				})
+			})
+
+			Context("when the request headers forwarded to backends are capped", func() {
+				BeforeEach(func() {
+					req.Header.Set("X-Some-Header", strings.Repeat("a", 100))
+					cfg.Backends.MaxRequestHeaderBytes = 200
+					transport.RoundTripReturns(&http.Response{StatusCode: http.StatusTeapot}, nil)
+				})
+
+				It("forwards requests within the cap", func() {
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+				})
+
+				It("rejects requests the added XFCC header pushes over the cap", func() {
+					req.Header.Set("X-Forwarded-Client-Cert", strings.Repeat("b", 100))
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(MatchError(fails.RequestHeadersTooLargeError))
+					Expect(transport.RoundTripCallCount()).To(Equal(0))
+
+					Expect(errorHandler.HandleErrorCallCount()).To(Equal(1))
+					_, err = errorHandler.HandleErrorArgsForCall(0)
+					Expect(err).To(MatchError(fails.RequestHeadersTooLargeError))
+				})
+			})
+
+			Context("when retries are restricted to connection failures", func() {
+				BeforeEach(func() {
+					numEndpoints = 2
+					cfg.Backends.RetryOnlyOnConnectionFailure = true
+					retriableClassifier.ClassifyStub = fails.ConnectionFailureClassifiers.Classify
+				})
+
+				It("retries a failed dial", func() {
+					transport.RoundTripStub = func(*http.Request) (*http.Response, error) {
+						if transport.RoundTripCallCount() == 1 {
+							return nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+						}
+						return &http.Response{StatusCode: http.StatusTeapot}, nil
+					}
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(transport.RoundTripCallCount()).To(Equal(2))
+					Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+				})
+
+				It("does not retry a connection reset after the request was partially written", func() {
+					retriableClassifier.ClassifyStub = nil
+					retriableClassifier.ClassifyReturns(true)
+					transport.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+						if transport.RoundTripCallCount() == 1 {
+							trace := httptrace.ContextClientTrace(req.Context())
+							trace.WroteHeaderField("Host", []string{req.Host})
+							return nil, &net.OpError{Op: "write", Err: errors.New("write: connection reset by peer")}
+						}
+						return &http.Response{StatusCode: http.StatusTeapot}, nil
+					}
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(HaveOccurred())
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when a time to first byte from backends is set", func() {
+				var backendCtx context.Context
+
+				BeforeEach(func() {
+					cfg.BackendTimeToFirstByte = time.Minute
+				})
+
+				It("releases the context of the backend request once the response body is closed", func() {
+					transport.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+						backendCtx = req.Context()
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("body"))}, nil
+					}
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(backendCtx.Err()).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(res.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal("body"))
+
+					Expect(res.Body.Close()).To(Succeed())
+					Expect(backendCtx.Err()).To(MatchError(context.Canceled))
+				})
+
+				It("releases the context of the backend request once it failed", func() {
+					transport.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+						backendCtx = req.Context()
+						return nil, dialError
+					}
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(HaveOccurred())
+					Expect(backendCtx.Err()).To(MatchError(context.Canceled))
+				})
+			})
+
+			Context("when the client times out sending the request body", func() {
+				BeforeEach(func() {
+					numEndpoints = 2
+					retriableClassifier.ClassifyReturns(true)
+				})
+
+				It("does not retry and hands a client request timeout to the error handler", func() {
+					req.Body = io.NopCloser(iotest.ErrReader(os.ErrDeadlineExceeded))
+					transport.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+						_, err := io.ReadAll(req.Body)
+						return nil, err
+					}
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(MatchError(fails.ClientRequestTimeoutError))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+
+					Expect(errorHandler.HandleErrorCallCount()).To(Equal(1))
+					_, err = errorHandler.HandleErrorArgsForCall(0)
+					Expect(err).To(MatchError(fails.ClientRequestTimeoutError))
+				})
+			})
+
+			Context("when request bodies are buffered for retries", func() {
+				var bodies []string
+
+				BeforeEach(func() {
+					numEndpoints = 2
+					cfg.Backends.RetryBodyBufferBytes = 16
+					retriableClassifier.ClassifyStub = fails.RetriableClassifiers.Classify
+					bodies = nil
+
+					req.Method = "POST"
+					req.Header.Set("Idempotency-Key", "abc123")
+					transport.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+						body, err := io.ReadAll(req.Body)
+						Expect(err).NotTo(HaveOccurred())
+						bodies = append(bodies, string(body))
+
+						trace := httptrace.ContextClientTrace(req.Context())
+						trace.GotConn(httptrace.GotConnInfo{})
+						trace.WroteHeaders()
+						if transport.RoundTripCallCount() == 1 {
+							return nil, io.EOF
+						}
+						return &http.Response{StatusCode: http.StatusTeapot}, nil
+					}
+				})
+
+				It("retries a POST with an idempotency key, sending its body again", func() {
+					reqBody.WriteString("some body")
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+					Expect(transport.RoundTripCallCount()).To(Equal(2))
+					Expect(bodies).To(Equal([]string{"some body", "some body"}))
+				})
+
+				It("does not retry a POST with a body larger than the buffer", func() {
+					reqBody.WriteString("a body larger than the buffer")
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(errors.Is(err, io.EOF)).To(BeTrue())
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+					Expect(bodies).To(Equal([]string{"a body larger than the buffer"}))
+				})
+
+				It("does not retry a POST without an idempotency key", func() {
+					req.Header.Del("Idempotency-Key")
+					reqBody.WriteString("some body")
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(errors.Is(err, io.EOF)).To(BeTrue())
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+				})
+			})
+
 			Context("with two endpoints, one of them failing", func() {
 				BeforeEach(func() {
 					numEndpoints = 2
@@ -816,6 +1444,33 @@ var _ = Describe("ProxyRoundTripper", func() {
 					Expect(resp.StatusCode).To(Equal(http.StatusTeapot))
 				})
 
+				Context("when the backend is registered as insecure", func() {
+					JustBeforeEach(func() {
+						endpoint = route.NewEndpoint(&route.EndpointOpts{
+							Host:                  "1.1.1.1",
+							Port:                  9090,
+							UseTLS:                true,
+							InsecureSkipTLSVerify: true,
+						})
+
+						added := routePool.Put(endpoint)
+						Expect(added).To(Equal(route.UPDATED))
+					})
+
+					It("requests an insecure transport and warns on every request", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(roundTripperFactory.RequestedRoundTripperTypes).To(Equal([]RequestedRoundTripperType{
+							{IsRouteService: false, IsHttp2: false, InsecureSkipVerify: true},
+						}))
+						Expect(logger.Buffer()).To(gbytes.Say("backend-tls-verification-disabled-for-route"))
+
+						_, err = proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(logger.Buffer()).To(gbytes.Say("backend-tls-verification-disabled-for-route"))
+					})
+				})
+
 				Context("when the backend is registered with a non-tls port", func() {
 					JustBeforeEach(func() {
 						endpoint = route.NewEndpoint(&route.EndpointOpts{
@@ -1058,6 +1713,7 @@ var _ = Describe("ProxyRoundTripper", func() {
 					sessionCookie *round_tripper.Cookie
 					endpoint1     *route.Endpoint
 					endpoint2     *route.Endpoint
+					stickyTTL     time.Duration
 
 					// options for transport.RoundTripStub
 					responseContainsNoCookies                     func(req *http.Request) (*http.Response, error)
@@ -1136,6 +1792,10 @@ var _ = Describe("ProxyRoundTripper", func() {
 					return resp, nil
 				}
 
+				BeforeEach(func() {
+					stickyTTL = 0
+				})
+
 				JustBeforeEach(func() {
 					sessionCookie = &round_tripper.Cookie{
 						Cookie: http.Cookie{
@@ -1145,9 +1805,11 @@ var _ = Describe("ProxyRoundTripper", func() {
 
 					endpoint1 = route.NewEndpoint(&route.EndpointOpts{
 						Host: "1.1.1.1", Port: 9091, PrivateInstanceId: "id-1",
+						StickySessionTTL: stickyTTL,
 					})
 					endpoint2 = route.NewEndpoint(&route.EndpointOpts{
 						Host: "1.1.1.1", Port: 9092, PrivateInstanceId: "id-2",
+						StickySessionTTL: stickyTTL,
 					})
 
 					added := routePool.Put(endpoint1)
@@ -1650,6 +2312,74 @@ var _ = Describe("ProxyRoundTripper", func() {
 						}).ShouldNot(HaveOccurred())
 					})
 				})
+
+				Context("when the route has a sticky session ttl", func() {
+					BeforeEach(func() {
+						stickyTTL = 5 * time.Minute
+						transport.RoundTripStub = responseContainsJSESSIONID
+					})
+
+					It("limits the lifetime of the VCAP_ID to the ttl", func() {
+						resp, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+
+						cookies := resp.Cookies()
+						Expect(cookies).To(HaveLen(2))
+						Expect(cookies[1].Name).To(Equal(round_tripper.VcapCookieId))
+						Expect(cookies[1].MaxAge).To(Equal(300))
+					})
+
+					Context("when the request is sent to the instance of its VCAP_ID", func() {
+						JustBeforeEach(func() {
+							req.AddCookie(&http.Cookie{Name: StickyCookieKey, Value: "session"})
+							req.AddCookie(&http.Cookie{Name: round_tripper.VcapCookieId, Value: "id-2"})
+						})
+
+						It("keeps the affinity without renewing it", func() {
+							resp, err := proxyRoundTripper.RoundTrip(req)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(transport.RoundTripArgsForCall(0).URL.Host).To(Equal("1.1.1.1:9092"))
+
+							cookies := resp.Cookies()
+							Expect(cookies).To(HaveLen(1))
+							Expect(cookies[0].Name).To(Equal(StickyCookieKey))
+						})
+					})
+
+					Context("when the VCAP_ID has expired but the session cookie has not", func() {
+						JustBeforeEach(func() {
+							req.AddCookie(&http.Cookie{Name: StickyCookieKey, Value: "session"})
+							transport.RoundTripStub = responseContainsNoCookies
+						})
+
+						It("balances the session anew and sets a VCAP_ID for the chosen instance", func() {
+							resp, err := proxyRoundTripper.RoundTrip(req)
+							Expect(err).ToNot(HaveOccurred())
+
+							cookies := resp.Cookies()
+							Expect(cookies).To(HaveLen(1))
+							Expect(cookies[0].Name).To(Equal(round_tripper.VcapCookieId))
+							Expect(cookies[0].Value).To(SatisfyAny(Equal("id-1"), Equal("id-2")))
+							Expect(cookies[0].MaxAge).To(Equal(300))
+						})
+					})
+				})
+
+				Context("when the route has no sticky session ttl", func() {
+					BeforeEach(func() {
+						transport.RoundTripStub = responseContainsNoCookies
+					})
+
+					JustBeforeEach(func() {
+						req.AddCookie(&http.Cookie{Name: StickyCookieKey, Value: "session"})
+					})
+
+					It("does not set a VCAP_ID for a request without one", func() {
+						resp, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(resp.Cookies()).To(BeEmpty())
+					})
+				})
 			})
 
 			Context("when endpoint timeout is not 0", func() {
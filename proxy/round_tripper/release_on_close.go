@@ -0,0 +1,40 @@
+package round_tripper
+
+import (
+	"io"
+	"sync"
+)
+
+// releasingBody calls release once the response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// releasingConn is the releasingBody of a 101 Switching Protocols response.
+// The reverse proxy writes to the body, which is the backend connection.
+type releasingConn struct {
+	*releasingBody
+	writer io.Writer
+}
+
+func (c releasingConn) Write(p []byte) (int, error) {
+	return c.writer.Write(p)
+}
+
+// releaseOnClose returns body calling release once it is closed. Bodies that
+// are writable stay writable.
+func releaseOnClose(body io.ReadCloser, release func()) io.ReadCloser {
+	b := &releasingBody{ReadCloser: body, release: release}
+	if w, ok := body.(io.Writer); ok {
+		return releasingConn{releasingBody: b, writer: w}
+	}
+	return b
+}
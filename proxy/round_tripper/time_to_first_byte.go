@@ -0,0 +1,60 @@
+package round_tripper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/mdimiceli/gorouter/proxy/fails"
+)
+
+// withTimeToFirstByte returns a copy of request which is aborted if the
+// backend does not start responding within timeout of the request being
+// written. The first returned function reports whether the request was
+// aborted. The second one releases the timer and the context of the request,
+// and must be called once the request failed or its response body is closed.
+func withTimeToFirstByte(request *http.Request, timeout time.Duration) (*http.Request, func() bool, func()) {
+	ctx, cancel := context.WithCancelCause(request.Context())
+
+	var (
+		mu        sync.Mutex
+		timer     *time.Timer
+		responded bool
+	)
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !responded && timer == nil {
+				timer = time.AfterFunc(timeout, func() {
+					cancel(fails.BackendTimeToFirstByteError)
+				})
+			}
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			responded = true
+			if timer != nil {
+				timer.Stop()
+			}
+		},
+	})
+
+	timedOut := func() bool {
+		return errors.Is(context.Cause(ctx), fails.BackendTimeToFirstByteError)
+	}
+	release := func() {
+		mu.Lock()
+		responded = true
+		if timer != nil {
+			timer.Stop()
+		}
+		mu.Unlock()
+		cancel(nil)
+	}
+	return request.WithContext(ctx), timedOut, release
+}
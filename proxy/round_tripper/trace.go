@@ -13,6 +13,7 @@ type requestTracer struct {
 	gotConn      atomic.Bool
 	connInfo     atomic.Pointer[httptrace.GotConnInfo]
 	wroteHeaders atomic.Bool
+	wroteBytes   atomic.Bool
 
 	// all times are stored as returned by time.Time{}.UnixNano()
 	dnsStart  atomic.Int64
@@ -28,6 +29,7 @@ func (t *requestTracer) Reset() {
 	t.gotConn.Store(false)
 	t.connInfo.Store(nil)
 	t.wroteHeaders.Store(false)
+	t.wroteBytes.Store(false)
 	t.dnsStart.Store(0)
 	t.dnsDone.Store(0)
 	t.dialStart.Store(0)
@@ -46,6 +48,12 @@ func (t *requestTracer) WroteHeaders() bool {
 	return t.wroteHeaders.Load()
 }
 
+// WroteRequestBytes returns true if any part of the request was written to the
+// connection on the traced request, even if the headers were not written in full.
+func (t *requestTracer) WroteRequestBytes() bool {
+	return t.wroteBytes.Load()
+}
+
 // ConnReused returns true if the traced request used an idle connection.
 // it returns false if no idle connection was used or if the information was unavailable.
 func (t *requestTracer) ConnReused() bool {
@@ -149,7 +157,11 @@ func traceRequest(req *http.Request) (*http.Request, *requestTracer) {
 		TLSHandshakeDone: func(_ tls.ConnectionState, _ error) {
 			t.tlsDone.Store(time.Now().UnixNano())
 		},
+		WroteHeaderField: func(_ string, _ []string) {
+			t.wroteBytes.Store(true)
+		},
 		WroteHeaders: func() {
+			t.wroteBytes.Store(true)
 			t.wroteHeaders.Store(true)
 		},
 	}))
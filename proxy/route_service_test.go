@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/mdimiceli/gorouter/common/secure"
+	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/routeservice"
 	"github.com/mdimiceli/gorouter/test_util"
 	. "github.com/onsi/ginkgo/v2"
@@ -225,6 +226,124 @@ var _ = Describe("Route Services", func() {
 				Expect(res.StatusCode).To(Equal(http.StatusOK))
 			})
 
+			Context("when the route requires basic auth", func() {
+				BeforeEach(func() {
+					conf.RouteBasicAuth = map[string]config.BasicAuthConfig{
+						"operators": {
+							Realm: "operators",
+							Users: map[string]string{"alice": "secret"},
+						},
+					}
+				})
+
+				It("routes to the backend instance without credentials", func() {
+					ln := test_util.RegisterConnHandler(r, "my_host.com", func(conn *test_util.HttpConn) {
+						_, err := conn.ReadRequest()
+						Expect(err).ToNot(HaveOccurred())
+
+						conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+						conn.Close()
+					}, test_util.RegisterConfig{RouteServiceUrl: routeServiceURL, BasicAuth: "operators"})
+					defer func() {
+						Expect(ln.Close()).ToNot(HaveErrored())
+					}()
+
+					conn := dialProxy(proxyServer)
+
+					req := test_util.NewRequest("GET", "my_host.com", "/resource+9-9_9?query=123&query$2=345#page1..5", nil)
+					req.Header.Set(routeservice.HeaderKeySignature, signatureHeader)
+					req.Header.Set(routeservice.HeaderKeyMetadata, metadataHeader)
+					req.Header.Set(routeservice.HeaderKeyForwardedURL, "http://some-backend-url")
+					conn.WriteRequest(req)
+
+					res, _ := conn.ReadResponse()
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+				})
+
+				It("requires credentials from requests without a signature", func() {
+					ln := test_util.RegisterConnHandler(r, "my_host.com", func(conn *test_util.HttpConn) {
+						defer GinkgoRecover()
+						Fail("Should not get here")
+					}, test_util.RegisterConfig{RouteServiceUrl: routeServiceURL, BasicAuth: "operators"})
+					defer func() {
+						Expect(ln.Close()).ToNot(HaveErrored())
+					}()
+
+					conn := dialProxy(proxyServer)
+					conn.WriteRequest(test_util.NewRequest("GET", "my_host.com", "/", nil))
+
+					res, _ := conn.ReadResponse()
+					Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+				})
+			})
+
+			Context("when requests are authenticated with client certificates", func() {
+				BeforeEach(func() {
+					conf.Auth.Type = config.AUTH_MTLS
+				})
+
+				It("routes to the backend instance without a client certificate", func() {
+					ln := test_util.RegisterConnHandler(r, "my_host.com", func(conn *test_util.HttpConn) {
+						_, err := conn.ReadRequest()
+						Expect(err).ToNot(HaveOccurred())
+
+						conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+						conn.Close()
+					}, test_util.RegisterConfig{RouteServiceUrl: routeServiceURL})
+					defer func() {
+						Expect(ln.Close()).ToNot(HaveErrored())
+					}()
+
+					conn := dialProxy(proxyServer)
+
+					req := test_util.NewRequest("GET", "my_host.com", "/resource+9-9_9?query=123&query$2=345#page1..5", nil)
+					req.Header.Set(routeservice.HeaderKeySignature, signatureHeader)
+					req.Header.Set(routeservice.HeaderKeyMetadata, metadataHeader)
+					req.Header.Set(routeservice.HeaderKeyForwardedURL, forwardedUrl)
+					conn.WriteRequest(req)
+
+					res, _ := conn.ReadResponse()
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+				})
+
+				It("rejects requests without a signature", func() {
+					ln := test_util.RegisterConnHandler(r, "my_host.com", func(conn *test_util.HttpConn) {
+						defer GinkgoRecover()
+						Fail("Should not get here")
+					}, test_util.RegisterConfig{RouteServiceUrl: routeServiceURL})
+					defer func() {
+						Expect(ln.Close()).ToNot(HaveErrored())
+					}()
+
+					conn := dialProxy(proxyServer)
+					conn.WriteRequest(test_util.NewRequest("GET", "my_host.com", "/", nil))
+
+					res, _ := conn.ReadResponse()
+					Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+				})
+
+				It("rejects requests replaying the signature of another URL", func() {
+					ln := test_util.RegisterConnHandler(r, "my_host.com", func(conn *test_util.HttpConn) {
+						defer GinkgoRecover()
+						Fail("Should not get here")
+					}, test_util.RegisterConfig{RouteServiceUrl: routeServiceURL})
+					defer func() {
+						Expect(ln.Close()).ToNot(HaveErrored())
+					}()
+
+					conn := dialProxy(proxyServer)
+
+					req := test_util.NewRequest("GET", "my_host.com", "/admin", nil)
+					req.Header.Set(routeservice.HeaderKeySignature, signatureHeader)
+					req.Header.Set(routeservice.HeaderKeyMetadata, metadataHeader)
+					req.Header.Set(routeservice.HeaderKeyForwardedURL, forwardedUrl)
+					conn.WriteRequest(req)
+
+					res, _ := conn.ReadResponse()
+					Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+				})
+			})
+
 			Context("when request has Host header with a port", func() {
 				It("routes to backend instance and disregards port in Host header", func() {
 					ln := test_util.RegisterConnHandler(r, "my_host.com", func(conn *test_util.HttpConn) {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"net/http"
+	"time"
 )
 
 type ProxyResponseWriter interface {
@@ -49,7 +50,13 @@ func (p *proxyResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if !ok {
 		return nil, nil, errors.New("response writer cannot hijack")
 	}
-	return hijacker.Hijack()
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && conn != nil {
+		// the connection outlives the request, so the read timeout of the
+		// server must no longer apply to it
+		_ = conn.SetReadDeadline(time.Time{})
+	}
+	return conn, rw, err
 }
 
 func (p *proxyResponseWriter) Write(b []byte) (int, error) {
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mdimiceli/gorouter/proxy/utils"
+)
+
+// writeStallResponseWriter bounds how long a single write of the response may
+// block on a client which does not read it. The reverse proxy copies the body
+// through a fixed size buffer and only reads from the backend once the
+// previous chunk was written, so a stalled write holds the backend connection
+// but not more memory.
+type writeStallResponseWriter struct {
+	utils.ProxyResponseWriter
+	rc        *http.ResponseController
+	timeout   time.Duration
+	stalled   bool
+	onStalled func()
+}
+
+func newWriteStallResponseWriter(w utils.ProxyResponseWriter, timeout time.Duration, onStalled func()) *writeStallResponseWriter {
+	return &writeStallResponseWriter{
+		ProxyResponseWriter: w,
+		rc:                  http.NewResponseController(w),
+		timeout:             timeout,
+		onStalled:           onStalled,
+	}
+}
+
+func (w *writeStallResponseWriter) Write(b []byte) (int, error) {
+	// writers which do not support deadlines are not limited
+	_ = w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+	n, err := w.ProxyResponseWriter.Write(b)
+	if err != nil && !w.stalled && errors.Is(err, os.ErrDeadlineExceeded) {
+		w.stalled = true
+		w.onStalled()
+	}
+	return n, err
+}
+
+// clearDeadline removes the write deadline so that it does not apply to the
+// next request on the same connection.
+func (w *writeStallResponseWriter) clearDeadline() {
+	_ = w.rc.SetWriteDeadline(time.Time{})
+}
+
+// Satisfy http.ResponseController support (Go 1.20+)
+func (w *writeStallResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ProxyResponseWriter
+}
@@ -25,6 +25,16 @@ type Registry interface {
 
 type PruneStatus int
 
+type registrationKey struct {
+	uri  route.Uri
+	addr string
+}
+
+type lastRegistration struct {
+	endpoint  *route.Endpoint
+	appliedAt time.Time
+}
+
 const (
 	CONNECTED = PruneStatus(iota)
 	DISCONNECTED
@@ -55,6 +65,25 @@ type RouteRegistry struct {
 	isolationSegments        []string
 
 	maxConnsPerBackend int64
+	slowStartWindow    time.Duration
+	maxHTTP2Requests   int64
+	routeConflictMode  string
+
+	// caseSensitivePaths keeps the case of route paths when registering and
+	// looking them up
+	caseSensitivePaths bool
+
+	// lastRegistrations holds the last applied registration of each endpoint
+	// of a route, to coalesce identical ones within the debounce interval
+	registrationDebounceInterval time.Duration
+	lastRegistrations            map[registrationKey]lastRegistration
+	lastRegistrationsLock        sync.Mutex
+
+	// snapshotEndpoints holds the endpoints loaded from a snapshot with the
+	// route key they were loaded for, until they expire at snapshotExpiresAt
+	snapshotEndpoints map[*route.Endpoint]route.Uri
+	snapshotExpiresAt time.Time
+	snapshotLock      sync.Mutex
 
 	EmptyPoolTimeout         time.Duration
 	EmptyPoolResponseCode503 bool
@@ -75,6 +104,13 @@ func NewRouteRegistry(logger logger.Logger, c *config.Config, reporter metrics.R
 	r.isolationSegments = c.IsolationSegments
 
 	r.maxConnsPerBackend = c.Backends.MaxConns
+	r.slowStartWindow = c.Backends.SlowStartWindow
+	r.maxHTTP2Requests = c.Backends.MaxHTTP2Requests
+	r.routeConflictMode = c.RouteConflictMode
+	r.caseSensitivePaths = c.CaseSensitiveRoutePaths
+	r.registrationDebounceInterval = c.RegistrationDebounceInterval
+	r.lastRegistrations = map[registrationKey]lastRegistration{}
+	r.snapshotEndpoints = map[*route.Endpoint]route.Uri{}
 	r.EmptyPoolTimeout = c.EmptyPoolTimeout
 	r.EmptyPoolResponseCode503 = c.EmptyPoolResponseCode503
 	return r
@@ -86,9 +122,13 @@ func (r *RouteRegistry) Register(uri route.Uri, endpoint *route.Endpoint) {
 	}
 
 	endpointAdded := r.register(uri, endpoint)
+	r.keepSnapshotEndpoint(uri, endpoint)
 
 	r.reporter.CaptureRegistryMessage(endpoint)
 
+	if endpointAdded == route.ADDED {
+		r.reporter.CaptureEndpointRegistered()
+	}
 	if endpointAdded == route.ADDED && !endpoint.UpdatedAt.IsZero() {
 		r.reporter.CaptureRouteRegistrationLatency(time.Since(endpoint.UpdatedAt))
 	}
@@ -108,7 +148,7 @@ func (r *RouteRegistry) register(uri route.Uri, endpoint *route.Endpoint) route.
 	defer r.RUnlock()
 
 	t := time.Now()
-	routekey := uri.RouteKey()
+	routekey := r.routeKey(uri)
 	pool := r.byURI.Find(routekey)
 
 	if pool == nil {
@@ -122,7 +162,39 @@ func (r *RouteRegistry) register(uri route.Uri, endpoint *route.Endpoint) route.
 		endpoint.StaleThreshold = r.dropletStaleThreshold
 	}
 
+	if r.debounced(routekey, endpoint, t) {
+		return route.UNMODIFIED
+	}
+
+	conflicting := conflictingEndpoints(pool, endpoint)
+	if len(conflicting) > 0 {
+		switch r.routeConflictMode {
+		case config.ROUTE_CONFLICT_REJECT:
+			r.reporter.CaptureRouteConflict()
+			r.logger.Error("route-conflict-registration-rejected", zapData(uri, endpoint)...)
+			return route.UNMODIFIED
+		case config.ROUTE_CONFLICT_LAST_WINS:
+			r.reporter.CaptureRouteConflict()
+			for _, e := range conflicting {
+				if pool.Remove(e) {
+					r.forgetRegistration(routekey, e)
+				}
+			}
+			r.logger.Info("route-conflict-endpoints-replaced", append(zapData(uri, endpoint), zap.Int("replaced_endpoints", len(conflicting)))...)
+		}
+	}
+
 	endpointAdded := pool.Put(endpoint)
+	if endpointAdded == route.ADDED || endpointAdded == route.UPDATED {
+		r.recordRegistration(routekey, endpoint, t)
+	}
+
+	// merged endpoints are only reported when the conflict arises, not on
+	// every refresh of their registration
+	if len(conflicting) > 0 && r.routeConflictMode == config.ROUTE_CONFLICT_MERGE && endpointAdded == route.ADDED {
+		r.reporter.CaptureRouteConflict()
+		r.logger.Info("route-conflict-endpoints-merged", zapData(uri, endpoint)...)
+	}
 
 	r.SetTimeOfLastUpdate(t)
 
@@ -144,6 +216,9 @@ func (r *RouteRegistry) insertRouteKey(routekey route.Uri, uri route.Uri) *route
 			Host:               host,
 			ContextPath:        contextPath,
 			MaxConnsPerBackend: r.maxConnsPerBackend,
+			SlowStartWindow:    r.slowStartWindow,
+
+			MaxRequestsPerHTTP2Backend: r.maxHTTP2Requests,
 		})
 		r.byURI.Insert(routekey, pool)
 		r.logger.Info("route-registered", zap.Stringer("uri", routekey))
@@ -167,12 +242,14 @@ func (r *RouteRegistry) unregister(uri route.Uri, endpoint *route.Endpoint) {
 	r.Lock()
 	defer r.Unlock()
 
-	uri = uri.RouteKey()
+	uri = r.routeKey(uri)
+	r.forgetRegistration(uri, endpoint)
 
 	pool := r.byURI.Find(uri)
 	if pool != nil {
 		endpointRemoved := pool.Remove(endpoint)
 		if endpointRemoved {
+			r.reporter.CaptureEndpointUnregistered()
 			r.logger.Info("endpoint-unregistered", zapData(uri, endpoint)...)
 		} else {
 			r.logger.Info("endpoint-not-unregistered", zapData(uri, endpoint)...)
@@ -207,7 +284,7 @@ func (r *RouteRegistry) lookup(uri route.Uri) *route.EndpointPool {
 	r.RLock()
 	defer r.RUnlock()
 
-	uri = uri.RouteKey()
+	uri = r.routeKey(uri)
 	var err error
 	pool := r.byURI.MatchUri(uri)
 	for pool == nil && err == nil {
@@ -236,7 +313,7 @@ func (r *RouteRegistry) endpointInRouterShard(endpoint *route.Endpoint) bool {
 }
 
 func (r *RouteRegistry) LookupWithInstance(uri route.Uri, appID string, appIndex string) *route.EndpointPool {
-	uri = uri.RouteKey()
+	uri = r.routeKey(uri)
 	p := r.Lookup(uri)
 
 	if p == nil {
@@ -253,6 +330,8 @@ func (r *RouteRegistry) LookupWithInstance(uri route.Uri, appID string, appIndex
 				Host:               p.Host(),
 				ContextPath:        p.ContextPath(),
 				MaxConnsPerBackend: p.MaxConnsPerBackend(),
+
+				MaxRequestsPerHTTP2Backend: p.MaxRequestsPerHTTP2Backend(),
 			})
 			surgicalPool.Put(e)
 		}
@@ -272,8 +351,10 @@ func (r *RouteRegistry) StartPruningCycle() {
 				<-r.ticker.C
 				r.logger.Debug("start-pruning-routes")
 				r.pruneStaleDroplets()
+				r.expireSnapshot(time.Now())
 				r.logger.Debug("finished-pruning-routes")
 				r.reporter.CaptureRouteStats(r.NumUris(), r.MSSinceLastUpdate())
+				r.reporter.CaptureTotalEndpoints(r.NumEndpoints())
 			}
 		}()
 	}
@@ -324,6 +405,26 @@ func (r *RouteRegistry) NumEndpoints() int {
 	return r.byURI.EndpointCount()
 }
 
+// DrainEndpoint stops routing new requests to the endpoint with the given
+// address on all of its routes, until it unregisters. It returns the number of
+// routes the endpoint is registered for.
+func (r *RouteRegistry) DrainEndpoint(addr string) int {
+	r.RLock()
+	defer r.RUnlock()
+
+	drained := 0
+	r.byURI.EachNodeWithPool(func(t *container.Trie) {
+		if t.Pool.Drain(addr) {
+			drained++
+		}
+	})
+
+	if drained > 0 {
+		r.logger.Info("endpoint-draining", zap.String("address", addr), zap.Int("routes", drained))
+	}
+	return drained
+}
+
 func (r *RouteRegistry) MarshalJSON() ([]byte, error) {
 	r.RLock()
 	defer r.RUnlock()
@@ -364,6 +465,7 @@ func (r *RouteRegistry) pruneStaleDroplets() {
 			addresses := []string{}
 			for _, e := range endpoints {
 				addresses = append(addresses, e.CanonicalAddr())
+				r.forgetRegistration(r.routeKey(route.Uri(t.ToPath())), e)
 			}
 			isolationSegment := endpoints[0].IsolationSegment
 			if isolationSegment == "" {
@@ -393,6 +495,14 @@ func (r *RouteRegistry) freshenRoutes() {
 	})
 }
 
+// routeKey returns the key uri is registered and looked up by.
+func (r *RouteRegistry) routeKey(uri route.Uri) route.Uri {
+	if r.caseSensitivePaths {
+		return uri.CaseSensitiveRouteKey()
+	}
+	return uri.RouteKey()
+}
+
 func splitHostAndContextPath(uri route.Uri) (string, string) {
 	contextPath := "/"
 	trimmedUri := strings.TrimPrefix(uri.String(), "/")
@@ -409,6 +519,61 @@ func splitHostAndContextPath(uri route.Uri) (string, string) {
 	return before, contextPath
 }
 
+// debounced reports whether endpoint is identical to the last applied
+// registration of its address for the route within the debounce interval.
+// Debounced registrations do not freshen the endpoint, so endpoints which go
+// stale within the interval are never debounced.
+func (r *RouteRegistry) debounced(uri route.Uri, endpoint *route.Endpoint, now time.Time) bool {
+	if r.registrationDebounceInterval <= 0 || endpoint.StaleThreshold <= r.registrationDebounceInterval {
+		return false
+	}
+
+	r.lastRegistrationsLock.Lock()
+	defer r.lastRegistrationsLock.Unlock()
+
+	last, ok := r.lastRegistrations[registrationKey{uri: uri, addr: endpoint.CanonicalAddr()}]
+	return ok && now.Sub(last.appliedAt) < r.registrationDebounceInterval && last.endpoint.Equal(endpoint)
+}
+
+// recordRegistration records endpoint as the last applied registration of its
+// address for the route.
+func (r *RouteRegistry) recordRegistration(uri route.Uri, endpoint *route.Endpoint, now time.Time) {
+	if r.registrationDebounceInterval <= 0 {
+		return
+	}
+
+	r.lastRegistrationsLock.Lock()
+	defer r.lastRegistrationsLock.Unlock()
+	r.lastRegistrations[registrationKey{uri: uri, addr: endpoint.CanonicalAddr()}] = lastRegistration{endpoint: endpoint, appliedAt: now}
+}
+
+func (r *RouteRegistry) forgetRegistration(uri route.Uri, endpoint *route.Endpoint) {
+	if r.registrationDebounceInterval <= 0 {
+		return
+	}
+
+	r.lastRegistrationsLock.Lock()
+	defer r.lastRegistrationsLock.Unlock()
+	delete(r.lastRegistrations, registrationKey{uri: uri, addr: endpoint.CanonicalAddr()})
+}
+
+// conflictingEndpoints returns the endpoints of the pool which belong to
+// another app than endpoint. An endpoint at the same address is not in
+// conflict, as the registration replaces it.
+func conflictingEndpoints(pool *route.EndpointPool, endpoint *route.Endpoint) []*route.Endpoint {
+	if endpoint.ApplicationId == "" {
+		return nil
+	}
+
+	var conflicting []*route.Endpoint
+	pool.Each(func(e *route.Endpoint) {
+		if e.ApplicationId != "" && e.ApplicationId != endpoint.ApplicationId && e.CanonicalAddr() != endpoint.CanonicalAddr() {
+			conflicting = append(conflicting, e)
+		}
+	})
+	return conflicting
+}
+
 func zapData(uri route.Uri, endpoint *route.Endpoint) []zap.Field {
 	isoSegField := zap.String("isolation_segment", "-")
 	if endpoint.IsolationSegment != "" {
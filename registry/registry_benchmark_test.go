@@ -39,6 +39,7 @@ func setupLogger() logger.Logger {
 	l := logger.NewLogger(
 		"test",
 		"unix-epoch",
+		"json",
 		zap.WarnLevel,
 		zap.Output(zap.MultiWriteSyncer(sink, zap.AddSync(ginkgo.GinkgoWriter))),
 		zap.ErrorOutput(zap.MultiWriteSyncer(sink, zap.AddSync(ginkgo.GinkgoWriter))),
@@ -77,6 +77,13 @@ var _ = Describe("RouteRegistry", func() {
 			Expect(reporter.CaptureRegistryMessageCallCount()).To(Equal(1))
 		})
 
+		It("counts added endpoints", func() {
+			r.Register("foo", fooEndpoint)
+			r.Register("foo", fooEndpoint)
+			r.Register("bar", fooEndpoint)
+			Expect(reporter.CaptureEndpointRegisteredCallCount()).To(Equal(2))
+		})
+
 		Context("when the endpoint has an UpdatedAt timestamp", func() {
 			BeforeEach(func() {
 				fooEndpoint.UpdatedAt = time.Now().Add(-3 * time.Second)
@@ -99,6 +106,180 @@ var _ = Describe("RouteRegistry", func() {
 			})
 		})
 
+		Context("when registrations are debounced", func() {
+			BeforeEach(func() {
+				configObj.DropletStaleThreshold = time.Minute
+				configObj.RegistrationDebounceInterval = 10 * time.Second
+				r = NewRouteRegistry(logger, configObj, reporter)
+			})
+
+			It("coalesces identical registrations", func() {
+				r.Register("foo", fooEndpoint)
+				firstUpdateTime := r.TimeOfLastUpdate()
+
+				r.Register("foo", route.NewEndpoint(&route.EndpointOpts{
+					Host: "192.168.1.1",
+					Tags: map[string]string{
+						"runtime":   "ruby18",
+						"framework": "sinatra",
+					}}))
+				Expect(r.TimeOfLastUpdate()).To(Equal(firstUpdateTime))
+				Expect(r.NumEndpoints()).To(Equal(1))
+			})
+
+			It("applies registrations which change the endpoint", func() {
+				r.Register("foo", fooEndpoint)
+				firstUpdateTime := r.TimeOfLastUpdate()
+
+				r.Register("foo", route.NewEndpoint(&route.EndpointOpts{
+					Host: "192.168.1.1",
+					Tags: map[string]string{"runtime": "ruby19"},
+				}))
+				Expect(r.TimeOfLastUpdate()).To(BeTemporally(">", firstUpdateTime))
+
+				var tags map[string]string
+				r.Lookup("foo").Each(func(e *route.Endpoint) { tags = e.Tags })
+				Expect(tags).To(Equal(map[string]string{"runtime": "ruby19"}))
+			})
+
+			It("applies registrations of other endpoints and routes", func() {
+				r.Register("foo", fooEndpoint)
+				r.Register("foo", barEndpoint)
+				r.Register("bar", fooEndpoint)
+
+				Expect(r.NumUris()).To(Equal(2))
+				Expect(r.NumEndpoints()).To(Equal(2))
+				Expect(r.Lookup("foo").NumEndpoints()).To(Equal(2))
+			})
+
+			It("applies a registration right after the endpoint was unregistered", func() {
+				r.Register("foo", fooEndpoint)
+				r.Unregister("foo", fooEndpoint)
+				Expect(r.NumUris()).To(BeZero())
+
+				r.Register("foo", fooEndpoint)
+				Expect(r.NumUris()).To(Equal(1))
+			})
+
+			It("applies registrations of endpoints which go stale within the interval", func() {
+				staleEndpoint := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", StaleThresholdInSeconds: 5})
+				r.Register("foo", staleEndpoint)
+				firstUpdateTime := r.TimeOfLastUpdate()
+
+				r.Register("foo", route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", StaleThresholdInSeconds: 5}))
+				Expect(r.TimeOfLastUpdate()).To(BeTemporally(">", firstUpdateTime))
+			})
+		})
+
+		Context("when two apps register the same route", func() {
+			var app1Endpoint, app2Endpoint *route.Endpoint
+
+			BeforeEach(func() {
+				app1Endpoint = route.NewEndpoint(&route.EndpointOpts{AppId: "app1", Host: "192.168.1.10", Port: 8080})
+				app2Endpoint = route.NewEndpoint(&route.EndpointOpts{AppId: "app2", Host: "192.168.1.11", Port: 8080})
+			})
+
+			appIDs := func() []string {
+				var ids []string
+				r.Lookup("conflict.route").Each(func(e *route.Endpoint) {
+					ids = append(ids, e.ApplicationId)
+				})
+				return ids
+			}
+
+			Context("in merge mode", func() {
+				It("routes to the endpoints of both apps", func() {
+					r.Register("conflict.route", app1Endpoint)
+					r.Register("conflict.route", app2Endpoint)
+
+					Expect(appIDs()).To(ConsistOf("app1", "app2"))
+					Expect(reporter.CaptureRouteConflictCallCount()).To(Equal(1))
+					Expect(logger).To(gbytes.Say(`route-conflict-endpoints-merged.*app2`))
+				})
+
+				It("reports the conflict only once", func() {
+					r.Register("conflict.route", app1Endpoint)
+					r.Register("conflict.route", app2Endpoint)
+					r.Register("conflict.route", app2Endpoint)
+
+					Expect(reporter.CaptureRouteConflictCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("in reject mode", func() {
+				BeforeEach(func() {
+					configObj.RouteConflictMode = config.ROUTE_CONFLICT_REJECT
+					r = NewRouteRegistry(logger, configObj, reporter)
+				})
+
+				It("rejects the registration of the second app", func() {
+					r.Register("conflict.route", app1Endpoint)
+					r.Register("conflict.route", app2Endpoint)
+
+					Expect(appIDs()).To(ConsistOf("app1"))
+					Expect(reporter.CaptureRouteConflictCallCount()).To(Equal(1))
+					Expect(logger).To(gbytes.Say(`route-conflict-registration-rejected.*app2`))
+				})
+
+				It("registers more endpoints of the first app", func() {
+					r.Register("conflict.route", app1Endpoint)
+					r.Register("conflict.route", route.NewEndpoint(&route.EndpointOpts{AppId: "app1", Host: "192.168.1.12", Port: 8080}))
+
+					Expect(appIDs()).To(ConsistOf("app1", "app1"))
+					Expect(reporter.CaptureRouteConflictCallCount()).To(BeZero())
+				})
+
+				Context("when registrations are debounced", func() {
+					BeforeEach(func() {
+						configObj.DropletStaleThreshold = time.Minute
+						configObj.RegistrationDebounceInterval = 10 * time.Second
+						r = NewRouteRegistry(logger, configObj, reporter)
+					})
+
+					It("applies a rejected registration once the conflict is gone", func() {
+						r.Register("conflict.route", app1Endpoint)
+						r.Register("conflict.route", app2Endpoint)
+						r.Unregister("conflict.route", app1Endpoint)
+
+						r.Register("conflict.route", app2Endpoint)
+						Expect(appIDs()).To(ConsistOf("app2"))
+					})
+				})
+			})
+
+			Context("in last wins mode", func() {
+				BeforeEach(func() {
+					configObj.RouteConflictMode = config.ROUTE_CONFLICT_LAST_WINS
+					r = NewRouteRegistry(logger, configObj, reporter)
+				})
+
+				It("replaces the endpoints of the first app", func() {
+					r.Register("conflict.route", app1Endpoint)
+					r.Register("conflict.route", app2Endpoint)
+
+					Expect(appIDs()).To(ConsistOf("app2"))
+					Expect(reporter.CaptureRouteConflictCallCount()).To(Equal(1))
+					Expect(logger).To(gbytes.Say(`route-conflict-endpoints-replaced.*app2`))
+				})
+
+				Context("when registrations are debounced", func() {
+					BeforeEach(func() {
+						configObj.DropletStaleThreshold = time.Minute
+						configObj.RegistrationDebounceInterval = 10 * time.Second
+						r = NewRouteRegistry(logger, configObj, reporter)
+					})
+
+					It("applies the registration of a replaced endpoint", func() {
+						r.Register("conflict.route", app1Endpoint)
+						r.Register("conflict.route", app2Endpoint)
+
+						r.Register("conflict.route", app1Endpoint)
+						Expect(appIDs()).To(ConsistOf("app1"))
+					})
+				})
+			})
+		})
+
 		Context("uri", func() {
 			It("records and tracks time of last update", func() {
 				r.Register("foo", fooEndpoint)
@@ -444,6 +625,13 @@ var _ = Describe("RouteRegistry", func() {
 	})
 
 	Context("Unregister", func() {
+		It("counts removed endpoints", func() {
+			r.Register("foo", fooEndpoint)
+			r.Unregister("foo", fooEndpoint)
+			r.Unregister("foo", fooEndpoint)
+			Expect(reporter.CaptureEndpointUnregisteredCallCount()).To(Equal(1))
+		})
+
 		Context("when endpoint has component tagged", func() {
 			BeforeEach(func() {
 				fooEndpoint.Tags = map[string]string{"component": "oauth-server"}
@@ -803,6 +991,69 @@ var _ = Describe("RouteRegistry", func() {
 			Expect(iter.Next(0).CanonicalAddr()).To(Equal("192.168.1.1:1234"))
 		})
 
+		It("matches paths case-insensitively", func() {
+			m := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
+
+			r.Register("foo.com/Bar", m)
+
+			Expect(r.Lookup("FOO.com/bar")).ToNot(BeNil())
+			Expect(r.Lookup("foo.com/BAR")).ToNot(BeNil())
+			Expect(r.Lookup("foo.com/BAR").ContextPath()).To(Equal("/bar"))
+		})
+
+		Context("when paths are case sensitive", func() {
+			BeforeEach(func() {
+				configObj.CaseSensitiveRoutePaths = true
+				r = NewRouteRegistry(logger, configObj, reporter)
+			})
+
+			It("matches paths only with the same case", func() {
+				m := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
+
+				r.Register("foo.com/Bar", m)
+				r.Register("foo.com/bar", m)
+
+				Expect(r.NumUris()).To(Equal(2))
+				Expect(r.Lookup("foo.com/Bar").ContextPath()).To(Equal("/Bar"))
+				Expect(r.Lookup("foo.com/bar").ContextPath()).To(Equal("/bar"))
+				Expect(r.Lookup("foo.com/BAR")).To(BeNil())
+			})
+
+			It("still matches hosts case-insensitively", func() {
+				m := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
+
+				r.Register("Foo.com/Bar", m)
+
+				Expect(r.Lookup("FOO.COM./Bar")).ToNot(BeNil())
+				Expect(r.Lookup("foo.com/Bar/baz")).ToNot(BeNil())
+			})
+
+			It("unregisters with the same case", func() {
+				m := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
+
+				r.Register("foo.com/Bar", m)
+				r.Unregister("foo.com/bar", m)
+				Expect(r.NumUris()).To(Equal(1))
+
+				r.Unregister("FOO.com/Bar", m)
+				Expect(r.NumUris()).To(BeZero())
+			})
+		})
+
+		It("ignores a trailing slash on the route and the request", func() {
+			m := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
+
+			r.Register("foo.com/bar/", m)
+			r.Register("foo.com/baz", m)
+
+			Expect(r.NumUris()).To(Equal(2))
+			Expect(r.Lookup("foo.com/bar")).ToNot(BeNil())
+			Expect(r.Lookup("foo.com/bar/")).ToNot(BeNil())
+			Expect(r.Lookup("foo.com/baz")).ToNot(BeNil())
+			Expect(r.Lookup("foo.com/baz/")).ToNot(BeNil())
+			Expect(r.Lookup("foo.com/bar").ContextPath()).To(Equal("/bar"))
+		})
+
 		It("selects one of the routes", func() {
 			m1 := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
 			m2 := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1235})
@@ -940,6 +1191,33 @@ var _ = Describe("RouteRegistry", func() {
 		})
 	})
 
+	Context("DrainEndpoint", func() {
+		BeforeEach(func() {
+			r.Register("foo", fooEndpoint)
+			r.Register("foo", barEndpoint)
+			r.Register("bar.com", fooEndpoint)
+		})
+
+		It("drains the endpoint on all of its routes", func() {
+			Expect(r.DrainEndpoint("192.168.1.1:0")).To(Equal(2))
+
+			Expect(r.Lookup("foo").Endpoints(logger, "", "", false, config.AZ_PREF_NONE, "").Next(0)).To(Equal(barEndpoint))
+			Expect(r.Lookup("bar.com").Endpoints(logger, "", "", false, config.AZ_PREF_NONE, "").Next(0)).To(BeNil())
+		})
+
+		It("does not drain anything for an unknown address", func() {
+			Expect(r.DrainEndpoint("10.0.0.1:8080")).To(BeZero())
+		})
+
+		It("no longer drains the endpoint once it registers anew", func() {
+			r.DrainEndpoint("192.168.1.1:0")
+			r.Unregister("bar.com", fooEndpoint)
+			r.Register("bar.com", fooEndpoint)
+
+			Expect(r.Lookup("bar.com").Endpoints(logger, "", "", false, config.AZ_PREF_NONE, "").Next(0)).To(Equal(fooEndpoint))
+		})
+	})
+
 	Context("LookupWithInstance", func() {
 		var (
 			appId    string
@@ -1219,6 +1497,16 @@ var _ = Describe("RouteRegistry", func() {
 				totalRoutes, _ := reporter.CaptureRouteStatsArgsForCall(0)
 				Expect(totalRoutes).To(Equal(2))
 			})
+
+			It("sends the number of endpoints left after pruning to the reporter", func() {
+				r.Register("foo", fooEndpoint)
+				r.Register("bar", barEndpoint)
+				r.StartPruningCycle()
+
+				Eventually(reporter.CaptureTotalEndpointsCallCount, 2*configObj.PruneStaleDropletsInterval).ShouldNot(BeZero())
+				Expect(reporter.CaptureTotalEndpointsArgsForCall(0)).To(Equal(0))
+				Expect(reporter.CaptureRoutesPrunedCallCount()).To(Equal(2))
+			})
 		})
 
 		Context("when stale threshold is greater than pruning cycle", func() {
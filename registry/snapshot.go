@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/route"
+)
+
+// WriteSnapshot writes the routing table to the file at path, replacing it
+// atomically. Endpoints loaded from a snapshot which were not registered since
+// are left out, so that they do not outlive their TTL across restarts.
+func (r *RouteRegistry) WriteSnapshot(path string) error {
+	snapshot := map[route.Uri][]*route.EndpointOpts{}
+
+	r.RLock()
+	for uri, pool := range r.byURI.ToMap() {
+		pool.Each(func(e *route.Endpoint) {
+			if !r.loadedFromSnapshot(e) {
+				snapshot[uri] = append(snapshot[uri], e.Opts())
+			}
+		})
+	}
+	r.RUnlock()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// LoadSnapshot registers the endpoints of the snapshot at path and returns
+// their number. They are removed by the first pruning cycle after ttl unless
+// they are registered again in the meantime. Endpoints which cannot be
+// registered are skipped and reported by the error, along with the number of
+// those which were.
+func (r *RouteRegistry) LoadSnapshot(path string, ttl time.Duration) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var snapshot map[route.Uri][]*route.EndpointOpts
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return 0, err
+	}
+
+	loaded, skipped := 0, 0
+	for uri, endpoints := range snapshot {
+		for _, opts := range endpoints {
+			// the shorter stale threshold makes any registration of the
+			// endpoint replace the loaded one
+			opts.StaleThresholdInSeconds = int(ttl / time.Second)
+			endpoint := route.NewEndpoint(opts)
+			if !r.endpointInRouterShard(endpoint) {
+				continue
+			}
+			if r.register(uri, endpoint) != route.ADDED {
+				skipped++
+				continue
+			}
+
+			r.snapshotLock.Lock()
+			r.snapshotEndpoints[endpoint] = r.routeKey(uri)
+			r.snapshotLock.Unlock()
+			loaded++
+		}
+	}
+
+	r.snapshotLock.Lock()
+	r.snapshotExpiresAt = time.Now().Add(ttl)
+	r.snapshotLock.Unlock()
+
+	if skipped > 0 {
+		return loaded, fmt.Errorf("%d endpoints of the snapshot could not be registered", skipped)
+	}
+	return loaded, nil
+}
+
+func (r *RouteRegistry) loadedFromSnapshot(endpoint *route.Endpoint) bool {
+	r.snapshotLock.Lock()
+	defer r.snapshotLock.Unlock()
+	_, ok := r.snapshotEndpoints[endpoint]
+	return ok
+}
+
+// keepSnapshotEndpoint stops the expiry of a loaded endpoint which is
+// registered again, as the registration may leave it in place.
+func (r *RouteRegistry) keepSnapshotEndpoint(uri route.Uri, endpoint *route.Endpoint) {
+	r.snapshotLock.Lock()
+	defer r.snapshotLock.Unlock()
+
+	routekey := r.routeKey(uri)
+	for e, key := range r.snapshotEndpoints {
+		if key == routekey && e.CanonicalAddr() == endpoint.CanonicalAddr() {
+			delete(r.snapshotEndpoints, e)
+		}
+	}
+}
+
+// expireSnapshot removes the endpoints loaded from a snapshot which were not
+// replaced by a registration, once the TTL of the snapshot has passed.
+func (r *RouteRegistry) expireSnapshot(now time.Time) {
+	r.snapshotLock.Lock()
+	if r.snapshotExpiresAt.IsZero() || now.Before(r.snapshotExpiresAt) {
+		r.snapshotLock.Unlock()
+		return
+	}
+	endpoints := r.snapshotEndpoints
+	r.snapshotEndpoints = map[*route.Endpoint]route.Uri{}
+	r.snapshotExpiresAt = time.Time{}
+	r.snapshotLock.Unlock()
+
+	r.Lock()
+	defer r.Unlock()
+
+	expired := 0
+	for endpoint, uri := range endpoints {
+		pool := r.byURI.Find(uri)
+		if pool == nil {
+			continue
+		}
+
+		current := false
+		pool.Each(func(e *route.Endpoint) {
+			current = current || e == endpoint
+		})
+		if !current {
+			continue
+		}
+
+		pool.Remove(endpoint)
+		expired++
+		if pool.IsEmpty() {
+			r.byURI.Delete(uri)
+		}
+	}
+
+	if expired > 0 {
+		r.logger.Info("registry-snapshot-endpoints-expired", zap.Int("endpoints", expired))
+	}
+}
+
+// SnapshotWriter writes the routing table of a RouteRegistry to a file every
+// interval and once more when it is signalled to stop.
+type SnapshotWriter struct {
+	registry *RouteRegistry
+	path     string
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewSnapshotWriter(registry *RouteRegistry, path string, interval time.Duration, logger logger.Logger) *SnapshotWriter {
+	return &SnapshotWriter{
+		registry: registry,
+		path:     path,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (w *SnapshotWriter) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.write()
+		case <-signals:
+			w.write()
+			w.logger.Info("exited")
+			return nil
+		}
+	}
+}
+
+func (w *SnapshotWriter) write() {
+	if err := w.registry.WriteSnapshot(w.path); err != nil {
+		w.logger.Error("registry-snapshot-write-failed", zap.String("path", w.path), zap.Error(err))
+	}
+}
@@ -0,0 +1,167 @@
+package registry_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/metrics/fakes"
+	. "github.com/mdimiceli/gorouter/registry"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+)
+
+var _ = Describe("Registry snapshots", func() {
+	var (
+		configObj    *config.Config
+		logger       logger.Logger
+		reporter     *fakes.FakeRouteRegistryReporter
+		r            *RouteRegistry
+		snapshotPath string
+	)
+
+	BeforeEach(func() {
+		logger = test_util.NewTestZapLogger("test")
+		var err error
+		configObj, err = config.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		configObj.DropletStaleThreshold = 10 * time.Second
+		reporter = new(fakes.FakeRouteRegistryReporter)
+
+		r = NewRouteRegistry(logger, configObj, reporter)
+		r.Register("foo", route.NewEndpoint(&route.EndpointOpts{
+			AppId:          "app1",
+			Host:           "192.168.1.1",
+			Port:           1234,
+			MaxConnections: 10,
+		}))
+		r.Register("foo", route.NewEndpoint(&route.EndpointOpts{
+			AppId: "app1",
+			Host:  "192.168.1.2",
+			Port:  1234,
+		}))
+		r.Register("bar", route.NewEndpoint(&route.EndpointOpts{
+			AppId: "app2",
+			Host:  "192.168.1.3",
+			Port:  1234,
+		}))
+
+		snapshotPath = filepath.Join(GinkgoT().TempDir(), "registry.json")
+		Expect(r.WriteSnapshot(snapshotPath)).To(Succeed())
+	})
+
+	It("loads the persisted routes", func() {
+		loaded := NewRouteRegistry(logger, configObj, reporter)
+		count, err := loaded.LoadSnapshot(snapshotPath, time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(3))
+
+		Expect(loaded.NumUris()).To(Equal(2))
+		Expect(loaded.NumEndpoints()).To(Equal(3))
+		Expect(loaded.Lookup("foo").MaxConnections()).To(Equal(int64(10)))
+
+		var addrs []string
+		loaded.Lookup("foo").Each(func(e *route.Endpoint) { addrs = append(addrs, e.CanonicalAddr()) })
+		Expect(addrs).To(ConsistOf("192.168.1.1:1234", "192.168.1.2:1234"))
+	})
+
+	It("returns an error if there is no snapshot", func() {
+		_, err := r.LoadSnapshot(filepath.Join(GinkgoT().TempDir(), "missing.json"), time.Minute)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("reports the routes which could not be registered", func() {
+		r.Register("bar", route.NewEndpoint(&route.EndpointOpts{
+			AppId: "app3",
+			Host:  "192.168.1.4",
+			Port:  1234,
+		}))
+		Expect(r.WriteSnapshot(snapshotPath)).To(Succeed())
+
+		configObj.RouteConflictMode = config.ROUTE_CONFLICT_REJECT
+		loaded := NewRouteRegistry(logger, configObj, reporter)
+		count, err := loaded.LoadSnapshot(snapshotPath, time.Minute)
+		Expect(err).To(MatchError("1 endpoints of the snapshot could not be registered"))
+		Expect(count).To(Equal(3))
+		Expect(loaded.NumEndpoints()).To(Equal(3))
+	})
+
+	Context("with a pruning cycle", func() {
+		var loaded *RouteRegistry
+
+		BeforeEach(func() {
+			configObj.PruneStaleDropletsInterval = 50 * time.Millisecond
+			loaded = NewRouteRegistry(logger, configObj, reporter)
+			// the loaded routes are only removed by the expiry of the
+			// snapshot, not as stale routes
+			loaded.SuspendPruning(func() bool { return true })
+		})
+
+		AfterEach(func() {
+			loaded.StopPruningCycle()
+		})
+
+		It("expires the loaded routes after the TTL", func() {
+			_, err := loaded.LoadSnapshot(snapshotPath, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.NumEndpoints()).To(Equal(3))
+
+			loaded.StartPruningCycle()
+			Consistently(loaded.NumEndpoints, 500*time.Millisecond).Should(Equal(3))
+			Eventually(loaded.NumEndpoints, 3*time.Second).Should(BeZero())
+			Expect(loaded.NumUris()).To(BeZero())
+		})
+
+		It("keeps the loaded routes which are registered again", func() {
+			_, err := loaded.LoadSnapshot(snapshotPath, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			loaded.StartPruningCycle()
+
+			loaded.Register("foo", route.NewEndpoint(&route.EndpointOpts{
+				AppId: "app1",
+				Host:  "192.168.1.2",
+				Port:  1234,
+			}))
+
+			Eventually(loaded.NumEndpoints, 3*time.Second).Should(Equal(1))
+			Consistently(loaded.NumEndpoints).Should(Equal(1))
+
+			var addrs []string
+			loaded.Lookup("foo").Each(func(e *route.Endpoint) { addrs = append(addrs, e.CanonicalAddr()) })
+			Expect(addrs).To(ConsistOf("192.168.1.2:1234"))
+		})
+	})
+
+	It("does not expire the loaded routes without a pruning cycle", func() {
+		loaded := NewRouteRegistry(logger, configObj, reporter)
+		_, err := loaded.LoadSnapshot(snapshotPath, 100*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+
+		Consistently(loaded.NumEndpoints, 300*time.Millisecond).Should(Equal(3))
+	})
+
+	It("does not persist loaded routes which were not registered again", func() {
+		loaded := NewRouteRegistry(logger, configObj, reporter)
+		_, err := loaded.LoadSnapshot(snapshotPath, time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+
+		loaded.Register("bar", route.NewEndpoint(&route.EndpointOpts{
+			AppId: "app2",
+			Host:  "192.168.1.3",
+			Port:  1234,
+		}))
+		Expect(loaded.WriteSnapshot(snapshotPath)).To(Succeed())
+
+		reloaded := NewRouteRegistry(logger, configObj, reporter)
+		count, err := reloaded.LoadSnapshot(snapshotPath, time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+		Expect(reloaded.Lookup("foo")).To(BeNil())
+		Expect(reloaded.Lookup("bar")).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,41 @@
+package route
+
+// excludingIterator is implemented by the iterators which can pass over a set
+// of endpoints, selecting among the others the way they always do.
+type excludingIterator interface {
+	excludeEndpoints(endpoints map[*Endpoint]struct{})
+}
+
+// ExcludeAttempted wraps an EndpointIterator so that retries within a single
+// request are not sent to an endpoint that was already attempted, e.g. when a
+// failure did not mark the endpoint as failed or concurrent requests advanced
+// the round robin position. The wrapped iterator passes over the attempted
+// endpoints, so that its load balancing, availability zone and slow start
+// preferences still apply, and selects one of them once every available
+// endpoint was attempted. Requests which must be sticky are unaffected, as
+// the iterators return their sticky endpoint without selecting one. Iterators
+// which cannot exclude endpoints are used as they are.
+type ExcludeAttempted struct {
+	EndpointIterator
+
+	attempted map[*Endpoint]struct{}
+}
+
+func NewExcludeAttempted(iter EndpointIterator) EndpointIterator {
+	x := &ExcludeAttempted{
+		EndpointIterator: iter,
+		attempted:        map[*Endpoint]struct{}{},
+	}
+	if ex, ok := iter.(excludingIterator); ok {
+		ex.excludeEndpoints(x.attempted)
+	}
+	return x
+}
+
+func (x *ExcludeAttempted) Next(attempt int) *Endpoint {
+	e := x.EndpointIterator.Next(attempt)
+	if e != nil {
+		x.attempted[e] = struct{}{}
+	}
+	return e
+}
@@ -0,0 +1,88 @@
+package route_test
+
+import (
+	"time"
+
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/route/fakes"
+	"github.com/mdimiceli/gorouter/test_util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExcludeAttempted", func() {
+	var (
+		logger     *test_util.TestZapLogger
+		pool       *route.EndpointPool
+		e1, e2, e3 *route.Endpoint
+	)
+
+	BeforeEach(func() {
+		logger = test_util.NewTestZapLogger("test")
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: 2 * time.Minute,
+		})
+		e1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, PrivateInstanceId: "instance-1"})
+		e2 = route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 1234, PrivateInstanceId: "instance-2"})
+		pool.Put(e1)
+		pool.Put(e2)
+	})
+
+	Context("with round robin", func() {
+		It("does not return an attempted endpoint again while others are available", func() {
+			iter := route.NewExcludeAttempted(route.NewRoundRobin(logger, pool, "", false, false, ""))
+			first := iter.Next(0)
+			Expect(first).NotTo(BeNil())
+			Expect(iter.Next(1)).NotTo(Equal(first))
+		})
+
+		It("does not return an attempted endpoint after concurrent requests advanced the position", func() {
+			iter := route.NewExcludeAttempted(route.NewRoundRobin(logger, pool, "", false, false, ""))
+			first := iter.Next(0)
+			route.NewRoundRobin(logger, pool, "", false, false, "").Next(0)
+			Expect(iter.Next(1)).NotTo(Equal(first))
+		})
+
+		It("returns an attempted endpoint once every endpoint was attempted", func() {
+			iter := route.NewExcludeAttempted(route.NewRoundRobin(logger, pool, "", false, false, ""))
+			Expect(iter.Next(0)).NotTo(BeNil())
+			Expect(iter.Next(1)).NotTo(BeNil())
+			Expect(iter.Next(2)).NotTo(BeNil())
+		})
+
+		It("does not choose another endpoint for requests that must be sticky", func() {
+			iter := route.NewExcludeAttempted(route.NewRoundRobin(logger, pool, "instance-1", true, false, ""))
+			Expect(iter.Next(0)).To(Equal(e1))
+			Expect(iter.Next(1)).To(Equal(e1))
+		})
+	})
+
+	Context("with least connection", func() {
+		BeforeEach(func() {
+			e3 = route.NewEndpoint(&route.EndpointOpts{Host: "9.10.11.12", Port: 1234})
+			pool.Put(e3)
+			for i := 0; i < 5; i++ {
+				e2.Stats.NumberConnections.Increment()
+			}
+			e3.Stats.NumberConnections.Increment()
+		})
+
+		It("returns the attempted endpoint with the least connections among the others", func() {
+			iter := route.NewExcludeAttempted(route.NewLeastConnection(logger, pool, "", false, false, ""))
+			Expect(iter.Next(0)).To(Equal(e1))
+			Expect(iter.Next(1)).To(Equal(e3))
+			Expect(iter.Next(2)).To(Equal(e2))
+			Expect(iter.Next(3)).NotTo(BeNil())
+		})
+	})
+
+	It("uses iterators which cannot exclude endpoints as they are", func() {
+		inner := &fakes.FakeEndpointIterator{}
+		inner.NextReturns(e1)
+
+		iter := route.NewExcludeAttempted(inner)
+		Expect(iter.Next(0)).To(Equal(e1))
+		Expect(iter.Next(1)).To(Equal(e1))
+	})
+})
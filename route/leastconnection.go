@@ -17,6 +17,7 @@ type LeastConnection struct {
 	randomize             *rand.Rand
 	locallyOptimistic     bool
 	localAvailabilityZone string
+	exclude               map[*Endpoint]struct{}
 }
 
 func NewLeastConnection(logger logger.Logger, p *EndpointPool, initial string, mustBeSticky bool, locallyOptimistic bool, localAvailabilityZone string) EndpointIterator {
@@ -43,6 +44,14 @@ func (r *LeastConnection) Next(attempt int) *Endpoint {
 			e = nil
 		}
 
+		if e != nil && e.isDraining() {
+			if r.mustBeSticky {
+				r.logger.Debug("endpoint-draining-but-request-must-be-sticky", e.endpoint.ToLogData()...)
+				return nil
+			}
+			e = nil
+		}
+
 		if e == nil && r.mustBeSticky {
 			r.logger.Debug("endpoint-missing-but-request-must-be-sticky", zap.Field(zap.String("requested-endpoint", r.initialEndpoint)))
 			return nil
@@ -85,7 +94,7 @@ func (r *LeastConnection) next(attempt int) *endpointElem {
 	r.pool.Lock()
 	defer r.pool.Unlock()
 
-	var selected, selectedLocal *endpointElem
+	var selected, selectedLocal, warmingUp, excluded *endpointElem
 	localDesired := r.locallyOptimistic && attempt == 0
 
 	// none
@@ -97,7 +106,7 @@ func (r *LeastConnection) next(attempt int) *endpointElem {
 	// single endpoint
 	if total == 1 {
 		e := r.pool.endpoints[0]
-		if e.isOverloaded() {
+		if e.isOverloaded() || e.isDraining() {
 			return nil
 		}
 
@@ -114,8 +123,23 @@ func (r *LeastConnection) next(attempt int) *endpointElem {
 		cur := r.pool.endpoints[randIdx]
 		curIsLocal := cur.endpoint.AvailabilityZone == r.localAvailabilityZone
 
-		// Never select an endpoint that is overloaded
-		if cur.isOverloaded() {
+		// Never select an endpoint that is overloaded or draining
+		if cur.isOverloaded() || cur.isDraining() {
+			continue
+		}
+
+		// Pass over excluded endpoints and those in their slow-start window,
+		// unless there is nothing else to select
+		if _, ok := r.exclude[cur.endpoint]; ok {
+			if excluded == nil {
+				excluded = cur
+			}
+			continue
+		}
+		if r.pool.skipWarmingUp(cur) {
+			if warmingUp == nil {
+				warmingUp = cur
+			}
 			continue
 		}
 
@@ -149,9 +173,22 @@ func (r *LeastConnection) next(attempt int) *endpointElem {
 		return selectedLocal
 	}
 
+	if selected == nil {
+		if warmingUp != nil {
+			return warmingUp
+		}
+		return excluded
+	}
+
 	return selected
 }
 
+// excludeEndpoints makes the iterator pass over the endpoints, unless there is
+// no other endpoint to select.
+func (r *LeastConnection) excludeEndpoints(endpoints map[*Endpoint]struct{}) {
+	r.exclude = endpoints
+}
+
 func (r *LeastConnection) EndpointFailed(err error) {
 	if r.lastEndpoint != nil {
 		r.pool.EndpointFailed(r.lastEndpoint, err)
@@ -1,11 +1,15 @@
 package route
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"maps"
 	"math/rand"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -30,6 +34,20 @@ const (
 	ADDED
 )
 
+const (
+	HOST_REDIRECT_APEX = "apex"
+	HOST_REDIRECT_WWW  = "www"
+)
+
+var AllowedHostRedirects = []string{HOST_REDIRECT_APEX, HOST_REDIRECT_WWW}
+
+const (
+	UPGRADE_INSECURE_REQUESTS_REDIRECT = "redirect"
+	UPGRADE_INSECURE_REQUESTS_CSP      = "csp"
+)
+
+var AllowedUpgradeInsecureRequests = []string{UPGRADE_INSECURE_REQUESTS_REDIRECT, UPGRADE_INSECURE_REQUESTS_CSP}
+
 func NewCounter(initial int64) *Counter {
 	return &Counter{initial}
 }
@@ -78,6 +96,88 @@ type Endpoint struct {
 	roundTripperMutex    sync.RWMutex
 	UpdatedAt            time.Time
 	RoundTripperInit     sync.Once
+	GzipRequestBody      bool
+	StripQueryParams     []string
+	AllowedQueryParams   []string
+	// AllowedContentTypes are the media types of the bodies requests to the
+	// route may carry. Empty allows any.
+	AllowedContentTypes []string
+	// InsecureSkipTLSVerify proxies to the endpoint even when its TLS
+	// certificate fails validation. It is meant for dev/test routes only.
+	InsecureSkipTLSVerify bool
+	// HTTP1ConcurrentReadWrite enables full-duplex HTTP/1.1 for requests to
+	// the route, regardless of the global setting.
+	HTTP1ConcurrentReadWrite bool
+	// MinHealthyEndpoints is the number of healthy endpoints below which
+	// requests to the route are rejected instead of overloading the rest.
+	MinHealthyEndpoints int
+	// MaxResponseBodyBytes is the largest response body the route may send.
+	// Responses exceeding it are aborted. Zero means no limit.
+	MaxResponseBodyBytes int64
+	// BasicAuth names the configured basic auth credentials requests to the
+	// route must present. Empty means the route is not protected.
+	BasicAuth string
+	// ConnectTunnel allows CONNECT requests to the route to be tunneled to
+	// its backends.
+	ConnectTunnel bool
+	// StatusRemap maps status codes returned by the route's backends to the
+	// status codes sent to clients instead.
+	StatusRemap map[int]int
+	// StatusRemapReplaceBody replaces the body of responses with a remapped
+	// status code with an error page for the new status code.
+	StatusRemapReplaceBody bool
+	// Deprecation is the value of the Deprecation header added to responses from
+	// the route, marking it as deprecated. Empty means the route is not
+	// deprecated.
+	Deprecation string
+	// Sunset is the value of the Sunset header added to responses from the route,
+	// the HTTP date after which the route is expected to go away.
+	Sunset string
+	// MaxConnections limits the number of concurrent connections to all
+	// backends of the route. Zero means no limit.
+	MaxConnections int64
+	// DecompressResponse decompresses gzip responses of the route's backends for
+	// clients which do not accept gzip.
+	DecompressResponse bool
+	// PreserveHeaderCase forwards request headers to HTTP/1 backends with the
+	// spelling the client used for their names, where it was captured.
+	PreserveHeaderCase bool
+	// HeadAsGet sends HEAD requests to the route's backends as GET requests, for
+	// backends which do not implement HEAD. The body of their responses is
+	// discarded.
+	HeadAsGet bool
+	// LogLevel overrides the level of the logs written while serving requests
+	// to the route, e.g. debug to troubleshoot a single route. Empty keeps
+	// the global level.
+	LogLevel string
+	// RequestTimeBreakdown emits metrics which attribute the time of requests
+	// to the route to the router, route service and backend.
+	RequestTimeBreakdown bool
+	// HostRedirect redirects requests to the same URL on the apex domain,
+	// without a www. prefix, or on the www. subdomain. HostRedirectStatus is
+	// the status of the redirect, 301 or 308. Zero means 308.
+	HostRedirect       string
+	HostRedirectStatus int
+	// StickySessionTTL limits how long a sticky session stays with the
+	// instance it was first sent to, so that long-lived sessions are balanced
+	// anew over time. Zero keeps the affinity for the life of the session.
+	StickySessionTTL time.Duration
+	// Maintenance has requests to the route answered with the maintenance
+	// page instead of being forwarded.
+	Maintenance bool
+	// ResponseDigest adds Digest and ETag headers computed from the body to
+	// small responses of the route's backends that have none.
+	ResponseDigest bool
+	// UpgradeInsecureRequests answers requests over plain HTTP that carry
+	// Upgrade-Insecure-Requests: 1 with a redirect to HTTPS, or adds a
+	// Content-Security-Policy upgrading them to responses. Empty leaves them.
+	UpgradeInsecureRequests string
+	// AccessLogOnlyErrors leaves responses with a status code in the
+	// configured suppressed range out of the access log.
+	AccessLogOnlyErrors bool
+	// TLSPassthrough allows TLS connections to the route to be forwarded to
+	// its TLS backends without being terminated by the router.
+	TLSPassthrough bool
 }
 
 func (e *Endpoint) RoundTripper() ProxyRoundTripper {
@@ -119,7 +219,35 @@ func (e *Endpoint) Equal(e2 *Endpoint) bool {
 		e.ModificationTag == e2.ModificationTag &&
 		e.IsolationSegment == e2.IsolationSegment &&
 		e.useTls == e2.useTls &&
-		e.UpdatedAt == e2.UpdatedAt
+		e.UpdatedAt == e2.UpdatedAt &&
+		e.GzipRequestBody == e2.GzipRequestBody &&
+		slices.Equal(e.StripQueryParams, e2.StripQueryParams) &&
+		slices.Equal(e.AllowedQueryParams, e2.AllowedQueryParams) &&
+		slices.Equal(e.AllowedContentTypes, e2.AllowedContentTypes) &&
+		e.InsecureSkipTLSVerify == e2.InsecureSkipTLSVerify &&
+		e.HTTP1ConcurrentReadWrite == e2.HTTP1ConcurrentReadWrite &&
+		e.MinHealthyEndpoints == e2.MinHealthyEndpoints &&
+		e.MaxResponseBodyBytes == e2.MaxResponseBodyBytes &&
+		e.BasicAuth == e2.BasicAuth &&
+		e.ConnectTunnel == e2.ConnectTunnel &&
+		maps.Equal(e.StatusRemap, e2.StatusRemap) &&
+		e.StatusRemapReplaceBody == e2.StatusRemapReplaceBody &&
+		e.Deprecation == e2.Deprecation &&
+		e.Sunset == e2.Sunset &&
+		e.MaxConnections == e2.MaxConnections &&
+		e.DecompressResponse == e2.DecompressResponse &&
+		e.PreserveHeaderCase == e2.PreserveHeaderCase &&
+		e.HeadAsGet == e2.HeadAsGet &&
+		e.LogLevel == e2.LogLevel &&
+		e.RequestTimeBreakdown == e2.RequestTimeBreakdown &&
+		e.HostRedirect == e2.HostRedirect &&
+		e.HostRedirectStatus == e2.HostRedirectStatus &&
+		e.StickySessionTTL == e2.StickySessionTTL &&
+		e.Maintenance == e2.Maintenance &&
+		e.ResponseDigest == e2.ResponseDigest &&
+		e.UpgradeInsecureRequests == e2.UpgradeInsecureRequests &&
+		e.AccessLogOnlyErrors == e2.AccessLogOnlyErrors &&
+		e.TLSPassthrough == e2.TLSPassthrough
 
 }
 
@@ -141,6 +269,10 @@ type endpointElem struct {
 	updated            time.Time
 	failedAt           *time.Time
 	maxConnsPerBackend int64
+	addedAt            time.Time
+	draining           atomic.Bool
+
+	maxRequestsPerHTTP2Backend int64
 }
 
 type EndpointPool struct {
@@ -152,9 +284,42 @@ type EndpointPool struct {
 	contextPath string
 	RouteSvcUrl string
 
+	stripQueryParams         []string
+	allowedQueryParams       []string
+	allowedContentTypes      []string
+	http1ConcurrentReadWrite bool
+	minHealthyEndpoints      int
+	maxResponseBodyBytes     int64
+	basicAuth                string
+	connectTunnel            bool
+	statusRemap              map[int]int
+	statusRemapReplaceBody   bool
+	deprecation              string
+	sunset                   string
+	maxConnections           int64
+	decompressResponse       bool
+	preserveHeaderCase       bool
+	headAsGet                bool
+	logLevel                 string
+	requestTimeBreakdown     bool
+	hostRedirect             string
+	hostRedirectStatus       int
+	stickySessionTTL         time.Duration
+	maintenance              bool
+	responseDigest           bool
+	upgradeInsecureRequests  string
+	accessLogOnlyErrors      bool
+	tlsPassthrough           bool
+
+	activeConns  int64
+	connReleased chan struct{}
+
 	retryAfterFailure  time.Duration
 	NextIdx            int
 	maxConnsPerBackend int64
+	slowStartWindow    time.Duration
+
+	maxRequestsPerHTTP2Backend int64
 
 	random    *rand.Rand
 	logger    logger.Logger
@@ -162,40 +327,148 @@ type EndpointPool struct {
 }
 
 type EndpointOpts struct {
-	AppId                   string
-	AvailabilityZone        string
-	Host                    string
-	Port                    uint16
-	Protocol                string
-	ServerCertDomainSAN     string
-	PrivateInstanceId       string
-	PrivateInstanceIndex    string
-	Tags                    map[string]string
-	StaleThresholdInSeconds int
-	RouteServiceUrl         string
-	ModificationTag         models.ModificationTag
-	IsolationSegment        string
-	UseTLS                  bool
-	UpdatedAt               time.Time
+	AppId                    string
+	AvailabilityZone         string
+	Host                     string
+	Port                     uint16
+	Protocol                 string
+	ServerCertDomainSAN      string
+	PrivateInstanceId        string
+	PrivateInstanceIndex     string
+	Tags                     map[string]string
+	StaleThresholdInSeconds  int
+	RouteServiceUrl          string
+	ModificationTag          models.ModificationTag
+	IsolationSegment         string
+	UseTLS                   bool
+	UpdatedAt                time.Time
+	GzipRequestBody          bool
+	StripQueryParams         []string
+	AllowedQueryParams       []string
+	AllowedContentTypes      []string
+	InsecureSkipTLSVerify    bool
+	HTTP1ConcurrentReadWrite bool
+	MinHealthyEndpoints      int
+	MaxResponseBodyBytes     int64
+	BasicAuth                string
+	ConnectTunnel            bool
+	StatusRemap              map[int]int
+	StatusRemapReplaceBody   bool
+	Deprecation              string
+	Sunset                   string
+	MaxConnections           int64
+	DecompressResponse       bool
+	PreserveHeaderCase       bool
+	HeadAsGet                bool
+	LogLevel                 string
+	RequestTimeBreakdown     bool
+	HostRedirect             string
+	HostRedirectStatus       int
+	StickySessionTTL         time.Duration
+	Maintenance              bool
+	ResponseDigest           bool
+	UpgradeInsecureRequests  string
+	AccessLogOnlyErrors      bool
+	TLSPassthrough           bool
 }
 
 func NewEndpoint(opts *EndpointOpts) *Endpoint {
 	return &Endpoint{
-		ApplicationId:        opts.AppId,
-		AvailabilityZone:     opts.AvailabilityZone,
-		addr:                 fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-		Protocol:             opts.Protocol,
-		Tags:                 opts.Tags,
-		useTls:               opts.UseTLS,
-		ServerCertDomainSAN:  opts.ServerCertDomainSAN,
-		PrivateInstanceId:    opts.PrivateInstanceId,
-		PrivateInstanceIndex: opts.PrivateInstanceIndex,
-		StaleThreshold:       time.Duration(opts.StaleThresholdInSeconds) * time.Second,
-		RouteServiceUrl:      opts.RouteServiceUrl,
-		ModificationTag:      opts.ModificationTag,
-		Stats:                NewStats(),
-		IsolationSegment:     opts.IsolationSegment,
-		UpdatedAt:            opts.UpdatedAt,
+		ApplicationId:            opts.AppId,
+		AvailabilityZone:         opts.AvailabilityZone,
+		addr:                     fmt.Sprintf("%s:%d", opts.Host, opts.Port),
+		Protocol:                 opts.Protocol,
+		Tags:                     opts.Tags,
+		useTls:                   opts.UseTLS,
+		ServerCertDomainSAN:      opts.ServerCertDomainSAN,
+		PrivateInstanceId:        opts.PrivateInstanceId,
+		PrivateInstanceIndex:     opts.PrivateInstanceIndex,
+		StaleThreshold:           time.Duration(opts.StaleThresholdInSeconds) * time.Second,
+		RouteServiceUrl:          opts.RouteServiceUrl,
+		ModificationTag:          opts.ModificationTag,
+		Stats:                    NewStats(),
+		IsolationSegment:         opts.IsolationSegment,
+		UpdatedAt:                opts.UpdatedAt,
+		GzipRequestBody:          opts.GzipRequestBody,
+		StripQueryParams:         opts.StripQueryParams,
+		AllowedQueryParams:       opts.AllowedQueryParams,
+		AllowedContentTypes:      opts.AllowedContentTypes,
+		InsecureSkipTLSVerify:    opts.InsecureSkipTLSVerify,
+		HTTP1ConcurrentReadWrite: opts.HTTP1ConcurrentReadWrite,
+		MinHealthyEndpoints:      opts.MinHealthyEndpoints,
+		MaxResponseBodyBytes:     opts.MaxResponseBodyBytes,
+		BasicAuth:                opts.BasicAuth,
+		ConnectTunnel:            opts.ConnectTunnel,
+		StatusRemap:              opts.StatusRemap,
+		StatusRemapReplaceBody:   opts.StatusRemapReplaceBody,
+		Deprecation:              opts.Deprecation,
+		Sunset:                   opts.Sunset,
+		MaxConnections:           opts.MaxConnections,
+		DecompressResponse:       opts.DecompressResponse,
+		PreserveHeaderCase:       opts.PreserveHeaderCase,
+		HeadAsGet:                opts.HeadAsGet,
+		LogLevel:                 opts.LogLevel,
+		RequestTimeBreakdown:     opts.RequestTimeBreakdown,
+		HostRedirect:             opts.HostRedirect,
+		HostRedirectStatus:       opts.HostRedirectStatus,
+		StickySessionTTL:         opts.StickySessionTTL,
+		Maintenance:              opts.Maintenance,
+		ResponseDigest:           opts.ResponseDigest,
+		UpgradeInsecureRequests:  opts.UpgradeInsecureRequests,
+		AccessLogOnlyErrors:      opts.AccessLogOnlyErrors,
+		TLSPassthrough:           opts.TLSPassthrough,
+	}
+}
+
+// Opts returns the options from which NewEndpoint creates an endpoint equal
+// to e.
+func (e *Endpoint) Opts() *EndpointOpts {
+	host, portStr, _ := net.SplitHostPort(e.addr)
+	port, _ := strconv.ParseUint(portStr, 10, 16)
+	return &EndpointOpts{
+		AppId:                    e.ApplicationId,
+		AvailabilityZone:         e.AvailabilityZone,
+		Host:                     host,
+		Port:                     uint16(port),
+		Protocol:                 e.Protocol,
+		ServerCertDomainSAN:      e.ServerCertDomainSAN,
+		PrivateInstanceId:        e.PrivateInstanceId,
+		PrivateInstanceIndex:     e.PrivateInstanceIndex,
+		Tags:                     e.Tags,
+		StaleThresholdInSeconds:  int(e.StaleThreshold / time.Second),
+		RouteServiceUrl:          e.RouteServiceUrl,
+		ModificationTag:          e.ModificationTag,
+		IsolationSegment:         e.IsolationSegment,
+		UseTLS:                   e.useTls,
+		UpdatedAt:                e.UpdatedAt,
+		GzipRequestBody:          e.GzipRequestBody,
+		StripQueryParams:         e.StripQueryParams,
+		AllowedQueryParams:       e.AllowedQueryParams,
+		AllowedContentTypes:      e.AllowedContentTypes,
+		InsecureSkipTLSVerify:    e.InsecureSkipTLSVerify,
+		HTTP1ConcurrentReadWrite: e.HTTP1ConcurrentReadWrite,
+		MinHealthyEndpoints:      e.MinHealthyEndpoints,
+		MaxResponseBodyBytes:     e.MaxResponseBodyBytes,
+		BasicAuth:                e.BasicAuth,
+		ConnectTunnel:            e.ConnectTunnel,
+		StatusRemap:              e.StatusRemap,
+		StatusRemapReplaceBody:   e.StatusRemapReplaceBody,
+		Deprecation:              e.Deprecation,
+		Sunset:                   e.Sunset,
+		MaxConnections:           e.MaxConnections,
+		DecompressResponse:       e.DecompressResponse,
+		PreserveHeaderCase:       e.PreserveHeaderCase,
+		HeadAsGet:                e.HeadAsGet,
+		LogLevel:                 e.LogLevel,
+		RequestTimeBreakdown:     e.RequestTimeBreakdown,
+		HostRedirect:             e.HostRedirect,
+		HostRedirectStatus:       e.HostRedirectStatus,
+		StickySessionTTL:         e.StickySessionTTL,
+		Maintenance:              e.Maintenance,
+		ResponseDigest:           e.ResponseDigest,
+		UpgradeInsecureRequests:  e.UpgradeInsecureRequests,
+		AccessLogOnlyErrors:      e.AccessLogOnlyErrors,
+		TLSPassthrough:           e.TLSPassthrough,
 	}
 }
 
@@ -208,7 +481,13 @@ type PoolOpts struct {
 	Host               string
 	ContextPath        string
 	MaxConnsPerBackend int64
+	SlowStartWindow    time.Duration
 	Logger             logger.Logger
+
+	// MaxRequestsPerHTTP2Backend limits the in-flight requests to HTTP/2
+	// endpoints instead of MaxConnsPerBackend, as they multiplex requests
+	// on few connections. Zero applies MaxConnsPerBackend to them too.
+	MaxRequestsPerHTTP2Backend int64
 }
 
 func NewPool(opts *PoolOpts) *EndpointPool {
@@ -218,11 +497,14 @@ func NewPool(opts *PoolOpts) *EndpointPool {
 		retryAfterFailure:  opts.RetryAfterFailure,
 		NextIdx:            -1,
 		maxConnsPerBackend: opts.MaxConnsPerBackend,
+		slowStartWindow:    opts.SlowStartWindow,
 		host:               opts.Host,
 		contextPath:        opts.ContextPath,
 		random:             rand.New(rand.NewSource(time.Now().UnixNano())),
 		logger:             opts.Logger,
 		updatedAt:          time.Now(),
+
+		maxRequestsPerHTTP2Backend: opts.MaxRequestsPerHTTP2Backend,
 	}
 }
 
@@ -242,6 +524,16 @@ func (p *EndpointPool) MaxConnsPerBackend() int64 {
 	return p.maxConnsPerBackend
 }
 
+func (p *EndpointPool) MaxRequestsPerHTTP2Backend() int64 {
+	return p.maxRequestsPerHTTP2Backend
+}
+
+// SlowStartWindow returns how long it takes endpoints added to the pool to
+// receive their full share of the requests.
+func (p *EndpointPool) SlowStartWindow() time.Duration {
+	return p.slowStartWindow
+}
+
 func (p *EndpointPool) LastUpdated() time.Time {
 	return p.updatedAt
 }
@@ -274,7 +566,8 @@ func (p *EndpointPool) Put(endpoint *Endpoint) PoolPutResult {
 				p.index[endpoint.PrivateInstanceId] = e
 			}
 
-			if oldEndpoint.ServerCertDomainSAN == endpoint.ServerCertDomainSAN {
+			if oldEndpoint.ServerCertDomainSAN == endpoint.ServerCertDomainSAN &&
+				oldEndpoint.InsecureSkipTLSVerify == endpoint.InsecureSkipTLSVerify {
 				endpoint.SetRoundTripper(oldEndpoint.RoundTripper())
 			}
 		}
@@ -284,6 +577,9 @@ func (p *EndpointPool) Put(endpoint *Endpoint) PoolPutResult {
 			endpoint:           endpoint,
 			index:              len(p.endpoints),
 			maxConnsPerBackend: p.maxConnsPerBackend,
+			addedAt:            time.Now(),
+
+			maxRequestsPerHTTP2Backend: p.maxRequestsPerHTTP2Backend,
 		}
 
 		p.endpoints = append(p.endpoints, e)
@@ -293,6 +589,32 @@ func (p *EndpointPool) Put(endpoint *Endpoint) PoolPutResult {
 
 	}
 	p.RouteSvcUrl = e.endpoint.RouteServiceUrl
+	p.stripQueryParams = e.endpoint.StripQueryParams
+	p.allowedQueryParams = e.endpoint.AllowedQueryParams
+	p.allowedContentTypes = e.endpoint.AllowedContentTypes
+	p.http1ConcurrentReadWrite = e.endpoint.HTTP1ConcurrentReadWrite
+	p.minHealthyEndpoints = e.endpoint.MinHealthyEndpoints
+	p.maxResponseBodyBytes = e.endpoint.MaxResponseBodyBytes
+	p.basicAuth = e.endpoint.BasicAuth
+	p.connectTunnel = e.endpoint.ConnectTunnel
+	p.statusRemap = e.endpoint.StatusRemap
+	p.statusRemapReplaceBody = e.endpoint.StatusRemapReplaceBody
+	p.deprecation = e.endpoint.Deprecation
+	p.sunset = e.endpoint.Sunset
+	p.maxConnections = e.endpoint.MaxConnections
+	p.decompressResponse = e.endpoint.DecompressResponse
+	p.preserveHeaderCase = e.endpoint.PreserveHeaderCase
+	p.headAsGet = e.endpoint.HeadAsGet
+	p.logLevel = e.endpoint.LogLevel
+	p.requestTimeBreakdown = e.endpoint.RequestTimeBreakdown
+	p.hostRedirect = e.endpoint.HostRedirect
+	p.hostRedirectStatus = e.endpoint.HostRedirectStatus
+	p.stickySessionTTL = e.endpoint.StickySessionTTL
+	p.maintenance = e.endpoint.Maintenance
+	p.responseDigest = e.endpoint.ResponseDigest
+	p.upgradeInsecureRequests = e.endpoint.UpgradeInsecureRequests
+	p.accessLogOnlyErrors = e.endpoint.AccessLogOnlyErrors
+	p.tlsPassthrough = e.endpoint.TLSPassthrough
 	e.updated = time.Now()
 	// set the update time of the pool
 	p.Update()
@@ -306,6 +628,291 @@ func (p *EndpointPool) RouteServiceUrl() string {
 	return p.RouteSvcUrl
 }
 
+// StripQueryParams returns the names of the query parameters that are
+// removed from requests before they are forwarded to the route's backends.
+func (p *EndpointPool) StripQueryParams() []string {
+	p.Lock()
+	defer p.Unlock()
+	return p.stripQueryParams
+}
+
+// AllowedQueryParams returns the names of the query parameters requests to
+// the route may carry. An empty list means any query parameter is allowed.
+func (p *EndpointPool) AllowedQueryParams() []string {
+	p.Lock()
+	defer p.Unlock()
+	return p.allowedQueryParams
+}
+
+// AllowedContentTypes returns the media types of the bodies requests to the
+// route may carry. An empty list means any content type is allowed.
+func (p *EndpointPool) AllowedContentTypes() []string {
+	p.Lock()
+	defer p.Unlock()
+	return p.allowedContentTypes
+}
+
+// HTTP1ConcurrentReadWrite returns whether the route opted in to full-duplex
+// HTTP/1.1, allowing the response to be written while the request body is
+// still being read.
+func (p *EndpointPool) HTTP1ConcurrentReadWrite() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.http1ConcurrentReadWrite
+}
+
+// RetryAfterFailure returns how long a failed endpoint is excluded from
+// load balancing.
+func (p *EndpointPool) RetryAfterFailure() time.Duration {
+	return p.retryAfterFailure
+}
+
+// MinHealthyEndpoints returns the number of healthy endpoints the route
+// requires to serve requests. Zero disables the threshold.
+func (p *EndpointPool) MinHealthyEndpoints() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.minHealthyEndpoints
+}
+
+// MaxResponseBodyBytes returns the largest response body the route's
+// backends may send. Zero means no limit.
+func (p *EndpointPool) MaxResponseBodyBytes() int64 {
+	p.Lock()
+	defer p.Unlock()
+	return p.maxResponseBodyBytes
+}
+
+// BasicAuth returns the name of the basic auth credentials requests to the
+// route must present, or an empty string if the route is not protected.
+func (p *EndpointPool) BasicAuth() string {
+	p.Lock()
+	defer p.Unlock()
+	return p.basicAuth
+}
+
+// ConnectTunnel returns whether CONNECT requests to the route may be
+// tunneled to its backends.
+func (p *EndpointPool) ConnectTunnel() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.connectTunnel
+}
+
+// StatusRemap returns the status codes of backend responses that are sent to
+// clients as a different status code, keyed by the backend status code.
+func (p *EndpointPool) StatusRemap() map[int]int {
+	p.Lock()
+	defer p.Unlock()
+	return p.statusRemap
+}
+
+// StatusRemapReplaceBody returns whether responses with a remapped status code
+// get an error page for the new status code instead of the backend's body.
+func (p *EndpointPool) StatusRemapReplaceBody() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.statusRemapReplaceBody
+}
+
+// Deprecation returns the value of the Deprecation header added to the route's
+// responses, or an empty string if the route is not deprecated.
+func (p *EndpointPool) Deprecation() string {
+	p.Lock()
+	defer p.Unlock()
+	return p.deprecation
+}
+
+// Sunset returns the value of the Sunset header added to the route's
+// responses, or an empty string if the route has no sunset date.
+func (p *EndpointPool) Sunset() string {
+	p.Lock()
+	defer p.Unlock()
+	return p.sunset
+}
+
+// MaxConnections returns the limit of concurrent connections to the backends
+// of the route, or zero if there is no limit.
+func (p *EndpointPool) MaxConnections() int64 {
+	p.Lock()
+	defer p.Unlock()
+	return p.maxConnections
+}
+
+// DecompressResponse returns whether the gzip responses of the backends are
+// decompressed for clients which do not accept gzip.
+func (p *EndpointPool) DecompressResponse() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.decompressResponse
+}
+
+// PreserveHeaderCase returns whether request headers are forwarded with the
+// spelling the client used for their names.
+func (p *EndpointPool) PreserveHeaderCase() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.preserveHeaderCase
+}
+
+// HeadAsGet returns whether HEAD requests are sent to the backends as GET
+// requests.
+func (p *EndpointPool) HeadAsGet() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.headAsGet
+}
+
+// LogLevel returns the level of the logs written while serving requests to
+// the route, or an empty string if the global level applies.
+func (p *EndpointPool) LogLevel() string {
+	p.Lock()
+	defer p.Unlock()
+	return p.logLevel
+}
+
+// RequestTimeBreakdown returns whether the time of requests to the route is
+// broken down into metrics for the router, route service and backend.
+func (p *EndpointPool) RequestTimeBreakdown() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.requestTimeBreakdown
+}
+
+// HostRedirect returns whether requests to the route are redirected to the
+// apex domain or the www. subdomain, and the status of the redirect. The
+// redirect is empty if requests are not redirected.
+func (p *EndpointPool) HostRedirect() (string, int) {
+	p.Lock()
+	defer p.Unlock()
+	return p.hostRedirect, p.hostRedirectStatus
+}
+
+// StickySessionTTL returns how long sticky sessions stay with the instance
+// they were first sent to, or zero if they do for the life of the session.
+func (p *EndpointPool) StickySessionTTL() time.Duration {
+	p.Lock()
+	defer p.Unlock()
+	return p.stickySessionTTL
+}
+
+// Maintenance returns whether the route is down for maintenance.
+func (p *EndpointPool) Maintenance() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.maintenance
+}
+
+// ResponseDigest returns whether Digest and ETag headers are added to small
+// responses of the backends.
+func (p *EndpointPool) ResponseDigest() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.responseDigest
+}
+
+// UpgradeInsecureRequests returns how requests asking to be upgraded to HTTPS
+// are handled, or an empty string if they are not.
+func (p *EndpointPool) UpgradeInsecureRequests() string {
+	p.Lock()
+	defer p.Unlock()
+	return p.upgradeInsecureRequests
+}
+
+// AccessLogOnlyErrors returns whether responses with a status code in the
+// suppressed range are left out of the access log for the route.
+func (p *EndpointPool) AccessLogOnlyErrors() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.accessLogOnlyErrors
+}
+
+// TLSPassthrough returns whether TLS connections to the route may be forwarded
+// to its backends without being terminated.
+func (p *EndpointPool) TLSPassthrough() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.tlsPassthrough
+}
+
+// AcquireConnection takes one of the MaxConnections of the route, waiting up
+// to timeout for one to be released when all are in use. It returns false if
+// none was released in time or ctx is done. Every acquired connection must be
+// given back with ReleaseConnection.
+func (p *EndpointPool) AcquireConnection(ctx context.Context, timeout time.Duration) bool {
+	var expired <-chan time.Time
+	for {
+		p.Lock()
+		if p.maxConnections <= 0 || p.activeConns < p.maxConnections {
+			p.activeConns++
+			p.Unlock()
+			return true
+		}
+		if p.connReleased == nil {
+			p.connReleased = make(chan struct{})
+		}
+		released := p.connReleased
+		p.Unlock()
+
+		if expired == nil {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		select {
+		case <-released:
+		case <-expired:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// ReleaseConnection gives back a connection taken with AcquireConnection.
+func (p *EndpointPool) ReleaseConnection() {
+	p.Lock()
+	defer p.Unlock()
+	p.activeConns--
+	if p.connReleased != nil {
+		close(p.connReleased)
+		p.connReleased = nil
+	}
+}
+
+// ActiveConnections returns the number of connections taken with
+// AcquireConnection that have not been released yet.
+func (p *EndpointPool) ActiveConnections() int64 {
+	p.Lock()
+	defer p.Unlock()
+	return p.activeConns
+}
+
+// NumHealthyEndpoints returns the number of endpoints that are not marked as
+// failed. Endpoints whose failure is older than the retry-after-failure
+// duration count as healthy again.
+func (p *EndpointPool) NumHealthyEndpoints() int {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	healthy := 0
+	for _, e := range p.endpoints {
+		if e.failedAt == nil || now.Sub(*e.failedAt) > p.retryAfterFailure {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// HasTooFewHealthyEndpoints returns true if the route requires a minimum
+// number of healthy endpoints and fewer are available.
+func (p *EndpointPool) HasTooFewHealthyEndpoints() bool {
+	minHealthy := p.MinHealthyEndpoints()
+	return minHealthy > 0 && p.NumHealthyEndpoints() < minHealthy
+}
+
 func (p *EndpointPool) PruneEndpoints() []*Endpoint {
 	p.Lock()
 	defer p.Unlock()
@@ -407,15 +1014,9 @@ func (p *EndpointPool) IsOverloaded() bool {
 
 	p.Lock()
 	defer p.Unlock()
-	if p.maxConnsPerBackend == 0 {
-		return false
-	}
-
-	if p.maxConnsPerBackend > 0 {
-		for _, e := range p.endpoints {
-			if e.endpoint.Stats.NumberConnections.Count() < p.maxConnsPerBackend {
-				return false
-			}
+	for _, e := range p.endpoints {
+		if !e.isOverloaded() {
+			return false
 		}
 	}
 
@@ -478,26 +1079,92 @@ func (e *endpointElem) failed() {
 	e.failedAt = &t
 }
 
+// skipWarmingUp reports whether the endpoint is passed over by a selection
+// because it is still in its slow-start window. Over the window, the chance of
+// an endpoint to be selected rises linearly from zero to one. The caller must
+// hold the pool lock.
+func (p *EndpointPool) skipWarmingUp(e *endpointElem) bool {
+	if p.slowStartWindow <= 0 {
+		return false
+	}
+	elapsed := time.Since(e.addedAt)
+	if elapsed >= p.slowStartWindow {
+		return false
+	}
+	return p.random.Float64() >= float64(elapsed)/float64(p.slowStartWindow)
+}
+
+// Drain stops selecting the endpoint with the given address for new requests
+// until it is removed from the pool. Requests in flight are unaffected. It
+// returns whether the pool has an endpoint with the address.
+func (p *EndpointPool) Drain(addr string) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	e, ok := p.index[addr]
+	if !ok {
+		return false
+	}
+	e.draining.Store(true)
+	return true
+}
+
+func (e *endpointElem) isDraining() bool {
+	return e.draining.Load()
+}
+
 func (e *endpointElem) isOverloaded() bool {
-	if e.maxConnsPerBackend == 0 {
+	limit := e.maxConnsPerBackend
+	if e.maxRequestsPerHTTP2Backend > 0 && e.endpoint.Protocol == "http2" {
+		limit = e.maxRequestsPerHTTP2Backend
+	}
+	if limit <= 0 {
 		return false
 	}
 
-	return e.endpoint.Stats.NumberConnections.Count() >= e.maxConnsPerBackend
+	return e.endpoint.Stats.NumberConnections.Count() >= limit
 }
 
 func (e *Endpoint) MarshalJSON() ([]byte, error) {
 	var jsonObj struct {
-		Address             string            `json:"address"`
-		AvailabilityZone    string            `json:"availability_zone"`
-		Protocol            string            `json:"protocol"`
-		TLS                 bool              `json:"tls"`
-		TTL                 int               `json:"ttl"`
-		RouteServiceUrl     string            `json:"route_service_url,omitempty"`
-		Tags                map[string]string `json:"tags"`
-		IsolationSegment    string            `json:"isolation_segment,omitempty"`
-		PrivateInstanceId   string            `json:"private_instance_id,omitempty"`
-		ServerCertDomainSAN string            `json:"server_cert_domain_san,omitempty"`
+		Address                  string            `json:"address"`
+		AvailabilityZone         string            `json:"availability_zone"`
+		Protocol                 string            `json:"protocol"`
+		TLS                      bool              `json:"tls"`
+		TTL                      int               `json:"ttl"`
+		RouteServiceUrl          string            `json:"route_service_url,omitempty"`
+		Tags                     map[string]string `json:"tags"`
+		IsolationSegment         string            `json:"isolation_segment,omitempty"`
+		PrivateInstanceId        string            `json:"private_instance_id,omitempty"`
+		ServerCertDomainSAN      string            `json:"server_cert_domain_san,omitempty"`
+		GzipRequestBody          bool              `json:"gzip_request_body,omitempty"`
+		StripQueryParams         []string          `json:"strip_query_params,omitempty"`
+		AllowedQueryParams       []string          `json:"allowed_query_params,omitempty"`
+		AllowedContentTypes      []string          `json:"allowed_content_types,omitempty"`
+		InsecureSkipTLSVerify    bool              `json:"insecure_skip_tls_verify,omitempty"`
+		HTTP1ConcurrentReadWrite bool              `json:"http1_concurrent_read_write,omitempty"`
+		MinHealthyEndpoints      int               `json:"min_healthy_endpoints,omitempty"`
+		MaxResponseBodyBytes     int64             `json:"max_response_body_bytes,omitempty"`
+		BasicAuth                string            `json:"basic_auth,omitempty"`
+		ConnectTunnel            bool              `json:"connect_tunnel,omitempty"`
+		StatusRemap              map[int]int       `json:"status_remap,omitempty"`
+		StatusRemapReplaceBody   bool              `json:"status_remap_replace_body,omitempty"`
+		Deprecation              string            `json:"deprecation,omitempty"`
+		Sunset                   string            `json:"sunset,omitempty"`
+		MaxConnections           int64             `json:"max_connections,omitempty"`
+		DecompressResponse       bool              `json:"decompress_response,omitempty"`
+		PreserveHeaderCase       bool              `json:"preserve_header_case,omitempty"`
+		HeadAsGet                bool              `json:"head_as_get,omitempty"`
+		LogLevel                 string            `json:"log_level,omitempty"`
+		RequestTimeBreakdown     bool              `json:"request_time_breakdown,omitempty"`
+		HostRedirect             string            `json:"host_redirect,omitempty"`
+		HostRedirectStatus       int               `json:"host_redirect_status,omitempty"`
+		StickySessionTTLSeconds  int64             `json:"sticky_session_ttl_seconds,omitempty"`
+		Maintenance              bool              `json:"maintenance,omitempty"`
+		ResponseDigest           bool              `json:"response_digest,omitempty"`
+		UpgradeInsecureRequests  string            `json:"upgrade_insecure_requests,omitempty"`
+		AccessLogOnlyErrors      bool              `json:"access_log_only_errors,omitempty"`
+		TLSPassthrough           bool              `json:"tls_passthrough,omitempty"`
 	}
 
 	jsonObj.Address = e.addr
@@ -510,6 +1177,34 @@ func (e *Endpoint) MarshalJSON() ([]byte, error) {
 	jsonObj.IsolationSegment = e.IsolationSegment
 	jsonObj.PrivateInstanceId = e.PrivateInstanceId
 	jsonObj.ServerCertDomainSAN = e.ServerCertDomainSAN
+	jsonObj.GzipRequestBody = e.GzipRequestBody
+	jsonObj.StripQueryParams = e.StripQueryParams
+	jsonObj.AllowedQueryParams = e.AllowedQueryParams
+	jsonObj.AllowedContentTypes = e.AllowedContentTypes
+	jsonObj.InsecureSkipTLSVerify = e.InsecureSkipTLSVerify
+	jsonObj.HTTP1ConcurrentReadWrite = e.HTTP1ConcurrentReadWrite
+	jsonObj.MinHealthyEndpoints = e.MinHealthyEndpoints
+	jsonObj.MaxResponseBodyBytes = e.MaxResponseBodyBytes
+	jsonObj.BasicAuth = e.BasicAuth
+	jsonObj.ConnectTunnel = e.ConnectTunnel
+	jsonObj.StatusRemap = e.StatusRemap
+	jsonObj.StatusRemapReplaceBody = e.StatusRemapReplaceBody
+	jsonObj.Deprecation = e.Deprecation
+	jsonObj.Sunset = e.Sunset
+	jsonObj.MaxConnections = e.MaxConnections
+	jsonObj.DecompressResponse = e.DecompressResponse
+	jsonObj.PreserveHeaderCase = e.PreserveHeaderCase
+	jsonObj.HeadAsGet = e.HeadAsGet
+	jsonObj.LogLevel = e.LogLevel
+	jsonObj.RequestTimeBreakdown = e.RequestTimeBreakdown
+	jsonObj.HostRedirect = e.HostRedirect
+	jsonObj.HostRedirectStatus = e.HostRedirectStatus
+	jsonObj.StickySessionTTLSeconds = int64(e.StickySessionTTL / time.Second)
+	jsonObj.Maintenance = e.Maintenance
+	jsonObj.ResponseDigest = e.ResponseDigest
+	jsonObj.UpgradeInsecureRequests = e.UpgradeInsecureRequests
+	jsonObj.AccessLogOnlyErrors = e.AccessLogOnlyErrors
+	jsonObj.TLSPassthrough = e.TLSPassthrough
 	return json.Marshal(jsonObj)
 }
 
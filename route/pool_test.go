@@ -1,6 +1,7 @@
 package route_test
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
@@ -40,6 +41,25 @@ var _ = Describe("Endpoint", func() {
 			})
 		})
 	})
+
+	Context("Opts", func() {
+		It("returns options which construct an equal endpoint", func() {
+			endpoint := route.NewEndpoint(&route.EndpointOpts{
+				AppId:                   "app-id",
+				Host:                    "10.0.0.1",
+				Port:                    8080,
+				PrivateInstanceId:       "instance-id",
+				Tags:                    map[string]string{"component": "app"},
+				StaleThresholdInSeconds: 30,
+				RouteServiceUrl:         "https://route-service.example.com",
+				UseTLS:                  true,
+				StripQueryParams:        []string{"token"},
+				MaxConnections:          5,
+			})
+
+			Expect(route.NewEndpoint(endpoint.Opts()).Equal(endpoint)).To(BeTrue())
+		})
+	})
 })
 
 var _ = Describe("EndpointPool", func() {
@@ -284,6 +304,214 @@ var _ = Describe("EndpointPool", func() {
 		})
 	})
 
+	Context("StripQueryParams", func() {
+		It("returns the params of the endpoint most recently updated in the pool", func() {
+			Expect(pool.StripQueryParams()).To(BeEmpty())
+
+			endpoint1 := route.NewEndpoint(&route.EndpointOpts{Host: "host-1", Port: 1234, StripQueryParams: []string{"a"}})
+			endpoint2 := route.NewEndpoint(&route.EndpointOpts{Host: "host-2", Port: 2234, StripQueryParams: []string{"a", "b"}})
+
+			Expect(pool.Put(endpoint1)).To(Equal(route.ADDED))
+			Expect(pool.StripQueryParams()).To(Equal([]string{"a"}))
+
+			Expect(pool.Put(endpoint2)).To(Equal(route.ADDED))
+			Expect(pool.StripQueryParams()).To(Equal([]string{"a", "b"}))
+		})
+	})
+
+	Context("AllowedQueryParams", func() {
+		It("returns the params of the endpoint most recently updated in the pool", func() {
+			Expect(pool.AllowedQueryParams()).To(BeEmpty())
+
+			endpoint := route.NewEndpoint(&route.EndpointOpts{Host: "host-1", Port: 1234, AllowedQueryParams: []string{"page"}})
+			Expect(pool.Put(endpoint)).To(Equal(route.ADDED))
+			Expect(pool.AllowedQueryParams()).To(Equal([]string{"page"}))
+		})
+	})
+
+	Context("HTTP1ConcurrentReadWrite", func() {
+		It("returns the setting of the endpoint most recently updated in the pool", func() {
+			Expect(pool.HTTP1ConcurrentReadWrite()).To(BeFalse())
+
+			endpoint := route.NewEndpoint(&route.EndpointOpts{Host: "host-1", Port: 1234, HTTP1ConcurrentReadWrite: true})
+			Expect(pool.Put(endpoint)).To(Equal(route.ADDED))
+			Expect(pool.HTTP1ConcurrentReadWrite()).To(BeTrue())
+		})
+	})
+
+	Context("Drain", func() {
+		BeforeEach(func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+		})
+
+		It("drains an endpoint of the pool", func() {
+			Expect(pool.Drain("1.1.1.1:8080")).To(BeTrue())
+		})
+
+		It("does not drain endpoints the pool does not have", func() {
+			Expect(pool.Drain("2.2.2.2:8080")).To(BeFalse())
+		})
+	})
+
+	Context("MaxResponseBodyBytes", func() {
+		It("has no maximum by default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			Expect(pool.MaxResponseBodyBytes()).To(BeZero())
+		})
+
+		It("returns the maximum of the endpoint most recently updated in the pool", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080, MaxResponseBodyBytes: 1024}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 8080, MaxResponseBodyBytes: 2048}))
+			Expect(pool.MaxResponseBodyBytes()).To(Equal(int64(2048)))
+		})
+	})
+
+	Context("BasicAuth", func() {
+		It("is not protected by default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			Expect(pool.BasicAuth()).To(BeEmpty())
+		})
+
+		It("returns the credentials of the endpoint most recently updated in the pool", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080, BasicAuth: "old"}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 8080, BasicAuth: "new"}))
+			Expect(pool.BasicAuth()).To(Equal("new"))
+		})
+	})
+
+	Context("ConnectTunnel", func() {
+		It("does not tunnel by default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			Expect(pool.ConnectTunnel()).To(BeFalse())
+		})
+
+		It("returns the setting of the endpoint most recently updated in the pool", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 8080, ConnectTunnel: true}))
+			Expect(pool.ConnectTunnel()).To(BeTrue())
+		})
+	})
+
+	Context("StatusRemap", func() {
+		It("does not remap by default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			Expect(pool.StatusRemap()).To(BeEmpty())
+			Expect(pool.StatusRemapReplaceBody()).To(BeFalse())
+		})
+
+		It("returns the remapping of the endpoint most recently updated in the pool", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080, StatusRemap: map[int]int{418: 503}}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 8080, StatusRemap: map[int]int{418: 429}, StatusRemapReplaceBody: true}))
+			Expect(pool.StatusRemap()).To(Equal(map[int]int{418: 429}))
+			Expect(pool.StatusRemapReplaceBody()).To(BeTrue())
+		})
+	})
+
+	Context("Deprecation and Sunset", func() {
+		It("are empty by default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			Expect(pool.Deprecation()).To(BeEmpty())
+			Expect(pool.Sunset()).To(BeEmpty())
+		})
+
+		It("return the values of the endpoint most recently updated in the pool", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 8080, Deprecation: "true", Sunset: "Wed, 11 Nov 2026 23:59:59 GMT"}))
+			Expect(pool.Deprecation()).To(Equal("true"))
+			Expect(pool.Sunset()).To(Equal("Wed, 11 Nov 2026 23:59:59 GMT"))
+		})
+	})
+
+	Context("MaxConnections", func() {
+		It("does not limit connections by default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+			Expect(pool.MaxConnections()).To(BeZero())
+			for i := 0; i < 10; i++ {
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+			}
+		})
+
+		Context("when a limit is set", func() {
+			BeforeEach(func() {
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080, MaxConnections: 2}))
+			})
+
+			It("bounds the number of active connections", func() {
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+				Expect(pool.AcquireConnection(context.Background(), 10*time.Millisecond)).To(BeFalse())
+				Expect(pool.ActiveConnections()).To(Equal(int64(2)))
+			})
+
+			It("hands a released connection to a waiting request", func() {
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+
+				acquired := make(chan bool)
+				go func() {
+					acquired <- pool.AcquireConnection(context.Background(), 5*time.Second)
+				}()
+				Consistently(acquired, 50*time.Millisecond).ShouldNot(Receive())
+
+				pool.ReleaseConnection()
+				Eventually(acquired).Should(Receive(BeTrue()))
+				Expect(pool.ActiveConnections()).To(Equal(int64(2)))
+			})
+
+			It("stops waiting when the context is done", func() {
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+				Expect(pool.AcquireConnection(context.Background(), 0)).To(BeTrue())
+
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				Expect(pool.AcquireConnection(ctx, 5*time.Second)).To(BeFalse())
+			})
+		})
+	})
+
+	Context("MinHealthyEndpoints", func() {
+		var endpoint1, endpoint2 *route.Endpoint
+
+		BeforeEach(func() {
+			endpoint1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080, MinHealthyEndpoints: 2})
+			endpoint2 = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 8080, MinHealthyEndpoints: 2})
+			pool.Put(endpoint1)
+			pool.Put(endpoint2)
+		})
+
+		It("returns the threshold of the endpoint most recently updated in the pool", func() {
+			Expect(pool.MinHealthyEndpoints()).To(Equal(2))
+		})
+
+		It("has enough healthy endpoints when the pool is at the threshold", func() {
+			Expect(pool.NumHealthyEndpoints()).To(Equal(2))
+			Expect(pool.HasTooFewHealthyEndpoints()).To(BeFalse())
+		})
+
+		It("does not count endpoints marked as failed as healthy", func() {
+			pool.EndpointFailed(endpoint1, &net.OpError{Op: "read", Err: errors.New("read: connection reset by peer")})
+
+			Expect(pool.NumHealthyEndpoints()).To(Equal(1))
+			Expect(pool.HasTooFewHealthyEndpoints()).To(BeTrue())
+		})
+
+		It("counts failed endpoints as healthy again after the retry-after-failure duration", func() {
+			pool = route.NewPool(&route.PoolOpts{Logger: logger, RetryAfterFailure: time.Millisecond})
+			pool.Put(endpoint1)
+			pool.Put(endpoint2)
+			pool.EndpointFailed(endpoint1, &net.OpError{Op: "read", Err: errors.New("read: connection reset by peer")})
+
+			Eventually(pool.HasTooFewHealthyEndpoints).Should(BeFalse())
+		})
+
+		It("has no threshold by default", func() {
+			pool = route.NewPool(&route.PoolOpts{Logger: logger})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 8080}))
+
+			Expect(pool.HasTooFewHealthyEndpoints()).To(BeFalse())
+		})
+	})
+
 	Context("EndpointFailed", func() {
 		Context("non-tls endpoints", func() {
 			var failedEndpoint, fineEndpoint *route.Endpoint
@@ -500,6 +728,39 @@ var _ = Describe("EndpointPool", func() {
 				})
 			})
 		})
+
+		Context("when MaxRequestsPerHTTP2Backend is set", func() {
+			BeforeEach(func() {
+				pool = route.NewPool(&route.PoolOpts{
+					Logger:                     logger,
+					RetryAfterFailure:          2 * time.Minute,
+					MaxConnsPerBackend:         2,
+					MaxRequestsPerHTTP2Backend: 4,
+				})
+			})
+
+			It("limits the in-flight requests to HTTP/2 endpoints", func() {
+				endpoint := route.NewEndpoint(&route.EndpointOpts{Port: 5678, Protocol: "http2"})
+				pool.Put(endpoint)
+
+				for i := 0; i < 3; i++ {
+					endpoint.Stats.NumberConnections.Increment()
+				}
+				Expect(pool.IsOverloaded()).To(BeFalse())
+
+				endpoint.Stats.NumberConnections.Increment()
+				Expect(pool.IsOverloaded()).To(BeTrue())
+			})
+
+			It("limits other endpoints by MaxConnsPerBackend", func() {
+				endpoint := route.NewEndpoint(&route.EndpointOpts{Port: 5678, Protocol: "http1"})
+				pool.Put(endpoint)
+
+				endpoint.Stats.NumberConnections.Increment()
+				endpoint.Stats.NumberConnections.Increment()
+				Expect(pool.IsOverloaded()).To(BeTrue())
+			})
+		})
 	})
 
 	Context("IsEmpty", func() {
@@ -16,6 +16,7 @@ type RoundRobin struct {
 	lastEndpoint          *Endpoint
 	locallyOptimistic     bool
 	localAvailabilityZone string
+	exclude               map[*Endpoint]struct{}
 }
 
 func NewRoundRobin(logger logger.Logger, p *EndpointPool, initial string, mustBeSticky bool, locallyOptimistic bool, localAvailabilityZone string) EndpointIterator {
@@ -41,6 +42,14 @@ func (r *RoundRobin) Next(attempt int) *Endpoint {
 			e = nil
 		}
 
+		if e != nil && e.isDraining() {
+			if r.mustBeSticky {
+				r.logger.Debug("endpoint-draining-but-request-must-be-sticky", e.endpoint.ToLogData()...)
+				return nil
+			}
+			e = nil
+		}
+
 		if e == nil && r.mustBeSticky {
 			r.logger.Debug("endpoint-missing-but-request-must-be-sticky", zap.Field(zap.String("requested-endpoint", r.initialEndpoint)))
 			return nil
@@ -76,6 +85,8 @@ func (r *RoundRobin) next(attempt int) *endpointElem {
 	defer r.pool.Unlock()
 
 	localDesired := r.locallyOptimistic && attempt == 0
+	slowStartDesired := true
+	excludeDesired := true
 
 	poolSize := len(r.pool.endpoints)
 	if poolSize == 0 {
@@ -91,6 +102,8 @@ func (r *RoundRobin) next(attempt int) *endpointElem {
 	startingIndex := r.pool.NextIdx
 	currentIndex := startingIndex
 	var nextIndex int
+	skippedWarmingUp := false
+	skippedExcluded := false
 
 	for {
 		e := r.pool.endpoints[currentIndex]
@@ -105,18 +118,39 @@ func (r *RoundRobin) next(attempt int) *endpointElem {
 		r.clearExpiredFailures(e)
 
 		if !localDesired || (localDesired && currentEndpointIsLocal) {
-			if e.failedAt == nil && !e.isOverloaded() {
-				r.pool.NextIdx = nextIndex
-				return e
+			if e.failedAt == nil && !e.isOverloaded() && !e.isDraining() {
+				if _, excluded := r.exclude[e.endpoint]; excluded && excludeDesired {
+					skippedExcluded = true
+				} else if !(slowStartDesired && r.pool.skipWarmingUp(e)) {
+					r.pool.NextIdx = nextIndex
+					return e
+				} else {
+					skippedWarmingUp = true
+				}
 			}
 		}
 
 		// If we've cycled through all of the indices and we WILL be back where we started.
 		if nextIndex == startingIndex {
-			if r.allEndpointsAreOverloaded() {
+			if r.allEndpointsAreUnavailable() {
 				return nil
 			}
 
+			// an endpoint passed over while warming up is available, so it is
+			// selected before any failed endpoint is retried
+			if skippedWarmingUp && slowStartDesired {
+				slowStartDesired = false
+				currentIndex = nextIndex
+				continue
+			}
+
+			// the same goes for an endpoint which was excluded
+			if skippedExcluded && excludeDesired {
+				excludeDesired = false
+				currentIndex = nextIndex
+				continue
+			}
+
 			// could not find a valid route in the same AZ
 			// start again but consider all AZs
 			localDesired = false
@@ -132,6 +166,12 @@ func (r *RoundRobin) next(attempt int) *endpointElem {
 	}
 }
 
+// excludeEndpoints makes the iterator pass over the endpoints, unless there is
+// no other endpoint to select.
+func (r *RoundRobin) excludeEndpoints(endpoints map[*Endpoint]struct{}) {
+	r.exclude = endpoints
+}
+
 func (r *RoundRobin) clearExpiredFailures(e *endpointElem) {
 	if e.failedAt != nil {
 		curTime := time.Now()
@@ -141,12 +181,13 @@ func (r *RoundRobin) clearExpiredFailures(e *endpointElem) {
 	}
 }
 
-func (r *RoundRobin) allEndpointsAreOverloaded() bool {
-	allEndpointsAreOverloaded := true
+func (r *RoundRobin) allEndpointsAreUnavailable() bool {
 	for _, e2 := range r.pool.endpoints {
-		allEndpointsAreOverloaded = allEndpointsAreOverloaded && e2.isOverloaded()
+		if !e2.isOverloaded() && !e2.isDraining() {
+			return false
+		}
 	}
-	return allEndpointsAreOverloaded
+	return true
 }
 
 func (r *RoundRobin) EndpointFailed(err error) {
@@ -258,6 +258,31 @@ var _ = Describe("RoundRobin", func() {
 			wg.Wait()
 		})
 
+		Context("when an HTTP/2 endpoint reaches its request limit", func() {
+			It("returns another endpoint", func() {
+				pool = route.NewPool(&route.PoolOpts{
+					Logger:                     test_util.NewTestZapLogger("test"),
+					RetryAfterFailure:          2 * time.Minute,
+					MaxRequestsPerHTTP2Backend: 2,
+				})
+				http2Endpoint := route.NewEndpoint(&route.EndpointOpts{Host: "5.5.5.5", Port: 5555, Protocol: "http2"})
+				pool.Put(http2Endpoint)
+				otherEndpoint := route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222, Protocol: "http2"})
+				pool.Put(otherEndpoint)
+
+				iter := route.NewRoundRobin(logger, pool, "", false, false, "meow-az")
+				iter.PreRequest(http2Endpoint)
+				iter.PreRequest(http2Endpoint)
+
+				for i := 0; i < 4; i++ {
+					Expect(iter.Next(i)).To(Equal(otherEndpoint))
+				}
+
+				iter.PostRequest(http2Endpoint)
+				Expect(pool.IsOverloaded()).To(BeFalse())
+			})
+		})
+
 		Context("when some endpoints are overloaded", func() {
 			var (
 				epOne, epTwo *route.Endpoint
@@ -775,6 +800,105 @@ var _ = Describe("RoundRobin", func() {
 		})
 	})
 
+	Describe("draining", func() {
+		var e1, e2 *route.Endpoint
+
+		BeforeEach(func() {
+			e1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234})
+			e2 = route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 5678})
+			pool.Put(e1)
+			pool.Put(e2)
+		})
+
+		It("does not select a draining endpoint", func() {
+			Expect(pool.Drain("1.2.3.4:1234")).To(BeTrue())
+
+			iter := route.NewRoundRobin(logger, pool, "", false, false, "meow-az")
+			for i := 0; i < 10; i++ {
+				Expect(iter.Next(i)).To(Equal(e2))
+			}
+		})
+
+		It("does not select an endpoint when all of them are draining", func() {
+			pool.Drain("1.2.3.4:1234")
+			pool.Drain("5.6.7.8:5678")
+
+			iter := route.NewRoundRobin(logger, pool, "", false, false, "meow-az")
+			Expect(iter.Next(0)).To(BeNil())
+		})
+
+		It("does not stick to a draining endpoint", func() {
+			pool.Drain("1.2.3.4:1234")
+
+			iter := route.NewRoundRobin(logger, pool, "1.2.3.4:1234", false, false, "meow-az")
+			Expect(iter.Next(0)).To(Equal(e2))
+		})
+
+		It("selects the endpoint again once it registers anew", func() {
+			pool.Drain("1.2.3.4:1234")
+			pool.Remove(e1)
+			pool.Remove(e2)
+			pool.Put(e1)
+
+			iter := route.NewRoundRobin(logger, pool, "", false, false, "meow-az")
+			Expect(iter.Next(0)).To(Equal(e1))
+		})
+	})
+
+	Describe("slow start", func() {
+		var established, added *route.Endpoint
+
+		shareOf := func(e *route.Endpoint) float64 {
+			iter := route.NewRoundRobin(logger, pool, "", false, false, "meow-az")
+			selected := 0
+			for i := 0; i < 1000; i++ {
+				if iter.Next(0) == e {
+					selected++
+				}
+			}
+			return float64(selected) / 1000
+		}
+
+		BeforeEach(func() {
+			pool = route.NewPool(&route.PoolOpts{
+				Logger:            logger,
+				RetryAfterFailure: 2 * time.Minute,
+				SlowStartWindow:   500 * time.Millisecond,
+			})
+			established = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234})
+			pool.Put(established)
+			time.Sleep(500 * time.Millisecond)
+
+			added = route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 5678})
+			pool.Put(added)
+		})
+
+		It("raises the share of the requests of a new endpoint over the window", func() {
+			Expect(shareOf(added)).To(BeNumerically("<", 0.1))
+
+			time.Sleep(250 * time.Millisecond)
+			Expect(shareOf(added)).To(And(BeNumerically(">", 0.15), BeNumerically("<", 0.45)))
+
+			time.Sleep(250 * time.Millisecond)
+			Expect(shareOf(added)).To(BeNumerically("~", 0.5, 0.01))
+		})
+
+		It("selects a new endpoint when it is the only one available", func() {
+			pool.Remove(established)
+			Expect(shareOf(added)).To(Equal(1.0))
+		})
+
+		It("selects a new endpoint before retrying failed ones", func() {
+			iter := route.NewRoundRobin(logger, pool, "", false, false, "meow-az")
+			Eventually(func() *route.Endpoint { return iter.Next(0) }).Should(Equal(established))
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+
+			for attempt := 1; attempt <= 100; attempt++ {
+				Expect(iter.Next(attempt)).To(Equal(added))
+			}
+		})
+	})
+
 	Describe("Failed", func() {
 		DescribeTable("it skips failed endpoints",
 			func(nextIdx int) {
@@ -26,10 +26,24 @@ func (u Uri) String() string {
 	return strings.TrimSuffix(string(u), "/")
 }
 
+// RouteKey returns the canonical form of the uri that routes are registered
+// and looked up by: lowercased, without a query string and without a
+// trailing dot on the host.
 func (u Uri) RouteKey() Uri {
-	key := u.ToLower()
-	if idx := strings.Index(string(key), "?"); idx >= 0 {
+	return u.ToLower().CaseSensitiveRouteKey()
+}
+
+// CaseSensitiveRouteKey returns the canonical form of the uri like RouteKey,
+// but only lowercases the host, leaving the case of the path.
+func (u Uri) CaseSensitiveRouteKey() Uri {
+	key := string(u)
+	if idx := strings.Index(key, "?"); idx >= 0 {
 		key = key[0:idx]
 	}
-	return key
+	host, path, found := strings.Cut(key, "/")
+	key = strings.ToLower(strings.TrimSuffix(host, "."))
+	if found {
+		key = key + "/" + path
+	}
+	return Uri(key)
 }
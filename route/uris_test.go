@@ -60,6 +60,22 @@ var _ = Describe("URIs", func() {
 
 		})
 
+		Context("has a trailing dot on the host", func() {
+
+			It("strips the trailing dot", func() {
+				key = route.Uri("dora.app.com.").RouteKey()
+				Expect(key.String()).To(Equal("dora.app.com"))
+
+				key = route.Uri("Dora.App.Com./v1?foo=bar").RouteKey()
+				Expect(key.String()).To(Equal("dora.app.com/v1"))
+			})
+
+			It("leaves dots in the path alone", func() {
+				key = route.Uri("dora.app.com/v1.").RouteKey()
+				Expect(key.String()).To(Equal("dora.app.com/v1."))
+			})
+		})
+
 		Context("has mixed case in uri", func() {
 
 			It("converts the uri to lowercase", func() {
@@ -73,4 +89,14 @@ var _ = Describe("URIs", func() {
 		})
 
 	})
+
+	Context("CaseSensitiveRouteKey", func() {
+		It("lowercases only the host", func() {
+			key := route.Uri("DoRa.ApP.CoM./V1/Abc?Foo=Bar").CaseSensitiveRouteKey()
+			Expect(key.String()).To(Equal("dora.app.com/V1/Abc"))
+
+			key = route.Uri("DORA.APP.COM/").CaseSensitiveRouteKey()
+			Expect(key.String()).To(Equal("dora.app.com"))
+		})
+	})
 })
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mdimiceli/gorouter/metrics"
+)
+
+// connCounter counts the client connections open across listeners and
+// reports the count at every interval, so that connections opening and
+// closing at a high rate do not cost a metric each.
+type connCounter struct {
+	open     atomic.Int64
+	reporter metrics.ProxyReporter
+	interval time.Duration
+	doneChan chan chan struct{}
+}
+
+func newConnCounter(reporter metrics.ProxyReporter, interval time.Duration) *connCounter {
+	return &connCounter{
+		reporter: reporter,
+		interval: interval,
+		doneChan: make(chan chan struct{}),
+	}
+}
+
+func (c *connCounter) Start() {
+	ticker := time.NewTicker(c.interval)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.reporter.CaptureConcurrentConnections(c.open.Load())
+		case stopped := <-c.doneChan:
+			ticker.Stop()
+			close(stopped)
+			return
+		}
+	}
+}
+
+func (c *connCounter) Stop() {
+	stopped := make(chan struct{})
+	c.doneChan <- stopped
+	<-stopped
+}
+
+// connCountListener counts the connections it accepts until they are closed.
+type connCountListener struct {
+	net.Listener
+	counter *connCounter
+}
+
+func (l *connCountListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.counter.open.Add(1)
+	return &connCountConn{Conn: conn, counter: l.counter}, nil
+}
+
+// connCountConn decrements the count of open connections once when it is
+// closed, as the server and the TLS stack may both close it.
+type connCountConn struct {
+	net.Conn
+	counter   *connCounter
+	closeOnce sync.Once
+}
+
+func (c *connCountConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { c.counter.open.Add(-1) })
+	return err
+}
+
+// CloseWrite half-closes the connection if it supports it, as the server
+// does before closing connections it rejects requests of, so that the client
+// reads the response before the connection is reset.
+func (c *connCountConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
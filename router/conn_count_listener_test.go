@@ -0,0 +1,106 @@
+package router
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mdimiceli/gorouter/metrics/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("connCountListener", func() {
+	var (
+		listener net.Listener
+		counter  *connCounter
+		server   *http.Server
+		reporter *fakes.FakeProxyReporter
+	)
+
+	BeforeEach(func() {
+		reporter = new(fakes.FakeProxyReporter)
+		counter = newConnCounter(reporter, 10*time.Millisecond)
+		go counter.Start()
+
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		listener = &connCountListener{Listener: tcpListener, counter: counter}
+	})
+
+	AfterEach(func() {
+		counter.Stop()
+	})
+
+	lastCount := func() int64 {
+		calls := reporter.CaptureConcurrentConnectionsCallCount()
+		if calls == 0 {
+			return -1
+		}
+		return reporter.CaptureConcurrentConnectionsArgsForCall(calls - 1)
+	}
+
+	Context("when serving HTTP", func() {
+		BeforeEach(func() {
+			server = &http.Server{Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})}
+			go server.Serve(listener)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("reports the number of open connections at every interval", func() {
+			Eventually(lastCount).Should(BeEquivalentTo(0))
+
+			first, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			defer first.Close()
+			Eventually(lastCount).Should(BeEquivalentTo(1))
+
+			second, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(lastCount).Should(BeEquivalentTo(2))
+
+			// the server closes the connection once the client closed it
+			second.Close()
+			Eventually(lastCount).Should(BeEquivalentTo(1))
+		})
+
+		It("counts a connection closed more than once only once", func() {
+			client, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			defer client.Close()
+			Eventually(lastCount).Should(BeEquivalentTo(1))
+
+			server.Close()
+			Eventually(lastCount).Should(BeEquivalentTo(0))
+			Consistently(lastCount).Should(BeEquivalentTo(0))
+		})
+	})
+
+	It("half-closes the connections it accepts", func() {
+		client, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		conn, err := listener.Accept()
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+
+		closeWriter, ok := conn.(interface{ CloseWrite() error })
+		Expect(ok).To(BeTrue())
+		Expect(closeWriter.CloseWrite()).To(Succeed())
+
+		_, err = client.Read(make([]byte, 1))
+		Expect(err).To(Equal(io.EOF))
+
+		// the connection can still be read from
+		_, err = client.Write([]byte("x"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = conn.Read(make([]byte, 1))
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,204 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/textproto"
+	"sync"
+
+	"github.com/mdimiceli/gorouter/handlers"
+)
+
+const (
+	// headerCaseMaxLineBytes is the longest line scanned for a header name.
+	// Longer lines, such as those of large cookies, are skipped.
+	headerCaseMaxLineBytes = 8 * 1024
+	// headerCaseMaxNames bounds the spellings recorded per request.
+	headerCaseMaxNames = 256
+	// headerCaseMaxBlocks bounds the header blocks read ahead of the request
+	// being served, such as those of pipelined requests.
+	headerCaseMaxBlocks = 16
+)
+
+// headerCaseListener wraps accepted connections in a headerCaseConn. On a TLS
+// listener it wraps the TLS connections, so that it reads the requests they
+// decrypt.
+type headerCaseListener struct {
+	net.Listener
+}
+
+func (l *headerCaseListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	hc := &headerCaseConn{Conn: conn}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		hc.tlsConn = tlsConn
+	}
+	return withTLSState(conn, hc), nil
+}
+
+// headerCaseBlock is the request line of a request read from a connection,
+// and the spelling of its header names which are not canonical.
+type headerCaseBlock struct {
+	requestLine string
+	names       headerCaseNames
+}
+
+// headerCaseNames maps canonical header names to the spelling of a request.
+type headerCaseNames map[string]string
+
+func (n headerCaseNames) HeaderCase(canonicalName string) string {
+	return n[canonicalName]
+}
+
+// headerCaseConn records the spelling of the header names of the HTTP/1
+// requests read from the connection, as the server canonicalizes them while
+// parsing the requests.
+//
+// The lines following a request line up to the first empty line are taken to
+// be its headers. The server reads ahead of the request it serves, so the
+// header blocks are queued and handed out by headerCaseHandler to the request
+// whose request line they follow. Request bodies are not parsed, so a body
+// which contains a request line followed by header lines is misread as a
+// request. Such blocks are dropped when the request after them is served,
+// unless the body repeats its request line, in which case only the spelling
+// of its header names is taken from the body, which does not change the
+// meaning of a compliant request.
+type headerCaseConn struct {
+	net.Conn
+	// tlsConn is the wrapped TLS connection, if any. HTTP/2 connections are
+	// not scanned, as HTTP/2 lowercases all header names.
+	tlsConn *tls.Conn
+
+	mu      sync.Mutex
+	started bool
+	http2   bool
+	lines   lineScanner
+	current *headerCaseBlock
+	blocks  []*headerCaseBlock
+}
+
+func (c *headerCaseConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.scan(b[:n])
+	}
+	return n, err
+}
+
+// takeHeaderCase returns the spelling of the header names of the request,
+// dropping the header blocks read before its own.
+func (c *headerCaseConn) takeHeaderCase(r *http.Request) headerCaseNames {
+	requestLine := r.Method + " " + r.RequestURI + " " + r.Proto
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, block := range c.blocks {
+		if block.requestLine == requestLine {
+			c.blocks = c.blocks[i+1:]
+			return block.names
+		}
+	}
+	return nil
+}
+
+func (c *headerCaseConn) scan(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the server completes the handshake before it reads from the connection
+	if !c.started {
+		c.started = true
+		c.http2 = c.tlsConn != nil && c.tlsConn.ConnectionState().NegotiatedProtocol == "h2"
+	}
+	if c.http2 {
+		return
+	}
+
+	c.lines.scan(data, headerCaseMaxLineBytes, c.scanLine)
+}
+
+func (c *headerCaseConn) scanLine(line []byte, truncated bool) {
+	if truncated {
+		return
+	}
+	if isRequestLine(line) {
+		c.current = &headerCaseBlock{requestLine: string(line), names: headerCaseNames{}}
+		return
+	}
+	if c.current == nil {
+		return
+	}
+	if len(line) == 0 {
+		if len(c.blocks) == headerCaseMaxBlocks {
+			c.blocks = c.blocks[1:]
+		}
+		c.blocks = append(c.blocks, c.current)
+		c.current = nil
+		return
+	}
+
+	name, _, found := bytes.Cut(line, []byte(":"))
+	if !found || len(name) == 0 || bytes.ContainsAny(name, " \t") {
+		return
+	}
+	raw := string(name)
+	canonical := textproto.CanonicalMIMEHeaderKey(raw)
+	names := c.current.names
+	if raw != canonical && (len(names) < headerCaseMaxNames || names[canonical] != "") {
+		names[canonical] = raw
+	}
+}
+
+// isRequestLine tells request lines apart from header lines, whose name ends
+// with a colon before the first space.
+func isRequestLine(line []byte) bool {
+	method, _, found := bytes.Cut(line, []byte(" "))
+	return found && len(method) > 0 && bytes.IndexByte(method, ':') < 0 &&
+		(bytes.HasSuffix(line, []byte(" HTTP/1.1")) || bytes.HasSuffix(line, []byte(" HTTP/1.0")))
+}
+
+// headerCaseConnOf returns the headerCaseConn of a connection, which may be
+// wrapped by other connections of the router.
+func headerCaseConnOf(conn net.Conn) (*headerCaseConn, bool) {
+	for {
+		if hc, ok := conn.(*headerCaseConn); ok {
+			return hc, true
+		}
+		var ok bool
+		if conn, ok = unwrapConn(conn); !ok {
+			return nil, false
+		}
+	}
+}
+
+type headerCaseConnCtxKey struct{}
+
+// contextWithHeaderCaseConn attaches the headerCaseConn of a client
+// connection to the context its requests are served with.
+func contextWithHeaderCaseConn(ctx context.Context, conn net.Conn) context.Context {
+	if hc, ok := headerCaseConnOf(conn); ok {
+		return context.WithValue(ctx, headerCaseConnCtxKey{}, hc)
+	}
+	return ctx
+}
+
+// headerCaseHandler attaches the spelling of the header names of HTTP/1
+// requests read from a headerCaseConn to their context.
+func headerCaseHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hc, ok := r.Context().Value(headerCaseConnCtxKey{}).(*headerCaseConn)
+		if !ok || r.ProtoMajor != 1 {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		ctx := handlers.ContextWithHeaderCaseRecorder(r.Context(), hc.takeHeaderCase(r))
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,225 @@
+package router
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("headerCaseListener", func() {
+	var (
+		listener net.Listener
+		server   *http.Server
+		spelling chan string
+		client   net.Conn
+		reader   *bufio.Reader
+	)
+
+	newServer := func() *http.Server {
+		return &http.Server{
+			Handler: headerCaseHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				recorder, ok := handlers.GetHeaderCaseRecorder(r)
+				if !ok {
+					spelling <- "no recorder"
+					return
+				}
+				spelling <- recorder.HeaderCase("X-Api-Key")
+			})),
+			ConnContext: connContext,
+		}
+	}
+
+	BeforeEach(func() {
+		spelling = make(chan string, 2)
+
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		listener = &headerCaseListener{Listener: tcpListener}
+
+		server = newServer()
+		go server.Serve(listener)
+
+		client, err = net.Dial("tcp", listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		reader = bufio.NewReader(client)
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	readResponse := func() {
+		resp, err := http.ReadResponse(reader, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		resp.Body.Close()
+	}
+
+	roundTrip := func(request string) {
+		_, err := fmt.Fprint(client, request)
+		Expect(err).ToNot(HaveOccurred())
+		readResponse()
+	}
+
+	It("records the spelling of header names the client used", func() {
+		roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nx-api-KEY: secret\r\n\r\n")
+		Expect(spelling).To(Receive(Equal("x-api-KEY")))
+	})
+
+	It("does not record canonical spellings", func() {
+		roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nx-api-key: secret\r\n\r\n")
+		Expect(spelling).To(Receive(Equal("x-api-key")))
+
+		roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nX-Api-Key: secret\r\n\r\n")
+		Expect(spelling).To(Receive(BeEmpty()))
+	})
+
+	It("scopes the spellings to the request which used them", func() {
+		roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nx-api-key: secret\r\n\r\n")
+		Expect(spelling).To(Receive(Equal("x-api-key")))
+
+		roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+		Expect(spelling).To(Receive(BeEmpty()))
+	})
+
+	It("hands the spellings of pipelined requests to each request", func() {
+		_, err := fmt.Fprint(client,
+			"GET /first HTTP/1.1\r\nHost: example.com\r\nx-api-KEY: secret\r\n\r\n"+
+				"GET /second HTTP/1.1\r\nHost: example.com\r\nX-API-key: secret\r\n\r\n")
+		Expect(err).ToNot(HaveOccurred())
+		readResponse()
+		readResponse()
+
+		Expect(spelling).To(Receive(Equal("x-api-KEY")))
+		Expect(spelling).To(Receive(Equal("X-API-key")))
+	})
+
+	It("does not record header names from request bodies", func() {
+		roundTrip("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 20\r\n\r\nx-api-key: secret\r\n\r\n")
+		Expect(spelling).To(Receive(BeEmpty()))
+	})
+
+	It("does not hand header names from request bodies to the next request", func() {
+		body := "GET /other HTTP/1.1\r\nx-api-key: secret\r\n\r\n"
+		roundTrip(fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: %d\r\n\r\n%s", len(body), body))
+		Expect(spelling).To(Receive(BeEmpty()))
+
+		roundTrip("GET /other HTTP/1.1\r\nHost: example.com\r\nX-Api-Key: secret\r\n\r\n")
+		Expect(spelling).To(Receive(BeEmpty()))
+	})
+
+	It("does not take header values for request lines", func() {
+		roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nReferer: GET / HTTP/1.1\r\nx-api-key: secret\r\n\r\n")
+		Expect(spelling).To(Receive(Equal("x-api-key")))
+	})
+
+	Context("with TLS", func() {
+		BeforeEach(func() {
+			client.Close()
+			server.Close()
+
+			tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			tlsConfig := &tls.Config{
+				Certificates: []tls.Certificate{test_util.CreateCert("default")},
+				NextProtos:   []string{"h2", "http/1.1"},
+			}
+			listener = &headerCaseListener{Listener: tls.NewListener(tcpListener, tlsConfig)}
+
+			server = newServer()
+			handler := server.Handler
+			server.Handler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				if r.TLS == nil {
+					spelling <- "no tls state"
+					return
+				}
+				handler.ServeHTTP(rw, r)
+			})
+			go server.Serve(listener)
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("records the spelling of header names of HTTP/1 requests", func() {
+			var err error
+			client, err = tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+				InsecureSkipVerify: true,
+				NextProtos:         []string{"http/1.1"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			reader = bufio.NewReader(client)
+
+			roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nx-api-KEY: secret\r\n\r\n")
+			Expect(spelling).To(Receive(Equal("x-api-KEY")))
+		})
+
+		It("serves HTTP/2 requests without recording their header names", func() {
+			httpClient := &http.Client{Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				ForceAttemptHTTP2: true,
+			}}
+			resp, err := httpClient.Get("https://" + listener.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.ProtoMajor).To(Equal(2))
+			Expect(spelling).To(Receive(Equal("no recorder")))
+		})
+	})
+
+	Describe("headerCaseConn", func() {
+		request := func(method, requestURI string) *http.Request {
+			return &http.Request{Method: method, RequestURI: requestURI, Proto: "HTTP/1.1"}
+		}
+
+		It("records header lines split across reads", func() {
+			conn := &headerCaseConn{}
+			conn.scan([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nx-ap"))
+			conn.scan([]byte("i-key: secret\r"))
+			conn.scan([]byte("\n\r\n"))
+
+			Expect(conn.takeHeaderCase(request("GET", "/")).HeaderCase("X-Api-Key")).To(Equal("x-api-key"))
+		})
+
+		It("skips lines which are too long", func() {
+			conn := &headerCaseConn{}
+			conn.scan([]byte("GET / HTTP/1.1\r\nx-long: "))
+			conn.scan(make([]byte, headerCaseMaxLineBytes))
+			conn.scan([]byte("\r\nx-api-key: secret\r\n\r\n"))
+
+			names := conn.takeHeaderCase(request("GET", "/"))
+			Expect(names.HeaderCase("X-Long")).To(BeEmpty())
+			Expect(names.HeaderCase("X-Api-Key")).To(Equal("x-api-key"))
+		})
+
+		It("drops the header blocks read before the request", func() {
+			conn := &headerCaseConn{}
+			conn.scan([]byte("GET /phantom HTTP/1.1\r\nx-api-key: secret\r\n\r\n"))
+			conn.scan([]byte("GET / HTTP/1.1\r\nx-api-KEY: secret\r\n\r\n"))
+
+			Expect(conn.takeHeaderCase(request("GET", "/")).HeaderCase("X-Api-Key")).To(Equal("x-api-KEY"))
+			Expect(conn.takeHeaderCase(request("GET", "/phantom")).HeaderCase("X-Api-Key")).To(BeEmpty())
+		})
+
+		It("bounds the header blocks read ahead", func() {
+			conn := &headerCaseConn{}
+			for i := 0; i <= headerCaseMaxBlocks; i++ {
+				conn.scan([]byte(fmt.Sprintf("GET /%d HTTP/1.1\r\nx-api-key: secret\r\n\r\n", i)))
+			}
+
+			Expect(conn.takeHeaderCase(request("GET", "/0")).HeaderCase("X-Api-Key")).To(BeEmpty())
+			Expect(conn.takeHeaderCase(request("GET", "/1")).HeaderCase("X-Api-Key")).To(Equal("x-api-key"))
+		})
+	})
+})
@@ -0,0 +1,35 @@
+package router
+
+import "bytes"
+
+// lineScanner splits the bytes read from a connection into lines, without
+// their line endings.
+type lineScanner struct {
+	line      []byte
+	truncated bool
+}
+
+// scan calls fn with every line completed by data. Lines longer than
+// maxLineBytes are cut short and passed on as truncated.
+func (s *lineScanner) scan(data []byte, maxLineBytes int, fn func(line []byte, truncated bool)) {
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			s.append(data, maxLineBytes)
+			return
+		}
+		s.append(data[:i], maxLineBytes)
+		fn(bytes.TrimSuffix(s.line, []byte("\r")), s.truncated)
+		s.line = s.line[:0]
+		s.truncated = false
+		data = data[i+1:]
+	}
+}
+
+func (s *lineScanner) append(data []byte, maxLineBytes int) {
+	if room := maxLineBytes - len(s.line); len(data) > room {
+		data = data[:room]
+		s.truncated = true
+	}
+	s.line = append(s.line, data...)
+}
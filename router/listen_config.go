@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"github.com/mdimiceli/gorouter/config"
+)
+
+// listenClients opens a TCP listener for client connections, applying the
+// configured socket options to every accepted connection.
+func listenClients(addr string, cfg config.ClientConnectionConfig) (net.Listener, error) {
+	listener, err := clientListenConfig(cfg).Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &noDelayListener{Listener: listener, noDelay: cfg.TCPNoDelay}, nil
+}
+
+func clientListenConfig(cfg config.ClientConnectionConfig) *net.ListenConfig {
+	lc := &net.ListenConfig{
+		KeepAliveConfig: net.KeepAliveConfig{
+			Enable:   cfg.KeepAlive,
+			Idle:     cfg.KeepAliveIdle,
+			Interval: cfg.KeepAliveInterval,
+			Count:    cfg.KeepAliveCount,
+		},
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				// accepted sockets inherit TCP_NODELAY from the listening socket
+				sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_NODELAY, boolToInt(cfg.TCPNoDelay))
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+	if !cfg.KeepAlive {
+		lc.KeepAlive = -1
+	}
+	return lc
+}
+
+// noDelayListener sets TCP_NODELAY explicitly on accepted connections, as the
+// Go runtime enables it on accept regardless of the listening socket.
+type noDelayListener struct {
+	net.Listener
+	noDelay bool
+}
+
+func (l *noDelayListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetNoDelay(l.noDelay)
+	}
+	return conn, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package router
+
+import (
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/mdimiceli/gorouter/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("listenClients", func() {
+	var (
+		cfg      config.ClientConnectionConfig
+		listener net.Listener
+		client   net.Conn
+		accepted net.Conn
+	)
+
+	sockOpt := func(conn net.Conn, level, opt int) int {
+		rawConn, err := conn.(*net.TCPConn).SyscallConn()
+		Expect(err).ToNot(HaveOccurred())
+
+		var value int
+		var sockErr error
+		Expect(rawConn.Control(func(fd uintptr) {
+			value, sockErr = syscall.GetsockoptInt(int(fd), level, opt)
+		})).To(Succeed())
+		Expect(sockErr).ToNot(HaveOccurred())
+		return value
+	}
+
+	BeforeEach(func() {
+		cfg = config.ClientConnectionConfig{
+			TCPNoDelay: true,
+			KeepAlive:  true,
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		listener, err = listenClients("127.0.0.1:0", cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		client, err = net.Dial("tcp", listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+
+		accepted, err = listener.Accept()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		accepted.Close()
+		client.Close()
+		listener.Close()
+	})
+
+	It("enables TCP_NODELAY on accepted connections", func() {
+		Expect(sockOpt(accepted, syscall.IPPROTO_TCP, syscall.TCP_NODELAY)).ToNot(BeZero())
+	})
+
+	Context("when TCP_NODELAY is disabled", func() {
+		BeforeEach(func() {
+			cfg.TCPNoDelay = false
+		})
+
+		It("disables it on accepted connections", func() {
+			Expect(sockOpt(accepted, syscall.IPPROTO_TCP, syscall.TCP_NODELAY)).To(BeZero())
+		})
+	})
+
+	Context("when a keep-alive interval is configured", func() {
+		BeforeEach(func() {
+			cfg.KeepAliveInterval = 7 * time.Second
+		})
+
+		It("applies it to accepted connections", func() {
+			Expect(sockOpt(accepted, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE)).ToNot(BeZero())
+			Expect(sockOpt(accepted, syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL)).To(Equal(7))
+		})
+	})
+
+	Context("when keep-alive is disabled", func() {
+		BeforeEach(func() {
+			cfg.KeepAlive = false
+		})
+
+		It("disables keep-alive probes on accepted connections", func() {
+			Expect(sockOpt(accepted, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE)).To(BeZero())
+		})
+	})
+})
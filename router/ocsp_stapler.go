@@ -0,0 +1,213 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/logger"
+)
+
+var errNoOCSPServer = errors.New("certificate has no OCSP server")
+
+// ocspStapler periodically fetches OCSP responses for the certificates of a
+// TLS config and serves them stapled via GetConfigForClient. Certificates for
+// which no response could be fetched are served without a staple.
+type ocspStapler struct {
+	base   *tls.Config
+	cfg    config.OCSPStaplingConfig
+	client *http.Client
+	logger logger.Logger
+
+	lock    sync.RWMutex
+	staples [][]byte
+	current *tls.Config
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newOCSPStapler(base *tls.Config, cfg config.OCSPStaplingConfig, logger logger.Logger) *ocspStapler {
+	return &ocspStapler{
+		base:    base,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.FetchTimeout},
+		logger:  logger,
+		staples: make([][]byte, len(base.Certificates)),
+		current: base,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start fetches the OCSP responses in the background and keeps refreshing
+// them until Stop is called.
+func (s *ocspStapler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			timer := time.NewTimer(s.refresh())
+			select {
+			case <-timer.C:
+			case <-s.stop:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (s *ocspStapler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.wg.Wait()
+}
+
+func (s *ocspStapler) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.current, nil
+}
+
+// refresh fetches a response for every certificate and returns how long to
+// wait before the next refresh. A failed fetch keeps the previous staple as
+// long as it has not expired.
+func (s *ocspStapler) refresh() time.Duration {
+	next := s.cfg.RefreshInterval
+	now := time.Now()
+
+	s.lock.RLock()
+	staples := make([][]byte, len(s.staples))
+	copy(staples, s.staples)
+	s.lock.RUnlock()
+
+	for i, cert := range s.base.Certificates {
+		leaf, issuer, err := parseChain(cert)
+		if err != nil {
+			s.logger.Error("ocsp-parse-certificate-failed", zap.Int("certificate-index", i), zap.Error(err))
+			continue
+		}
+		logger := s.logger.With(zap.String("certificate-subject", leaf.Subject.String()))
+
+		raw, resp, err := s.fetch(leaf, issuer)
+		if errors.Is(err, errNoOCSPServer) {
+			logger.Debug("ocsp-no-responder-for-certificate")
+			continue
+		}
+		if err != nil {
+			if staples[i] != nil && !stapleValid(staples[i], leaf, issuer, now) {
+				staples[i] = nil
+			}
+			logger.Error("ocsp-fetch-failed", zap.Error(err))
+			continue
+		}
+		if resp.Status != ocsp.Good {
+			staples[i] = nil
+			logger.Error("ocsp-certificate-not-good", zap.Int("status", resp.Status))
+			continue
+		}
+
+		staples[i] = raw
+		logger.Info("ocsp-response-stapled", zap.Time("next-update", resp.NextUpdate))
+
+		// refresh halfway to the next update so that a failing responder
+		// does not immediately leave us without a valid staple
+		if !resp.NextUpdate.IsZero() {
+			if untilUpdate := resp.NextUpdate.Sub(now) / 2; untilUpdate > 0 && untilUpdate < next {
+				next = untilUpdate
+			}
+		}
+	}
+
+	s.apply(staples)
+	return next
+}
+
+func (s *ocspStapler) apply(staples [][]byte) {
+	current := s.base.Clone()
+	current.GetConfigForClient = nil
+	current.Certificates = make([]tls.Certificate, len(s.base.Certificates))
+	for i, cert := range s.base.Certificates {
+		cert.OCSPStaple = staples[i]
+		current.Certificates[i] = cert
+	}
+	//lint:ignore SA1019 - see serveHTTPS
+	current.BuildNameToCertificate()
+
+	s.lock.Lock()
+	s.staples = staples
+	s.current = current
+	s.lock.Unlock()
+}
+
+func (s *ocspStapler) fetch(leaf, issuer *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	if len(leaf.OCSPServer) == 0 {
+		return nil, nil, errNoOCSPServer
+	}
+
+	reqBody, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.FetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, leaf.OCSPServer[0], bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected OCSP responder status: %d", res.StatusCode)
+	}
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, resp, nil
+}
+
+func parseChain(cert tls.Certificate) (*x509.Certificate, *x509.Certificate, error) {
+	if len(cert.Certificate) < 2 {
+		return nil, nil, errors.New("certificate chain does not contain the issuer")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	issuer, err := x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return leaf, issuer, nil
+}
+
+func stapleValid(raw []byte, leaf, issuer *x509.Certificate, now time.Time) bool {
+	resp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return false
+	}
+	return resp.NextUpdate.IsZero() || now.Before(resp.NextUpdate)
+}
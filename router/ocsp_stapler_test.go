@@ -0,0 +1,156 @@
+package router
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ocspStapler", func() {
+	var (
+		stapler       *ocspStapler
+		responder     *httptest.Server
+		responderFail atomic.Bool
+		caCert        *x509.Certificate
+		caKey         *rsa.PrivateKey
+		leafCert      *x509.Certificate
+		serverConfig  *tls.Config
+		clientRoots   *x509.CertPool
+	)
+
+	handshake := func() tls.ConnectionState {
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+
+		go func() {
+			defer serverConn.Close()
+			tls.Server(serverConn, serverConfig).Handshake()
+		}()
+
+		client := tls.Client(clientConn, &tls.Config{
+			ServerName: "example.com",
+			RootCAs:    clientRoots,
+		})
+		Expect(client.Handshake()).To(Succeed())
+		return client.ConnectionState()
+	}
+
+	BeforeEach(func() {
+		responderFail.Store(false)
+		responder = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if responderFail.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			req, err := ocsp.ParseRequest(body)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := ocsp.CreateResponse(caCert, caCert, ocsp.Response{
+				Status:       ocsp.Good,
+				SerialNumber: req.SerialNumber,
+				ThisUpdate:   time.Now().Add(-time.Minute),
+				NextUpdate:   time.Now().Add(time.Hour),
+			}, caKey)
+			Expect(err).ToNot(HaveOccurred())
+			w.Header().Set("Content-Type", "application/ocsp-response")
+			w.Write(resp)
+		}))
+
+		chain := test_util.CreateSignedCertWithRootCA(test_util.CertNames{CommonName: "theCA"})
+		caCert = chain.CACert
+		caKey = chain.CAPrivKey
+		clientRoots = x509.NewCertPool()
+		clientRoots.AddCert(caCert)
+
+		leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(42),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			DNSNames:     []string{"example.com"},
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+			OCSPServer:   []string{responder.URL},
+		}, caCert, &leafKey.PublicKey, caKey)
+		Expect(err).ToNot(HaveOccurred())
+		leafCert, err = x509.ParseCertificate(leafDER)
+		Expect(err).ToNot(HaveOccurred())
+
+		serverConfig = &tls.Config{
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{leafDER, caCert.Raw},
+				PrivateKey:  leafKey,
+			}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		stapler = newOCSPStapler(serverConfig, config.OCSPStaplingConfig{
+			Enabled:         true,
+			RefreshInterval: time.Hour,
+			FetchTimeout:    time.Second,
+		}, test_util.NewTestZapLogger("ocsp-test"))
+		serverConfig.GetConfigForClient = stapler.GetConfigForClient
+		stapler.Start()
+	})
+
+	AfterEach(func() {
+		stapler.Stop()
+		responder.Close()
+	})
+
+	It("staples the OCSP response to the handshake", func() {
+		Eventually(func() []byte {
+			return handshake().OCSPResponse
+		}).ShouldNot(BeEmpty())
+
+		resp, err := ocsp.ParseResponseForCert(handshake().OCSPResponse, leafCert, caCert)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Status).To(Equal(ocsp.Good))
+		Expect(resp.SerialNumber).To(Equal(big.NewInt(42)))
+	})
+
+	Context("when the OCSP responder fails", func() {
+		BeforeEach(func() {
+			responderFail.Store(true)
+		})
+
+		It("completes the handshake without a staple", func() {
+			Consistently(func() []byte {
+				return handshake().OCSPResponse
+			}).Should(BeEmpty())
+		})
+	})
+
+	Context("when a refresh fails after a response was stapled", func() {
+		It("keeps serving the unexpired staple", func() {
+			Eventually(func() []byte {
+				return handshake().OCSPResponse
+			}).ShouldNot(BeEmpty())
+
+			responderFail.Store(true)
+			stapler.refresh()
+			Expect(handshake().OCSPResponse).ToNot(BeEmpty())
+		})
+	})
+})
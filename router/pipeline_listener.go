@@ -0,0 +1,120 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/mdimiceli/gorouter/handlers"
+)
+
+// pipelineListener wraps accepted connections in a pipelineConn. On a TLS
+// listener it wraps the TLS connections, so that it reads the requests they
+// decrypt.
+type pipelineListener struct {
+	net.Listener
+}
+
+func (l *pipelineListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return withTLSState(conn, &pipelineConn{Conn: conn}), nil
+}
+
+// pipelineConn detects HTTP/1.1 requests a client sends before it has
+// received the response to its previous request.
+//
+// The server reads requests through a buffer, so a pipelined request is
+// usually read from the socket along with the previous one. A request the
+// server starts serving without having read from the socket since the
+// connection went idle was therefore pipelined. The same holds for a request
+// whose first byte arrived while the previous one was served: once it has read
+// a request body, the server reads a single byte in the background to notice
+// clients closing the connection.
+type pipelineConn struct {
+	net.Conn
+
+	served        atomic.Bool
+	active        atomic.Bool
+	readSinceIdle atomic.Bool
+	readEarly     atomic.Bool
+	pipelined     atomic.Bool
+}
+
+func (c *pipelineConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.readSinceIdle.Store(true)
+		if len(b) == 1 && c.active.Load() {
+			c.readEarly.Store(true)
+		}
+	}
+	return n, err
+}
+
+// Pipelined reports whether the request currently served on the connection
+// was pipelined.
+func (c *pipelineConn) Pipelined() bool {
+	return c.pipelined.Load()
+}
+
+func (c *pipelineConn) setState(state http.ConnState) {
+	switch state {
+	case http.StateActive:
+		c.pipelined.Store(c.served.Load() && (!c.readSinceIdle.Load() || c.readEarly.Load()))
+		c.readEarly.Store(false)
+		c.active.Store(true)
+	case http.StateIdle:
+		c.active.Store(false)
+		c.readSinceIdle.Store(false)
+		c.served.Store(true)
+	}
+}
+
+func pipelineConnOf(conn net.Conn) (*pipelineConn, bool) {
+	for {
+		if pc, ok := conn.(*pipelineConn); ok {
+			return pc, true
+		}
+		var ok bool
+		if conn, ok = unwrapConn(conn); !ok {
+			return nil, false
+		}
+	}
+}
+
+func pipelineConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if pc, ok := pipelineConnOf(conn); ok {
+		return handlers.ContextWithPipelineDetector(ctx, pc)
+	}
+	return ctx
+}
+
+// connContext attaches the start time, detectors and recorders of a client
+// connection to the context its requests are served with.
+func connContext(ctx context.Context, conn net.Conn) context.Context {
+	ctx = handlers.ContextWithConnectionStartedAt(ctx, time.Now())
+	ctx = pipelineConnContext(ctx, conn)
+	ctx = contextWithRequestHeadConn(ctx, conn)
+	return contextWithHeaderCaseConn(ctx, conn)
+}
+
+// unwrapConn returns the connection wrapped by one of the connections of the
+// router.
+func unwrapConn(conn net.Conn) (net.Conn, bool) {
+	switch c := conn.(type) {
+	case *tlsStateConn:
+		return c.Conn, true
+	case *pipelineConn:
+		return c.Conn, true
+	case *requestHeadConn:
+		return c.Conn, true
+	case *headerCaseConn:
+		return c.Conn, true
+	}
+	return nil, false
+}
@@ -0,0 +1,156 @@
+package router
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pipelineListener", func() {
+	var (
+		useTLS    bool
+		listener  net.Listener
+		server    *http.Server
+		pipelined chan bool
+		tlsState  chan *tls.ConnectionState
+		proceed   chan struct{}
+		accepted  chan *pipelineConn
+		client    net.Conn
+		reader    *bufio.Reader
+	)
+
+	BeforeEach(func() {
+		useTLS = false
+	})
+
+	JustBeforeEach(func() {
+		pipelined = make(chan bool, 2)
+		tlsState = make(chan *tls.ConnectionState, 2)
+		proceed = make(chan struct{}, 2)
+		accepted = make(chan *pipelineConn, 1)
+
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		listener = tcpListener
+		if useTLS {
+			listener = tls.NewListener(listener, &tls.Config{
+				Certificates: []tls.Certificate{test_util.CreateCert("default")},
+			})
+		}
+		listener = &pipelineListener{Listener: listener}
+
+		server = &http.Server{
+			Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				d, ok := r.Context().Value(handlers.PipelineDetectorCtxKey).(handlers.PipelineDetector)
+				pipelined <- ok && d.Pipelined()
+				tlsState <- r.TLS
+				<-proceed
+			}),
+			ConnState: func(conn net.Conn, state http.ConnState) {
+				if pc, ok := pipelineConnOf(conn); ok {
+					if state == http.StateNew {
+						accepted <- pc
+					}
+					pc.setState(state)
+				}
+			},
+			ConnContext: pipelineConnContext,
+		}
+		go server.Serve(listener)
+
+		if useTLS {
+			client, err = tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+				InsecureSkipVerify: true,
+				NextProtos:         []string{"http/1.1"},
+			})
+		} else {
+			client, err = net.Dial("tcp", listener.Addr().String())
+		}
+		Expect(err).ToNot(HaveOccurred())
+		reader = bufio.NewReader(client)
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	writeRequest := func(path string) {
+		_, err := fmt.Fprintf(client, "GET %s HTTP/1.1\r\nHost: example.com\r\n\r\n", path)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	readResponse := func() {
+		resp, err := http.ReadResponse(reader, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		resp.Body.Close()
+	}
+
+	itDetectsPipelinedRequests := func() {
+		It("does not detect requests sent after the previous response", func() {
+			for i := 0; i < 2; i++ {
+				writeRequest("/")
+				Eventually(pipelined).Should(Receive(BeFalse()))
+				proceed <- struct{}{}
+				readResponse()
+			}
+		})
+
+		It("detects a request sent along with the previous one", func() {
+			_, err := fmt.Fprint(client, "GET /1 HTTP/1.1\r\nHost: example.com\r\n\r\nGET /2 HTTP/1.1\r\nHost: example.com\r\n\r\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(pipelined).Should(Receive(BeFalse()))
+			proceed <- struct{}{}
+			Eventually(pipelined).Should(Receive(BeTrue()))
+			proceed <- struct{}{}
+			readResponse()
+			readResponse()
+		})
+
+		It("detects a request sent while the previous one is served", func() {
+			var conn *pipelineConn
+			writeRequest("/1")
+			Eventually(accepted).Should(Receive(&conn))
+			Eventually(pipelined).Should(Receive(BeFalse()))
+
+			writeRequest("/2")
+			Eventually(conn.readEarly.Load).Should(BeTrue())
+			proceed <- struct{}{}
+
+			Eventually(pipelined).Should(Receive(BeTrue()))
+			proceed <- struct{}{}
+			readResponse()
+			readResponse()
+		})
+	}
+
+	itDetectsPipelinedRequests()
+
+	Context("on a TLS listener", func() {
+		BeforeEach(func() {
+			useTLS = true
+		})
+
+		itDetectsPipelinedRequests()
+
+		It("serves requests with the TLS state of the connection", func() {
+			writeRequest("/")
+			var state *tls.ConnectionState
+			Eventually(tlsState).Should(Receive(&state))
+			Expect(state).ToNot(BeNil())
+			Expect(state.HandshakeComplete).To(BeTrue())
+			proceed <- struct{}{}
+			readResponse()
+		})
+	})
+})
@@ -0,0 +1,178 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/mdimiceli/gorouter/handlers"
+)
+
+const (
+	// requestHeadMaxLineBytes is the longest line checked for a request
+	// line. Of longer lines, only the start is inspected.
+	requestHeadMaxLineBytes = 8 * 1024
+	// requestHeadMaxBlocks bounds the header blocks read ahead of the request
+	// being served, such as those of pipelined requests.
+	requestHeadMaxBlocks = 16
+)
+
+// requestHeadListener wraps accepted connections in a requestHeadConn. On a
+// TLS listener it wraps the TLS connections, so that it reads the requests
+// they decrypt.
+type requestHeadListener struct {
+	net.Listener
+}
+
+func (l *requestHeadListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return withTLSState(conn, &requestHeadConn{Conn: conn}), nil
+}
+
+// requestHead is the request line of a request read from a connection, and
+// what its header lines looked like before the server parsed them.
+type requestHead struct {
+	requestLine      string
+	obsFolded        bool
+	contentLengths   int
+	transferEncoding bool
+}
+
+func (h *requestHead) ObsFolded() bool {
+	return h.obsFolded
+}
+
+func (h *requestHead) ConflictingFraming() bool {
+	return h.contentLengths > 1 || (h.contentLengths > 0 && h.transferEncoding)
+}
+
+// requestHeadConn inspects the header lines of the HTTP/1 requests read from
+// the connection, as the server repairs some malformed ones while parsing the
+// requests: it joins folded header lines (obs-fold) into a single line, drops
+// the Content-Length of requests with a Transfer-Encoding and merges identical
+// Content-Length headers.
+//
+// Header blocks are told apart and queued the same way as by headerCaseConn,
+// and handed out by requestHeadHandler to the request whose request line they
+// follow. A request body which repeats the request line of the next request,
+// followed by malformed header lines, therefore gets that request taken for
+// malformed as well.
+type requestHeadConn struct {
+	net.Conn
+
+	mu      sync.Mutex
+	lines   lineScanner
+	current *requestHead
+	blocks  []*requestHead
+}
+
+func (c *requestHeadConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.scan(b[:n])
+	}
+	return n, err
+}
+
+// takeRequestHead returns the head of the request, dropping the header blocks
+// read before its own.
+func (c *requestHeadConn) takeRequestHead(r *http.Request) (*requestHead, bool) {
+	requestLine := r.Method + " " + r.RequestURI + " " + r.Proto
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, block := range c.blocks {
+		if block.requestLine == requestLine {
+			c.blocks = c.blocks[i+1:]
+			return block, true
+		}
+	}
+	return nil, false
+}
+
+func (c *requestHeadConn) scan(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines.scan(data, requestHeadMaxLineBytes, c.scanLine)
+}
+
+func (c *requestHeadConn) scanLine(line []byte, truncated bool) {
+	if !truncated && isRequestLine(line) {
+		c.current = &requestHead{requestLine: string(line)}
+		return
+	}
+	if c.current == nil {
+		return
+	}
+	if len(line) == 0 {
+		if len(c.blocks) == requestHeadMaxBlocks {
+			c.blocks = c.blocks[1:]
+		}
+		c.blocks = append(c.blocks, c.current)
+		c.current = nil
+		return
+	}
+
+	if line[0] == ' ' || line[0] == '\t' {
+		c.current.obsFolded = true
+		return
+	}
+	name, _, found := bytes.Cut(line, []byte(":"))
+	if !found {
+		return
+	}
+	switch {
+	case bytes.EqualFold(name, []byte("Content-Length")):
+		c.current.contentLengths++
+	case bytes.EqualFold(name, []byte("Transfer-Encoding")):
+		c.current.transferEncoding = true
+	}
+}
+
+func requestHeadConnOf(conn net.Conn) (*requestHeadConn, bool) {
+	for {
+		if rc, ok := conn.(*requestHeadConn); ok {
+			return rc, true
+		}
+		var ok bool
+		if conn, ok = unwrapConn(conn); !ok {
+			return nil, false
+		}
+	}
+}
+
+type requestHeadConnCtxKey struct{}
+
+// contextWithRequestHeadConn attaches the requestHeadConn of a client
+// connection to the context its requests are served with.
+func contextWithRequestHeadConn(ctx context.Context, conn net.Conn) context.Context {
+	if rc, ok := requestHeadConnOf(conn); ok {
+		return context.WithValue(ctx, requestHeadConnCtxKey{}, rc)
+	}
+	return ctx
+}
+
+// requestHeadHandler attaches the heads of HTTP/1 requests read from a
+// requestHeadConn to their context.
+func requestHeadHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rc, ok := r.Context().Value(requestHeadConnCtxKey{}).(*requestHeadConn)
+		if !ok || r.ProtoMajor != 1 {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		head, ok := rc.takeRequestHead(r)
+		if !ok {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		ctx := handlers.ContextWithRawRequestHead(r.Context(), head)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/mdimiceli/gorouter/handlers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("requestHeadListener", func() {
+	var (
+		listener net.Listener
+		server   *http.Server
+		heads    chan handlers.RawRequestHead
+		client   net.Conn
+		reader   *bufio.Reader
+	)
+
+	BeforeEach(func() {
+		heads = make(chan handlers.RawRequestHead, 2)
+
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		listener = &requestHeadListener{Listener: tcpListener}
+
+		server = &http.Server{
+			Handler: requestHeadHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				head, _ := handlers.GetRawRequestHead(r)
+				heads <- head
+			})),
+			ConnContext: connContext,
+		}
+		go server.Serve(listener)
+
+		client, err = net.Dial("tcp", listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		reader = bufio.NewReader(client)
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	readResponse := func() {
+		resp, err := http.ReadResponse(reader, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		resp.Body.Close()
+	}
+
+	roundTrip := func(request string) handlers.RawRequestHead {
+		_, err := fmt.Fprint(client, request)
+		Expect(err).ToNot(HaveOccurred())
+		readResponse()
+
+		var head handlers.RawRequestHead
+		Expect(heads).To(Receive(&head))
+		Expect(head).NotTo(BeNil())
+		return head
+	}
+
+	It("detects header lines folded with a space", func() {
+		head := roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nX-Folded: foo\r\n bar\r\n\r\n")
+		Expect(head.ObsFolded()).To(BeTrue())
+	})
+
+	It("detects header lines folded with a tab", func() {
+		head := roundTrip("GET / HTTP/1.1\r\nHost: example.com\r\nX-Folded: foo\r\n\tbar\r\n\r\n")
+		Expect(head.ObsFolded()).To(BeTrue())
+	})
+
+	It("detects requests with both a Content-Length and a Transfer-Encoding", func() {
+		head := roundTrip("POST / HTTP/1.1\r\nHost: example.com\r\ncontent-length: 4\r\nTransfer-Encoding: chunked\r\n\r\n4\r\nbody\r\n0\r\n\r\n")
+		Expect(head.ConflictingFraming()).To(BeTrue())
+	})
+
+	It("detects requests with more than one Content-Length", func() {
+		head := roundTrip("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\nContent-Length: 4\r\n\r\nbody")
+		Expect(head.ConflictingFraming()).To(BeTrue())
+	})
+
+	It("does not detect well-formed requests", func() {
+		head := roundTrip("POST / HTTP/1.1\r\nHost: example.com\r\nX-Header: foo bar\r\nContent-Length: 4\r\n\r\nbody")
+		Expect(head.ObsFolded()).To(BeFalse())
+		Expect(head.ConflictingFraming()).To(BeFalse())
+
+		head = roundTrip("POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n4\r\nbody\r\n0\r\n\r\n")
+		Expect(head.ObsFolded()).To(BeFalse())
+		Expect(head.ConflictingFraming()).To(BeFalse())
+	})
+
+	It("hands the head of pipelined requests to each request", func() {
+		_, err := fmt.Fprint(client,
+			"GET /first HTTP/1.1\r\nHost: example.com\r\nX-Folded: foo\r\n bar\r\n\r\n"+
+				"GET /second HTTP/1.1\r\nHost: example.com\r\n\r\n")
+		Expect(err).ToNot(HaveOccurred())
+		readResponse()
+		readResponse()
+
+		var head handlers.RawRequestHead
+		Expect(heads).To(Receive(&head))
+		Expect(head.ObsFolded()).To(BeTrue())
+		Expect(heads).To(Receive(&head))
+		Expect(head.ObsFolded()).To(BeFalse())
+	})
+
+	It("does not take lines of request bodies for header lines", func() {
+		body := "\r\n indented\r\nContent-Length: 4\r\n"
+		head := roundTrip(fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: %d\r\n\r\n%s", len(body), body))
+		Expect(head.ObsFolded()).To(BeFalse())
+		Expect(head.ConflictingFraming()).To(BeFalse())
+	})
+
+	Describe("requestHeadConn", func() {
+		request := func(method, requestURI string) *http.Request {
+			return &http.Request{Method: method, RequestURI: requestURI, Proto: "HTTP/1.1"}
+		}
+
+		It("inspects header lines split across reads", func() {
+			conn := &requestHeadConn{}
+			conn.scan([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nX-Folded: foo\r"))
+			conn.scan([]byte("\n"))
+			conn.scan([]byte(" bar\r\nContent-Le"))
+			conn.scan([]byte("ngth: 0\r\nTransfer-Encoding: chunked\r\n\r\n"))
+
+			head, ok := conn.takeRequestHead(request("GET", "/"))
+			Expect(ok).To(BeTrue())
+			Expect(head.ObsFolded()).To(BeTrue())
+			Expect(head.ConflictingFraming()).To(BeTrue())
+		})
+
+		It("inspects the start of lines which are too long", func() {
+			conn := &requestHeadConn{}
+			conn.scan([]byte("GET / HTTP/1.1\r\nContent-Length: 0\r\nTransfer-Encoding: "))
+			conn.scan(make([]byte, requestHeadMaxLineBytes))
+			conn.scan([]byte("\r\n more\r\n\r\n"))
+
+			head, ok := conn.takeRequestHead(request("GET", "/"))
+			Expect(ok).To(BeTrue())
+			Expect(head.ObsFolded()).To(BeTrue())
+			Expect(head.ConflictingFraming()).To(BeTrue())
+		})
+
+		It("drops the header blocks read before the request", func() {
+			conn := &requestHeadConn{}
+			conn.scan([]byte("GET /phantom HTTP/1.1\r\nX-Folded: foo\r\n bar\r\n\r\n"))
+			conn.scan([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+			head, ok := conn.takeRequestHead(request("GET", "/"))
+			Expect(ok).To(BeTrue())
+			Expect(head.ObsFolded()).To(BeFalse())
+
+			_, ok = conn.takeRequestHead(request("GET", "/phantom"))
+			Expect(ok).To(BeFalse())
+		})
+
+		It("bounds the header blocks read ahead", func() {
+			conn := &requestHeadConn{}
+			for i := 0; i <= requestHeadMaxBlocks; i++ {
+				conn.scan([]byte(fmt.Sprintf("GET /%d HTTP/1.1\r\nX-Folded: foo\r\n bar\r\n\r\n", i)))
+			}
+
+			_, ok := conn.takeRequestHead(request("GET", "/0"))
+			Expect(ok).To(BeFalse())
+			_, ok = conn.takeRequestHead(request("GET", "/1"))
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"github.com/mdimiceli/gorouter/config"
 	"github.com/mdimiceli/gorouter/handlers"
 	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/metrics"
 	"github.com/mdimiceli/gorouter/metrics/monitor"
 	"github.com/mdimiceli/gorouter/registry"
 	"github.com/mdimiceli/gorouter/varz"
@@ -47,6 +48,7 @@ type Router struct {
 	mbusClient        *nats.Conn
 	registry          *registry.RouteRegistry
 	varz              varz.Varz
+	reporter          metrics.ProxyReporter
 	component         *common.VcapComponent
 	routesListener    *RoutesListener
 	healthListener    *HealthListener
@@ -54,10 +56,13 @@ type Router struct {
 
 	listener            net.Listener
 	tlsListener         net.Listener
+	tlsPassthrough      *TLSPassthroughListener
+	ocspStapler         *ocspStapler
 	closeConnections    bool
 	connLock            sync.Mutex
 	idleConns           map[net.Conn]struct{}
 	activeConns         map[net.Conn]struct{}
+	connCounter         *connCounter
 	drainDone           chan struct{}
 	serveDone           chan struct{}
 	tlsServeDone        chan struct{}
@@ -77,10 +82,12 @@ func NewRouter(
 	mbusClient *nats.Conn,
 	r *registry.RouteRegistry,
 	v varz.Varz,
+	reporter metrics.ProxyReporter,
 	h *health.Health,
 	logCounter *schema.LogCounter,
 	errChan chan error,
 	routeServicesServer rss,
+	debugTap *handlers.DebugTap,
 ) (*Router, error) {
 	var host string
 	if cfg.Status.Port != 0 {
@@ -93,8 +100,10 @@ func NewRouter(
 	}
 
 	routesListener := &RoutesListener{
-		Config:        cfg,
-		RouteRegistry: r,
+		Config:          cfg,
+		RouteRegistry:   r,
+		DebugTap:        debugTap,
+		EndpointDrainer: r,
 	}
 	if err := routesListener.ListenAndServe(); err != nil {
 		return nil, err
@@ -106,6 +115,7 @@ func NewRouter(
 		mbusClient:          mbusClient,
 		registry:            r,
 		varz:                v,
+		reporter:            reporter,
 		routesListener:      routesListener,
 		serveDone:           make(chan struct{}),
 		tlsServeDone:        make(chan struct{}),
@@ -118,6 +128,10 @@ func NewRouter(
 		routeServicesServer: routeServicesServer,
 	}
 
+	if cfg.ConcurrentConnectionsMetricReporting {
+		router.connCounter = newConnCounter(reporter, emitInterval)
+	}
+
 	healthCheck := handlers.NewHealthcheck(h, logger)
 	if cfg.Status.EnableNonTLSHealthChecks {
 		// TODO: remove all vcapcomponent logic in Summer 2026
@@ -205,11 +219,24 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	r.logger.Debug("Sleeping before returning success on /health endpoint to preload routing table", zap.Float64("sleep_time_seconds", r.config.StartResponseDelayInterval.Seconds()))
 	time.Sleep(r.config.StartResponseDelayInterval)
 
+	handler := r.handler
+	if r.config.CaptureRequestHeaderCase {
+		handler = headerCaseHandler(handler)
+	}
+	if r.inspectsRequestHeads() {
+		handler = requestHeadHandler(handler)
+	}
+
 	server := &http.Server{
-		Handler:        r.handler,
+		Handler:        handler,
 		ConnState:      r.HandleConnState,
+		ConnContext:    connContext,
 		IdleTimeout:    r.config.FrontendIdleTimeout,
+		ReadTimeout:    r.config.FrontendReadTimeout,
 		MaxHeaderBytes: MAX_HEADER_BYTES,
+		// OPTIONS * requests are handled by the proxy, see
+		// config.AsteriskOptionsRequests
+		DisableGeneralOptionsHandler: true,
 	}
 
 	err := r.serveHTTP(server, r.errChan)
@@ -222,6 +249,11 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 		r.errChan <- err
 		return err
 	}
+	err = r.serveTLSPassthrough()
+	if err != nil {
+		r.errChan <- err
+		return err
+	}
 	err = r.routeServicesServer.Serve(r.handler, r.errChan)
 	if err != nil {
 		r.errChan <- err
@@ -230,6 +262,9 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 
 	r.logger.Info("gorouter.started")
 	go r.uptimeMonitor.Start()
+	if r.connCounter != nil {
+		go r.connCounter.Start()
+	}
 
 	close(ready)
 
@@ -254,7 +289,7 @@ func (r *Router) OnErrOrSignal(signals <-chan os.Signal, errChan chan error) {
 				)
 			}
 		}()
-		if sig == syscall.SIGUSR1 {
+		if sig == syscall.SIGUSR1 || r.config.DrainOnTerm && (sig == syscall.SIGTERM || sig == syscall.SIGINT) {
 			r.health.SetHealth(health.Degraded)
 		} else {
 			r.Stop()
@@ -300,18 +335,32 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
 	}
 
+	if r.config.ECH.Enabled {
+		tlsConfig.EncryptedClientHelloKeys = r.config.ECH.ServerKeys
+	}
+
 	// Although this functionality is deprecated there is no intention to remove it from the stdlib
 	// due to the Go 1 compatibility promise. We rely on it to prefer more specific matches (a full
 	// SNI match over wildcard matches) instead of relying on the order of certificates.
 	//lint:ignore SA1019 - see ^^
 	tlsConfig.BuildNameToCertificate()
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", r.config.SSLPort))
+	if r.config.OCSPStapling.Enabled {
+		r.ocspStapler = newOCSPStapler(tlsConfig, r.config.OCSPStapling, r.logger)
+		tlsConfig.GetConfigForClient = r.ocspStapler.GetConfigForClient
+		r.ocspStapler.Start()
+	}
+
+	listener, err := listenClients(fmt.Sprintf(":%d", r.config.SSLPort), r.config.ClientConnections)
 	if err != nil {
 		r.logger.Fatal("tls-listener-error", zap.Error(err))
 		return err
 	}
 
+	if r.connCounter != nil {
+		listener = &connCountListener{Listener: listener, counter: r.connCounter}
+	}
+
 	if r.config.EnablePROXY {
 		listener = &proxyproto.Listener{
 			Listener:           listener,
@@ -319,7 +368,23 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 		}
 	}
 
-	r.tlsListener = tls.NewListener(listener, tlsConfig)
+	if r.config.TLSHandshakeLimit.MaxConcurrent > 0 {
+		r.tlsListener = newTLSHandshakeLimitListener(listener, tlsConfig, r.config.TLSHandshakeLimit, r.reporter, r.logger)
+	} else {
+		r.tlsListener = tls.NewListener(listener, tlsConfig)
+	}
+
+	if r.config.CaptureRequestHeaderCase {
+		r.tlsListener = &headerCaseListener{Listener: r.tlsListener}
+	}
+
+	if r.inspectsRequestHeads() {
+		r.tlsListener = &requestHeadListener{Listener: r.tlsListener}
+	}
+
+	if r.config.PipelinedRequests == config.PIPELINED_REQUESTS_REJECT {
+		r.tlsListener = &pipelineListener{Listener: r.tlsListener}
+	}
 
 	r.logger.Info("tls-listener-started", zap.Object("address", r.tlsListener.Addr()))
 
@@ -335,6 +400,23 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 	return nil
 }
 
+func (r *Router) serveTLSPassthrough() error {
+	if !r.config.TLSPassthrough.Enabled {
+		return nil
+	}
+
+	r.tlsPassthrough = &TLSPassthroughListener{
+		Config:        r.config,
+		RouteRegistry: r.registry,
+		Logger:        r.logger,
+	}
+	if err := r.tlsPassthrough.ListenAndServe(); err != nil {
+		r.logger.Fatal("tls-passthrough-listener-error", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 // verifyMtlsMetadata checks the Config.VerifyClientCertificateMetadataRules rules, if any are defined.
 //
 // Returns an error if one of the applicable verification rules fails.
@@ -345,18 +427,29 @@ func (r *Router) verifyMtlsMetadata(_ [][]byte, chains [][]*x509.Certificate) er
 	return nil
 }
 
+// inspectsRequestHeads reports whether the header lines of requests are
+// inspected as read from client connections, for the handlers which reject
+// malformed ones the server would otherwise repair.
+func (r *Router) inspectsRequestHeads() bool {
+	return r.config.StrictHeaderValidation || r.config.StrictRequestFraming
+}
+
 func (r *Router) serveHTTP(server *http.Server, errChan chan error) error {
 	if r.config.DisableHTTP {
 		r.logger.Info("tcp-listener-disabled")
 		return nil
 	}
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", r.config.Port))
+	listener, err := listenClients(fmt.Sprintf(":%d", r.config.Port), r.config.ClientConnections)
 	if err != nil {
 		r.logger.Fatal("tcp-listener-error", zap.Error(err))
 		return err
 	}
 
+	if r.connCounter != nil {
+		listener = &connCountListener{Listener: listener, counter: r.connCounter}
+	}
+
 	r.listener = listener
 	if r.config.EnablePROXY {
 		r.listener = &proxyproto.Listener{
@@ -365,6 +458,18 @@ func (r *Router) serveHTTP(server *http.Server, errChan chan error) error {
 		}
 	}
 
+	if r.config.CaptureRequestHeaderCase {
+		r.listener = &headerCaseListener{Listener: r.listener}
+	}
+
+	if r.inspectsRequestHeads() {
+		r.listener = &requestHeadListener{Listener: r.listener}
+	}
+
+	if r.config.PipelinedRequests == config.PIPELINED_REQUESTS_REJECT {
+		r.listener = &pipelineListener{Listener: r.listener}
+	}
+
 	r.logger.Info("tcp-listener-started", zap.Object("address", r.listener.Addr()))
 
 	go func() {
@@ -384,6 +489,7 @@ func (r *Router) Drain(drainWait, drainTimeout time.Duration) error {
 	<-time.After(drainWait)
 
 	r.stopListening()
+	r.logger.Info("router.drain.stopped-listening")
 
 	drained := make(chan struct{})
 
@@ -405,6 +511,14 @@ func (r *Router) Drain(drainWait, drainTimeout time.Duration) error {
 	case <-drained:
 	case <-time.After(drainTimeout):
 		r.logger.Info("router.drain.timed-out")
+		if r.config.DrainForceClose {
+			r.connLock.Lock()
+			r.logger.Info("router.drain.force-closing", zap.Int("active-connections", len(r.activeConns)))
+			for conn := range r.activeConns {
+				conn.Close()
+			}
+			r.connLock.Unlock()
+		}
 		return DrainTimeout
 	}
 
@@ -428,6 +542,10 @@ func (r *Router) Stop() {
 	r.closeIdleConns()
 	r.connLock.Unlock()
 
+	if r.tlsPassthrough != nil {
+		r.tlsPassthrough.Stop()
+	}
+
 	if r.component != nil {
 		r.component.Stop()
 	}
@@ -439,6 +557,9 @@ func (r *Router) Stop() {
 		r.healthTLSListener.Stop()
 	}
 	r.uptimeMonitor.Stop()
+	if r.connCounter != nil {
+		r.connCounter.Stop()
+	}
 	r.logger.Info(
 		"gorouter.stopped",
 		zap.Duration("took", time.Since(stoppingAt)),
@@ -469,6 +590,14 @@ func (r *Router) stopListening() {
 		<-r.tlsServeDone
 	}
 
+	if r.tlsPassthrough != nil {
+		r.tlsPassthrough.StopListening()
+	}
+
+	if r.ocspStapler != nil {
+		r.ocspStapler.Stop()
+	}
+
 	r.routeServicesServer.Stop()
 }
 
@@ -497,6 +626,10 @@ func (r *Router) ScheduleFlushApps() {
 }
 
 func (r *Router) HandleConnState(conn net.Conn, state http.ConnState) {
+	if pc, ok := pipelineConnOf(conn); ok {
+		pc.setState(state)
+	}
+
 	r.connLock.Lock()
 
 	switch state {
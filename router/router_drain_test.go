@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"syscall"
@@ -187,12 +188,12 @@ var _ = Describe("Router", func() {
 
 		rt := &sharedfakes.RoundTripper{}
 		p = proxy.NewProxy(logger, &accesslog.NullAccessLogger{}, nil, ew, config, registry, combinedReporter,
-			&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, healthStatus, rt)
+			&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, healthStatus, rt, nil, nil)
 
 		errChan := make(chan error, 2)
 		var err error
 		rss := &sharedfakes.RouteServicesServer{}
-		rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, healthStatus, logcounter, errChan, rss)
+		rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, combinedReporter, healthStatus, logcounter, errChan, rss, nil)
 		Expect(err).ToNot(HaveOccurred())
 
 		config.Index = 4321
@@ -327,6 +328,47 @@ var _ = Describe("Router", func() {
 			<-appRequestComplete
 		})
 
+		It("closes the connections still serving requests on timeout when configured to", func() {
+			config.DrainForceClose = true
+
+			app := common.NewTestApp([]route.Uri{"draintimeout." + test_util.LocalhostDNS}, config.Port, mbusClient, nil, "")
+
+			appRequestReceived := make(chan struct{})
+			appRequestComplete := make(chan struct{})
+			clientErr := make(chan error, 1)
+
+			app.AddHandler("/", func(w http.ResponseWriter, r *http.Request) {
+				appRequestReceived <- struct{}{}
+				<-appRequestComplete
+			})
+			app.RegisterAndListen()
+
+			Eventually(func() bool {
+				return appRegistered(registry, app)
+			}).Should(BeTrue())
+
+			go func() {
+				defer GinkgoRecover()
+				req, err := http.NewRequest("GET", app.Endpoint(), nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				client := http.Client{}
+				resp, err := client.Do(req)
+				if err == nil {
+					resp.Body.Close()
+				}
+				clientErr <- err
+			}()
+
+			<-appRequestReceived
+
+			err := rtr.Drain(0, 500*time.Millisecond)
+			Expect(err).To(Equal(router.DrainTimeout))
+
+			Eventually(clientErr).Should(Receive(HaveOccurred()))
+			close(appRequestComplete)
+		})
+
 		Context("with http and https servers", func() {
 			It("it drains and stops the router", func() {
 				app := common.NewTestApp([]route.Uri{"drain." + test_util.LocalhostDNS}, config.Port, mbusClient, nil, "")
@@ -422,12 +464,12 @@ var _ = Describe("Router", func() {
 				config.Status.Routes.Port = test_util.NextAvailPort()
 				rt := &sharedfakes.RoundTripper{}
 				p := proxy.NewProxy(logger, &accesslog.NullAccessLogger{}, nil, ew, config, registry, combinedReporter,
-					&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, h, rt)
+					&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, h, rt, nil, nil)
 
 				errChan = make(chan error, 2)
 				var err error
 				rss := &sharedfakes.RouteServicesServer{}
-				rtr2, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, h, logcounter, errChan, rss)
+				rtr2, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, combinedReporter, h, logcounter, errChan, rss, nil)
 				Expect(err).ToNot(HaveOccurred())
 				runRouter(rtr2)
 			})
@@ -462,6 +504,35 @@ var _ = Describe("Router", func() {
 			})
 		})
 
+		Context("when a SIGTERM signal is sent and the router drains on SIGTERM", func() {
+			BeforeEach(func() {
+				config.DrainOnTerm = true
+				config.DrainWait = 1 * time.Second
+				config.DrainTimeout = 1 * time.Second
+				healthStatus.OnDegrade = rtr.DrainAndStop
+			})
+
+			It("fails the health check and refuses new connections only after the drain wait", func() {
+				signals, closeChannel := runRouter(rtr)
+				addr := fmt.Sprintf("%s:%d", config.Ip, config.Port)
+				dial := func() error {
+					conn, err := net.Dial("tcp", addr)
+					if err == nil {
+						conn.Close()
+					}
+					return err
+				}
+
+				signals <- syscall.SIGTERM
+
+				Eventually(healthStatus.Health).Should(Equal(health.Degraded))
+				Consistently(dial, 500*time.Millisecond).Should(Succeed())
+
+				Eventually(dial, 2*time.Second).ShouldNot(Succeed())
+				Eventually(closeChannel, 2*time.Second).Should(BeClosed())
+			})
+		})
+
 		Context("when a SIGINT signal is sent", func() {
 			It("it drains and stops the router", func() {
 				signals, closeChannel := runRouter(rtr)
@@ -985,6 +985,169 @@ var _ = Describe("Router", func() {
 		})
 	})
 
+	Describe("pipelined requests", func() {
+		var (
+			conn   net.Conn
+			reader *bufio.Reader
+		)
+
+		JustBeforeEach(func() {
+			app := test.NewGreetApp([]route.Uri{"pipelining." + test_util.LocalhostDNS}, config.Port, mbusClient, nil)
+			app.RegisterAndListen()
+			Eventually(func() bool {
+				return appRegistered(registry, app)
+			}).Should(BeTrue())
+
+			conn, err = net.Dial("tcp", fmt.Sprintf("%s:%d", config.Ip, config.Port))
+			Expect(err).ToNot(HaveOccurred())
+			reader = bufio.NewReader(conn)
+
+			request := fmt.Sprintf("GET / HTTP/1.1\r\nHost: pipelining.%s\r\n\r\n", test_util.LocalhostDNS)
+			_, err = conn.Write([]byte(request + request))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			conn.Close()
+		})
+
+		readResponse := func() *http.Response {
+			resp, err := http.ReadResponse(reader, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			return resp
+		}
+
+		It("serves them one after the other", func() {
+			Expect(readResponse().StatusCode).To(Equal(http.StatusOK))
+			Expect(readResponse().StatusCode).To(Equal(http.StatusOK))
+		})
+
+		Context("when pipelined requests are rejected", func() {
+			BeforeEach(func() {
+				config.PipelinedRequests = cfg.PIPELINED_REQUESTS_REJECT
+			})
+
+			It("serves the first request and rejects the pipelined one, closing the connection", func() {
+				Expect(readResponse().StatusCode).To(Equal(http.StatusOK))
+
+				resp := readResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(resp.Header.Get("X-Cf-Routererror")).To(Equal("pipelined-request"))
+
+				_, err := reader.ReadByte()
+				Expect(err).To(MatchError(io.EOF))
+			})
+		})
+	})
+
+	Describe("malformed request heads", func() {
+		var (
+			conn    net.Conn
+			reader  *bufio.Reader
+			request string
+		)
+
+		JustBeforeEach(func() {
+			app := test.NewGreetApp([]route.Uri{"malformed." + test_util.LocalhostDNS}, config.Port, mbusClient, nil)
+			app.RegisterAndListen()
+			Eventually(func() bool {
+				return appRegistered(registry, app)
+			}).Should(BeTrue())
+
+			conn, err = net.Dial("tcp", fmt.Sprintf("%s:%d", config.Ip, config.Port))
+			Expect(err).ToNot(HaveOccurred())
+			reader = bufio.NewReader(conn)
+
+			_, err = fmt.Fprintf(conn, request, test_util.LocalhostDNS)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			conn.Close()
+		})
+
+		readResponse := func() *http.Response {
+			resp, err := http.ReadResponse(reader, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			return resp
+		}
+
+		expectRejected := func(routerError string) {
+			resp := readResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(resp.Header.Get("X-Cf-Routererror")).To(Equal(routerError))
+
+			_, err := reader.ReadByte()
+			Expect(err).To(MatchError(io.EOF))
+		}
+
+		Context("with folded header lines", func() {
+			BeforeEach(func() {
+				request = "GET / HTTP/1.1\r\nHost: malformed.%s\r\nX-Folded: foo\r\n bar\r\n\r\n"
+			})
+
+			It("serves them joined into a single line", func() {
+				Expect(readResponse().StatusCode).To(Equal(http.StatusOK))
+			})
+
+			Context("when request headers are validated strictly", func() {
+				BeforeEach(func() {
+					config.StrictHeaderValidation = true
+				})
+
+				It("rejects them, closing the connection", func() {
+					expectRejected("malformed-request-header")
+				})
+			})
+		})
+
+		Context("with both a Content-Length and a Transfer-Encoding", func() {
+			BeforeEach(func() {
+				request = "POST / HTTP/1.1\r\nHost: malformed.%s\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n4\r\nbody\r\n0\r\n\r\n"
+			})
+
+			It("rejects them, closing the connection", func() {
+				expectRejected("conflicting-request-framing")
+			})
+
+			Context("when request framing is not strict", func() {
+				BeforeEach(func() {
+					config.StrictRequestFraming = false
+				})
+
+				It("serves them chunked", func() {
+					Expect(readResponse().StatusCode).To(Equal(http.StatusOK))
+				})
+			})
+		})
+
+		Context("with identical Content-Length headers", func() {
+			BeforeEach(func() {
+				request = "POST / HTTP/1.1\r\nHost: malformed.%s\r\nContent-Length: 4\r\nContent-Length: 4\r\n\r\nbody"
+			})
+
+			It("rejects them, closing the connection", func() {
+				expectRejected("conflicting-request-framing")
+			})
+		})
+
+		Context("with a chunked body", func() {
+			BeforeEach(func() {
+				request = "POST / HTTP/1.1\r\nHost: malformed.%s\r\nTransfer-Encoding: chunked\r\n\r\n4\r\nbody\r\n0\r\n\r\n"
+			})
+
+			It("serves them", func() {
+				Expect(readResponse().StatusCode).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
 	Describe("MaxHeaderBytes", func() {
 		var client http.Client
 
@@ -1711,6 +1874,62 @@ var _ = Describe("Router", func() {
 			resp.Body.Close()
 		})
 
+		Context("when ECH is enabled", func() {
+			var (
+				publicName    string
+				echConfigList []byte
+			)
+
+			dial := func(configList []byte) (*tls.Conn, error) {
+				clientConfig := tlsClientConfig.Clone()
+				clientConfig.ServerName = publicName
+				clientConfig.MinVersion = tls.VersionTLS13
+				clientConfig.EncryptedClientHelloConfigList = configList
+				return tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.SSLPort), clientConfig)
+			}
+
+			BeforeEach(func() {
+				publicName = "test." + test_util.LocalhostDNS
+
+				var key tls.EncryptedClientHelloKey
+				key, echConfigList = test_util.CreateECHKey(publicName, 1)
+				config.MaxTLSVersion = tls.VersionTLS13
+				config.ECH.Enabled = true
+				config.ECH.ServerKeys = []tls.EncryptedClientHelloKey{key}
+			})
+
+			It("accepts the encrypted client hello", func() {
+				conn, err := dial(echConfigList)
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+
+				Expect(conn.ConnectionState().ECHAccepted).To(BeTrue())
+			})
+
+			It("negotiates normally with clients that do not use ECH", func() {
+				conn, err := dial(nil)
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+
+				Expect(conn.ConnectionState().ECHAccepted).To(BeFalse())
+				Expect(conn.ConnectionState().Version).To(Equal(uint16(tls.VersionTLS13)))
+			})
+
+			It("completes the outer handshake and sends retry configs for unknown ECH configs", func() {
+				_, staleConfigList := test_util.CreateECHKey(publicName, 2)
+
+				_, err := dial(staleConfigList)
+				var echErr *tls.ECHRejectionError
+				Expect(errors.As(err, &echErr)).To(BeTrue())
+				Expect(echErr.RetryConfigList).ToNot(BeEmpty())
+
+				conn, err := dial(echErr.RetryConfigList)
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+				Expect(conn.ConnectionState().ECHAccepted).To(BeTrue())
+			})
+		})
+
 		Context("when a ca cert is provided", func() {
 			BeforeEach(func() {
 				config.CACerts = []string{string(cert)}
@@ -2396,12 +2615,12 @@ func initializeRouter(config *cfg.Config, backendIdleTimeout, requestTimeout tim
 	proxyConfig.EndpointTimeout = requestTimeout
 	routeServicesTransport := &sharedfakes.RoundTripper{}
 	p := proxy.NewProxy(logger, &accesslog.NullAccessLogger{}, nil, ew, &proxyConfig, registry, combinedReporter,
-		routeServiceConfig, &tls.Config{}, &tls.Config{}, &health.Health{}, routeServicesTransport)
+		routeServiceConfig, &tls.Config{}, &tls.Config{}, &health.Health{}, routeServicesTransport, nil, nil)
 
 	h := &health.Health{}
 	logcounter := schema.NewLogCounter()
 	config.EndpointTimeout = backendIdleTimeout
-	router, e := NewRouter(logger, config, p, mbusClient, registry, varz, h, logcounter, nil, routeServicesServer)
+	router, e := NewRouter(logger, config, p, mbusClient, registry, varz, combinedReporter, h, logcounter, nil, routeServicesServer, nil)
 
 	h.OnDegrade = router.DrainAndStop
 
@@ -2,18 +2,28 @@ package router
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	common "github.com/mdimiceli/gorouter/common/http"
 	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/handlers"
 )
 
+// EndpointDrainer stops routing new requests to an endpoint.
+type EndpointDrainer interface {
+	DrainEndpoint(addr string) int
+}
+
 type RoutesListener struct {
-	Config        *config.Config
-	RouteRegistry json.Marshaler
+	Config          *config.Config
+	RouteRegistry   json.Marshaler
+	DebugTap        *handlers.DebugTap
+	EndpointDrainer EndpointDrainer
 
 	listener net.Listener
 }
@@ -28,6 +38,12 @@ func (rl *RoutesListener) ListenAndServe() error {
 		enc := json.NewEncoder(w)
 		enc.Encode(rl.RouteRegistry)
 	})
+	if rl.DebugTap != nil {
+		hs.HandleFunc("/debug/tap", rl.serveDebugTap)
+	}
+	if rl.EndpointDrainer != nil {
+		hs.HandleFunc("/endpoints/drain", rl.serveDrainEndpoint)
+	}
 
 	f := func(user, password string) bool {
 		return user == rl.Config.Status.User && password == rl.Config.Status.Pass
@@ -53,6 +69,90 @@ func (rl *RoutesListener) ListenAndServe() error {
 	return nil
 }
 
+// serveDebugTap streams the debug tap events of the route given in the query
+// as server-sent events, until the client disconnects.
+func (rl *RoutesListener) serveDebugTap(w http.ResponseWriter, req *http.Request) {
+	routeUri := req.URL.Query().Get("route")
+	if routeUri == "" {
+		http.Error(w, "route is required", http.StatusBadRequest)
+		return
+	}
+
+	sampleRate := 1.0
+	if s := req.URL.Query().Get("sample_rate"); s != "" {
+		var err error
+		sampleRate, err = strconv.ParseFloat(s, 64)
+		if err != nil || sampleRate <= 0 || sampleRate > 1 {
+			http.Error(w, "sample_rate must be in (0, 1]", http.StatusBadRequest)
+			return
+		}
+	}
+
+	sub, err := rl.DebugTap.Subscribe(routeUri, sampleRate)
+	if errors.Is(err, handlers.ErrTooManyDebugTapSubscribers) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	// the stream outlives the timeouts of the listener
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// serveDrainEndpoint drains the endpoint with the address given in the query,
+// responding with the number of routes it is drained for.
+func (rl *RoutesListener) serveDrainEndpoint(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	addr := req.URL.Query().Get("address")
+	if addr == "" {
+		http.Error(w, "address is required", http.StatusBadRequest)
+		return
+	}
+
+	drained := rl.EndpointDrainer.DrainEndpoint(addr)
+	if drained == 0 {
+		http.Error(w, "endpoint not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int{"routes": drained})
+}
+
 func (rl *RoutesListener) Stop() {
 	if rl.listener != nil {
 		rl.listener.Close()
@@ -1,19 +1,35 @@
 package router
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 
 	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/handlers"
+	"github.com/mdimiceli/gorouter/route"
 	"github.com/mdimiceli/gorouter/test_util"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni/v3"
 )
 
+type fakeEndpointDrainer struct {
+	drained map[string]int
+	calls   []string
+}
+
+func (d *fakeEndpointDrainer) DrainEndpoint(addr string) int {
+	d.calls = append(d.calls, addr)
+	return d.drained[addr]
+}
+
 type MarshalableValue struct {
 	Value map[string]string
 }
@@ -53,8 +69,6 @@ var _ = Describe("RoutesListener", func() {
 			Config:        cfg,
 			RouteRegistry: registry,
 		}
-		err := routesListener.ListenAndServe()
-		Expect(err).ToNot(HaveOccurred())
 	})
 
 	AfterEach(func() {
@@ -62,7 +76,9 @@ var _ = Describe("RoutesListener", func() {
 	})
 
 	JustBeforeEach(func() {
-		var err error
+		err := routesListener.ListenAndServe()
+		Expect(err).ToNot(HaveOccurred())
+
 		req, err = http.NewRequest("GET", fmt.Sprintf("http://%s:%d/routes", addr, port), nil)
 		Expect(err).ToNot(HaveOccurred())
 	})
@@ -132,4 +148,141 @@ var _ = Describe("RoutesListener", func() {
 			Expect(string(body)).To(Equal("401 Unauthorized\n"))
 		})
 	})
+
+	Context("when the debug tap is enabled", func() {
+		var (
+			tap       *handlers.DebugTap
+			tapServer *negroni.Negroni
+		)
+
+		BeforeEach(func() {
+			tap = handlers.NewDebugTap(1, 10)
+			routesListener.DebugTap = tap
+
+			logger := test_util.NewTestZapLogger("test")
+			pool := route.NewPool(&route.PoolOpts{Logger: logger, Host: "app.example.com", ContextPath: "/"})
+			tapServer = negroni.New()
+			tapServer.Use(handlers.NewRequestInfo())
+			tapServer.Use(handlers.NewProxyWriter(logger))
+			tapServer.Use(handlers.NewDebugTapHandler(tap))
+			tapServer.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				reqInfo, err := handlers.ContextRequestInfo(r)
+				Expect(err).ToNot(HaveOccurred())
+				reqInfo.RoutePool = pool
+				rw.WriteHeader(http.StatusOK)
+			})
+		})
+
+		tapURL := func(query string) string {
+			return fmt.Sprintf("http://%s:%d/debug/tap?%s", addr, port, query)
+		}
+
+		It("streams the events of the route as server-sent events", func() {
+			req, err := http.NewRequest("GET", tapURL("route=app.example.com"), nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("test-user", "test-pass")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+			Expect(tap.Active()).To(BeTrue())
+
+			appReq, err := http.NewRequest("GET", "http://app.example.com/foo", nil)
+			Expect(err).ToNot(HaveOccurred())
+			tapServer.ServeHTTP(httptest.NewRecorder(), appReq)
+
+			line, err := bufio.NewReader(resp.Body).ReadString('\n')
+			Expect(err).ToNot(HaveOccurred())
+			Expect(line).To(HavePrefix("data: "))
+
+			var event handlers.DebugTapEvent
+			Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)).To(Succeed())
+			Expect(event.Route).To(Equal("app.example.com"))
+			Expect(event.Path).To(Equal("/foo"))
+			Expect(event.Status).To(Equal(http.StatusOK))
+
+			resp.Body.Close()
+			Eventually(tap.Active).Should(BeFalse())
+		})
+
+		It("requires a route", func() {
+			req, err := http.NewRequest("GET", tapURL(""), nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("test-user", "test-pass")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("rejects subscribers beyond the limit", func() {
+			sub, err := tap.Subscribe("other.example.com", 1)
+			Expect(err).ToNot(HaveOccurred())
+			defer sub.Unsubscribe()
+
+			req, err := http.NewRequest("GET", tapURL("route=app.example.com"), nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("test-user", "test-pass")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Context("when endpoints can be drained", func() {
+		var drainer *fakeEndpointDrainer
+
+		BeforeEach(func() {
+			drainer = &fakeEndpointDrainer{drained: map[string]int{"10.0.0.1:8080": 2}}
+			routesListener.EndpointDrainer = drainer
+		})
+
+		drain := func(method, query string) *http.Response {
+			req, err := http.NewRequest(method, fmt.Sprintf("http://%s:%d/endpoints/drain?%s", addr, port, query), nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("test-user", "test-pass")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			return resp
+		}
+
+		It("drains the endpoint and responds with the number of its routes", func() {
+			resp := drain("POST", "address=10.0.0.1:8080")
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal(`{"routes":2}` + "\n"))
+			Expect(drainer.calls).To(Equal([]string{"10.0.0.1:8080"}))
+		})
+
+		It("responds with a 404 for an unknown endpoint", func() {
+			resp := drain("POST", "address=10.0.0.2:8080")
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		It("requires an address", func() {
+			resp := drain("POST", "")
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(drainer.calls).To(BeEmpty())
+		})
+
+		It("only accepts POST requests", func() {
+			resp := drain("GET", "address=10.0.0.1:8080")
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+			Expect(drainer.calls).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/metrics"
+)
+
+// tlsHandshakeLimitListener is a TLS listener which completes the handshake
+// of accepted connections before returning them, with at most
+// cfg.MaxConcurrent handshakes in progress at a time. Connections over the
+// limit wait for a slot, or are closed right away in reject mode.
+type tlsHandshakeLimitListener struct {
+	net.Listener
+	tlsConfig *tls.Config
+	cfg       config.TLSHandshakeLimitConfig
+	reporter  metrics.ProxyReporter
+	logger    logger.Logger
+
+	slots     chan struct{}
+	accepted  chan net.Conn
+	acceptErr chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newTLSHandshakeLimitListener(inner net.Listener, tlsConfig *tls.Config, cfg config.TLSHandshakeLimitConfig, reporter metrics.ProxyReporter, logger logger.Logger) *tlsHandshakeLimitListener {
+	l := &tlsHandshakeLimitListener{
+		Listener:  inner,
+		tlsConfig: tlsConfig,
+		cfg:       cfg,
+		reporter:  reporter,
+		logger:    logger,
+		slots:     make(chan struct{}, cfg.MaxConcurrent),
+		accepted:  make(chan net.Conn),
+		acceptErr: make(chan error),
+		done:      make(chan struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+func (l *tlsHandshakeLimitListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.accepted:
+		return conn, nil
+	case err := <-l.acceptErr:
+		return nil, err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *tlsHandshakeLimitListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+func (l *tlsHandshakeLimitListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			select {
+			case l.acceptErr <- err:
+			case <-l.done:
+				return
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go l.handshake(conn)
+	}
+}
+
+func (l *tlsHandshakeLimitListener) handshake(conn net.Conn) {
+	if !l.acquireSlot() {
+		conn.Close()
+		return
+	}
+
+	tlsConn := tls.Server(conn, l.tlsConfig)
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.HandshakeTimeout)
+	err := tlsConn.HandshakeContext(ctx)
+	cancel()
+	<-l.slots
+
+	if err != nil {
+		l.logger.Debug("tls-handshake-failed", zap.Error(err))
+		tlsConn.Close()
+		return
+	}
+
+	select {
+	case l.accepted <- tlsConn:
+	case <-l.done:
+		tlsConn.Close()
+	}
+}
+
+// acquireSlot takes one of the handshake slots, waiting for up to
+// cfg.QueueTimeout in queue mode.
+func (l *tlsHandshakeLimitListener) acquireSlot() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	l.reporter.CaptureTLSHandshakeOverLimit()
+	if l.cfg.Mode == config.TLS_HANDSHAKE_LIMIT_REJECT {
+		l.logger.Info("tls-handshake-rejected-over-limit")
+		return false
+	}
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		l.logger.Info("tls-handshake-queue-timeout")
+		return false
+	case <-l.done:
+		return false
+	}
+}
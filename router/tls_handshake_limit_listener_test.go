@@ -0,0 +1,118 @@
+package router
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/mdimiceli/gorouter/config"
+	fakeMetrics "github.com/mdimiceli/gorouter/metrics/fakes"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tlsHandshakeLimitListener", func() {
+	var (
+		listener *tlsHandshakeLimitListener
+		reporter *fakeMetrics.FakeProxyReporter
+		cfg      config.TLSHandshakeLimitConfig
+		stalled  net.Conn
+	)
+
+	BeforeEach(func() {
+		reporter = &fakeMetrics.FakeProxyReporter{}
+		cfg = config.TLSHandshakeLimitConfig{
+			MaxConcurrent:    1,
+			Mode:             config.TLS_HANDSHAKE_LIMIT_QUEUE,
+			QueueTimeout:     5 * time.Second,
+			HandshakeTimeout: 10 * time.Second,
+		}
+	})
+
+	JustBeforeEach(func() {
+		keyPEM, certPEM := test_util.CreateKeyPair("example.com")
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		Expect(err).ToNot(HaveOccurred())
+
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		listener = newTLSHandshakeLimitListener(
+			tcpListener,
+			&tls.Config{Certificates: []tls.Certificate{cert}},
+			cfg,
+			reporter,
+			test_util.NewTestZapLogger("test"),
+		)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+			}
+		}()
+
+		// a client which never sends its ClientHello keeps the only slot busy
+		stalled, err = net.Dial("tcp", listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(listener.slots).Should(HaveLen(1))
+	})
+
+	AfterEach(func() {
+		stalled.Close()
+		listener.Close()
+	})
+
+	dialTLS := func() <-chan error {
+		result := make(chan error, 1)
+		go func() {
+			conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+			if err == nil {
+				conn.Close()
+			}
+			result <- err
+		}()
+		return result
+	}
+
+	Context("in queue mode", func() {
+		It("waits for a handshake slot to free up", func() {
+			result := dialTLS()
+			Consistently(result, 200*time.Millisecond).ShouldNot(Receive())
+			Eventually(reporter.CaptureTLSHandshakeOverLimitCallCount).Should(Equal(1))
+
+			stalled.Close()
+			Eventually(result).Should(Receive(BeNil()))
+		})
+
+		Context("when the queue timeout expires", func() {
+			BeforeEach(func() {
+				cfg.QueueTimeout = 50 * time.Millisecond
+			})
+
+			It("closes the connection", func() {
+				Eventually(dialTLS()).Should(Receive(HaveOccurred()))
+			})
+		})
+	})
+
+	Context("in reject mode", func() {
+		BeforeEach(func() {
+			cfg.Mode = config.TLS_HANDSHAKE_LIMIT_REJECT
+		})
+
+		It("closes connections over the limit right away", func() {
+			Eventually(dialTLS()).Should(Receive(HaveOccurred()))
+			Expect(reporter.CaptureTLSHandshakeOverLimitCallCount()).To(Equal(1))
+		})
+
+		It("accepts connections again once a slot is free", func() {
+			stalled.Close()
+			Eventually(listener.slots).Should(BeEmpty())
+			Eventually(dialTLS()).Should(Receive(BeNil()))
+		})
+	})
+})
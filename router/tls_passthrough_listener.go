@@ -0,0 +1,336 @@
+package router
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/armon/go-proxyproto"
+	"go.uber.org/zap"
+
+	"github.com/mdimiceli/gorouter/config"
+	"github.com/mdimiceli/gorouter/logger"
+	"github.com/mdimiceli/gorouter/registry"
+	"github.com/mdimiceli/gorouter/route"
+)
+
+var errClientHelloRead = errors.New("client hello read")
+
+// TLSPassthroughListener accepts TLS connections and forwards the raw TLS
+// stream, without terminating it, to a TLS backend of the route matching the
+// server name in the ClientHello.
+type TLSPassthroughListener struct {
+	Config        *config.Config
+	RouteRegistry registry.Registry
+	Logger        logger.Logger
+
+	listener net.Listener
+	wg       sync.WaitGroup
+	// tunnels counts the accepted connections still being handled.
+	tunnels sync.WaitGroup
+
+	connsLock          sync.Mutex
+	conns              map[net.Conn]struct{}
+	closed             bool
+	stoppedListeningAt time.Time
+}
+
+func (tl *TLSPassthroughListener) ListenAndServe() error {
+	listener, err := listenClients(fmt.Sprintf(":%d", tl.Config.TLSPassthrough.Port), tl.Config.ClientConnections)
+	if err != nil {
+		return err
+	}
+
+	tl.listener = listener
+	if tl.Config.EnablePROXY {
+		tl.listener = &proxyproto.Listener{
+			Listener:           listener,
+			ProxyHeaderTimeout: proxyProtocolHeaderTimeout,
+		}
+	}
+
+	tl.Logger.Info("tls-passthrough-listener-started", zap.Object("address", tl.listener.Addr()))
+
+	tl.wg.Add(1)
+	go func() {
+		defer tl.wg.Done()
+		for {
+			conn, err := tl.listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					tl.Logger.Error("tls-passthrough-accept-failed", zap.Error(err))
+				}
+				return
+			}
+			tl.tunnels.Add(1)
+			go func() {
+				defer tl.tunnels.Done()
+				tl.handleConn(conn)
+			}()
+		}
+	}()
+	return nil
+}
+
+func (tl *TLSPassthroughListener) Addr() net.Addr {
+	return tl.listener.Addr()
+}
+
+// StopListening stops accepting connections. The open connections are kept
+// until Stop.
+func (tl *TLSPassthroughListener) StopListening() {
+	if tl.listener == nil {
+		return
+	}
+	tl.listener.Close()
+	tl.wg.Wait()
+
+	tl.connsLock.Lock()
+	if tl.stoppedListeningAt.IsZero() {
+		tl.stoppedListeningAt = time.Now()
+	}
+	tl.connsLock.Unlock()
+}
+
+// Stop stops accepting connections and waits for the open ones to be closed
+// by their peers until the drain timeout has passed since it stopped
+// listening, then closes those still open.
+func (tl *TLSPassthroughListener) Stop() {
+	if tl.listener == nil {
+		return
+	}
+	tl.StopListening()
+
+	done := make(chan struct{})
+	go func() {
+		tl.tunnels.Wait()
+		close(done)
+	}()
+
+	tl.connsLock.Lock()
+	drainTimeout := tl.Config.DrainTimeout - time.Since(tl.stoppedListeningAt)
+	tl.connsLock.Unlock()
+
+	timer := time.NewTimer(drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+	}
+
+	tl.connsLock.Lock()
+	tl.Logger.Info("tls-passthrough-closing-connections", zap.Int("connections", len(tl.conns)))
+	tl.closed = true
+	for conn := range tl.conns {
+		conn.Close()
+	}
+	tl.connsLock.Unlock()
+	<-done
+}
+
+// track records a connection to be closed on Stop. It returns false once the
+// open connections have been closed, in which case the caller must close it.
+func (tl *TLSPassthroughListener) track(conn net.Conn) bool {
+	tl.connsLock.Lock()
+	defer tl.connsLock.Unlock()
+	if tl.closed {
+		return false
+	}
+	if tl.conns == nil {
+		tl.conns = map[net.Conn]struct{}{}
+	}
+	tl.conns[conn] = struct{}{}
+	return true
+}
+
+func (tl *TLSPassthroughListener) untrack(conn net.Conn) {
+	tl.connsLock.Lock()
+	defer tl.connsLock.Unlock()
+	delete(tl.conns, conn)
+}
+
+func (tl *TLSPassthroughListener) handleConn(conn net.Conn) {
+	defer conn.Close()
+	if !tl.track(conn) {
+		return
+	}
+	defer tl.untrack(conn)
+
+	// the ClientHello is read through a buffer so that it can be replayed,
+	// byte for byte, to the backend
+	var clientHello bytes.Buffer
+	conn.SetReadDeadline(time.Now().Add(tl.Config.TLSPassthrough.ClientHelloTimeout))
+	serverName, err := readServerName(io.TeeReader(conn, &clientHello))
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		tl.Logger.Info("tls-passthrough-invalid-client-hello", zap.Error(err))
+		return
+	}
+
+	logger := tl.Logger.With(zap.String("server_name", serverName))
+	if serverName == "" {
+		logger.Info("tls-passthrough-missing-server-name")
+		return
+	}
+
+	pool := tl.RouteRegistry.Lookup(route.Uri(serverName))
+	if pool == nil || pool.IsEmpty() {
+		logger.Info("tls-passthrough-unknown-route")
+		return
+	}
+
+	// the router only sees the encrypted stream, so it can neither apply the
+	// route service nor any other per-request handling of the route
+	if !pool.TLSPassthrough() {
+		logger.Info("tls-passthrough-not-enabled-for-route")
+		return
+	}
+	if pool.RouteServiceUrl() != "" {
+		logger.Info("tls-passthrough-route-service-bound")
+		return
+	}
+
+	backendConn, endpoint, iter := tl.dialBackend(pool, logger)
+	if backendConn == nil {
+		return
+	}
+	defer backendConn.Close()
+	if !tl.track(backendConn) {
+		return
+	}
+	defer tl.untrack(backendConn)
+	logger = logger.With(zap.String("backend", endpoint.CanonicalAddr()))
+
+	// the connection counts as a request for the least-connection balancing
+	iter.PreRequest(endpoint)
+	defer iter.PostRequest(endpoint)
+
+	if _, err := backendConn.Write(clientHello.Bytes()); err != nil {
+		logger.Error("tls-passthrough-write-failed", zap.Error(err))
+		return
+	}
+
+	deadline := passthroughDeadline{
+		conns:   []net.Conn{conn, backendConn},
+		timeout: tl.Config.TLSPassthrough.IdleTimeout,
+	}
+	deadline.extend()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		copyAndCloseWrite(backendConn, passthroughReader{Reader: conn, deadline: deadline})
+	}()
+	copyAndCloseWrite(conn, passthroughReader{Reader: backendConn, deadline: deadline})
+	wg.Wait()
+}
+
+// dialBackend dials the next TLS endpoint of the pool. Endpoints that do not
+// serve TLS themselves are skipped, as they cannot complete the handshake.
+func (tl *TLSPassthroughListener) dialBackend(pool *route.EndpointPool, logger logger.Logger) (net.Conn, *route.Endpoint, route.EndpointIterator) {
+	iter := pool.Endpoints(logger, tl.Config.LoadBalance, "", false, tl.Config.LoadBalanceAZPreference, tl.Config.Zone)
+
+	for attempt := 1; attempt <= pool.NumEndpoints(); attempt++ {
+		endpoint := iter.Next(attempt)
+		if endpoint == nil {
+			break
+		}
+		if !endpoint.IsTLS() {
+			continue
+		}
+
+		conn, err := net.DialTimeout("tcp", endpoint.CanonicalAddr(), tl.Config.EndpointDialTimeout)
+		if err != nil {
+			logger.Error("tls-passthrough-dial-failed", zap.String("backend", endpoint.CanonicalAddr()), zap.Error(err))
+			iter.EndpointFailed(err)
+			continue
+		}
+		return conn, endpoint, iter
+	}
+
+	logger.Info("tls-passthrough-no-tls-endpoints")
+	return nil, nil, nil
+}
+
+// passthroughDeadline closes both ends of a forwarded connection once no data
+// was sent through it in either direction for the timeout.
+type passthroughDeadline struct {
+	conns   []net.Conn
+	timeout time.Duration
+}
+
+func (d passthroughDeadline) extend() {
+	if d.timeout <= 0 {
+		return
+	}
+	deadline := time.Now().Add(d.timeout)
+	for _, conn := range d.conns {
+		conn.SetDeadline(deadline)
+	}
+}
+
+// passthroughReader extends the deadline of the forwarded connection whenever
+// data is read.
+type passthroughReader struct {
+	io.Reader
+	deadline passthroughDeadline
+}
+
+func (r passthroughReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.deadline.extend()
+	}
+	return n, err
+}
+
+func copyAndCloseWrite(dst net.Conn, src io.Reader) {
+	io.Copy(dst, src)
+	if tcpConn, ok := dst.(interface{ CloseWrite() error }); ok {
+		tcpConn.CloseWrite()
+	} else {
+		dst.Close()
+	}
+}
+
+// readServerName reads a ClientHello from reader and returns its SNI server
+// name. Nothing is ever written back to the client.
+func readServerName(reader io.Reader) (string, error) {
+	var serverName string
+	var helloRead bool
+
+	err := tls.Server(readOnlyConn{reader: reader}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			helloRead = true
+			return nil, errClientHelloRead
+		},
+	}).Handshake()
+
+	if !helloRead {
+		return "", err
+	}
+	return serverName, nil
+}
+
+// readOnlyConn is a net.Conn that reads from reader and discards writes, so
+// that a ClientHello can be parsed without responding to it.
+type readOnlyConn struct {
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error)       { return c.reader.Read(p) }
+func (c readOnlyConn) Write(p []byte) (int, error)      { return 0, io.ErrClosedPipe }
+func (c readOnlyConn) Close() error                     { return nil }
+func (c readOnlyConn) LocalAddr() net.Addr              { return nil }
+func (c readOnlyConn) RemoteAddr() net.Addr             { return nil }
+func (c readOnlyConn) SetDeadline(time.Time) error      { return nil }
+func (c readOnlyConn) SetReadDeadline(time.Time) error  { return nil }
+func (c readOnlyConn) SetWriteDeadline(time.Time) error { return nil }
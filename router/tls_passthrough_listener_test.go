@@ -0,0 +1,386 @@
+package router
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdimiceli/gorouter/config"
+	fakeRegistry "github.com/mdimiceli/gorouter/registry/fakes"
+	"github.com/mdimiceli/gorouter/route"
+	"github.com/mdimiceli/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+type recordingConn struct {
+	net.Conn
+	written *gbytes.Buffer
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	c.written.Write(p)
+	return c.Conn.Write(p)
+}
+
+var _ = Describe("TLSPassthroughListener", func() {
+	var (
+		listener *TLSPassthroughListener
+		cfg      *config.Config
+		reg      *fakeRegistry.FakeRegistry
+		pool     *route.EndpointPool
+		backends []net.Listener
+	)
+
+	registerBackend := func(ln net.Listener, useTLS bool) {
+		host, portStr, err := net.SplitHostPort(ln.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		port, err := strconv.Atoi(portStr)
+		Expect(err).ToNot(HaveOccurred())
+
+		pool.Put(route.NewEndpoint(&route.EndpointOpts{
+			Host:           host,
+			Port:           uint16(port),
+			UseTLS:         useTLS,
+			TLSPassthrough: true,
+		}))
+		backends = append(backends, ln)
+	}
+
+	dialPassthrough := func() net.Conn {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.TLSPassthrough.Port))
+		Expect(err).ToNot(HaveOccurred())
+		return conn
+	}
+
+	BeforeEach(func() {
+		var err error
+		cfg, err = config.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TLSPassthrough.Enabled = true
+		cfg.TLSPassthrough.Port = test_util.NextAvailPort()
+		cfg.TLSPassthrough.ClientHelloTimeout = time.Second
+
+		logger := test_util.NewTestZapLogger("tls-passthrough-test")
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:            logger,
+			RetryAfterFailure: time.Minute,
+			Host:              "app.example.com",
+		})
+		backends = nil
+
+		reg = &fakeRegistry.FakeRegistry{}
+		reg.LookupStub = func(uri route.Uri) *route.EndpointPool {
+			if uri == "app.example.com" {
+				return pool
+			}
+			return nil
+		}
+
+		listener = &TLSPassthroughListener{
+			Config:        cfg,
+			RouteRegistry: reg,
+			Logger:        logger,
+		}
+	})
+
+	JustBeforeEach(func() {
+		Expect(listener.ListenAndServe()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		listener.Stop()
+		for _, ln := range backends {
+			ln.Close()
+		}
+	})
+
+	Context("when a TLS backend is registered for the server name", func() {
+		var received *gbytes.Buffer
+
+		BeforeEach(func() {
+			received = gbytes.NewBuffer()
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				io.Copy(received, conn)
+			}()
+			registerBackend(ln, true)
+		})
+
+		It("forwards the bytes sent by the client unmodified", func() {
+			conn := &recordingConn{Conn: dialPassthrough(), written: gbytes.NewBuffer()}
+			defer conn.Close()
+
+			go tls.Client(conn, &tls.Config{ServerName: "app.example.com"}).Handshake()
+
+			Eventually(func() bool {
+				written := conn.written.Contents()
+				return len(written) > 0 && bytes.Equal(written, received.Contents())
+			}).Should(BeTrue())
+			Expect(reg.LookupCallCount()).To(Equal(1))
+			Expect(reg.LookupArgsForCall(0)).To(Equal(route.Uri("app.example.com")))
+		})
+	})
+
+	Context("when a forwarded connection stays open", func() {
+		var (
+			received     *gbytes.Buffer
+			handshakeErr chan error
+		)
+
+		BeforeEach(func() {
+			received = gbytes.NewBuffer()
+			handshakeErr = make(chan error, 1)
+
+			// the backend never answers the ClientHello
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				io.Copy(received, conn)
+			}()
+			registerBackend(ln, true)
+		})
+
+		openTunnel := func() {
+			conn := dialPassthrough()
+			DeferCleanup(conn.Close)
+			go func() {
+				handshakeErr <- tls.Client(conn, &tls.Config{ServerName: "app.example.com"}).Handshake()
+			}()
+			Eventually(received.Contents).ShouldNot(BeEmpty())
+		}
+
+		Context("with an idle timeout", func() {
+			BeforeEach(func() {
+				cfg.TLSPassthrough.IdleTimeout = 200 * time.Millisecond
+			})
+
+			It("closes it once it was idle for the idle timeout", func() {
+				openTunnel()
+
+				Eventually(handshakeErr).Should(Receive(HaveOccurred()))
+			})
+		})
+
+		It("waits for it on Stop up to the drain timeout, then closes it", func() {
+			cfg.DrainTimeout = 300 * time.Millisecond
+			openTunnel()
+
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				listener.Stop()
+			}()
+
+			Consistently(stopped, 150*time.Millisecond).ShouldNot(BeClosed())
+			Eventually(stopped).Should(BeClosed())
+			Eventually(handshakeErr).Should(Receive(HaveOccurred()))
+		})
+
+		It("keeps it open when it stops listening", func() {
+			openTunnel()
+
+			listener.StopListening()
+			Consistently(handshakeErr, 200*time.Millisecond).ShouldNot(Receive())
+
+			_, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.TLSPassthrough.Port))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the backend terminates TLS itself", func() {
+		var caPool *x509.CertPool
+
+		BeforeEach(func() {
+			caPool = x509.NewCertPool()
+			certChain := test_util.CreateCertAndAddCA(test_util.CertNames{
+				CommonName: "app.example.com",
+				SANs:       test_util.SubjectAltNames{DNS: "app.example.com"},
+			}, caPool)
+
+			ln, err := tls.Listen("tcp", "127.0.0.1:0", certChain.AsTLSConfig())
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				line := make([]byte, 4)
+				if _, err := io.ReadFull(conn, line); err == nil {
+					conn.Write([]byte(strings.ToUpper(string(line))))
+				}
+			}()
+			registerBackend(ln, true)
+		})
+
+		It("lets the client complete the handshake with the backend", func() {
+			conn := tls.Client(dialPassthrough(), &tls.Config{
+				ServerName: "app.example.com",
+				RootCAs:    caPool,
+			})
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(Succeed())
+			Expect(conn.ConnectionState().PeerCertificates[0].DNSNames).To(ConsistOf("app.example.com"))
+
+			_, err := conn.Write([]byte("ping"))
+			Expect(err).ToNot(HaveOccurred())
+
+			reply := make([]byte, 4)
+			_, err = io.ReadFull(conn, reply)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(reply)).To(Equal("PING"))
+		})
+	})
+
+	Context("when only plain backends are registered for the server name", func() {
+		var accepted chan struct{}
+
+		BeforeEach(func() {
+			accepted = make(chan struct{}, 1)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+				accepted <- struct{}{}
+			}()
+			registerBackend(ln, false)
+		})
+
+		It("closes the client connection without dialing the backend", func() {
+			conn := tls.Client(dialPassthrough(), &tls.Config{ServerName: "app.example.com"})
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(HaveOccurred())
+			Consistently(accepted).ShouldNot(Receive())
+		})
+	})
+
+	Context("when the route does not allow TLS passthrough", func() {
+		var accepted chan struct{}
+
+		BeforeEach(func() {
+			accepted = make(chan struct{}, 1)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+				accepted <- struct{}{}
+			}()
+			backends = append(backends, ln)
+
+			host, portStr, err := net.SplitHostPort(ln.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			port, err := strconv.Atoi(portStr)
+			Expect(err).ToNot(HaveOccurred())
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:   host,
+				Port:   uint16(port),
+				UseTLS: true,
+			}))
+		})
+
+		It("closes the client connection without dialing the backend", func() {
+			conn := tls.Client(dialPassthrough(), &tls.Config{ServerName: "app.example.com"})
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(HaveOccurred())
+			Consistently(accepted).ShouldNot(Receive())
+		})
+	})
+
+	Context("when a route service is bound to the route", func() {
+		var accepted chan struct{}
+
+		BeforeEach(func() {
+			accepted = make(chan struct{}, 1)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+				accepted <- struct{}{}
+			}()
+			backends = append(backends, ln)
+
+			host, portStr, err := net.SplitHostPort(ln.Addr().String())
+			Expect(err).ToNot(HaveOccurred())
+			port, err := strconv.Atoi(portStr)
+			Expect(err).ToNot(HaveOccurred())
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{
+				Host:            host,
+				Port:            uint16(port),
+				UseTLS:          true,
+				TLSPassthrough:  true,
+				RouteServiceUrl: "https://route-service.example.com",
+			}))
+		})
+
+		It("closes the client connection without dialing the backend", func() {
+			conn := tls.Client(dialPassthrough(), &tls.Config{ServerName: "app.example.com"})
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(HaveOccurred())
+			Consistently(accepted).ShouldNot(Receive())
+		})
+	})
+
+	Context("when no route matches the server name", func() {
+		It("closes the client connection", func() {
+			conn := tls.Client(dialPassthrough(), &tls.Config{ServerName: "unknown.example.com"})
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(HaveOccurred())
+			Expect(reg.LookupArgsForCall(0)).To(Equal(route.Uri("unknown.example.com")))
+		})
+	})
+
+	Context("when the client does not send a ClientHello", func() {
+		It("closes the connection", func() {
+			conn := dialPassthrough()
+			defer conn.Close()
+
+			_, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: app.example.com\r\n\r\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = conn.Read(make([]byte, 1))
+			Expect(err).To(HaveOccurred())
+			Expect(reg.LookupCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// tlsStateConn is a connection wrapping a *tls.Conn, such as a pipelineConn
+// reading the requests decrypted by it. The server handles connections which
+// have a handshake and a connection state as TLS connections, so it completes
+// the handshake, negotiates HTTP/2 and sets the TLS state of requests just as
+// it does for a *tls.Conn.
+type tlsStateConn struct {
+	net.Conn
+	tlsConn *tls.Conn
+}
+
+func (c *tlsStateConn) HandshakeContext(ctx context.Context) error {
+	return c.tlsConn.HandshakeContext(ctx)
+}
+
+func (c *tlsStateConn) ConnectionState() tls.ConnectionState {
+	return c.tlsConn.ConnectionState()
+}
+
+func (c *tlsStateConn) CloseWrite() error {
+	return c.tlsConn.CloseWrite()
+}
+
+// withTLSState returns wrapped, which wraps conn, as a tlsStateConn if conn is
+// a TLS connection.
+func withTLSState(conn net.Conn, wrapped net.Conn) net.Conn {
+	switch c := conn.(type) {
+	case *tls.Conn:
+		return &tlsStateConn{Conn: wrapped, tlsConn: c}
+	case *tlsStateConn:
+		return &tlsStateConn{Conn: wrapped, tlsConn: c.tlsConn}
+	}
+	return wrapped
+}
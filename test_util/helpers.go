@@ -1,6 +1,8 @@
 package test_util
 
 import (
+	"bytes"
+	"crypto/ecdh"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -9,6 +11,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/binary"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -40,16 +43,43 @@ func RegisterAddr(reg *registry.RouteRegistry, path string, addr string, cfg Reg
 	reg.Register(
 		route.Uri(path),
 		route.NewEndpoint(&route.EndpointOpts{
-			AppId:                   cfg.AppId,
-			Host:                    host,
-			Protocol:                cfg.Protocol,
-			Port:                    uint16(port),
-			ServerCertDomainSAN:     cfg.ServerCertDomainSAN,
-			PrivateInstanceIndex:    cfg.InstanceIndex,
-			PrivateInstanceId:       cfg.InstanceId,
-			StaleThresholdInSeconds: cfg.StaleThreshold,
-			RouteServiceUrl:         cfg.RouteServiceUrl,
-			UseTLS:                  cfg.TLSConfig != nil,
+			AppId:                    cfg.AppId,
+			Host:                     host,
+			Protocol:                 cfg.Protocol,
+			Port:                     uint16(port),
+			ServerCertDomainSAN:      cfg.ServerCertDomainSAN,
+			PrivateInstanceIndex:     cfg.InstanceIndex,
+			PrivateInstanceId:        cfg.InstanceId,
+			StaleThresholdInSeconds:  cfg.StaleThreshold,
+			RouteServiceUrl:          cfg.RouteServiceUrl,
+			UseTLS:                   cfg.TLSConfig != nil,
+			StripQueryParams:         cfg.StripQueryParams,
+			AllowedQueryParams:       cfg.AllowedQueryParams,
+			AllowedContentTypes:      cfg.AllowedContentTypes,
+			InsecureSkipTLSVerify:    cfg.InsecureSkipTLSVerify,
+			HTTP1ConcurrentReadWrite: cfg.HTTP1ConcurrentReadWrite,
+			MinHealthyEndpoints:      cfg.MinHealthyEndpoints,
+			MaxResponseBodyBytes:     cfg.MaxResponseBodyBytes,
+			BasicAuth:                cfg.BasicAuth,
+			ConnectTunnel:            cfg.ConnectTunnel,
+			StatusRemap:              cfg.StatusRemap,
+			StatusRemapReplaceBody:   cfg.StatusRemapReplaceBody,
+			Deprecation:              cfg.Deprecation,
+			Sunset:                   cfg.Sunset,
+			MaxConnections:           cfg.MaxConnections,
+			DecompressResponse:       cfg.DecompressResponse,
+			PreserveHeaderCase:       cfg.PreserveHeaderCase,
+			HeadAsGet:                cfg.HeadAsGet,
+			LogLevel:                 cfg.LogLevel,
+			RequestTimeBreakdown:     cfg.RequestTimeBreakdown,
+			HostRedirect:             cfg.HostRedirect,
+			HostRedirectStatus:       cfg.HostRedirectStatus,
+			StickySessionTTL:         cfg.StickySessionTTL,
+			Maintenance:              cfg.Maintenance,
+			ResponseDigest:           cfg.ResponseDigest,
+			UpgradeInsecureRequests:  cfg.UpgradeInsecureRequests,
+			AccessLogOnlyErrors:      cfg.AccessLogOnlyErrors,
+			TLSPassthrough:           cfg.TLSPassthrough,
 		}),
 	)
 }
@@ -124,15 +154,42 @@ func prepareConfig(rcfg RegisterConfig) RegisterConfig {
 }
 
 type RegisterConfig struct {
-	RouteServiceUrl     string
-	ServerCertDomainSAN string
-	InstanceId          string
-	InstanceIndex       string
-	AppId               string
-	StaleThreshold      int
-	TLSConfig           *tls.Config
-	IgnoreTLSConfig     bool
-	Protocol            string
+	RouteServiceUrl          string
+	ServerCertDomainSAN      string
+	InstanceId               string
+	InstanceIndex            string
+	AppId                    string
+	StaleThreshold           int
+	TLSConfig                *tls.Config
+	IgnoreTLSConfig          bool
+	Protocol                 string
+	StripQueryParams         []string
+	AllowedQueryParams       []string
+	AllowedContentTypes      []string
+	InsecureSkipTLSVerify    bool
+	HTTP1ConcurrentReadWrite bool
+	MinHealthyEndpoints      int
+	MaxResponseBodyBytes     int64
+	BasicAuth                string
+	ConnectTunnel            bool
+	StatusRemap              map[int]int
+	StatusRemapReplaceBody   bool
+	Deprecation              string
+	Sunset                   string
+	MaxConnections           int64
+	DecompressResponse       bool
+	PreserveHeaderCase       bool
+	HeadAsGet                bool
+	LogLevel                 string
+	RequestTimeBreakdown     bool
+	HostRedirect             string
+	HostRedirectStatus       int
+	StickySessionTTL         time.Duration
+	Maintenance              bool
+	ResponseDigest           bool
+	UpgradeInsecureRequests  string
+	AccessLogOnlyErrors      bool
+	TLSPassthrough           bool
 }
 
 func runBackendInstance(ln net.Listener, handler connHandler) {
@@ -550,6 +607,43 @@ func CreateCert(cname string) tls.Certificate {
 	return tlsCert
 }
 
+// CreateECHKey generates an X25519 HPKE key pair and the matching ECHConfig
+// for publicName. It returns the server side key and the ECHConfigList to
+// hand to clients.
+func CreateECHKey(publicName string, configID uint8) (tls.EncryptedClientHelloKey, []byte) {
+	privKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	pubKey := privKey.PublicKey().Bytes()
+
+	var contents bytes.Buffer
+	contents.WriteByte(configID)
+	binary.Write(&contents, binary.BigEndian, uint16(0x0020)) // DHKEM(X25519, HKDF-SHA256)
+	binary.Write(&contents, binary.BigEndian, uint16(len(pubKey)))
+	contents.Write(pubKey)
+	binary.Write(&contents, binary.BigEndian, uint16(4))      // one cipher suite
+	binary.Write(&contents, binary.BigEndian, uint16(0x0001)) // HKDF-SHA256
+	binary.Write(&contents, binary.BigEndian, uint16(0x0001)) // AES-128-GCM
+	contents.WriteByte(0)                                     // maximum name length
+	contents.WriteByte(uint8(len(publicName)))
+	contents.WriteString(publicName)
+	binary.Write(&contents, binary.BigEndian, uint16(0)) // no extensions
+
+	var echConfig bytes.Buffer
+	binary.Write(&echConfig, binary.BigEndian, uint16(0xfe0d))
+	binary.Write(&echConfig, binary.BigEndian, uint16(contents.Len()))
+	echConfig.Write(contents.Bytes())
+
+	var configList bytes.Buffer
+	binary.Write(&configList, binary.BigEndian, uint16(echConfig.Len()))
+	configList.Write(echConfig.Bytes())
+
+	return tls.EncryptedClientHelloKey{
+		Config:      echConfig.Bytes(),
+		PrivateKey:  privKey.Bytes(),
+		SendAsRetry: true,
+	}, configList.Bytes()
+}
+
 type HangingReadCloser struct {
 	mu        sync.Mutex
 	readCalls int